@@ -12,9 +12,11 @@ import (
 	"github.com/appnity/media-vault/internal/config"
 	"github.com/appnity/media-vault/internal/crypto"
 	"github.com/appnity/media-vault/internal/handlers"
+	"github.com/appnity/media-vault/internal/jobs"
 	"github.com/appnity/media-vault/internal/middleware"
 	"github.com/appnity/media-vault/internal/models"
 	"github.com/appnity/media-vault/internal/repository"
+	"github.com/appnity/media-vault/internal/scanner"
 	"github.com/appnity/media-vault/internal/services"
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -52,24 +54,57 @@ func main() {
 	// Initialize repository
 	repo := repository.NewRepository(pool)
 
+	// Initialize antivirus scanner (optional)
+	var clamd *scanner.ClamdScanner
+	if cfg.ClamdAddress != "" {
+		clamd = scanner.NewClamdScanner(cfg.ClamdAddress, time.Duration(cfg.ClamdTimeoutSecs)*time.Second)
+		if err := clamd.Ping(context.Background()); err != nil {
+			log.Printf("Warning: clamd at %s is not reachable: %v", cfg.ClamdAddress, err)
+		} else {
+			log.Printf("Antivirus scanning enabled via clamd at %s", cfg.ClamdAddress)
+		}
+	}
+
 	// Initialize services
-	authService := services.NewAuthService(repo, cfg, encryptor)
-	mediaService := services.NewMediaService(repo, encryptor)
-	storageService := services.NewStorageService(repo, encryptor)
+	authService, err := services.NewAuthService(repo, cfg, encryptor)
+	if err != nil {
+		log.Fatalf("Failed to load JWT signing keys: %v", err)
+	}
+	webhookService := services.NewWebhookService(repo, cfg, encryptor)
+	activityHub := services.NewActivityHub(repo)
+	mediaService := services.NewMediaService(repo, cfg, encryptor, clamd, webhookService, activityHub)
+	storageService := services.NewStorageService(repo, encryptor, webhookService, mediaService)
 	groupService := services.NewGroupService(repo)
 
 	// Create default admin if not exists
 	createDefaultAdmin(repo, cfg)
 
+	// Start background jobs
+	scheduler := jobs.NewScheduler()
+	scheduler.Every("scan-retry", time.Duration(cfg.ScanRetryIntervalMin)*time.Minute, mediaService.RetryScans)
+	scheduler.Every("trash-purge", time.Duration(cfg.TrashPurgeIntervalMin)*time.Minute, mediaService.PurgeExpiredTrash)
+	scheduler.Every("retention-sweep", time.Duration(cfg.RetentionSweepIntervalMin)*time.Minute, mediaService.SweepExpiredMedia)
+	scheduler.Every("webhook-delivery", time.Duration(cfg.WebhookDeliveryIntervalSec)*time.Second, webhookService.DeliverDue)
+	scheduler.Every("search-terms-refresh", time.Duration(cfg.SearchTermsRefreshIntervalMin)*time.Minute, mediaService.RefreshSearchTerms)
+	scheduler.Every("refresh-token-purge", 24*time.Hour, authService.PurgeExpiredRefreshTokens)
+	scheduler.Every("oidc-login-purge", time.Hour, authService.PurgeExpiredOIDCLogins)
+	scheduler.Start(context.Background())
+
+	// Start live activity fan-out
+	activityCtx, stopActivity := context.WithCancel(context.Background())
+	go activityHub.Run(activityCtx)
+
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authService)
 	mediaHandler := handlers.NewMediaHandler(mediaService, repo)
 	storageHandler := handlers.NewStorageHandler(storageService, mediaService)
 	groupHandler := handlers.NewGroupHandler(groupService, mediaService)
 	configHandler := handlers.NewConfigHandler(repo)
+	webhookHandler := handlers.NewWebhookHandler(webhookService, mediaService)
+	scimHandler := handlers.NewSCIMHandler(services.NewSCIMService(repo, cfg, authService))
 
 	// Setup router
-	router := setupRouter(authService, authHandler, mediaHandler, storageHandler, groupHandler, configHandler)
+	router := setupRouter(cfg, authService, authHandler, mediaHandler, storageHandler, groupHandler, configHandler, webhookHandler, scimHandler)
 
 	// Create server
 	srv := &http.Server{
@@ -79,6 +114,8 @@ func main() {
 		WriteTimeout: 30 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
+	// Event streams never go idle; end them so shutdown does not wait on them
+	srv.RegisterOnShutdown(stopActivity)
 
 	// Start server in goroutine
 	go func() {
@@ -102,16 +139,21 @@ func main() {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 
+	scheduler.Stop()
+
 	log.Println("Server exited properly")
 }
 
 func setupRouter(
+	cfg *config.Config,
 	authService *services.AuthService,
 	authHandler *handlers.AuthHandler,
 	mediaHandler *handlers.MediaHandler,
 	storageHandler *handlers.StorageHandler,
 	groupHandler *handlers.GroupHandler,
 	configHandler *handlers.ConfigHandler,
+	webhookHandler *handlers.WebhookHandler,
+	scimHandler *handlers.SCIMHandler,
 ) *gin.Engine {
 	router := gin.New()
 	router.Use(gin.Recovery())
@@ -122,6 +164,49 @@ func setupRouter(
 		c.JSON(http.StatusOK, gin.H{"status": "ok", "service": "media-vault"})
 	})
 
+	// Public keys for verifying access tokens
+	router.GET("/.well-known/jwks.json", authHandler.JWKS)
+
+	// Share links (public, authorized by token)
+	share := router.Group("/s/:token")
+	{
+		share.GET("", mediaHandler.OpenShareLink)
+		share.GET("/info", mediaHandler.GetSharedContent)
+		share.GET("/files/:media_id", mediaHandler.DownloadSharedFile)
+	}
+
+	// Guest upload links (public, authorized by token)
+	guestUpload := router.Group("/u/:token")
+	{
+		guestUpload.GET("", mediaHandler.GetGuestUploadInfo)
+		guestUpload.POST("/init", mediaHandler.InitiateGuestUpload)
+		guestUpload.POST("/complete", mediaHandler.CompleteGuestUpload)
+	}
+
+	// SCIM provisioning by the identity provider (enabled by SCIM_TOKEN)
+	if cfg.SCIMToken != "" {
+		scim := router.Group("/scim/v2")
+		scim.Use(middleware.SCIMAuth(cfg.SCIMToken))
+		{
+			scim.GET("/ServiceProviderConfig", scimHandler.ServiceProviderConfig)
+			scim.GET("/ResourceTypes", scimHandler.ListResourceTypes)
+
+			scim.GET("/Users", scimHandler.ListUsers)
+			scim.POST("/Users", scimHandler.CreateUser)
+			scim.GET("/Users/:id", scimHandler.GetUser)
+			scim.PUT("/Users/:id", scimHandler.ReplaceUser)
+			scim.PATCH("/Users/:id", scimHandler.PatchUser)
+			scim.DELETE("/Users/:id", scimHandler.DeleteUser)
+
+			scim.GET("/Groups", scimHandler.ListGroups)
+			scim.POST("/Groups", scimHandler.CreateGroup)
+			scim.GET("/Groups/:id", scimHandler.GetGroup)
+			scim.PUT("/Groups/:id", scimHandler.ReplaceGroup)
+			scim.PATCH("/Groups/:id", scimHandler.PatchGroup)
+			scim.DELETE("/Groups/:id", scimHandler.DeleteGroup)
+		}
+	}
+
 	// API routes
 	api := router.Group("/api")
 
@@ -130,6 +215,16 @@ func setupRouter(
 	{
 		auth.POST("/login", authHandler.Login)
 		auth.POST("/refresh", authHandler.RefreshToken)
+		auth.POST("/logout", authHandler.Logout)
+
+		// Second step of a login with two-factor authentication
+		auth.POST("/mfa/verify", authHandler.VerifyMFA)
+		auth.POST("/mfa/setup", authHandler.BeginMFASetup)
+		auth.POST("/mfa/setup/confirm", authHandler.CompleteMFASetup)
+
+		// Single sign-on with the OpenID Connect provider
+		auth.POST("/oidc/authorize", authHandler.BeginOIDCLogin)
+		auth.POST("/oidc/callback", authHandler.CompleteOIDCLogin)
 	}
 
 	// Config routes
@@ -144,24 +239,41 @@ func setupRouter(
 	{
 		// Current user
 		protected.GET("/auth/me", authHandler.GetCurrentUser)
+		protected.POST("/auth/logout-all", authHandler.LogoutAll)
+		protected.GET("/auth/sessions", authHandler.ListSessions)
+		protected.DELETE("/auth/sessions/:id", authHandler.RevokeSession)
+		protected.GET("/auth/mfa", authHandler.GetMFAStatus)
+		protected.POST("/auth/mfa/enroll", authHandler.BeginTOTPEnrollment)
+		protected.POST("/auth/mfa/enroll/confirm", authHandler.ConfirmTOTPEnrollment)
+		protected.POST("/auth/mfa/recovery-codes", authHandler.RegenerateRecoveryCodes)
+		protected.DELETE("/auth/mfa", authHandler.DisableMFA)
+
+		// Live activity stream (Server-Sent Events)
+		protected.GET("/events/stream", mediaHandler.StreamEvents)
 
 		// Media routes
 		media := protected.Group("/media")
+		media.Use(mediaHandler.EnforceKeyLimits)
 		{
 			media.GET("", mediaHandler.ListMedia)
 			media.GET("/:id", mediaHandler.GetMedia)
 			media.GET("/:id/url", mediaHandler.GetPublicURL)
 			media.GET("/:id/download", mediaHandler.DownloadMedia)
+			media.GET("/:id/versions", mediaHandler.ListMediaVersions)
+			media.GET("/:id/versions/:version/download", mediaHandler.DownloadMediaVersion)
 			media.POST("/batch-download", mediaHandler.BatchDownloadMedia)
 
 			// Upload routes (require write access)
 			upload := media.Group("")
-			upload.Use(middleware.AllExceptViewer())
+			upload.Use(middleware.RequirePermission(models.PermMediaUpload))
 			{
 				upload.POST("/upload/init", mediaHandler.InitiateUpload)
 				upload.POST("/upload/complete", mediaHandler.CompleteUpload)
 				upload.PATCH("/:id", mediaHandler.UpdateMedia)
 				upload.POST("/:id/move", mediaHandler.MoveMedia)
+				upload.POST("/:id/versions/init", mediaHandler.InitiateVersionUpload)
+				upload.POST("/:id/versions/complete", mediaHandler.CompleteVersionUpload)
+				upload.POST("/:id/versions/:version/restore", mediaHandler.RestoreMediaVersion)
 				upload.DELETE("/:id", mediaHandler.DeleteMedia)
 				upload.POST("/batch-delete", mediaHandler.BatchDeleteMedia)
 			}
@@ -175,7 +287,7 @@ func setupRouter(
 
 			// Write operations restricted to Developers and Admins
 			storageWrite := storage.Group("")
-			storageWrite.Use(middleware.DeveloperOrAdmin())
+			storageWrite.Use(middleware.RequirePermission(models.PermStorageManage))
 			{
 				storageWrite.POST("", storageHandler.CreateStorageAccount)
 				storageWrite.PATCH("/:id", storageHandler.UpdateStorageAccount)
@@ -186,7 +298,7 @@ func setupRouter(
 
 			// Access management (Admin only)
 			storageAccess := storage.Group("/:id/access")
-			storageAccess.Use(middleware.AdminOnly())
+			storageAccess.Use(middleware.RequirePermission(models.PermStorageAccess))
 			{
 				storageAccess.GET("", storageHandler.GetStorageAccountAccess)
 				storageAccess.POST("", storageHandler.GrantStorageAccess)
@@ -194,6 +306,62 @@ func setupRouter(
 			}
 		}
 
+		// Trash routes
+		trash := protected.Group("/trash")
+		{
+			trash.GET("", mediaHandler.ListTrash)
+			trash.POST("/:id/restore", mediaHandler.RestoreMedia)
+			trash.DELETE("/:id", middleware.RequirePermission(models.PermMediaPurge), mediaHandler.PurgeMedia)
+		}
+
+		// Share link routes
+		shares := protected.Group("/shares")
+		shares.Use(middleware.RequirePermission(models.PermSharesCreate))
+		{
+			shares.GET("", mediaHandler.ListShareLinks)
+			shares.POST("", mediaHandler.CreateShareLink)
+			shares.DELETE("/:id", mediaHandler.RevokeShareLink)
+		}
+
+		// Guest upload request routes
+		uploadRequests := protected.Group("/upload-requests")
+		uploadRequests.Use(middleware.RequirePermission(models.PermSharesCreate))
+		{
+			uploadRequests.GET("", mediaHandler.ListUploadRequests)
+			uploadRequests.POST("", mediaHandler.CreateUploadRequest)
+			uploadRequests.DELETE("/:id", mediaHandler.RevokeUploadRequest)
+			uploadRequests.GET("/review", mediaHandler.ListPendingReview)
+			uploadRequests.POST("/review/:media_id", mediaHandler.ReviewGuestUpload)
+		}
+
+		// Saved search and smart collection routes
+		savedSearches := protected.Group("/saved-searches")
+		{
+			savedSearches.GET("", mediaHandler.ListSavedSearches)
+			savedSearches.POST("", mediaHandler.CreateSavedSearch)
+			savedSearches.GET("/:id", mediaHandler.GetSavedSearch)
+			savedSearches.PATCH("/:id", mediaHandler.UpdateSavedSearch)
+			savedSearches.DELETE("/:id", mediaHandler.DeleteSavedSearch)
+			savedSearches.GET("/:id/media", mediaHandler.ListSavedSearchMedia)
+		}
+
+		// Collection routes
+		collections := protected.Group("/collections")
+		{
+			collections.GET("", mediaHandler.ListCollections)
+			collections.POST("", mediaHandler.CreateCollection)
+			collections.GET("/:id", mediaHandler.GetCollection)
+			collections.PATCH("/:id", mediaHandler.UpdateCollection)
+			collections.DELETE("/:id", mediaHandler.DeleteCollection)
+			collections.GET("/:id/media", mediaHandler.ListCollectionMedia)
+			collections.POST("/:id/media", mediaHandler.AddCollectionItems)
+			collections.POST("/:id/media/remove", mediaHandler.RemoveCollectionItems)
+			collections.PUT("/:id/order", mediaHandler.ReorderCollectionItems)
+			collections.GET("/:id/download", mediaHandler.DownloadCollection)
+			collections.GET("/:id/access", mediaHandler.GetCollectionAccess)
+			collections.PUT("/:id/access", mediaHandler.SetCollectionAccess)
+		}
+
 		// Media group routes
 		groups := protected.Group("/groups")
 		{
@@ -202,7 +370,7 @@ func setupRouter(
 
 			// Write operations
 			groupWrite := groups.Group("")
-			groupWrite.Use(middleware.DeveloperOrAdmin())
+			groupWrite.Use(middleware.RequirePermission(models.PermGroupsManage))
 			{
 				groupWrite.POST("", groupHandler.CreateMediaGroup)
 				groupWrite.PATCH("/:id", groupHandler.UpdateMediaGroup)
@@ -211,20 +379,88 @@ func setupRouter(
 		}
 	}
 
-	// Admin routes
+	// Admin routes, each area behind its own permission
 	admin := api.Group("/admin")
 	admin.Use(middleware.AuthMiddleware(authService))
-	admin.Use(middleware.AdminOnly())
 	{
 		// Employee management
-		admin.POST("/employees", authHandler.CreateEmployee)
-		admin.GET("/employees", authHandler.ListEmployees)
-		admin.GET("/employees/:id", authHandler.GetEmployee)
-		admin.PATCH("/employees/:id", authHandler.UpdateEmployee)
-		admin.DELETE("/employees/:id", authHandler.DeleteEmployee)
+		employees := admin.Group("")
+		employees.Use(middleware.RequirePermission(models.PermEmployeesManage))
+		{
+			employees.POST("/employees", authHandler.CreateEmployee)
+			employees.GET("/employees", authHandler.ListEmployees)
+			employees.GET("/employees/:id", authHandler.GetEmployee)
+			employees.PATCH("/employees/:id", authHandler.UpdateEmployee)
+			employees.DELETE("/employees/:id", authHandler.DeleteEmployee)
+			employees.GET("/employees/:id/sessions", authHandler.ListEmployeeSessions)
+			employees.DELETE("/employees/:id/sessions", authHandler.RevokeEmployeeSessions)
+			employees.DELETE("/employees/:id/sessions/:session_id", authHandler.RevokeEmployeeSession)
+			employees.DELETE("/employees/:id/mfa", authHandler.ResetEmployeeMFA)
+			employees.GET("/mfa-policy", authHandler.GetMFAPolicy)
+			employees.PUT("/mfa-policy", authHandler.UpdateMFAPolicy)
+
+			// Service accounts and their API keys
+			employees.GET("/service-accounts", authHandler.ListServiceAccounts)
+			employees.POST("/service-accounts", authHandler.CreateServiceAccount)
+			employees.DELETE("/service-accounts/:id", authHandler.DeleteServiceAccount)
+			employees.GET("/service-accounts/:id/keys", authHandler.ListAPIKeys)
+			employees.POST("/service-accounts/:id/keys", authHandler.CreateAPIKey)
+			employees.DELETE("/service-accounts/:id/keys/:key_id", authHandler.RevokeAPIKey)
+		}
+
+		// Roles and permissions
+		roles := admin.Group("")
+		roles.Use(middleware.RequirePermission(models.PermRolesManage))
+		{
+			roles.GET("/permissions", authHandler.ListPermissions)
+			roles.GET("/roles", authHandler.ListRoles)
+			roles.POST("/roles", authHandler.CreateCustomRole)
+			roles.PATCH("/roles/:id", authHandler.UpdateCustomRole)
+			roles.DELETE("/roles/:id", authHandler.DeleteCustomRole)
+		}
 
 		// Audit logs
-		admin.GET("/audit-logs", mediaHandler.ListAuditLogs)
+		admin.GET("/audit-logs", middleware.RequirePermission(models.PermAuditRead), mediaHandler.ListAuditLogs)
+
+		// Antivirus quarantine review
+		quarantine := admin.Group("")
+		quarantine.Use(middleware.RequirePermission(models.PermQuarantineManage))
+		{
+			quarantine.GET("/quarantine", mediaHandler.ListQuarantinedMedia)
+			quarantine.POST("/quarantine/:id/release", mediaHandler.ReleaseQuarantinedMedia)
+			quarantine.DELETE("/quarantine/:id", mediaHandler.DeleteQuarantinedMedia)
+			quarantine.POST("/media/:id/scan", mediaHandler.RescanMedia)
+		}
+
+		// Retention policies and legal holds
+		compliance := admin.Group("")
+		compliance.Use(middleware.RequirePermission(models.PermComplianceManage))
+		{
+			compliance.GET("/retention-policies", mediaHandler.ListRetentionPolicies)
+			compliance.POST("/retention-policies", mediaHandler.CreateRetentionPolicy)
+			compliance.PATCH("/retention-policies/:id", mediaHandler.UpdateRetentionPolicy)
+			compliance.DELETE("/retention-policies/:id", mediaHandler.DeleteRetentionPolicy)
+
+			compliance.GET("/legal-holds", mediaHandler.ListLegalHolds)
+			compliance.POST("/legal-holds", mediaHandler.PlaceLegalHold)
+			compliance.GET("/legal-holds/:id", mediaHandler.GetLegalHold)
+			compliance.POST("/legal-holds/:id/release", mediaHandler.ReleaseLegalHold)
+		}
+
+		// Webhooks
+		webhooks := admin.Group("/webhooks")
+		webhooks.Use(middleware.RequirePermission(models.PermWebhooksManage))
+		{
+			webhooks.GET("", webhookHandler.ListWebhookEndpoints)
+			webhooks.POST("", webhookHandler.CreateWebhookEndpoint)
+			webhooks.GET("/:id", webhookHandler.GetWebhookEndpoint)
+			webhooks.PATCH("/:id", webhookHandler.UpdateWebhookEndpoint)
+			webhooks.DELETE("/:id", webhookHandler.DeleteWebhookEndpoint)
+			webhooks.POST("/:id/rotate-secret", webhookHandler.RotateWebhookSecret)
+			webhooks.POST("/:id/test", webhookHandler.SendWebhookTest)
+			webhooks.GET("/:id/deliveries", webhookHandler.ListWebhookDeliveries)
+			webhooks.POST("/deliveries/:delivery_id/replay", webhookHandler.ReplayWebhookDelivery)
+		}
 	}
 
 	return router
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -18,14 +20,77 @@ type Config struct {
 	DatabaseURL string
 
 	// Security
-	JWTSecret          string
-	EncryptionKey      []byte
-	AccessTokenExpiry  int // minutes
-	RefreshTokenExpiry int // days
+	JWTSigningAlg       string   // HS256, EdDSA or RS256
+	JWTSecret           string   // HS256 signing secret
+	JWTPrivateKeyFile   string   // PEM private key for EdDSA and RS256
+	JWTKeyID            string   // kid of the current key, stamped on every token it signs
+	JWTPreviousKeys     []JWTKey // retired HS256 secrets still accepted for verification
+	JWTPreviousKeyFiles []JWTKey // retired EdDSA/RS256 keys still accepted and published
+	JWTIssuer           string   // iss of every token issued
+	JWTAudience         string   // aud of access tokens, which verifiers must check
+	EncryptionKey       []byte
+	AccessTokenExpiry   int    // minutes
+	RefreshTokenExpiry  int    // days
+	MFAIssuer           string // issuer name shown in authenticator apps
+
+	// Single sign-on (OpenID Connect), disabled when OIDCIssuerURL is empty
+	OIDCIssuerURL      string
+	OIDCClientID       string
+	OIDCClientSecret   string // empty for public clients, which rely on PKCE alone
+	OIDCRedirectURL    string // page of the frontend the provider sends the authorization code to
+	OIDCScopes         []string
+	OIDCRoleClaim      string            // ID token claim holding the employee's groups or roles
+	OIDCRoleMapping    []OIDCRoleMapping // first match wins
+	OIDCDefaultRole    string            // role when no mapping matches; empty refuses the login
+	OIDCAllowedDomains []string          // email domains that may sign in; empty allows any
+	OIDCTrustMFA       bool              // skip the second factor when the ID token's amr contains "mfa"
+
+	// SCIM 2.0 provisioning, disabled when SCIMToken is empty
+	SCIMToken string // bearer token identity providers authenticate with
 
 	// Default Admin
 	DefaultAdminEmail    string
 	DefaultAdminPassword string
+
+	// Antivirus
+	ClamdAddress         string // host:port, scanning is disabled when empty
+	ClamdTimeoutSecs     int
+	ScanRetryIntervalMin int // how often failed scans and quarantine moves are retried
+
+	// Trash
+	TrashRetentionDays    int // days before trashed media is purged from storage
+	TrashPurgeIntervalMin int
+
+	// Retention
+	RetentionSweepIntervalMin int
+
+	// Share links
+	PublicBaseURL string // base URL share links are built on
+
+	// Webhooks
+	WebhookDeliveryIntervalSec int
+	WebhookMaxAttempts         int // attempts before a delivery is marked failed
+	WebhookTimeoutSecs         int
+	WebhookAllowPrivate        bool // allow endpoints on loopback, private and link-local addresses
+
+	// Search
+	SearchTermsRefreshIntervalMin int // how often the "did you mean" vocabulary is rebuilt
+}
+
+// JWTKey is a retired JWT signing key: an HS256 secret, or the path of a PEM
+// key file for EdDSA and RS256. Tokens it signed are accepted until they
+// could no longer be live, counted from RetiredAt.
+type JWTKey struct {
+	ID        string
+	Secret    string
+	File      string
+	RetiredAt time.Time
+}
+
+// OIDCRoleMapping grants a role to employees whose role claim contains Value
+type OIDCRoleMapping struct {
+	Value string
+	Role  string
 }
 
 // Load reads configuration from environment variables
@@ -38,27 +103,114 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("ENCRYPTION_KEY must be exactly 32 bytes")
 	}
 
+	jwtSigningAlg := getEnvOrDefault("JWT_SIGNING_ALG", "HS256")
 	jwtSecret := os.Getenv("JWT_SECRET")
-	if len(jwtSecret) < 32 {
-		return nil, fmt.Errorf("JWT_SECRET must be at least 32 characters")
+	jwtPrivateKeyFile := os.Getenv("JWT_PRIVATE_KEY_FILE")
+	switch jwtSigningAlg {
+	case "HS256":
+		if len(jwtSecret) < 32 {
+			return nil, fmt.Errorf("JWT_SECRET must be at least 32 characters")
+		}
+	case "EdDSA", "RS256":
+		if jwtPrivateKeyFile == "" {
+			return nil, fmt.Errorf("JWT_PRIVATE_KEY_FILE is required when JWT_SIGNING_ALG is %s", jwtSigningAlg)
+		}
+	default:
+		return nil, fmt.Errorf("JWT_SIGNING_ALG must be HS256, EdDSA or RS256")
+	}
+
+	previousKeys, err := parseJWTKeys("JWT_PREVIOUS_KEYS", false)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range previousKeys {
+		if len(key.Secret) < 32 {
+			return nil, fmt.Errorf("JWT_PREVIOUS_KEYS secret for %q must be at least 32 characters", key.ID)
+		}
+	}
+	previousKeyFiles, err := parseJWTKeys("JWT_PREVIOUS_KEY_FILES", true)
+	if err != nil {
+		return nil, err
+	}
+
+	oidcRoleMapping, err := parseOIDCRoleMapping(os.Getenv("OIDC_ROLE_MAPPING"))
+	if err != nil {
+		return nil, err
+	}
+	oidcDefaultRole := getEnvOrDefault("OIDC_DEFAULT_ROLE", "viewer")
+	if oidcDefaultRole == "none" {
+		oidcDefaultRole = ""
+	} else if !isAssignableRole(oidcDefaultRole) {
+		return nil, fmt.Errorf("OIDC_DEFAULT_ROLE must be admin, developer, marketing, viewer or none")
 	}
 
 	cfg := &Config{
-		Port:                 getEnvOrDefault("PORT", "8080"),
-		GinMode:              getEnvOrDefault("GIN_MODE", "debug"),
-		DatabaseURL:          os.Getenv("DATABASE_URL"),
-		JWTSecret:            jwtSecret,
-		EncryptionKey:        []byte(encryptionKey),
-		AccessTokenExpiry:    getEnvAsIntOrDefault("ACCESS_TOKEN_EXPIRY_MIN", 15),
-		RefreshTokenExpiry:   getEnvAsIntOrDefault("REFRESH_TOKEN_EXPIRY_DAYS", 7),
-		DefaultAdminEmail:    getEnvOrDefault("DEFAULT_ADMIN_EMAIL", "admin@company.com"),
-		DefaultAdminPassword: os.Getenv("DEFAULT_ADMIN_PASSWORD"),
+		Port:                       getEnvOrDefault("PORT", "8080"),
+		GinMode:                    getEnvOrDefault("GIN_MODE", "debug"),
+		DatabaseURL:                os.Getenv("DATABASE_URL"),
+		JWTSigningAlg:              jwtSigningAlg,
+		JWTSecret:                  jwtSecret,
+		JWTPrivateKeyFile:          jwtPrivateKeyFile,
+		JWTKeyID:                   getEnvOrDefault("JWT_KEY_ID", "default"),
+		JWTPreviousKeys:            previousKeys,
+		JWTPreviousKeyFiles:        previousKeyFiles,
+		JWTIssuer:                  getEnvOrDefault("JWT_ISSUER", getEnvOrDefault("PUBLIC_BASE_URL", "http://localhost:8080")),
+		JWTAudience:                getEnvOrDefault("JWT_AUDIENCE", "media-vault-api"),
+		EncryptionKey:              []byte(encryptionKey),
+		AccessTokenExpiry:          getEnvAsIntOrDefault("ACCESS_TOKEN_EXPIRY_MIN", 15),
+		RefreshTokenExpiry:         getEnvAsIntOrDefault("REFRESH_TOKEN_EXPIRY_DAYS", 7),
+		MFAIssuer:                  getEnvOrDefault("MFA_ISSUER", "Media Vault"),
+		OIDCIssuerURL:              strings.TrimSuffix(os.Getenv("OIDC_ISSUER_URL"), "/"),
+		OIDCClientID:               os.Getenv("OIDC_CLIENT_ID"),
+		OIDCClientSecret:           os.Getenv("OIDC_CLIENT_SECRET"),
+		OIDCRedirectURL:            os.Getenv("OIDC_REDIRECT_URL"),
+		OIDCScopes:                 splitList(getEnvOrDefault("OIDC_SCOPES", "openid email profile"), " "),
+		OIDCRoleClaim:              getEnvOrDefault("OIDC_ROLE_CLAIM", "groups"),
+		OIDCRoleMapping:            oidcRoleMapping,
+		OIDCDefaultRole:            oidcDefaultRole,
+		OIDCAllowedDomains:         splitList(strings.ToLower(os.Getenv("OIDC_ALLOWED_DOMAINS")), ","),
+		OIDCTrustMFA:               getEnvAsBoolOrDefault("OIDC_TRUST_PROVIDER_MFA", false),
+		SCIMToken:                  os.Getenv("SCIM_TOKEN"),
+		DefaultAdminEmail:          getEnvOrDefault("DEFAULT_ADMIN_EMAIL", "admin@company.com"),
+		DefaultAdminPassword:       os.Getenv("DEFAULT_ADMIN_PASSWORD"),
+		ClamdAddress:               os.Getenv("CLAMD_ADDRESS"),
+		ClamdTimeoutSecs:           getEnvAsIntOrDefault("CLAMD_TIMEOUT_SEC", 120),
+		ScanRetryIntervalMin:       getEnvAsIntOrDefault("SCAN_RETRY_INTERVAL_MIN", 15),
+		TrashRetentionDays:         getEnvAsIntOrDefault("TRASH_RETENTION_DAYS", 30),
+		TrashPurgeIntervalMin:      getEnvAsIntOrDefault("TRASH_PURGE_INTERVAL_MIN", 60),
+		RetentionSweepIntervalMin:  getEnvAsIntOrDefault("RETENTION_SWEEP_INTERVAL_MIN", 60),
+		PublicBaseURL:              getEnvOrDefault("PUBLIC_BASE_URL", "http://localhost:8080"),
+		WebhookDeliveryIntervalSec: getEnvAsIntOrDefault("WEBHOOK_DELIVERY_INTERVAL_SEC", 5),
+		WebhookMaxAttempts:         getEnvAsIntOrDefault("WEBHOOK_MAX_ATTEMPTS", 8),
+		WebhookTimeoutSecs:         getEnvAsIntOrDefault("WEBHOOK_TIMEOUT_SEC", 10),
+		WebhookAllowPrivate:        getEnvAsBoolOrDefault("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false),
+
+		SearchTermsRefreshIntervalMin: getEnvAsIntOrDefault("SEARCH_TERMS_REFRESH_INTERVAL_MIN", 30),
 	}
 
 	if cfg.DatabaseURL == "" {
 		return nil, fmt.Errorf("DATABASE_URL is required")
 	}
 
+	if cfg.OIDCIssuerURL != "" && (cfg.OIDCClientID == "" || cfg.OIDCRedirectURL == "") {
+		return nil, fmt.Errorf("OIDC_CLIENT_ID and OIDC_REDIRECT_URL are required when OIDC_ISSUER_URL is set")
+	}
+
+	if cfg.SCIMToken != "" && len(cfg.SCIMToken) < 32 {
+		return nil, fmt.Errorf("SCIM_TOKEN must be at least 32 characters")
+	}
+
+	seen := map[string]bool{cfg.JWTKeyID: true}
+	var retired []JWTKey
+	retired = append(retired, cfg.JWTPreviousKeys...)
+	retired = append(retired, cfg.JWTPreviousKeyFiles...)
+	for _, key := range retired {
+		if seen[key.ID] {
+			return nil, fmt.Errorf("JWT key id %q is used more than once", key.ID)
+		}
+		seen[key.ID] = true
+	}
+
 	return cfg, nil
 }
 
@@ -77,3 +229,84 @@ func getEnvAsIntOrDefault(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getEnvAsBoolOrDefault(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+// parseJWTKeys parses retired signing keys from an environment variable
+// holding a comma separated list of kid:value:retired-date entries, e.g.
+// "2024-q1:oldsecret:2024-04-01". The value is a secret, or a key file path
+// when files is set.
+func parseJWTKeys(env string, files bool) ([]JWTKey, error) {
+	var keys []JWTKey
+	for _, entry := range strings.Split(os.Getenv(env), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		kid, rest, ok := strings.Cut(entry, ":")
+		sep := strings.LastIndex(rest, ":")
+		if !ok || kid == "" || sep < 0 {
+			return nil, fmt.Errorf("%s entry must be kid:value:retired-date", env)
+		}
+		keyValue, date := rest[:sep], rest[sep+1:]
+		retiredAt, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			return nil, fmt.Errorf("%s retired date for %q must be YYYY-MM-DD", env, kid)
+		}
+
+		key := JWTKey{ID: kid, RetiredAt: retiredAt}
+		if files {
+			key.File = keyValue
+		} else {
+			key.Secret = keyValue
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// splitList splits a separated list, dropping empty entries
+func splitList(value, sep string) []string {
+	var items []string
+	for _, item := range strings.Split(value, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseOIDCRoleMapping parses a comma separated list of claim-value=role
+// entries, e.g. "media-admins=admin,engineering=developer"
+func parseOIDCRoleMapping(value string) ([]OIDCRoleMapping, error) {
+	var mapping []OIDCRoleMapping
+	for _, entry := range splitList(value, ",") {
+		claim, role, ok := strings.Cut(entry, "=")
+		claim, role = strings.TrimSpace(claim), strings.TrimSpace(role)
+		if !ok || claim == "" {
+			return nil, fmt.Errorf("OIDC_ROLE_MAPPING entry must be claim-value=role")
+		}
+		if !isAssignableRole(role) {
+			return nil, fmt.Errorf("OIDC_ROLE_MAPPING role for %q must be admin, developer, marketing or viewer", claim)
+		}
+		mapping = append(mapping, OIDCRoleMapping{Value: claim, Role: role})
+	}
+	return mapping, nil
+}
+
+// isAssignableRole reports whether role names a built-in employee role
+func isAssignableRole(role string) bool {
+	switch role {
+	case "admin", "developer", "marketing", "viewer":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,530 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/appnity/media-vault/internal/config"
+	"github.com/appnity/media-vault/internal/crypto"
+	"github.com/appnity/media-vault/internal/middleware"
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/services"
+	"github.com/appnity/media-vault/internal/testdb"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// accessCaller is who a test request is made as
+type accessCaller struct {
+	employee    *models.Employee
+	permissions []models.Permission
+}
+
+// accessFixture is a library seen by three callers:
+//   - restricted, a viewer granted the shared account, who sees sharedMedia
+//     but not ownerMedia, which is in a group for developers
+//   - owner, a developer granted the shared account, who uploaded both
+//   - viewAll, a viewer holding storage:view_all, who sees everything,
+//     including hiddenMedia on an account nobody was granted
+//
+// restricted and owner hold the same permissions, so the differences between
+// them come from row-level access alone.
+type accessFixture struct {
+	router *gin.Engine
+
+	restricted, owner, viewAll *accessCaller
+	bystander                  *models.Employee
+
+	shared, hidden                       *models.StorageAccount
+	devGroup                             *models.MediaGroup
+	ownerMedia, sharedMedia, hiddenMedia *models.Media
+	mixedFolder, sharedFolder            uuid.UUID
+}
+
+func newAccessFixture(t *testing.T) *accessFixture {
+	t.Helper()
+	repo, pool := testdb.Open(t)
+	ctx := context.Background()
+
+	encryptor, err := crypto.NewEncryptor([]byte(strings.Repeat("k", 32)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := testdb.NewObjectStore(t)
+
+	admin := testdb.Employee(t, repo, models.RoleAdmin)
+	granted := []models.Permission{
+		models.PermMediaUpload, models.PermSharesCreate, models.PermStorageManage,
+		models.PermStorageAccess, models.PermGroupsManage,
+	}
+	f := &accessFixture{
+		restricted: &accessCaller{testdb.Employee(t, repo, models.RoleViewer), granted},
+		owner:      &accessCaller{testdb.Employee(t, repo, models.RoleDeveloper), granted},
+		viewAll: &accessCaller{testdb.Employee(t, repo, models.RoleViewer), append([]models.Permission{
+			models.PermStorageViewAll, models.PermMediaEditAny, models.PermMediaManageAny,
+		}, granted...)},
+		bystander: testdb.Employee(t, repo, models.RoleViewer),
+	}
+
+	f.shared = testdb.StorageAccount(t, repo, encryptor, store, admin)
+	f.hidden = testdb.StorageAccount(t, repo, encryptor, store, admin)
+	for _, e := range []*models.Employee{f.restricted.employee, f.owner.employee} {
+		if err := repo.GrantStorageAccess(ctx, f.shared.ID, e.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f.devGroup = testdb.MediaGroup(t, repo, admin, models.RoleDeveloper)
+	f.mixedFolder = testdb.Folder(t, pool, f.shared, admin, "/mixed")
+	f.sharedFolder = testdb.Folder(t, pool, f.shared, admin, "/shared")
+
+	f.ownerMedia = testdb.Media(t, repo, store, &models.Media{
+		StorageAccountID: f.shared.ID, MediaGroupID: &f.devGroup.ID, FolderID: &f.mixedFolder,
+		UploadedBy: f.owner.employee.ID,
+	})
+	f.sharedMedia = testdb.Media(t, repo, store, &models.Media{
+		StorageAccountID: f.shared.ID, FolderID: &f.mixedFolder, UploadedBy: f.owner.employee.ID,
+	})
+	f.hiddenMedia = testdb.Media(t, repo, store, &models.Media{
+		StorageAccountID: f.hidden.ID, UploadedBy: admin.ID,
+		OriginalFilename: "hidden.png", Tags: []string{"forecast"},
+	})
+	// The shared folder holds only media the restricted caller can see
+	testdb.Media(t, repo, store, &models.Media{
+		StorageAccountID: f.shared.ID, FolderID: &f.sharedFolder, UploadedBy: f.owner.employee.ID,
+	})
+
+	cfg := &config.Config{PublicBaseURL: "https://vault.example.com", TrashRetentionDays: 30}
+	mediaService := services.NewMediaService(repo, cfg, encryptor, nil, nil, nil)
+	if err := mediaService.RefreshSearchTerms(ctx); err != nil {
+		t.Fatal(err)
+	}
+	f.router = accessRouter(f,
+		NewMediaHandler(mediaService, repo),
+		NewStorageHandler(services.NewStorageService(repo, encryptor, nil, mediaService), mediaService),
+		NewGroupHandler(services.NewGroupService(repo), mediaService),
+	)
+	return f
+}
+
+// accessRouter registers the routes under test as main.go does, behind a
+// stand-in for the auth middleware that signs requests in as the caller
+// named by the X-Test-Caller header
+func accessRouter(f *accessFixture, media *MediaHandler, storage *StorageHandler, groups *GroupHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	callers := map[string]*accessCaller{"restricted": f.restricted, "owner": f.owner, "viewAll": f.viewAll}
+
+	router := gin.New()
+	api := router.Group("/api")
+	api.Use(func(c *gin.Context) {
+		caller, ok := callers[c.GetHeader("X-Test-Caller")]
+		if !ok {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		c.Set("employee_id", caller.employee.ID)
+		c.Set("employee_email", caller.employee.Email)
+		c.Set("employee_role", caller.employee.Role)
+		c.Set("employee_permissions", caller.permissions)
+		c.Next()
+	})
+
+	m := api.Group("/media")
+	m.GET("", media.ListMedia)
+	m.GET("/:id", media.GetMedia)
+	m.GET("/:id/url", media.GetPublicURL)
+	m.GET("/:id/download", media.DownloadMedia)
+	m.GET("/:id/versions", media.ListMediaVersions)
+	m.GET("/:id/versions/:version/download", media.DownloadMediaVersion)
+	m.POST("/batch-download", media.BatchDownloadMedia)
+	upload := m.Group("", middleware.RequirePermission(models.PermMediaUpload))
+	upload.PATCH("/:id", media.UpdateMedia)
+	upload.POST("/:id/move", media.MoveMedia)
+	upload.DELETE("/:id", media.DeleteMedia)
+
+	api.POST("/shares", middleware.RequirePermission(models.PermSharesCreate), media.CreateShareLink)
+
+	s := api.Group("/storage-accounts")
+	s.GET("", storage.ListStorageAccounts)
+	s.GET("/:id", storage.GetStorageAccount)
+	storageWrite := s.Group("", middleware.RequirePermission(models.PermStorageManage))
+	storageWrite.PATCH("/:id", storage.UpdateStorageAccount)
+	storageWrite.DELETE("/:id", storage.DeleteStorageAccount)
+	storageAccess := s.Group("/:id/access", middleware.RequirePermission(models.PermStorageAccess))
+	storageAccess.GET("", storage.GetStorageAccountAccess)
+	storageAccess.POST("", storage.GrantStorageAccess)
+	storageAccess.DELETE("/:employee_id", storage.RevokeStorageAccess)
+
+	cl := api.Group("/collections")
+	cl.GET("", media.ListCollections)
+	cl.POST("", media.CreateCollection)
+	cl.GET("/:id", media.GetCollection)
+	cl.PUT("/:id/access", media.SetCollectionAccess)
+
+	g := api.Group("/groups")
+	g.GET("", groups.ListMediaGroups)
+	g.GET("/:id", groups.GetMediaGroup)
+	groupWrite := g.Group("", middleware.RequirePermission(models.PermGroupsManage))
+	groupWrite.PATCH("/:id", groups.UpdateMediaGroup)
+	groupWrite.DELETE("/:id", groups.DeleteMediaGroup)
+
+	return router
+}
+
+// do makes a request as a caller and returns the response
+func (f *accessFixture) do(t *testing.T, caller, method, path string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	var encoded []byte
+	if body != nil {
+		var err error
+		if encoded, err = json.Marshal(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(encoded))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Test-Caller", caller)
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, req)
+	return w
+}
+
+type accessCase struct {
+	caller string
+	method string
+	path   string
+	body   any
+	want   int
+}
+
+func (f *accessFixture) run(t *testing.T, cases []accessCase) {
+	t.Helper()
+	for _, tc := range cases {
+		t.Run(fmt.Sprintf("%s %s %s", tc.caller, tc.method, tc.path), func(t *testing.T) {
+			w := f.do(t, tc.caller, tc.method, tc.path, tc.body)
+			if w.Code != tc.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tc.want, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestMediaReadAccess(t *testing.T) {
+	f := newAccessFixture(t)
+	own := "/api/media/" + f.ownerMedia.ID.String()
+	shared := "/api/media/" + f.sharedMedia.ID.String()
+	hidden := "/api/media/" + f.hiddenMedia.ID.String()
+
+	f.run(t, []accessCase{
+		{"restricted", "GET", own, nil, http.StatusNotFound},
+		{"restricted", "GET", shared, nil, http.StatusOK},
+		{"owner", "GET", own, nil, http.StatusOK},
+		{"owner", "GET", hidden, nil, http.StatusNotFound},
+		{"viewAll", "GET", hidden, nil, http.StatusOK},
+
+		{"restricted", "GET", own + "/url", nil, http.StatusNotFound},
+		{"restricted", "GET", shared + "/url", nil, http.StatusOK},
+		{"owner", "GET", own + "/url", nil, http.StatusOK},
+		{"owner", "GET", hidden + "/url", nil, http.StatusNotFound},
+		{"viewAll", "GET", hidden + "/url", nil, http.StatusOK},
+
+		{"restricted", "GET", own + "/download", nil, http.StatusNotFound},
+		{"restricted", "GET", shared + "/download", nil, http.StatusOK},
+		{"owner", "GET", own + "/download", nil, http.StatusOK},
+		{"owner", "GET", hidden + "/download", nil, http.StatusNotFound},
+		{"viewAll", "GET", hidden + "/download", nil, http.StatusOK},
+
+		{"restricted", "GET", own + "/versions", nil, http.StatusNotFound},
+		{"restricted", "GET", shared + "/versions", nil, http.StatusOK},
+		{"owner", "GET", own + "/versions", nil, http.StatusOK},
+		{"viewAll", "GET", hidden + "/versions", nil, http.StatusOK},
+
+		{"restricted", "GET", own + "/versions/1/download", nil, http.StatusNotFound},
+		{"owner", "GET", own + "/versions/1/download", nil, http.StatusOK},
+		{"owner", "GET", hidden + "/versions/1/download", nil, http.StatusNotFound},
+		{"viewAll", "GET", hidden + "/versions/1/download", nil, http.StatusOK},
+	})
+}
+
+func TestListMediaAccess(t *testing.T) {
+	f := newAccessFixture(t)
+
+	list := func(caller, query string) (ids map[uuid.UUID]bool, didYouMean []string) {
+		t.Helper()
+		w := f.do(t, caller, "GET", "/api/media"+query, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d: %s", caller, w.Code, w.Body.String())
+		}
+		var page models.PaginatedResponse[models.MediaWithDetails]
+		if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+			t.Fatal(err)
+		}
+		ids = make(map[uuid.UUID]bool)
+		for _, m := range page.Data {
+			ids[m.ID] = true
+		}
+		return ids, page.DidYouMean
+	}
+
+	tests := []struct {
+		caller string
+		sees   map[uuid.UUID]bool
+	}{
+		{"restricted", map[uuid.UUID]bool{f.ownerMedia.ID: false, f.sharedMedia.ID: true, f.hiddenMedia.ID: false}},
+		{"owner", map[uuid.UUID]bool{f.ownerMedia.ID: true, f.sharedMedia.ID: true, f.hiddenMedia.ID: false}},
+		{"viewAll", map[uuid.UUID]bool{f.ownerMedia.ID: true, f.sharedMedia.ID: true, f.hiddenMedia.ID: true}},
+	}
+	for _, tt := range tests {
+		ids, _ := list(tt.caller, "")
+		for id, want := range tt.sees {
+			if ids[id] != want {
+				t.Errorf("%s: lists %s = %v, want %v", tt.caller, id, ids[id], want)
+			}
+		}
+	}
+
+	// "forecast" only occurs in media the restricted caller cannot see
+	_, suggestions := list("restricted", "?fuzzy=forecats")
+	if strings.Contains(strings.Join(suggestions, " "), "forecast") {
+		t.Errorf("restricted: suggestions %v reveal hidden media", suggestions)
+	}
+	_, suggestions = list("viewAll", "?fuzzy=forecats")
+	if !strings.Contains(strings.Join(suggestions, " "), "forecast") {
+		t.Errorf("viewAll: suggestions %v lack a term of visible media", suggestions)
+	}
+}
+
+func TestBatchDownloadAccess(t *testing.T) {
+	f := newAccessFixture(t)
+	ids := []uuid.UUID{f.ownerMedia.ID, f.sharedMedia.ID, f.hiddenMedia.ID}
+
+	tests := []struct {
+		caller string
+		want   []string
+	}{
+		{"restricted", []string{f.sharedMedia.OriginalFilename}},
+		{"owner", []string{f.ownerMedia.OriginalFilename, f.sharedMedia.OriginalFilename}},
+		{"viewAll", []string{f.ownerMedia.OriginalFilename, f.sharedMedia.OriginalFilename, f.hiddenMedia.OriginalFilename}},
+	}
+	for _, tt := range tests {
+		w := f.do(t, tt.caller, "POST", "/api/media/batch-download", map[string]any{"ids": ids})
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d", tt.caller, w.Code)
+		}
+		archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.caller, err)
+		}
+		var got []string
+		for _, file := range archive.File {
+			got = append(got, file.Name)
+		}
+		sort.Strings(got)
+		sort.Strings(tt.want)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: archive holds %v, want %v", tt.caller, got, tt.want)
+		}
+	}
+}
+
+func TestCreateShareLinkAccess(t *testing.T) {
+	f := newAccessFixture(t)
+	media := func(id uuid.UUID) map[string]any {
+		return map[string]any{"scope": "media", "media_ids": []uuid.UUID{id}}
+	}
+	folder := func(id uuid.UUID) map[string]any {
+		return map[string]any{"scope": "folder", "folder_id": id}
+	}
+
+	f.run(t, []accessCase{
+		{"restricted", "POST", "/api/shares", media(f.ownerMedia.ID), http.StatusNotFound},
+		{"restricted", "POST", "/api/shares", media(f.sharedMedia.ID), http.StatusCreated},
+		{"owner", "POST", "/api/shares", media(f.ownerMedia.ID), http.StatusCreated},
+		{"owner", "POST", "/api/shares", media(f.hiddenMedia.ID), http.StatusNotFound},
+		{"viewAll", "POST", "/api/shares", media(f.hiddenMedia.ID), http.StatusCreated},
+
+		{"restricted", "POST", "/api/shares", folder(f.mixedFolder), http.StatusForbidden},
+		{"restricted", "POST", "/api/shares", folder(f.sharedFolder), http.StatusCreated},
+		{"owner", "POST", "/api/shares", folder(f.mixedFolder), http.StatusCreated},
+		{"viewAll", "POST", "/api/shares", folder(f.mixedFolder), http.StatusCreated},
+	})
+}
+
+func TestMediaWriteAccess(t *testing.T) {
+	f := newAccessFixture(t)
+	own := "/api/media/" + f.ownerMedia.ID.String()
+	shared := "/api/media/" + f.sharedMedia.ID.String()
+	hidden := "/api/media/" + f.hiddenMedia.ID.String()
+	tags := map[string]any{"tags": []string{"reviewed"}}
+	move := map[string]any{"folder_path": ""}
+
+	f.run(t, []accessCase{
+		// Invisible media is missing, visible media of others is forbidden
+		// without the edit_any and manage_any permissions
+		{"restricted", "PATCH", own, tags, http.StatusNotFound},
+		{"restricted", "PATCH", shared, tags, http.StatusForbidden},
+		{"owner", "PATCH", own, tags, http.StatusOK},
+		{"owner", "PATCH", hidden, tags, http.StatusNotFound},
+		{"viewAll", "PATCH", hidden, tags, http.StatusOK},
+
+		{"restricted", "POST", own + "/move", move, http.StatusNotFound},
+		{"restricted", "POST", shared + "/move", move, http.StatusForbidden},
+		{"owner", "POST", own + "/move", move, http.StatusOK},
+		{"owner", "POST", hidden + "/move", move, http.StatusNotFound},
+		{"viewAll", "POST", hidden + "/move", move, http.StatusOK},
+
+		{"restricted", "DELETE", own, nil, http.StatusNotFound},
+		{"restricted", "DELETE", shared, nil, http.StatusForbidden},
+		{"owner", "DELETE", hidden, nil, http.StatusNotFound},
+		{"owner", "DELETE", own, nil, http.StatusOK},
+		{"viewAll", "DELETE", hidden, nil, http.StatusOK},
+	})
+}
+
+func TestStorageAccountAccess(t *testing.T) {
+	f := newAccessFixture(t)
+	shared := "/api/storage-accounts/" + f.shared.ID.String()
+	hidden := "/api/storage-accounts/" + f.hidden.ID.String()
+	rename := map[string]any{"name": "renamed"}
+	grant := map[string]any{"employee_id": f.bystander.ID}
+
+	f.run(t, []accessCase{
+		{"restricted", "GET", shared, nil, http.StatusOK},
+		{"restricted", "GET", hidden, nil, http.StatusNotFound},
+		{"owner", "GET", hidden, nil, http.StatusNotFound},
+		{"viewAll", "GET", hidden, nil, http.StatusOK},
+
+		{"restricted", "PATCH", hidden, rename, http.StatusNotFound},
+		{"owner", "PATCH", shared, rename, http.StatusOK},
+		{"viewAll", "PATCH", hidden, rename, http.StatusOK},
+		{"restricted", "DELETE", hidden, nil, http.StatusNotFound},
+
+		{"restricted", "GET", hidden + "/access", nil, http.StatusNotFound},
+		{"viewAll", "GET", hidden + "/access", nil, http.StatusOK},
+		{"restricted", "POST", hidden + "/access", map[string]any{"employee_id": f.restricted.employee.ID}, http.StatusNotFound},
+		{"viewAll", "POST", hidden + "/access", grant, http.StatusOK},
+		{"restricted", "DELETE", hidden + "/access/" + f.bystander.ID.String(), nil, http.StatusNotFound},
+		{"viewAll", "DELETE", hidden + "/access/" + f.bystander.ID.String(), nil, http.StatusOK},
+	})
+
+	// The restricted caller did not grant themselves the hidden account
+	if w := f.do(t, "restricted", "GET", hidden, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("hidden account after self-grant attempt: status = %d", w.Code)
+	}
+
+	lists := map[string]map[uuid.UUID]bool{
+		"restricted": {f.shared.ID: true, f.hidden.ID: false},
+		"owner":      {f.shared.ID: true, f.hidden.ID: false},
+		"viewAll":    {f.shared.ID: true, f.hidden.ID: true},
+	}
+	for caller, want := range lists {
+		w := f.do(t, caller, "GET", "/api/storage-accounts", nil)
+		var accounts []models.StorageAccountWithStats
+		if err := json.Unmarshal(w.Body.Bytes(), &accounts); err != nil {
+			t.Fatalf("%s: %v: %s", caller, err, w.Body.String())
+		}
+		got := make(map[uuid.UUID]bool)
+		for _, a := range accounts {
+			got[a.ID] = true
+		}
+		for id, sees := range want {
+			if got[id] != sees {
+				t.Errorf("%s: lists account %s = %v, want %v", caller, id, got[id], sees)
+			}
+		}
+	}
+}
+
+func TestMediaGroupAccess(t *testing.T) {
+	f := newAccessFixture(t)
+	group := "/api/groups/" + f.devGroup.ID.String()
+	describe := map[string]any{"description": "for developers"}
+
+	f.run(t, []accessCase{
+		{"restricted", "GET", group, nil, http.StatusNotFound},
+		{"owner", "GET", group, nil, http.StatusOK},
+		{"viewAll", "GET", group, nil, http.StatusOK},
+
+		{"restricted", "PATCH", group, describe, http.StatusNotFound},
+		{"owner", "PATCH", group, describe, http.StatusOK},
+		{"viewAll", "PATCH", group, describe, http.StatusOK},
+		{"restricted", "DELETE", group, nil, http.StatusNotFound},
+	})
+
+	lists := map[string]bool{"restricted": false, "owner": true, "viewAll": true}
+	for caller, want := range lists {
+		w := f.do(t, caller, "GET", "/api/groups", nil)
+		var groups []models.MediaGroup
+		if err := json.Unmarshal(w.Body.Bytes(), &groups); err != nil {
+			t.Fatalf("%s: %v: %s", caller, err, w.Body.String())
+		}
+		got := false
+		for _, g := range groups {
+			got = got || g.ID == f.devGroup.ID
+		}
+		if got != want {
+			t.Errorf("%s: lists the developer group = %v, want %v", caller, got, want)
+		}
+	}
+}
+
+func TestCollectionCoverAccess(t *testing.T) {
+	f := newAccessFixture(t)
+
+	// The owner shares a collection, with a cover the restricted caller
+	// cannot see, with everyone
+	w := f.do(t, "owner", "POST", "/api/collections", map[string]any{
+		"name":           "launch",
+		"cover_media_id": f.ownerMedia.ID,
+		"media_ids":      []uuid.UUID{f.ownerMedia.ID, f.sharedMedia.ID},
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create collection: status = %d: %s", w.Code, w.Body.String())
+	}
+	var created models.Collection
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	path := "/api/collections/" + created.ID.String()
+	w = f.do(t, "owner", "PUT", path+"/access", map[string]any{
+		"grants": []map[string]any{{"employee_id": f.restricted.employee.ID, "permission": "view"}},
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("share collection: status = %d: %s", w.Code, w.Body.String())
+	}
+
+	tests := []struct {
+		caller    string
+		wantCover bool
+		wantItems int64
+	}{
+		{"owner", true, 2},
+		{"restricted", false, 1},
+	}
+	for _, tt := range tests {
+		var got models.Collection
+		w := f.do(t, tt.caller, "GET", path, nil)
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Fatalf("%s: %v: %s", tt.caller, err, w.Body.String())
+		}
+		if (got.CoverURL != nil) != tt.wantCover || got.ItemCount != tt.wantItems {
+			t.Errorf("%s: cover %v and %d items, want cover %v and %d items", tt.caller, got.CoverURL, got.ItemCount, tt.wantCover, tt.wantItems)
+		}
+
+		var listed []models.Collection
+		w = f.do(t, tt.caller, "GET", "/api/collections", nil)
+		if err := json.Unmarshal(w.Body.Bytes(), &listed); err != nil {
+			t.Fatalf("%s: %v: %s", tt.caller, err, w.Body.String())
+		}
+		if len(listed) != 1 || (listed[0].CoverURL != nil) != tt.wantCover {
+			t.Errorf("%s: lists %+v, want one collection with cover %v", tt.caller, listed, tt.wantCover)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// activityHeartbeat keeps idle streams from being closed by proxies
+const activityHeartbeat = 25 * time.Second
+
+// StreamEvents pushes live activity the employee can see as Server-Sent
+// Events: uploads, deletions, moves and storage sync progress. The stream
+// ends when the token it was opened with expires, and what the employee may
+// see is resolved again on every heartbeat.
+// GET /api/events/stream
+func (h *MediaHandler) StreamEvents(c *gin.Context) {
+	employee, _ := h.getEmployee(c)
+
+	sub, err := h.mediaService.SubscribeActivity(c.Request.Context(), employee)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to open event stream",
+			Code:  "STREAM_FAILED",
+		})
+		return
+	}
+	defer sub.Close()
+
+	// The stream outlives the server's write timeout
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Disable nginx response buffering
+	c.Status(http.StatusOK)
+	fmt.Fprint(c.Writer, ": connected\n\n")
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(activityHeartbeat)
+	defer heartbeat.Stop()
+
+	// Clients reconnect with a fresh token once their stream ends
+	var expired <-chan time.Time
+	if expiresAt, ok := c.Get("credentials_expire_at"); ok {
+		expiry := time.NewTimer(time.Until(expiresAt.(time.Time)))
+		defer expiry.Stop()
+		expired = expiry.C
+	}
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-sub.Done():
+			return false
+		case <-expired:
+			return false
+		case event := <-sub.Events():
+			c.SSEvent(event.Type, event)
+			return true
+		case <-heartbeat.C:
+			err := h.mediaService.RefreshActivity(c.Request.Context(), sub)
+			if errors.Is(err, services.ErrAccountDisabled) {
+				return false
+			}
+			if err != nil {
+				// Keep the visibility resolved last and try again next time
+				log.Printf("[MediaHandler] failed to refresh event stream of %s: %v", employee.ID, err)
+			}
+			fmt.Fprint(w, ": keepalive\n\n")
+			return true
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"log"
 	"net/http"
 
@@ -33,7 +34,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	log.Printf("Login attempt for email: %s", req.Email)
-	response, err := h.authService.Login(c.Request.Context(), req.Email, req.Password)
+	response, challenge, err := h.authService.Login(c.Request.Context(), req.Email, req.Password, sessionClient(c))
 	if err != nil {
 		log.Printf("Login failed for email %s: %v", req.Email, err)
 		status := http.StatusUnauthorized
@@ -47,6 +48,10 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		})
 		return
 	}
+	if challenge != nil {
+		c.JSON(http.StatusOK, challenge)
+		return
+	}
 
 	c.JSON(http.StatusOK, response)
 }
@@ -65,18 +70,80 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	response, err := h.authService.RefreshTokens(c.Request.Context(), req.RefreshToken)
+	response, err := h.authService.RefreshTokens(c.Request.Context(), req.RefreshToken, sessionClient(c))
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-			Error: "Invalid or expired refresh token",
-			Code:  "INVALID_TOKEN",
-		})
+		switch err {
+		case services.ErrTokenReused:
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error: err.Error(),
+				Code:  "TOKEN_REUSED",
+			})
+		case services.ErrAccountDisabled:
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error: err.Error(),
+				Code:  "ACCOUNT_DISABLED",
+			})
+		default:
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error: "Invalid or expired refresh token",
+				Code:  "INVALID_TOKEN",
+			})
+		}
 		return
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
+// Logout revokes the refresh token and the rest of its login
+// POST /api/auth/logout
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid request body",
+			Code:  "INVALID_REQUEST",
+		})
+		return
+	}
+
+	if err := h.authService.Logout(c.Request.Context(), req.RefreshToken); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to log out",
+			Code:  "LOGOUT_FAILED",
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// LogoutAll revokes every refresh token of the current employee
+// POST /api/auth/logout-all
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	employeeID := c.MustGet("employee_id").(uuid.UUID)
+
+	if err := h.authService.LogoutAll(c.Request.Context(), employeeID); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to log out",
+			Code:  "LOGOUT_FAILED",
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// JWKS publishes the public keys access tokens are signed with. The set is
+// empty when tokens are signed with a shared secret.
+// GET /.well-known/jwks.json
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.authService.JWKS())
+}
+
 // GetCurrentUser returns the current authenticated user
 // GET /api/auth/me
 func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
@@ -90,6 +157,7 @@ func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
 		})
 		return
 	}
+	employee.Permissions = permissions(c)
 
 	c.JSON(http.StatusOK, employee)
 }
@@ -107,11 +175,12 @@ func (h *AuthHandler) CreateEmployee(c *gin.Context) {
 		return
 	}
 
-	employee, err := h.authService.CreateEmployee(c.Request.Context(), &req)
+	employee, err := h.authService.CreateEmployee(c.Request.Context(), &req, caller(c))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+		status, code := employeeErrorStatus(err, "CREATE_FAILED")
+		c.JSON(status, models.ErrorResponse{
 			Error: err.Error(),
-			Code:  "CREATE_FAILED",
+			Code:  code,
 		})
 		return
 	}
@@ -189,11 +258,12 @@ func (h *AuthHandler) UpdateEmployee(c *gin.Context) {
 		return
 	}
 
-	employee, err := h.authService.UpdateEmployee(c.Request.Context(), id, &req)
+	employee, err := h.authService.UpdateEmployee(c.Request.Context(), id, &req, caller(c))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+		status, code := employeeErrorStatus(err, "UPDATE_FAILED")
+		c.JSON(status, models.ErrorResponse{
 			Error: err.Error(),
-			Code:  "UPDATE_FAILED",
+			Code:  code,
 		})
 		return
 	}
@@ -225,3 +295,14 @@ func (h *AuthHandler) DeleteEmployee(c *gin.Context) {
 		Message: "Employee deleted successfully",
 	})
 }
+
+// employeeErrorStatus maps employee management errors to a status and code,
+// falling back to a bad request with fallback
+func employeeErrorStatus(err error, fallback string) (int, string) {
+	switch {
+	case errors.Is(err, services.ErrRoleNotGrantable), errors.Is(err, services.ErrOwnRole):
+		return http.StatusForbidden, "FORBIDDEN"
+	default:
+		return http.StatusBadRequest, fallback
+	}
+}
@@ -0,0 +1,373 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// collectionErrorStatus maps collection service errors to HTTP statuses
+func collectionErrorStatus(err error) (int, string) {
+	switch err {
+	case services.ErrCollectionNotFound, services.ErrMediaNotFound, services.ErrEmployeeNotFound:
+		return http.StatusNotFound, "NOT_FOUND"
+	case services.ErrForbidden:
+		return http.StatusForbidden, "FORBIDDEN"
+	case services.ErrMediaQuarantined:
+		return http.StatusForbidden, "MEDIA_QUARANTINED"
+	case services.ErrInvalidInput:
+		return http.StatusBadRequest, "INVALID_REQUEST"
+	default:
+		return http.StatusInternalServerError, "COLLECTION_FAILED"
+	}
+}
+
+// CreateCollection creates a collection
+// POST /api/collections
+func (h *MediaHandler) CreateCollection(c *gin.Context) {
+	var req models.CreateCollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Code:    "INVALID_REQUEST",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	employee, _ := h.getEmployee(c)
+
+	collection, err := h.mediaService.CreateCollection(c.Request.Context(), &req, employee)
+	if err != nil {
+		status, code := collectionErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, collection)
+}
+
+// ListCollections lists the collections the employee can view
+// GET /api/collections
+func (h *MediaHandler) ListCollections(c *gin.Context) {
+	employee, _ := h.getEmployee(c)
+
+	collections, err := h.mediaService.ListCollections(c.Request.Context(), employee)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to list collections",
+			Code:  "LIST_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, collections)
+}
+
+// GetCollection gets a collection
+// GET /api/collections/:id
+func (h *MediaHandler) GetCollection(c *gin.Context) {
+	id, ok := parseCollectionID(c)
+	if !ok {
+		return
+	}
+
+	employee, _ := h.getEmployee(c)
+
+	collection, err := h.mediaService.GetCollection(c.Request.Context(), id, employee)
+	if err != nil {
+		status, code := collectionErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, collection)
+}
+
+// UpdateCollection changes a collection's name, description or cover
+// PATCH /api/collections/:id
+func (h *MediaHandler) UpdateCollection(c *gin.Context) {
+	id, ok := parseCollectionID(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateCollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Code:    "INVALID_REQUEST",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	employee, _ := h.getEmployee(c)
+
+	collection, err := h.mediaService.UpdateCollection(c.Request.Context(), id, &req, employee)
+	if err != nil {
+		status, code := collectionErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, collection)
+}
+
+// DeleteCollection deletes a collection, leaving its media in place
+// DELETE /api/collections/:id
+func (h *MediaHandler) DeleteCollection(c *gin.Context) {
+	id, ok := parseCollectionID(c)
+	if !ok {
+		return
+	}
+
+	employee, _ := h.getEmployee(c)
+
+	if err := h.mediaService.DeleteCollection(c.Request.Context(), id, employee); err != nil {
+		status, code := collectionErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Collection deleted successfully",
+	})
+}
+
+// ListCollectionMedia lists the media of a collection in order
+// GET /api/collections/:id/media
+func (h *MediaHandler) ListCollectionMedia(c *gin.Context) {
+	id, ok := parseCollectionID(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "50"))
+
+	employee, _ := h.getEmployee(c)
+
+	response, err := h.mediaService.ListCollectionMedia(c.Request.Context(), id, page, pageSize, employee)
+	if err != nil {
+		status, code := collectionErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// AddCollectionItems appends media to a collection
+// POST /api/collections/:id/media
+func (h *MediaHandler) AddCollectionItems(c *gin.Context) {
+	h.changeCollectionItems(c, h.mediaService.AddCollectionItems)
+}
+
+// RemoveCollectionItems takes media out of a collection
+// POST /api/collections/:id/media/remove
+func (h *MediaHandler) RemoveCollectionItems(c *gin.Context) {
+	h.changeCollectionItems(c, h.mediaService.RemoveCollectionItems)
+}
+
+// changeCollectionItems runs a bulk add or remove of collection items
+func (h *MediaHandler) changeCollectionItems(c *gin.Context, change func(context.Context, uuid.UUID, []uuid.UUID, *models.Employee) (*models.Collection, error)) {
+	id, ok := parseCollectionID(c)
+	if !ok {
+		return
+	}
+
+	var req models.CollectionItemsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Code:    "INVALID_REQUEST",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	employee, _ := h.getEmployee(c)
+
+	collection, err := change(c.Request.Context(), id, req.MediaIDs, employee)
+	if err != nil {
+		status, code := collectionErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, collection)
+}
+
+// ReorderCollectionItems moves media to the front of a collection in the
+// given order
+// PUT /api/collections/:id/order
+func (h *MediaHandler) ReorderCollectionItems(c *gin.Context) {
+	id, ok := parseCollectionID(c)
+	if !ok {
+		return
+	}
+
+	var req models.CollectionItemsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Code:    "INVALID_REQUEST",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	employee, _ := h.getEmployee(c)
+
+	if err := h.mediaService.ReorderCollectionItems(c.Request.Context(), id, req.MediaIDs, employee); err != nil {
+		status, code := collectionErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Collection reordered successfully",
+	})
+}
+
+// DownloadCollection exports a collection as a ZIP, in collection order
+// GET /api/collections/:id/download
+func (h *MediaHandler) DownloadCollection(c *gin.Context) {
+	id, ok := parseCollectionID(c)
+	if !ok {
+		return
+	}
+
+	employee, _ := h.getEmployee(c)
+
+	collection, ids, err := h.mediaService.CollectionExportIDs(c.Request.Context(), id, employee)
+	if err != nil {
+		status, code := collectionErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", collectionZipName(collection.Name)))
+
+	if err := h.mediaService.BatchDownloadMedia(c.Request.Context(), ids, employee, c.Writer); err != nil {
+		log.Printf("Collection download failed: %v", err)
+		// Header is already sent, so we can't send a JSON error
+	}
+}
+
+// GetCollectionAccess lists who may view or edit a collection
+// GET /api/collections/:id/access
+func (h *MediaHandler) GetCollectionAccess(c *gin.Context) {
+	id, ok := parseCollectionID(c)
+	if !ok {
+		return
+	}
+
+	employee, _ := h.getEmployee(c)
+
+	grants, err := h.mediaService.GetCollectionAccess(c.Request.Context(), id, employee)
+	if err != nil {
+		status, code := collectionErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, grants)
+}
+
+// SetCollectionAccess replaces who may view or edit a collection
+// PUT /api/collections/:id/access
+func (h *MediaHandler) SetCollectionAccess(c *gin.Context) {
+	id, ok := parseCollectionID(c)
+	if !ok {
+		return
+	}
+
+	var req models.SetCollectionAccessRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Code:    "INVALID_REQUEST",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	employee, _ := h.getEmployee(c)
+
+	grants, err := h.mediaService.SetCollectionAccess(c.Request.Context(), id, &req, employee)
+	if err != nil {
+		status, code := collectionErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, grants)
+}
+
+// collectionZipName turns a collection name into a safe ZIP file name
+func collectionZipName(name string) string {
+	safe := strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(`"\/:*?<>|`, r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if safe == "" {
+		safe = "collection"
+	}
+	return safe + ".zip"
+}
+
+// parseCollectionID reads the :id parameter of collection routes
+func parseCollectionID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid collection ID",
+			Code:  "INVALID_ID",
+		})
+		return uuid.Nil, false
+	}
+	return id, true
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ListLegalHolds lists legal holds (admin only)
+// GET /api/admin/legal-holds
+func (h *MediaHandler) ListLegalHolds(c *gin.Context) {
+	activeOnly := c.DefaultQuery("active", "true") == "true"
+
+	holds, err := h.mediaService.ListLegalHolds(c.Request.Context(), activeOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to list legal holds",
+			Code:  "LIST_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, holds)
+}
+
+// GetLegalHold gets a single legal hold (admin only)
+// GET /api/admin/legal-holds/:id
+func (h *MediaHandler) GetLegalHold(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid legal hold ID",
+			Code:  "INVALID_ID",
+		})
+		return
+	}
+
+	hold, err := h.mediaService.GetLegalHold(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: "Legal hold not found",
+			Code:  "NOT_FOUND",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, hold)
+}
+
+// PlaceLegalHold places a legal hold on a media item, folder or group (admin only)
+// POST /api/admin/legal-holds
+func (h *MediaHandler) PlaceLegalHold(c *gin.Context) {
+	var req models.CreateLegalHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Code:    "INVALID_REQUEST",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	employee, _ := h.getEmployee(c)
+
+	hold, err := h.mediaService.PlaceLegalHold(c.Request.Context(), &req, employee)
+	if err != nil {
+		var status int
+		switch err {
+		case services.ErrGroupNotFound, services.ErrMediaNotFound, services.ErrEmployeeNotFound:
+			status = http.StatusNotFound
+		case services.ErrInvalidInput:
+			status = http.StatusBadRequest
+		default:
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "HOLD_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, hold)
+}
+
+// ReleaseLegalHold releases a legal hold (admin only)
+// POST /api/admin/legal-holds/:id/release
+func (h *MediaHandler) ReleaseLegalHold(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid legal hold ID",
+			Code:  "INVALID_ID",
+		})
+		return
+	}
+
+	var req models.ReleaseLegalHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Code:    "INVALID_REQUEST",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	employee, _ := h.getEmployee(c)
+
+	hold, err := h.mediaService.ReleaseLegalHold(c.Request.Context(), id, req.Reason, employee)
+	if err != nil {
+		var status int
+		switch err {
+		case services.ErrHoldNotFound:
+			status = http.StatusNotFound
+		case services.ErrHoldReleased:
+			status = http.StatusConflict
+		default:
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "RELEASE_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, hold)
+}
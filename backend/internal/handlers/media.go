@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -33,11 +34,82 @@ func (h *MediaHandler) getEmployee(c *gin.Context) (*models.Employee, error) {
 	email := c.MustGet("employee_email").(string)
 	role := c.MustGet("employee_role").(models.Role)
 
-	return &models.Employee{
+	employee := &models.Employee{
 		ID:    employeeID,
 		Email: email,
 		Role:  role,
-	}, nil
+	}
+	employee.KeyLimits = keyLimits(c)
+	employee.Permissions = permissions(c)
+	return employee, nil
+}
+
+// permissions returns the permissions the caller was authenticated with
+func permissions(c *gin.Context) []models.Permission {
+	if held, ok := c.Get("employee_permissions"); ok {
+		return held.([]models.Permission)
+	}
+	return nil
+}
+
+// caller returns the employee making the request with the permissions they
+// were authenticated with
+func caller(c *gin.Context) *models.Employee {
+	return &models.Employee{
+		ID:          c.MustGet("employee_id").(uuid.UUID),
+		Role:        c.MustGet("employee_role").(models.Role),
+		Permissions: permissions(c),
+	}
+}
+
+// can reports whether the caller holds a permission
+func can(c *gin.Context, permission models.Permission) bool {
+	for _, held := range permissions(c) {
+		if held == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// keyLimits returns the limits of the API key a request was made with, or
+// nil when the request is not limited
+func keyLimits(c *gin.Context) *models.APIKeyLimits {
+	if limits, ok := c.Get("api_key_limits"); ok {
+		return limits.(*models.APIKeyLimits)
+	}
+	return nil
+}
+
+// EnforceKeyLimits keeps API keys limited to storage accounts or groups
+// away from media outside them, on routes addressing media by :id
+func (h *MediaHandler) EnforceKeyLimits(c *gin.Context) {
+	limits := keyLimits(c)
+	if limits == nil || c.Param("id") == "" {
+		c.Next()
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Next() // The handler reports the invalid ID
+		return
+	}
+	employee, _ := h.getEmployee(c)
+	media, err := h.mediaService.GetMedia(c.Request.Context(), id, employee)
+	if err != nil {
+		c.Next() // The handler reports the missing media
+		return
+	}
+
+	if !limits.AllowsStorageAccount(media.StorageAccountID) || !limits.AllowsMediaGroup(media.MediaGroupID) {
+		c.AbortWithStatusJSON(http.StatusNotFound, models.ErrorResponse{
+			Error: "Media not found",
+			Code:  "NOT_FOUND",
+		})
+		return
+	}
+	c.Next()
 }
 
 // InitiateUpload starts the upload process
@@ -83,6 +155,20 @@ func (h *MediaHandler) InitiateUpload(c *gin.Context) {
 		req.FileSize,
 		employee,
 	)
+	if err == services.ErrOutsideKeyLimits {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "OUTSIDE_KEY_LIMITS",
+		})
+		return
+	}
+	if err == services.ErrForbidden {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error: "Storage account or media group not available to you",
+			Code:  "FORBIDDEN",
+		})
+		return
+	}
 	if err != nil {
 		log.Printf("[MediaHandler] InitiateUpload: Service error: %v", err)
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
@@ -133,7 +219,9 @@ func (h *MediaHandler) CompleteUpload(c *gin.Context) {
 	c.JSON(http.StatusOK, media)
 }
 
-// ListMedia lists media with filters
+// ListMedia lists media with filters. Passing cursor (empty for the first
+// page) switches from page numbers to keyset pagination, and facets=true
+// adds counts per facet for the filtered set.
 // GET /api/media
 func (h *MediaHandler) ListMedia(c *gin.Context) {
 	var filters models.MediaFilterRequest
@@ -147,9 +235,34 @@ func (h *MediaHandler) ListMedia(c *gin.Context) {
 	}
 
 	employee, _ := h.getEmployee(c)
+	h.respondMediaList(c, &filters, employee)
+}
 
-	response, err := h.mediaService.ListMedia(c.Request.Context(), &filters, employee)
+// respondMediaList lists media in page or cursor mode and writes the result
+func (h *MediaHandler) respondMediaList(c *gin.Context, filters *models.MediaFilterRequest, employee *models.Employee) {
+	var response any
+	var err error
+	if filters.Cursor != nil {
+		response, err = h.mediaService.ListMediaCursor(c.Request.Context(), filters, employee)
+	} else {
+		response, err = h.mediaService.ListMedia(c.Request.Context(), filters, employee)
+	}
 	if err != nil {
+		var queryErr *services.QueryError
+		if errors.As(err, &queryErr) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: queryErr.Error(),
+				Code:  "INVALID_QUERY",
+			})
+			return
+		}
+		if err == services.ErrInvalidCursor {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: err.Error(),
+				Code:  "INVALID_CURSOR",
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error: "Failed to list media",
 			Code:  "LIST_FAILED",
@@ -172,7 +285,9 @@ func (h *MediaHandler) GetMedia(c *gin.Context) {
 		return
 	}
 
-	media, err := h.mediaService.GetMedia(c.Request.Context(), id)
+	employee, _ := h.getEmployee(c)
+
+	media, err := h.mediaService.GetMedia(c.Request.Context(), id, employee)
 	if err != nil {
 		c.JSON(http.StatusNotFound, models.ErrorResponse{
 			Error: "Media not found",
@@ -206,31 +321,25 @@ func (h *MediaHandler) UpdateMedia(c *gin.Context) {
 		return
 	}
 
-	// Get current media
-	media, err := h.mediaService.GetMedia(c.Request.Context(), id)
-	if err != nil {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{
-			Error: "Media not found",
-			Code:  "NOT_FOUND",
-		})
-		return
-	}
-
-	// Update fields
-	if req.MediaGroupID != nil {
-		media.MediaGroupID = req.MediaGroupID
-	}
-	if req.FolderID != nil {
-		media.FolderID = req.FolderID
-	}
-	if req.Tags != nil {
-		media.Tags = req.Tags
-	}
+	employee, _ := h.getEmployee(c)
 
-	if err := h.repo.UpdateMedia(c.Request.Context(), &media.Media); err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error: "Failed to update media",
-			Code:  "UPDATE_FAILED",
+	media, err := h.mediaService.UpdateMedia(c.Request.Context(), id, &req, employee)
+	if err != nil {
+		var status int
+		var code string
+		switch err {
+		case services.ErrMediaNotFound:
+			status, code = http.StatusNotFound, "NOT_FOUND"
+		case services.ErrForbidden:
+			status, code = http.StatusForbidden, "FORBIDDEN"
+		case services.ErrLegalHold:
+			status, code = http.StatusConflict, "LEGAL_HOLD"
+		default:
+			status, code = http.StatusInternalServerError, "UPDATE_FAILED"
+		}
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
 		})
 		return
 	}
@@ -238,9 +347,15 @@ func (h *MediaHandler) UpdateMedia(c *gin.Context) {
 	c.JSON(http.StatusOK, media)
 }
 
-// DeleteMedia soft deletes a media item
+// DeleteMedia moves a media item into the trash. With ?permanent=true it is
+// deleted permanently right away instead (admin only).
 // DELETE /api/media/:id
 func (h *MediaHandler) DeleteMedia(c *gin.Context) {
+	if c.Query("permanent") == "true" {
+		h.PurgeMedia(c)
+		return
+	}
+
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
@@ -262,6 +377,12 @@ func (h *MediaHandler) DeleteMedia(c *gin.Context) {
 		case services.ErrForbidden:
 			status = http.StatusForbidden
 			code = "FORBIDDEN"
+		case services.ErrRetentionActive:
+			status = http.StatusConflict
+			code = "RETENTION_ACTIVE"
+		case services.ErrLegalHold:
+			status = http.StatusConflict
+			code = "LEGAL_HOLD"
 		default:
 			status = http.StatusBadRequest
 			code = "DELETE_FAILED"
@@ -274,11 +395,11 @@ func (h *MediaHandler) DeleteMedia(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, models.SuccessResponse{
-		Message: "Media deleted successfully",
+		Message: "Media moved to trash",
 	})
 }
 
-// BatchDeleteMedia soft deletes multiple media items
+// BatchDeleteMedia moves multiple media items into the trash
 // POST /api/media/batch-delete
 func (h *MediaHandler) BatchDeleteMedia(c *gin.Context) {
 	var req struct {
@@ -304,7 +425,7 @@ func (h *MediaHandler) BatchDeleteMedia(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, models.SuccessResponse{
-		Message: "Media items deleted successfully",
+		Message: "Media items moved to trash",
 	})
 }
 
@@ -340,6 +461,8 @@ func (h *MediaHandler) MoveMedia(c *gin.Context) {
 			status = http.StatusNotFound
 		case services.ErrForbidden:
 			status = http.StatusForbidden
+		case services.ErrLegalHold:
+			status = http.StatusConflict
 		default:
 			status = http.StatusBadRequest
 		}
@@ -365,7 +488,30 @@ func (h *MediaHandler) GetPublicURL(c *gin.Context) {
 		return
 	}
 
-	url, err := h.mediaService.GetPublicURL(c.Request.Context(), id)
+	employee, _ := h.getEmployee(c)
+
+	url, err := h.mediaService.GetPublicURL(c.Request.Context(), id, employee)
+	if err == services.ErrMediaQuarantined {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "MEDIA_QUARANTINED",
+		})
+		return
+	}
+	if err == services.ErrScanPending {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "SCAN_PENDING",
+		})
+		return
+	}
+	if err == services.ErrAwaitingReview {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "AWAITING_REVIEW",
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusNotFound, models.ErrorResponse{
 			Error: "Media not found",
@@ -391,7 +537,23 @@ func (h *MediaHandler) DownloadMedia(c *gin.Context) {
 		return
 	}
 
-	media, reader, err := h.mediaService.DownloadMedia(c.Request.Context(), id)
+	employee, _ := h.getEmployee(c)
+
+	media, reader, err := h.mediaService.DownloadMedia(c.Request.Context(), id, employee)
+	if err == services.ErrMediaQuarantined {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "MEDIA_QUARANTINED",
+		})
+		return
+	}
+	if err == services.ErrScanPending {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "SCAN_PENDING",
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusNotFound, models.ErrorResponse{
 			Error: "Failed to download media",
@@ -425,10 +587,12 @@ func (h *MediaHandler) BatchDownloadMedia(c *gin.Context) {
 		return
 	}
 
+	employee, _ := h.getEmployee(c)
+
 	c.Header("Content-Type", "application/zip")
 	c.Header("Content-Disposition", "attachment; filename=\"media_vault_export.zip\"")
 
-	err := h.mediaService.BatchDownloadMedia(c.Request.Context(), req.IDs, c.Writer)
+	err := h.mediaService.BatchDownloadMedia(c.Request.Context(), req.IDs, employee, c.Writer)
 	if err != nil {
 		log.Printf("Batch download failed: %v", err)
 		// Header is already sent, so we can't send a JSON error
@@ -448,6 +612,26 @@ func (h *MediaHandler) ListAuditLogs(c *gin.Context) {
 		return
 	}
 
+	if filter.Cursor != nil {
+		page, err := h.mediaService.ListAuditLogsCursor(c.Request.Context(), &filter)
+		if err != nil {
+			if err == services.ErrInvalidCursor {
+				c.JSON(http.StatusBadRequest, models.ErrorResponse{
+					Error: err.Error(),
+					Code:  "INVALID_CURSOR",
+				})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: "Failed to list audit logs",
+				Code:  "LIST_FAILED",
+			})
+			return
+		}
+		c.JSON(http.StatusOK, page)
+		return
+	}
+
 	if filter.Page <= 0 {
 		filter.Page = 1
 	}
@@ -470,10 +654,11 @@ func (h *MediaHandler) ListAuditLogs(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, models.PaginatedResponse[models.AuditLog]{
-		Data:       logs,
-		Total:      total,
-		Page:       filter.Page,
-		PageSize:   filter.PageSize,
-		TotalPages: totalPages,
+		Data:           logs,
+		Total:          total,
+		Page:           filter.Page,
+		PageSize:       filter.PageSize,
+		TotalPages:     totalPages,
+		TotalEstimated: filter.Count == models.CountEstimate,
 	})
 }
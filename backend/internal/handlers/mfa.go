@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// mfaErrorStatus maps two-factor authentication errors to a status and
+// error code. Anything else is an internal error.
+func mfaErrorStatus(err error) (int, string) {
+	switch err {
+	case services.ErrMFAInvalidCode:
+		return http.StatusUnauthorized, "INVALID_MFA_CODE"
+	case services.ErrMFALocked:
+		return http.StatusTooManyRequests, "MFA_LOCKED"
+	case services.ErrInvalidToken:
+		return http.StatusUnauthorized, "INVALID_TOKEN"
+	case services.ErrAccountDisabled:
+		return http.StatusUnauthorized, "ACCOUNT_DISABLED"
+	case services.ErrMFAAlreadyEnabled:
+		return http.StatusConflict, "MFA_ALREADY_ENABLED"
+	case services.ErrMFARequired:
+		return http.StatusForbidden, "MFA_REQUIRED"
+	case services.ErrMFANotEnabled, services.ErrMFANotPending:
+		return http.StatusBadRequest, "INVALID_REQUEST"
+	case services.ErrEmployeeNotFound:
+		return http.StatusNotFound, "NOT_FOUND"
+	default:
+		return http.StatusInternalServerError, "MFA_FAILED"
+	}
+}
+
+// respondMFAError writes the response for a two-factor authentication error
+func respondMFAError(c *gin.Context, err error) {
+	status, code := mfaErrorStatus(err)
+	c.JSON(status, models.ErrorResponse{
+		Error: err.Error(),
+		Code:  code,
+	})
+}
+
+// VerifyMFA completes a login with a TOTP or recovery code
+// POST /api/auth/mfa/verify
+func (h *AuthHandler) VerifyMFA(c *gin.Context) {
+	var req models.MFAVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid request body",
+			Code:  "INVALID_REQUEST",
+		})
+		return
+	}
+
+	response, err := h.authService.VerifyMFA(c.Request.Context(), req.MFAToken, req.Code, sessionClient(c))
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// BeginMFASetup starts TOTP enrollment during a login whose role requires it
+// POST /api/auth/mfa/setup
+func (h *AuthHandler) BeginMFASetup(c *gin.Context) {
+	var req models.MFASetupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid request body",
+			Code:  "INVALID_REQUEST",
+		})
+		return
+	}
+
+	enrollment, err := h.authService.BeginMFASetup(c.Request.Context(), req.MFAToken)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// CompleteMFASetup confirms the TOTP enrollment of a login and signs in
+// POST /api/auth/mfa/setup/confirm
+func (h *AuthHandler) CompleteMFASetup(c *gin.Context) {
+	var req models.MFAVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid request body",
+			Code:  "INVALID_REQUEST",
+		})
+		return
+	}
+
+	response, err := h.authService.CompleteMFASetup(c.Request.Context(), req.MFAToken, req.Code, sessionClient(c))
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetMFAStatus describes the current employee's two-factor authentication
+// GET /api/auth/mfa
+func (h *AuthHandler) GetMFAStatus(c *gin.Context) {
+	employeeID := c.MustGet("employee_id").(uuid.UUID)
+	status, err := h.authService.MFAStatus(c.Request.Context(), employeeID)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// BeginTOTPEnrollment creates a TOTP secret for the current employee. The
+// provisioning URI is meant to be shown as a QR code.
+// POST /api/auth/mfa/enroll
+func (h *AuthHandler) BeginTOTPEnrollment(c *gin.Context) {
+	employeeID := c.MustGet("employee_id").(uuid.UUID)
+	enrollment, err := h.authService.BeginTOTPEnrollment(c.Request.Context(), employeeID)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// ConfirmTOTPEnrollment enables TOTP with a first code
+// POST /api/auth/mfa/enroll/confirm
+func (h *AuthHandler) ConfirmTOTPEnrollment(c *gin.Context) {
+	var req models.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid request body",
+			Code:  "INVALID_REQUEST",
+		})
+		return
+	}
+
+	employeeID := c.MustGet("employee_id").(uuid.UUID)
+	codes, err := h.authService.ConfirmTOTPEnrollment(c.Request.Context(), employeeID, req.Code)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// RegenerateRecoveryCodes replaces the current employee's recovery codes
+// POST /api/auth/mfa/recovery-codes
+func (h *AuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req models.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid request body",
+			Code:  "INVALID_REQUEST",
+		})
+		return
+	}
+
+	employeeID := c.MustGet("employee_id").(uuid.UUID)
+	codes, err := h.authService.RegenerateRecoveryCodes(c.Request.Context(), employeeID, req.Code)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableMFA turns off the current employee's two-factor authentication
+// DELETE /api/auth/mfa
+func (h *AuthHandler) DisableMFA(c *gin.Context) {
+	var req models.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid request body",
+			Code:  "INVALID_REQUEST",
+		})
+		return
+	}
+
+	employeeID := c.MustGet("employee_id").(uuid.UUID)
+	if err := h.authService.DisableMFA(c.Request.Context(), employeeID, req.Code); err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ResetEmployeeMFA removes an employee's two-factor authentication
+// DELETE /api/admin/employees/:id/mfa
+func (h *AuthHandler) ResetEmployeeMFA(c *gin.Context) {
+	employeeID, ok := parseEmployeeID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.authService.ResetMFA(c.Request.Context(), employeeID); err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetMFAPolicy lists the roles that must use two-factor authentication
+// GET /api/admin/mfa-policy
+func (h *AuthHandler) GetMFAPolicy(c *gin.Context) {
+	policy, err := h.authService.GetMFAPolicy(c.Request.Context())
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// UpdateMFAPolicy replaces the roles that must use two-factor authentication
+// PUT /api/admin/mfa-policy
+func (h *AuthHandler) UpdateMFAPolicy(c *gin.Context) {
+	var req models.MFAPolicy
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Code:    "INVALID_REQUEST",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	adminID := c.MustGet("employee_id").(uuid.UUID)
+	policy, err := h.authService.SetMFAPolicy(c.Request.Context(), &req, adminID)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
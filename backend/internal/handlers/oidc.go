@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// oidcErrorStatus maps single sign-on errors to a status, error code and the
+// message to show. Provider details are only logged.
+func oidcErrorStatus(err error) (int, string, string) {
+	switch {
+	case errors.Is(err, services.ErrOIDCDisabled):
+		return http.StatusNotFound, "SSO_DISABLED", err.Error()
+	case errors.Is(err, services.ErrInvalidToken):
+		return http.StatusUnauthorized, "INVALID_TOKEN", services.ErrInvalidToken.Error()
+	case errors.Is(err, services.ErrAccountDisabled):
+		return http.StatusUnauthorized, "ACCOUNT_DISABLED", err.Error()
+	case errors.Is(err, services.ErrOIDCNotAllowed):
+		return http.StatusForbidden, "SSO_NOT_ALLOWED", err.Error()
+	case errors.Is(err, services.ErrOIDCAccountConflict):
+		return http.StatusConflict, "ACCOUNT_CONFLICT", err.Error()
+	case errors.Is(err, services.ErrOIDCProvider):
+		return http.StatusBadGateway, "SSO_PROVIDER_ERROR", services.ErrOIDCProvider.Error()
+	default:
+		return http.StatusInternalServerError, "SSO_FAILED", "Single sign-on failed"
+	}
+}
+
+// BeginOIDCLogin starts a single sign-on login. The client sends the browser
+// to the returned authorization URL.
+// POST /api/auth/oidc/authorize
+func (h *AuthHandler) BeginOIDCLogin(c *gin.Context) {
+	authorization, err := h.authService.BeginOIDCLogin(c.Request.Context())
+	if err != nil {
+		log.Printf("Single sign-on could not start: %v", err)
+		status, code, message := oidcErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: message,
+			Code:  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, authorization)
+}
+
+// CompleteOIDCLogin completes a single sign-on login with the code and state
+// the identity provider redirected back with. Like a password login, it may
+// answer with a two-factor challenge instead of tokens.
+// POST /api/auth/oidc/callback
+func (h *AuthHandler) CompleteOIDCLogin(c *gin.Context) {
+	var req models.OIDCCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid request body",
+			Code:  "INVALID_REQUEST",
+		})
+		return
+	}
+
+	response, challenge, err := h.authService.CompleteOIDCLogin(c.Request.Context(), req.Code, req.State, sessionClient(c))
+	if err != nil {
+		log.Printf("Single sign-on login failed: %v", err)
+		status, code, message := oidcErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: message,
+			Code:  code,
+		})
+		return
+	}
+	if challenge != nil {
+		c.JSON(http.StatusOK, challenge)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ListQuarantinedMedia lists media held in quarantine (admin only)
+// GET /api/admin/quarantine
+func (h *MediaHandler) ListQuarantinedMedia(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "50"))
+
+	response, err := h.mediaService.ListQuarantinedMedia(c.Request.Context(), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to list quarantined media",
+			Code:  "LIST_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ReleaseQuarantinedMedia releases a media item from quarantine after review (admin only)
+// POST /api/admin/quarantine/:id/release
+func (h *MediaHandler) ReleaseQuarantinedMedia(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid media ID",
+			Code:  "INVALID_ID",
+		})
+		return
+	}
+
+	var req models.ReleaseQuarantineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Code:    "INVALID_REQUEST",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	employee, _ := h.getEmployee(c)
+
+	media, err := h.mediaService.ReleaseFromQuarantine(c.Request.Context(), id, req.Note, employee)
+	if err != nil {
+		var status int
+		switch err {
+		case services.ErrMediaNotFound:
+			status = http.StatusNotFound
+		case services.ErrInvalidInput:
+			status = http.StatusConflict
+		default:
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "RELEASE_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, media)
+}
+
+// DeleteQuarantinedMedia permanently removes an infected file (admin only)
+// DELETE /api/admin/quarantine/:id
+func (h *MediaHandler) DeleteQuarantinedMedia(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid media ID",
+			Code:  "INVALID_ID",
+		})
+		return
+	}
+
+	employee, _ := h.getEmployee(c)
+
+	if err := h.mediaService.DeleteQuarantinedMedia(c.Request.Context(), id, employee); err != nil {
+		var status int
+		switch err {
+		case services.ErrMediaNotFound:
+			status = http.StatusNotFound
+		case services.ErrInvalidInput, services.ErrRetentionActive, services.ErrLegalHold:
+			status = http.StatusConflict
+		default:
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "DELETE_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Quarantined media deleted successfully",
+	})
+}
+
+// RescanMedia runs the antivirus scan again for a media item (admin only)
+// POST /api/admin/media/:id/scan
+func (h *MediaHandler) RescanMedia(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid media ID",
+			Code:  "INVALID_ID",
+		})
+		return
+	}
+
+	employee, _ := h.getEmployee(c)
+
+	media, err := h.mediaService.ScanMedia(c.Request.Context(), id, employee)
+	if err != nil {
+		var status int
+		switch err {
+		case services.ErrMediaNotFound:
+			status = http.StatusNotFound
+		case services.ErrScannerDisabled:
+			status = http.StatusServiceUnavailable
+		default:
+			status = http.StatusBadGateway
+		}
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "SCAN_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, media)
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ListRetentionPolicies lists all retention policies (admin only)
+// GET /api/admin/retention-policies
+func (h *MediaHandler) ListRetentionPolicies(c *gin.Context) {
+	policies, err := h.mediaService.ListRetentionPolicies(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to list retention policies",
+			Code:  "LIST_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, policies)
+}
+
+// CreateRetentionPolicy creates a retention policy (admin only)
+// POST /api/admin/retention-policies
+func (h *MediaHandler) CreateRetentionPolicy(c *gin.Context) {
+	var req models.CreateRetentionPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Code:    "INVALID_REQUEST",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	employee, _ := h.getEmployee(c)
+
+	policy, err := h.mediaService.CreateRetentionPolicy(c.Request.Context(), &req, employee)
+	if err != nil {
+		var status int
+		switch err {
+		case services.ErrGroupNotFound, services.ErrMediaNotFound:
+			status = http.StatusNotFound
+		case services.ErrInvalidInput:
+			status = http.StatusBadRequest
+		default:
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "CREATE_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, policy)
+}
+
+// UpdateRetentionPolicy updates a retention policy (admin only)
+// PATCH /api/admin/retention-policies/:id
+func (h *MediaHandler) UpdateRetentionPolicy(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid policy ID",
+			Code:  "INVALID_ID",
+		})
+		return
+	}
+
+	var req models.UpdateRetentionPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Code:    "INVALID_REQUEST",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	employee, _ := h.getEmployee(c)
+
+	policy, err := h.mediaService.UpdateRetentionPolicy(c.Request.Context(), id, &req, employee)
+	if err != nil {
+		var status int
+		switch err {
+		case services.ErrPolicyNotFound:
+			status = http.StatusNotFound
+		case services.ErrInvalidInput:
+			status = http.StatusBadRequest
+		default:
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "UPDATE_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// DeleteRetentionPolicy deletes a retention policy (admin only)
+// DELETE /api/admin/retention-policies/:id
+func (h *MediaHandler) DeleteRetentionPolicy(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid policy ID",
+			Code:  "INVALID_ID",
+		})
+		return
+	}
+
+	employee, _ := h.getEmployee(c)
+
+	if err := h.mediaService.DeleteRetentionPolicy(c.Request.Context(), id, employee); err != nil {
+		status := http.StatusInternalServerError
+		if err == services.ErrPolicyNotFound {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "DELETE_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Retention policy deleted successfully",
+	})
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ListPermissions lists every permission a role can grant
+// GET /api/admin/permissions
+func (h *AuthHandler) ListPermissions(c *gin.Context) {
+	c.JSON(http.StatusOK, models.AllPermissions)
+}
+
+// ListRoles lists the built-in roles and the custom roles
+// GET /api/admin/roles
+func (h *AuthHandler) ListRoles(c *gin.Context) {
+	roles, err := h.authService.ListRoles(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to list roles",
+			Code:  "LIST_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
+
+// CreateCustomRole defines a custom role
+// POST /api/admin/roles
+func (h *AuthHandler) CreateCustomRole(c *gin.Context) {
+	var req models.CreateCustomRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Code:    "INVALID_REQUEST",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	role, err := h.authService.CreateCustomRole(c.Request.Context(), &req, caller(c))
+	if err != nil {
+		status, code := roleErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, role)
+}
+
+// UpdateCustomRole changes a custom role's name, description or permissions
+// PATCH /api/admin/roles/:id
+func (h *AuthHandler) UpdateCustomRole(c *gin.Context) {
+	id, ok := parseRoleID(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateCustomRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Code:    "INVALID_REQUEST",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	role, err := h.authService.UpdateCustomRole(c.Request.Context(), id, &req, caller(c))
+	if err != nil {
+		status, code := roleErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+// DeleteCustomRole deletes a custom role
+// DELETE /api/admin/roles/:id
+func (h *AuthHandler) DeleteCustomRole(c *gin.Context) {
+	id, ok := parseRoleID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.authService.DeleteCustomRole(c.Request.Context(), id); err != nil {
+		status, code := roleErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// parseRoleID reads the :id parameter of custom role routes
+func parseRoleID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid role ID",
+			Code:  "INVALID_ID",
+		})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// roleErrorStatus maps custom role errors to a status and error code.
+// Anything else is a validation error.
+func roleErrorStatus(err error) (int, string) {
+	switch err {
+	case services.ErrCustomRoleNotFound:
+		return http.StatusNotFound, "NOT_FOUND"
+	case services.ErrCustomRoleExists:
+		return http.StatusConflict, "ROLE_EXISTS"
+	case services.ErrRoleNotGrantable:
+		return http.StatusForbidden, "FORBIDDEN"
+	default:
+		return http.StatusBadRequest, "INVALID_REQUEST"
+	}
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// savedSearchErrorStatus maps saved search service errors to HTTP statuses
+func savedSearchErrorStatus(err error) (int, string) {
+	switch err {
+	case services.ErrSavedSearchNotFound, services.ErrGroupNotFound:
+		return http.StatusNotFound, "NOT_FOUND"
+	case services.ErrSavedSearchExists:
+		return http.StatusConflict, "NAME_TAKEN"
+	case services.ErrForbidden:
+		return http.StatusForbidden, "FORBIDDEN"
+	case services.ErrInvalidInput:
+		return http.StatusBadRequest, "INVALID_REQUEST"
+	default:
+		var queryErr *services.QueryError
+		if errors.As(err, &queryErr) {
+			return http.StatusBadRequest, "INVALID_QUERY"
+		}
+		return http.StatusInternalServerError, "SAVED_SEARCH_FAILED"
+	}
+}
+
+// CreateSavedSearch saves a media filter
+// POST /api/saved-searches
+func (h *MediaHandler) CreateSavedSearch(c *gin.Context) {
+	var req models.CreateSavedSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Code:    "INVALID_REQUEST",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	employee, _ := h.getEmployee(c)
+
+	search, err := h.mediaService.CreateSavedSearch(c.Request.Context(), &req, employee)
+	if err != nil {
+		status, code := savedSearchErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, search)
+}
+
+// ListSavedSearches lists the saved searches available to the employee.
+// collections=true lists smart collections only.
+// GET /api/saved-searches
+func (h *MediaHandler) ListSavedSearches(c *gin.Context) {
+	employee, _ := h.getEmployee(c)
+
+	searches, err := h.mediaService.ListSavedSearches(c.Request.Context(), employee, c.Query("collections") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to list saved searches",
+			Code:  "LIST_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, searches)
+}
+
+// GetSavedSearch gets a saved search
+// GET /api/saved-searches/:id
+func (h *MediaHandler) GetSavedSearch(c *gin.Context) {
+	id, ok := parseSavedSearchID(c)
+	if !ok {
+		return
+	}
+
+	employee, _ := h.getEmployee(c)
+
+	search, err := h.mediaService.GetSavedSearch(c.Request.Context(), id, employee)
+	if err != nil {
+		status, code := savedSearchErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, search)
+}
+
+// UpdateSavedSearch changes a saved search
+// PATCH /api/saved-searches/:id
+func (h *MediaHandler) UpdateSavedSearch(c *gin.Context) {
+	id, ok := parseSavedSearchID(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateSavedSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Code:    "INVALID_REQUEST",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	employee, _ := h.getEmployee(c)
+
+	search, err := h.mediaService.UpdateSavedSearch(c.Request.Context(), id, &req, employee)
+	if err != nil {
+		status, code := savedSearchErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, search)
+}
+
+// DeleteSavedSearch deletes a saved search
+// DELETE /api/saved-searches/:id
+func (h *MediaHandler) DeleteSavedSearch(c *gin.Context) {
+	id, ok := parseSavedSearchID(c)
+	if !ok {
+		return
+	}
+
+	employee, _ := h.getEmployee(c)
+
+	if err := h.mediaService.DeleteSavedSearch(c.Request.Context(), id, employee); err != nil {
+		status, code := savedSearchErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Saved search deleted successfully",
+	})
+}
+
+// ListSavedSearchMedia lists the media a saved search matches right now. It
+// takes the pagination parameters of GET /api/media; other filters narrow
+// the results further, with the saved filters taking precedence.
+// GET /api/saved-searches/:id/media
+func (h *MediaHandler) ListSavedSearchMedia(c *gin.Context) {
+	id, ok := parseSavedSearchID(c)
+	if !ok {
+		return
+	}
+
+	var filters models.MediaFilterRequest
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid query parameters",
+			Code:    "INVALID_REQUEST",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	employee, _ := h.getEmployee(c)
+
+	if _, err := h.mediaService.ApplySavedSearch(c.Request.Context(), id, &filters, employee); err != nil {
+		status, code := savedSearchErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	h.respondMediaList(c, &filters, employee)
+}
+
+// parseSavedSearchID reads the :id parameter of saved search routes
+func parseSavedSearchID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid saved search ID",
+			Code:  "INVALID_ID",
+		})
+		return uuid.Nil, false
+	}
+	return id, true
+}
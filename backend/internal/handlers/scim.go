@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// SCIMHandler serves the SCIM 2.0 provisioning endpoints identity providers
+// call. Responses use the SCIM error format rather than models.ErrorResponse.
+type SCIMHandler struct {
+	scimService *services.SCIMService
+}
+
+// NewSCIMHandler creates a new SCIM handler
+func NewSCIMHandler(scimService *services.SCIMService) *SCIMHandler {
+	return &SCIMHandler{scimService: scimService}
+}
+
+// scimErrorStatus maps SCIM service errors to HTTP statuses and SCIM error
+// types
+func scimErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, services.ErrEmployeeNotFound), errors.Is(err, services.ErrSCIMGroupNotFound):
+		return http.StatusNotFound, ""
+	case errors.Is(err, services.ErrSCIMUserExists), errors.Is(err, services.ErrSCIMGroupExists):
+		return http.StatusConflict, "uniqueness"
+	case errors.Is(err, services.ErrSCIMInvalidFilter):
+		return http.StatusBadRequest, "invalidFilter"
+	case errors.Is(err, services.ErrSCIMInvalidValue):
+		return http.StatusBadRequest, "invalidValue"
+	default:
+		return http.StatusInternalServerError, ""
+	}
+}
+
+// ServiceProviderConfig describes the supported SCIM features
+// GET /scim/v2/ServiceProviderConfig
+func (h *SCIMHandler) ServiceProviderConfig(c *gin.Context) {
+	scimJSON(c, http.StatusOK, h.scimService.ServiceProviderConfig())
+}
+
+// ListResourceTypes lists the SCIM resource types
+// GET /scim/v2/ResourceTypes
+func (h *SCIMHandler) ListResourceTypes(c *gin.Context) {
+	scimJSON(c, http.StatusOK, h.scimService.ResourceTypes())
+}
+
+// ListUsers lists provisioned employees, optionally filtered
+// GET /scim/v2/Users
+func (h *SCIMHandler) ListUsers(c *gin.Context) {
+	startIndex, count := scimPaging(c)
+	users, err := h.scimService.ListUsers(c.Request.Context(), c.Query("filter"), startIndex, count)
+	if err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+	scimJSON(c, http.StatusOK, users)
+}
+
+// GetUser retrieves an employee
+// GET /scim/v2/Users/:id
+func (h *SCIMHandler) GetUser(c *gin.Context) {
+	id, ok := scimID(c)
+	if !ok {
+		return
+	}
+	user, err := h.scimService.GetUser(c.Request.Context(), id)
+	if err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+	scimJSON(c, http.StatusOK, user)
+}
+
+// CreateUser provisions an employee
+// POST /scim/v2/Users
+func (h *SCIMHandler) CreateUser(c *gin.Context) {
+	var req models.SCIMUser
+	if !bindSCIM(c, &req) {
+		return
+	}
+	user, err := h.scimService.CreateUser(c.Request.Context(), &req)
+	if err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+	scimJSON(c, http.StatusCreated, user)
+}
+
+// ReplaceUser replaces an employee's attributes
+// PUT /scim/v2/Users/:id
+func (h *SCIMHandler) ReplaceUser(c *gin.Context) {
+	id, ok := scimID(c)
+	if !ok {
+		return
+	}
+	var req models.SCIMUser
+	if !bindSCIM(c, &req) {
+		return
+	}
+	user, err := h.scimService.ReplaceUser(c.Request.Context(), id, &req)
+	if err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+	scimJSON(c, http.StatusOK, user)
+}
+
+// PatchUser updates some of an employee's attributes, e.g. to deactivate them
+// PATCH /scim/v2/Users/:id
+func (h *SCIMHandler) PatchUser(c *gin.Context) {
+	id, ok := scimID(c)
+	if !ok {
+		return
+	}
+	var req models.SCIMPatchRequest
+	if !bindSCIM(c, &req) {
+		return
+	}
+	user, err := h.scimService.PatchUser(c.Request.Context(), id, &req)
+	if err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+	scimJSON(c, http.StatusOK, user)
+}
+
+// DeleteUser deprovisions an employee
+// DELETE /scim/v2/Users/:id
+func (h *SCIMHandler) DeleteUser(c *gin.Context) {
+	id, ok := scimID(c)
+	if !ok {
+		return
+	}
+	if err := h.scimService.DeleteUser(c.Request.Context(), id); err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListGroups lists groups, optionally filtered
+// GET /scim/v2/Groups
+func (h *SCIMHandler) ListGroups(c *gin.Context) {
+	startIndex, count := scimPaging(c)
+	groups, err := h.scimService.ListGroups(c.Request.Context(), c.Query("filter"), startIndex, count, scimWithMembers(c))
+	if err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+	scimJSON(c, http.StatusOK, groups)
+}
+
+// GetGroup retrieves a group
+// GET /scim/v2/Groups/:id
+func (h *SCIMHandler) GetGroup(c *gin.Context) {
+	id, ok := scimID(c)
+	if !ok {
+		return
+	}
+	group, err := h.scimService.GetGroup(c.Request.Context(), id, scimWithMembers(c))
+	if err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+	scimJSON(c, http.StatusOK, group)
+}
+
+// CreateGroup creates a group
+// POST /scim/v2/Groups
+func (h *SCIMHandler) CreateGroup(c *gin.Context) {
+	var req models.SCIMGroupResource
+	if !bindSCIM(c, &req) {
+		return
+	}
+	group, err := h.scimService.CreateGroup(c.Request.Context(), &req)
+	if err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+	scimJSON(c, http.StatusCreated, group)
+}
+
+// ReplaceGroup replaces a group's name and members
+// PUT /scim/v2/Groups/:id
+func (h *SCIMHandler) ReplaceGroup(c *gin.Context) {
+	id, ok := scimID(c)
+	if !ok {
+		return
+	}
+	var req models.SCIMGroupResource
+	if !bindSCIM(c, &req) {
+		return
+	}
+	group, err := h.scimService.ReplaceGroup(c.Request.Context(), id, &req)
+	if err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+	scimJSON(c, http.StatusOK, group)
+}
+
+// PatchGroup renames a group or adds and removes members
+// PATCH /scim/v2/Groups/:id
+func (h *SCIMHandler) PatchGroup(c *gin.Context) {
+	id, ok := scimID(c)
+	if !ok {
+		return
+	}
+	var req models.SCIMPatchRequest
+	if !bindSCIM(c, &req) {
+		return
+	}
+	group, err := h.scimService.PatchGroup(c.Request.Context(), id, &req)
+	if err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+	scimJSON(c, http.StatusOK, group)
+}
+
+// DeleteGroup deletes a group
+// DELETE /scim/v2/Groups/:id
+func (h *SCIMHandler) DeleteGroup(c *gin.Context) {
+	id, ok := scimID(c)
+	if !ok {
+		return
+	}
+	if err := h.scimService.DeleteGroup(c.Request.Context(), id); err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// scimJSON writes a SCIM response body
+func scimJSON(c *gin.Context, status int, body any) {
+	c.Header("Content-Type", models.SCIMMediaType)
+	c.JSON(status, body)
+}
+
+// scimErrorJSON writes a SCIM error
+func scimErrorJSON(c *gin.Context, status int, scimType, detail string) {
+	scimJSON(c, status, models.SCIMError{
+		Schemas:  []string{models.SCIMErrorSchema},
+		Status:   strconv.Itoa(status),
+		SCIMType: scimType,
+		Detail:   detail,
+	})
+}
+
+// respondSCIMError writes the SCIM error for a service error
+func respondSCIMError(c *gin.Context, err error) {
+	status, scimType := scimErrorStatus(err)
+	detail := err.Error()
+	if status == http.StatusInternalServerError {
+		detail = "Internal server error"
+	}
+	scimErrorJSON(c, status, scimType, detail)
+}
+
+// bindSCIM decodes a SCIM request body, which identity providers send as
+// application/scim+json
+func bindSCIM(c *gin.Context, v any) bool {
+	if err := c.ShouldBindJSON(v); err != nil {
+		scimErrorJSON(c, http.StatusBadRequest, "invalidSyntax", err.Error())
+		return false
+	}
+	return true
+}
+
+// scimID parses the resource id. An id that is not ours is an unknown
+// resource.
+func scimID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		scimErrorJSON(c, http.StatusNotFound, "", "Resource not found")
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// scimPaging reads the 1-based startIndex and the count. A missing count
+// leaves the page size to the service.
+func scimPaging(c *gin.Context) (int, int) {
+	startIndex, err := strconv.Atoi(c.DefaultQuery("startIndex", "1"))
+	if err != nil {
+		startIndex = 1
+	}
+	count, err := strconv.Atoi(c.DefaultQuery("count", "-1"))
+	if err != nil {
+		count = -1
+	}
+	return startIndex, count
+}
+
+// scimWithMembers reports whether group members were asked for. Providers
+// exclude them when they only look a group up.
+func scimWithMembers(c *gin.Context) bool {
+	for _, attribute := range strings.Split(c.Query("excludedAttributes"), ",") {
+		if strings.EqualFold(strings.TrimSpace(attribute), "members") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateServiceAccount creates a service account (admin only)
+// POST /api/admin/service-accounts
+func (h *AuthHandler) CreateServiceAccount(c *gin.Context) {
+	var req models.CreateServiceAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Code:    "INVALID_REQUEST",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	account, err := h.authService.CreateServiceAccount(c.Request.Context(), &req, caller(c))
+	if errors.Is(err, services.ErrRoleNotGrantable) {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "FORBIDDEN",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to create service account",
+			Code:  "CREATE_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, account)
+}
+
+// ListServiceAccounts lists service accounts (admin only)
+// GET /api/admin/service-accounts
+func (h *AuthHandler) ListServiceAccounts(c *gin.Context) {
+	accounts, err := h.authService.ListServiceAccounts(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to list service accounts",
+			Code:  "LIST_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, accounts)
+}
+
+// DeleteServiceAccount deletes a service account and revokes its keys (admin only)
+// DELETE /api/admin/service-accounts/:id
+func (h *AuthHandler) DeleteServiceAccount(c *gin.Context) {
+	id, ok := parseServiceAccountID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.authService.DeleteServiceAccount(c.Request.Context(), id); err != nil {
+		status, code := apiKeyErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListAPIKeys lists a service account's API keys (admin only)
+// GET /api/admin/service-accounts/:id/keys
+func (h *AuthHandler) ListAPIKeys(c *gin.Context) {
+	id, ok := parseServiceAccountID(c)
+	if !ok {
+		return
+	}
+
+	keys, err := h.authService.ListAPIKeys(c.Request.Context(), id)
+	if err != nil {
+		status, code := apiKeyErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// CreateAPIKey issues an API key to a service account. The key is only
+// shown in this response. (admin only)
+// POST /api/admin/service-accounts/:id/keys
+func (h *AuthHandler) CreateAPIKey(c *gin.Context) {
+	id, ok := parseServiceAccountID(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Code:    "INVALID_REQUEST",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	adminID := c.MustGet("employee_id").(uuid.UUID)
+	key, err := h.authService.CreateAPIKey(c.Request.Context(), id, &req, adminID)
+	if err != nil {
+		status, code := apiKeyErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+// RevokeAPIKey revokes a service account's API key (admin only)
+// DELETE /api/admin/service-accounts/:id/keys/:key_id
+func (h *AuthHandler) RevokeAPIKey(c *gin.Context) {
+	id, ok := parseServiceAccountID(c)
+	if !ok {
+		return
+	}
+	keyID, err := uuid.Parse(c.Param("key_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid API key ID",
+			Code:  "INVALID_ID",
+		})
+		return
+	}
+
+	if err := h.authService.RevokeAPIKey(c.Request.Context(), id, keyID); err != nil {
+		status, code := apiKeyErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// parseServiceAccountID reads the :id parameter of service account routes
+func parseServiceAccountID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid service account ID",
+			Code:  "INVALID_ID",
+		})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// apiKeyErrorStatus maps service account and API key errors to a status
+// and error code. Anything else is a validation error.
+func apiKeyErrorStatus(err error) (int, string) {
+	switch err {
+	case services.ErrServiceAccountNotFound, services.ErrAPIKeyNotFound:
+		return http.StatusNotFound, "NOT_FOUND"
+	default:
+		return http.StatusBadRequest, "INVALID_REQUEST"
+	}
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// sessionClient describes the client of a login or refresh request
+func sessionClient(c *gin.Context) services.SessionClient {
+	return services.SessionClient{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
+
+// parseEmployeeID reads the :id parameter of admin employee routes
+func parseEmployeeID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid employee ID",
+			Code:  "INVALID_ID",
+		})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// parseSessionID reads the session ID path parameter of session routes
+func parseSessionID(c *gin.Context, param string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param(param))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid session ID",
+			Code:  "INVALID_ID",
+		})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// ListSessions lists the current employee's sessions
+// GET /api/auth/sessions
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	employeeID := c.MustGet("employee_id").(uuid.UUID)
+	sessionID, _ := c.Get("session_id")
+	current, _ := sessionID.(uuid.UUID)
+
+	h.respondSessions(c, employeeID, current)
+}
+
+// RevokeSession signs the current employee out of one of their sessions
+// DELETE /api/auth/sessions/:id
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	sessionID, ok := parseSessionID(c, "id")
+	if !ok {
+		return
+	}
+
+	h.revokeSession(c, c.MustGet("employee_id").(uuid.UUID), sessionID)
+}
+
+// ListEmployeeSessions lists an employee's sessions (admin only)
+// GET /api/admin/employees/:id/sessions
+func (h *AuthHandler) ListEmployeeSessions(c *gin.Context) {
+	employeeID, ok := parseEmployeeID(c)
+	if !ok {
+		return
+	}
+
+	h.respondSessions(c, employeeID, uuid.Nil)
+}
+
+// RevokeEmployeeSession signs an employee out of one session (admin only)
+// DELETE /api/admin/employees/:id/sessions/:session_id
+func (h *AuthHandler) RevokeEmployeeSession(c *gin.Context) {
+	employeeID, ok := parseEmployeeID(c)
+	if !ok {
+		return
+	}
+	sessionID, ok := parseSessionID(c, "session_id")
+	if !ok {
+		return
+	}
+
+	h.revokeSession(c, employeeID, sessionID)
+}
+
+// RevokeEmployeeSessions signs an employee out of every session (admin only)
+// DELETE /api/admin/employees/:id/sessions
+func (h *AuthHandler) RevokeEmployeeSessions(c *gin.Context) {
+	employeeID, ok := parseEmployeeID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.authService.LogoutAll(c.Request.Context(), employeeID); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to revoke sessions",
+			Code:  "REVOKE_FAILED",
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AuthHandler) respondSessions(c *gin.Context, employeeID, current uuid.UUID) {
+	sessions, err := h.authService.ListSessions(c.Request.Context(), employeeID, current)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to list sessions",
+			Code:  "LIST_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+func (h *AuthHandler) revokeSession(c *gin.Context, employeeID, sessionID uuid.UUID) {
+	err := h.authService.RevokeSession(c.Request.Context(), employeeID, sessionID)
+	if err == services.ErrSessionNotFound {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "NOT_FOUND",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to revoke session",
+			Code:  "REVOKE_FAILED",
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
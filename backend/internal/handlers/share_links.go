@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// sharePasswordHeader carries the password of a protected share link
+const sharePasswordHeader = "X-Share-Password"
+
+// shareErrorStatus maps share link service errors to HTTP statuses
+func shareErrorStatus(err error) (int, string) {
+	switch err {
+	case services.ErrShareNotFound, services.ErrMediaNotFound:
+		return http.StatusNotFound, "NOT_FOUND"
+	case services.ErrShareUnavailable:
+		return http.StatusGone, "SHARE_UNAVAILABLE"
+	case services.ErrSharePassword:
+		return http.StatusUnauthorized, "PASSWORD_REQUIRED"
+	case services.ErrShareLocked:
+		return http.StatusTooManyRequests, "SHARE_LOCKED"
+	case services.ErrShareRevoked:
+		return http.StatusConflict, "ALREADY_REVOKED"
+	case services.ErrForbidden:
+		return http.StatusForbidden, "FORBIDDEN"
+	case services.ErrMediaQuarantined:
+		return http.StatusForbidden, "MEDIA_QUARANTINED"
+	case services.ErrInvalidInput:
+		return http.StatusBadRequest, "INVALID_REQUEST"
+	default:
+		return http.StatusInternalServerError, "SHARE_FAILED"
+	}
+}
+
+// CreateShareLink creates a share link
+// POST /api/shares
+func (h *MediaHandler) CreateShareLink(c *gin.Context) {
+	var req models.CreateShareLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Code:    "INVALID_REQUEST",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	employee, _ := h.getEmployee(c)
+
+	link, err := h.mediaService.CreateShareLink(c.Request.Context(), &req, employee)
+	if err != nil {
+		status, code := shareErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, link)
+}
+
+// ListShareLinks lists share links
+// GET /api/shares
+func (h *MediaHandler) ListShareLinks(c *gin.Context) {
+	employee, _ := h.getEmployee(c)
+
+	links, err := h.mediaService.ListShareLinks(c.Request.Context(), employee)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to list share links",
+			Code:  "LIST_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, links)
+}
+
+// RevokeShareLink revokes a share link
+// DELETE /api/shares/:id
+func (h *MediaHandler) RevokeShareLink(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid share link ID",
+			Code:  "INVALID_ID",
+		})
+		return
+	}
+
+	employee, _ := h.getEmployee(c)
+
+	if err := h.mediaService.RevokeShareLink(c.Request.Context(), id, employee); err != nil {
+		status, code := shareErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Share link revoked successfully",
+	})
+}
+
+// OpenShareLink serves a share link to an unauthenticated visitor. Links to
+// a single file download it; other links list their files.
+// GET /s/:token
+func (h *MediaHandler) OpenShareLink(c *gin.Context) {
+	link, visitor, ok := h.resolveShareLink(c)
+	if !ok {
+		return
+	}
+
+	if link.Scope == models.ShareScopeMedia {
+		h.serveSharedFile(c, link, nil, visitor)
+		return
+	}
+
+	h.serveSharedContent(c, link, visitor)
+}
+
+// GetSharedContent lists the files behind a share link
+// GET /s/:token/info
+func (h *MediaHandler) GetSharedContent(c *gin.Context) {
+	link, visitor, ok := h.resolveShareLink(c)
+	if !ok {
+		return
+	}
+
+	h.serveSharedContent(c, link, visitor)
+}
+
+// DownloadSharedFile downloads one file of a folder or selection link
+// GET /s/:token/files/:media_id
+func (h *MediaHandler) DownloadSharedFile(c *gin.Context) {
+	mediaID, err := uuid.Parse(c.Param("media_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid media ID",
+			Code:  "INVALID_ID",
+		})
+		return
+	}
+
+	link, visitor, ok := h.resolveShareLink(c)
+	if !ok {
+		return
+	}
+
+	h.serveSharedFile(c, link, &mediaID, visitor)
+}
+
+// resolveShareLink validates the token and password of a share request.
+// The password is only read from the X-Share-Password header, never the
+// query string, which ends up in access logs and browser history.
+func (h *MediaHandler) resolveShareLink(c *gin.Context) (*models.ShareLink, services.ShareVisitor, bool) {
+	visitor := services.ShareVisitor{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+
+	link, err := h.mediaService.ResolveShareLink(c.Request.Context(), c.Param("token"), c.GetHeader(sharePasswordHeader), visitor)
+	if err != nil {
+		status, code := shareErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return nil, visitor, false
+	}
+
+	return link, visitor, true
+}
+
+// serveSharedContent responds with the file listing of a share link
+func (h *MediaHandler) serveSharedContent(c *gin.Context, link *models.ShareLink, visitor services.ShareVisitor) {
+	content, err := h.mediaService.GetSharedContent(c.Request.Context(), link, visitor)
+	if err != nil {
+		status, code := shareErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, content)
+}
+
+// serveSharedFile streams a shared file, or redirects to a short-lived
+// signed URL when ?redirect=true is given
+func (h *MediaHandler) serveSharedFile(c *gin.Context, link *models.ShareLink, mediaID *uuid.UUID, visitor services.ShareVisitor) {
+	if c.Query("redirect") == "true" {
+		url, err := h.mediaService.SignSharedDownload(c.Request.Context(), link, mediaID, visitor)
+		if err != nil {
+			status, code := shareErrorStatus(err)
+			c.JSON(status, models.ErrorResponse{
+				Error: err.Error(),
+				Code:  code,
+			})
+			return
+		}
+		c.Redirect(http.StatusFound, url)
+		return
+	}
+
+	media, reader, err := h.mediaService.DownloadShared(c.Request.Context(), link, mediaID, visitor)
+	if err != nil {
+		status, code := shareErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+	defer reader.Close()
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", media.OriginalFilename))
+	c.Header("Content-Type", media.MimeType)
+	c.Header("Content-Length", fmt.Sprintf("%d", media.FileSizeBytes))
+
+	if _, err := io.Copy(c.Writer, reader); err != nil {
+		log.Printf("Failed to stream shared download: %v", err)
+	}
+}
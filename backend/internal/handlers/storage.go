@@ -76,9 +76,8 @@ func (h *StorageHandler) CreateStorageAccount(c *gin.Context) {
 // GET /api/storage-accounts
 func (h *StorageHandler) ListStorageAccounts(c *gin.Context) {
 	employeeID := c.MustGet("employee_id").(uuid.UUID)
-	role := c.MustGet("employee_role").(models.Role)
 
-	accounts, err := h.storageService.ListStorageAccounts(c.Request.Context(), employeeID, role)
+	accounts, err := h.storageService.ListStorageAccounts(c.Request.Context(), employeeID, can(c, models.PermStorageViewAll))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error: "Failed to list storage accounts",
@@ -87,6 +86,16 @@ func (h *StorageHandler) ListStorageAccounts(c *gin.Context) {
 		return
 	}
 
+	if limits := keyLimits(c); limits != nil {
+		allowed := accounts[:0]
+		for _, account := range accounts {
+			if limits.AllowsStorageAccount(account.ID) {
+				allowed = append(allowed, account)
+			}
+		}
+		accounts = allowed
+	}
+
 	c.JSON(http.StatusOK, accounts)
 }
 
@@ -101,9 +110,12 @@ func (h *StorageHandler) GetStorageAccount(c *gin.Context) {
 		})
 		return
 	}
+	if !h.requireStorageAccount(c, id) {
+		return
+	}
 
 	account, err := h.storageService.GetStorageAccount(c.Request.Context(), id)
-	if err != nil {
+	if err != nil || !keyLimits(c).AllowsStorageAccount(id) {
 		c.JSON(http.StatusNotFound, models.ErrorResponse{
 			Error: "Storage account not found",
 			Code:  "NOT_FOUND",
@@ -125,6 +137,9 @@ func (h *StorageHandler) UpdateStorageAccount(c *gin.Context) {
 		})
 		return
 	}
+	if !h.requireStorageAccount(c, id) {
+		return
+	}
 
 	var req models.UpdateStorageAccountRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -173,11 +188,24 @@ func (h *StorageHandler) DeleteStorageAccount(c *gin.Context) {
 		})
 		return
 	}
+	if !h.requireStorageAccount(c, id) {
+		return
+	}
 
 	if err := h.storageService.DeleteStorageAccount(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+		status := http.StatusBadRequest
+		code := "DELETE_FAILED"
+		switch err {
+		case services.ErrRetentionActive:
+			status = http.StatusConflict
+			code = "RETENTION_ACTIVE"
+		case services.ErrLegalHold:
+			status = http.StatusConflict
+			code = "LEGAL_HOLD"
+		}
+		c.JSON(status, models.ErrorResponse{
 			Error: err.Error(),
-			Code:  "DELETE_FAILED",
+			Code:  code,
 		})
 		return
 	}
@@ -209,6 +237,9 @@ func (h *StorageHandler) TestStorageConnection(c *gin.Context) {
 		})
 		return
 	}
+	if !h.requireStorageAccount(c, id) {
+		return
+	}
 
 	if err := h.storageService.TestStorageConnection(c.Request.Context(), id); err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
@@ -235,6 +266,9 @@ func (h *StorageHandler) GrantStorageAccess(c *gin.Context) {
 		})
 		return
 	}
+	if !h.requireStorageAccount(c, id) {
+		return
+	}
 
 	var req models.GrantStorageAccessRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -270,6 +304,9 @@ func (h *StorageHandler) RevokeStorageAccess(c *gin.Context) {
 		})
 		return
 	}
+	if !h.requireStorageAccount(c, id) {
+		return
+	}
 
 	employeeID, err := uuid.Parse(c.Param("employee_id"))
 	if err != nil {
@@ -304,6 +341,9 @@ func (h *StorageHandler) GetStorageAccountAccess(c *gin.Context) {
 		})
 		return
 	}
+	if !h.requireStorageAccount(c, id) {
+		return
+	}
 
 	users, err := h.storageService.GetStorageAccountAccess(c.Request.Context(), id)
 	if err != nil {
@@ -330,6 +370,17 @@ func (h *StorageHandler) SyncStorageAccount(c *gin.Context) {
 		return
 	}
 
+	if !h.requireStorageAccount(c, id) {
+		return
+	}
+	if !keyLimits(c).AllowsStorageAccount(id) {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error: services.ErrOutsideKeyLimits.Error(),
+			Code:  "OUTSIDE_KEY_LIMITS",
+		})
+		return
+	}
+
 	employeeID := c.MustGet("employee_id").(uuid.UUID)
 
 	result, err := h.mediaService.SyncStorageAccount(c.Request.Context(), id, employeeID)
@@ -344,6 +395,28 @@ func (h *StorageHandler) SyncStorageAccount(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
+// requireStorageAccount answers 404 unless the caller may use a storage
+// account, so accounts without a grant look like they do not exist
+func (h *StorageHandler) requireStorageAccount(c *gin.Context, id uuid.UUID) bool {
+	employeeID := c.MustGet("employee_id").(uuid.UUID)
+	ok, err := h.storageService.CanAccessStorageAccount(c.Request.Context(), id, employeeID, can(c, models.PermStorageViewAll))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to check storage account access",
+			Code:  "INTERNAL_ERROR",
+		})
+		return false
+	}
+	if !ok {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: "Storage account not found",
+			Code:  "NOT_FOUND",
+		})
+		return false
+	}
+	return true
+}
+
 // GroupHandler handles media group endpoints
 type GroupHandler struct {
 	groupService *services.GroupService
@@ -404,7 +477,7 @@ func (h *GroupHandler) CreateMediaGroup(c *gin.Context) {
 func (h *GroupHandler) ListMediaGroups(c *gin.Context) {
 	role := c.MustGet("employee_role").(models.Role)
 
-	groups, err := h.groupService.ListMediaGroups(c.Request.Context(), role)
+	groups, err := h.groupService.ListMediaGroups(c.Request.Context(), role, can(c, models.PermStorageViewAll))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error: "Failed to list media groups",
@@ -413,6 +486,16 @@ func (h *GroupHandler) ListMediaGroups(c *gin.Context) {
 		return
 	}
 
+	if limits := keyLimits(c); limits != nil {
+		allowed := groups[:0]
+		for _, group := range groups {
+			if limits.AllowsMediaGroup(&group.ID) {
+				allowed = append(allowed, group)
+			}
+		}
+		groups = allowed
+	}
+
 	c.JSON(http.StatusOK, groups)
 }
 
@@ -427,9 +510,12 @@ func (h *GroupHandler) GetMediaGroup(c *gin.Context) {
 		})
 		return
 	}
+	if !h.requireMediaGroup(c, id) {
+		return
+	}
 
 	group, err := h.groupService.GetMediaGroup(c.Request.Context(), id)
-	if err != nil {
+	if err != nil || !keyLimits(c).AllowsMediaGroup(&id) {
 		c.JSON(http.StatusNotFound, models.ErrorResponse{
 			Error: "Media group not found",
 			Code:  "NOT_FOUND",
@@ -451,6 +537,9 @@ func (h *GroupHandler) UpdateMediaGroup(c *gin.Context) {
 		})
 		return
 	}
+	if !h.requireMediaGroup(c, id) {
+		return
+	}
 
 	var req models.UpdateMediaGroupRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -485,6 +574,9 @@ func (h *GroupHandler) DeleteMediaGroup(c *gin.Context) {
 		})
 		return
 	}
+	if !h.requireMediaGroup(c, id) {
+		return
+	}
 
 	if err := h.groupService.DeleteMediaGroup(c.Request.Context(), id); err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
@@ -498,3 +590,24 @@ func (h *GroupHandler) DeleteMediaGroup(c *gin.Context) {
 		Message: "Media group deleted successfully",
 	})
 }
+
+// requireMediaGroup answers 404 unless the group admits the caller's role
+func (h *GroupHandler) requireMediaGroup(c *gin.Context, id uuid.UUID) bool {
+	role := c.MustGet("employee_role").(models.Role)
+	ok, err := h.groupService.CanAccessMediaGroup(c.Request.Context(), id, role, can(c, models.PermStorageViewAll))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to check media group access",
+			Code:  "INTERNAL_ERROR",
+		})
+		return false
+	}
+	if !ok {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: "Media group not found",
+			Code:  "NOT_FOUND",
+		})
+		return false
+	}
+	return true
+}
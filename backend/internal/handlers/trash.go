@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ListTrash lists deleted media that can still be restored
+// GET /api/trash
+func (h *MediaHandler) ListTrash(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "50"))
+
+	employee, _ := h.getEmployee(c)
+
+	response, err := h.mediaService.ListTrash(c.Request.Context(), employee, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to list trash",
+			Code:  "LIST_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RestoreMedia restores a media item from the trash
+// POST /api/trash/:id/restore
+func (h *MediaHandler) RestoreMedia(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid media ID",
+			Code:  "INVALID_ID",
+		})
+		return
+	}
+
+	employee, _ := h.getEmployee(c)
+
+	media, err := h.mediaService.RestoreMedia(c.Request.Context(), id, employee)
+	if err != nil {
+		var status int
+		switch err {
+		case services.ErrMediaNotFound:
+			status = http.StatusNotFound
+		case services.ErrForbidden:
+			status = http.StatusForbidden
+		case services.ErrRestoreConflict:
+			status = http.StatusConflict
+		default:
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "RESTORE_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, media)
+}
+
+// PurgeMedia permanently deletes a media item right away, from the trash or
+// straight from the library (admin only)
+// DELETE /api/trash/:id
+// DELETE /api/media/:id?permanent=true
+func (h *MediaHandler) PurgeMedia(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid media ID",
+			Code:  "INVALID_ID",
+		})
+		return
+	}
+
+	employee, _ := h.getEmployee(c)
+
+	if err := h.mediaService.PurgeMedia(c.Request.Context(), id, employee); err != nil {
+		var status int
+		switch err {
+		case services.ErrMediaNotFound:
+			status = http.StatusNotFound
+		case services.ErrForbidden:
+			status = http.StatusForbidden
+		case services.ErrRetentionActive, services.ErrLegalHold:
+			status = http.StatusConflict
+		default:
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "PURGE_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Media permanently deleted",
+	})
+}
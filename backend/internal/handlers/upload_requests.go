@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// uploadRequestErrorStatus maps upload request service errors to HTTP statuses
+func uploadRequestErrorStatus(err error) (int, string) {
+	switch err {
+	case services.ErrUploadRequestNotFound, services.ErrMediaNotFound:
+		return http.StatusNotFound, "NOT_FOUND"
+	case services.ErrGroupNotFound:
+		return http.StatusNotFound, "GROUP_NOT_FOUND"
+	case services.ErrUploadRequestUnavailable:
+		return http.StatusGone, "UPLOAD_LINK_UNAVAILABLE"
+	case services.ErrUploadRequestRevoked:
+		return http.StatusConflict, "ALREADY_REVOKED"
+	case services.ErrNotPendingReview:
+		return http.StatusConflict, "NOT_PENDING_REVIEW"
+	case services.ErrFileNotAllowed:
+		return http.StatusBadRequest, "FILE_NOT_ALLOWED"
+	case services.ErrForbidden:
+		return http.StatusForbidden, "FORBIDDEN"
+	case services.ErrInvalidInput:
+		return http.StatusBadRequest, "INVALID_REQUEST"
+	default:
+		return http.StatusInternalServerError, "UPLOAD_REQUEST_FAILED"
+	}
+}
+
+// CreateUploadRequest creates a guest upload link
+// POST /api/upload-requests
+func (h *MediaHandler) CreateUploadRequest(c *gin.Context) {
+	var req models.CreateUploadRequestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Code:    "INVALID_REQUEST",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	employee, _ := h.getEmployee(c)
+
+	uploadRequest, err := h.mediaService.CreateUploadRequest(c.Request.Context(), &req, employee)
+	if err != nil {
+		status, code := uploadRequestErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, uploadRequest)
+}
+
+// ListUploadRequests lists guest upload links
+// GET /api/upload-requests
+func (h *MediaHandler) ListUploadRequests(c *gin.Context) {
+	employee, _ := h.getEmployee(c)
+
+	uploadRequests, err := h.mediaService.ListUploadRequests(c.Request.Context(), employee)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to list upload requests",
+			Code:  "LIST_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, uploadRequests)
+}
+
+// RevokeUploadRequest revokes a guest upload link
+// DELETE /api/upload-requests/:id
+func (h *MediaHandler) RevokeUploadRequest(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid upload request ID",
+			Code:  "INVALID_ID",
+		})
+		return
+	}
+
+	employee, _ := h.getEmployee(c)
+
+	if err := h.mediaService.RevokeUploadRequest(c.Request.Context(), id, employee); err != nil {
+		status, code := uploadRequestErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Upload request revoked successfully",
+	})
+}
+
+// ListPendingReview lists guest uploads awaiting review
+// GET /api/upload-requests/review
+func (h *MediaHandler) ListPendingReview(c *gin.Context) {
+	employee, _ := h.getEmployee(c)
+
+	mediaList, err := h.mediaService.ListPendingReview(c.Request.Context(), employee)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to list uploads awaiting review",
+			Code:  "LIST_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, mediaList)
+}
+
+// ReviewGuestUpload approves or rejects a guest upload
+// POST /api/upload-requests/review/:media_id
+func (h *MediaHandler) ReviewGuestUpload(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("media_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid media ID",
+			Code:  "INVALID_ID",
+		})
+		return
+	}
+
+	var req models.ReviewUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Code:    "INVALID_REQUEST",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	employee, _ := h.getEmployee(c)
+
+	media, err := h.mediaService.ReviewGuestUpload(c.Request.Context(), id, req.Decision, employee)
+	if err != nil {
+		status, code := uploadRequestErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, media)
+}
+
+// GetGuestUploadInfo describes an upload link to a guest
+// GET /u/:token
+func (h *MediaHandler) GetGuestUploadInfo(c *gin.Context) {
+	uploadRequest, ok := h.resolveUploadRequest(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, h.mediaService.GetGuestUploadInfo(uploadRequest))
+}
+
+// InitiateGuestUpload starts a guest upload
+// POST /u/:token/init
+func (h *MediaHandler) InitiateGuestUpload(c *gin.Context) {
+	var req models.GuestUploadInitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Code:    "INVALID_REQUEST",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	uploadRequest, ok := h.resolveUploadRequest(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.mediaService.InitiateGuestUpload(c.Request.Context(), uploadRequest, &req)
+	if err != nil {
+		status, code := uploadRequestErrorStatus(err)
+		if code == "UPLOAD_REQUEST_FAILED" {
+			// Routing and storage limit errors from the regular upload flow
+			status, code = http.StatusBadRequest, "UPLOAD_INIT_FAILED"
+		}
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CompleteGuestUpload finalizes a guest upload
+// POST /u/:token/complete
+func (h *MediaHandler) CompleteGuestUpload(c *gin.Context) {
+	var req models.UploadCompleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Code:    "INVALID_REQUEST",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	uploadRequest, err := h.mediaService.GetUploadRequestForCompletion(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		status, code := uploadRequestErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	file, err := h.mediaService.CompleteGuestUpload(c.Request.Context(), uploadRequest, &req)
+	if err != nil {
+		status, code := uploadRequestErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, file)
+}
+
+// resolveUploadRequest validates the token of a guest upload request
+func (h *MediaHandler) resolveUploadRequest(c *gin.Context) (*models.UploadRequest, bool) {
+	uploadRequest, err := h.mediaService.ResolveUploadRequest(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		status, code := uploadRequestErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return nil, false
+	}
+	return uploadRequest, true
+}
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// versionErrorStatus maps version service errors to HTTP statuses
+func versionErrorStatus(err error) (int, string) {
+	switch err {
+	case services.ErrMediaNotFound, services.ErrVersionNotFound:
+		return http.StatusNotFound, "NOT_FOUND"
+	case services.ErrForbidden:
+		return http.StatusForbidden, "FORBIDDEN"
+	case services.ErrMediaQuarantined:
+		return http.StatusForbidden, "MEDIA_QUARANTINED"
+	case services.ErrScanPending:
+		return http.StatusConflict, "SCAN_PENDING"
+	case services.ErrFileInfected:
+		return http.StatusUnprocessableEntity, "FILE_INFECTED"
+	case services.ErrLegalHold:
+		return http.StatusConflict, "LEGAL_HOLD"
+	case services.ErrVersionConflict:
+		return http.StatusConflict, "VERSION_CONFLICT"
+	case services.ErrInvalidInput:
+		return http.StatusBadRequest, "INVALID_REQUEST"
+	default:
+		return http.StatusInternalServerError, "VERSION_FAILED"
+	}
+}
+
+// parseVersionParams parses the media ID and version number from the path
+func parseVersionParams(c *gin.Context) (uuid.UUID, int, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid media ID",
+			Code:  "INVALID_ID",
+		})
+		return uuid.Nil, 0, false
+	}
+
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid version number",
+			Code:  "INVALID_VERSION",
+		})
+		return uuid.Nil, 0, false
+	}
+
+	return id, version, true
+}
+
+// ListMediaVersions lists all versions of a media item
+// GET /api/media/:id/versions
+func (h *MediaHandler) ListMediaVersions(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid media ID",
+			Code:  "INVALID_ID",
+		})
+		return
+	}
+
+	employee, _ := h.getEmployee(c)
+
+	versions, err := h.mediaService.ListMediaVersions(c.Request.Context(), id, employee)
+	if err != nil {
+		status, code := versionErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, versions)
+}
+
+// InitiateVersionUpload starts the upload of a new version
+// POST /api/media/:id/versions/init
+func (h *MediaHandler) InitiateVersionUpload(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid media ID",
+			Code:  "INVALID_ID",
+		})
+		return
+	}
+
+	var req models.InitiateVersionUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Code:    "INVALID_REQUEST",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	employee, _ := h.getEmployee(c)
+
+	response, err := h.mediaService.InitiateVersionUpload(c.Request.Context(), id, &req, employee)
+	if err != nil {
+		status, code := versionErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CompleteVersionUpload makes an uploaded version the current version
+// POST /api/media/:id/versions/complete
+func (h *MediaHandler) CompleteVersionUpload(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid media ID",
+			Code:  "INVALID_ID",
+		})
+		return
+	}
+
+	var req models.VersionCompleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Code:    "INVALID_REQUEST",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	employee, _ := h.getEmployee(c)
+
+	media, err := h.mediaService.CompleteVersionUpload(c.Request.Context(), id, &req, employee)
+	if err != nil {
+		status, code := versionErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, media)
+}
+
+// RestoreMediaVersion makes a previous version current again
+// POST /api/media/:id/versions/:version/restore
+func (h *MediaHandler) RestoreMediaVersion(c *gin.Context) {
+	id, version, ok := parseVersionParams(c)
+	if !ok {
+		return
+	}
+
+	employee, _ := h.getEmployee(c)
+
+	media, err := h.mediaService.RestoreMediaVersion(c.Request.Context(), id, version, employee)
+	if err != nil {
+		status, code := versionErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, media)
+}
+
+// DownloadMediaVersion downloads a specific version of a media item
+// GET /api/media/:id/versions/:version/download
+func (h *MediaHandler) DownloadMediaVersion(c *gin.Context) {
+	id, version, ok := parseVersionParams(c)
+	if !ok {
+		return
+	}
+
+	employee, _ := h.getEmployee(c)
+
+	media, v, reader, err := h.mediaService.DownloadMediaVersion(c.Request.Context(), id, version, employee)
+	if err != nil {
+		status, code := versionErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+	defer reader.Close()
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", media.OriginalFilename))
+	c.Header("Content-Type", v.MimeType)
+	c.Header("Content-Length", fmt.Sprintf("%d", v.FileSizeBytes))
+
+	if _, err := io.Copy(c.Writer, reader); err != nil {
+		log.Printf("Failed to stream version download: %v", err)
+	}
+}
@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// WebhookHandler handles webhook endpoint management
+type WebhookHandler struct {
+	webhookService *services.WebhookService
+	mediaService   *services.MediaService
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(webhookService *services.WebhookService, mediaService *services.MediaService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+		mediaService:   mediaService,
+	}
+}
+
+// webhookErrorStatus maps webhook service errors to HTTP statuses
+func webhookErrorStatus(err error) (int, string) {
+	switch err {
+	case services.ErrWebhookNotFound, services.ErrDeliveryNotFound:
+		return http.StatusNotFound, "NOT_FOUND"
+	case services.ErrInvalidInput:
+		return http.StatusBadRequest, "INVALID_REQUEST"
+	default:
+		return http.StatusInternalServerError, "WEBHOOK_FAILED"
+	}
+}
+
+// ListWebhookEndpoints lists webhook endpoints
+// GET /api/admin/webhooks
+func (h *WebhookHandler) ListWebhookEndpoints(c *gin.Context) {
+	endpoints, err := h.webhookService.ListEndpoints(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to list webhook endpoints",
+			Code:  "LIST_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, endpoints)
+}
+
+// CreateWebhookEndpoint subscribes a URL to events. The response carries the
+// signing secret, which is not shown again.
+// POST /api/admin/webhooks
+func (h *WebhookHandler) CreateWebhookEndpoint(c *gin.Context) {
+	var req models.CreateWebhookEndpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Code:    "INVALID_REQUEST",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	employeeID := c.MustGet("employee_id").(uuid.UUID)
+
+	endpoint, err := h.webhookService.CreateEndpoint(c.Request.Context(), &req, employeeID)
+	if err != nil {
+		status, code := webhookErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	h.audit(c, models.AuditActionCreate, &endpoint.ID, map[string]any{
+		"name":        endpoint.Name,
+		"url":         endpoint.URL,
+		"event_types": endpoint.EventTypes,
+	})
+
+	c.JSON(http.StatusCreated, endpoint)
+}
+
+// GetWebhookEndpoint gets a webhook endpoint
+// GET /api/admin/webhooks/:id
+func (h *WebhookHandler) GetWebhookEndpoint(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	endpoint, err := h.webhookService.GetEndpoint(c.Request.Context(), id)
+	if err != nil {
+		status, code := webhookErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, endpoint)
+}
+
+// UpdateWebhookEndpoint changes the URL, event filter or state of an endpoint
+// PATCH /api/admin/webhooks/:id
+func (h *WebhookHandler) UpdateWebhookEndpoint(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateWebhookEndpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Code:    "INVALID_REQUEST",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	endpoint, err := h.webhookService.UpdateEndpoint(c.Request.Context(), id, &req)
+	if err != nil {
+		status, code := webhookErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	h.audit(c, models.AuditActionUpdate, &id, map[string]any{
+		"name":        endpoint.Name,
+		"url":         endpoint.URL,
+		"event_types": endpoint.EventTypes,
+		"is_active":   endpoint.IsActive,
+	})
+
+	c.JSON(http.StatusOK, endpoint)
+}
+
+// DeleteWebhookEndpoint deletes a webhook endpoint
+// DELETE /api/admin/webhooks/:id
+func (h *WebhookHandler) DeleteWebhookEndpoint(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.webhookService.DeleteEndpoint(c.Request.Context(), id); err != nil {
+		status, code := webhookErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	h.audit(c, models.AuditActionDelete, &id, nil)
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Webhook endpoint deleted successfully",
+	})
+}
+
+// RotateWebhookSecret replaces the signing secret of an endpoint
+// POST /api/admin/webhooks/:id/rotate-secret
+func (h *WebhookHandler) RotateWebhookSecret(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	endpoint, err := h.webhookService.RotateSecret(c.Request.Context(), id)
+	if err != nil {
+		status, code := webhookErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	h.audit(c, models.AuditActionUpdate, &id, map[string]any{
+		"secret_rotated": true,
+	})
+
+	c.JSON(http.StatusOK, endpoint)
+}
+
+// SendWebhookTest queues a webhook.ping event for an endpoint
+// POST /api/admin/webhooks/:id/test
+func (h *WebhookHandler) SendWebhookTest(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookService.SendTestEvent(c.Request.Context(), id)
+	if err != nil {
+		status, code := webhookErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+// ListWebhookDeliveries lists the delivery log of an endpoint
+// GET /api/admin/webhooks/:id/deliveries
+func (h *WebhookHandler) ListWebhookDeliveries(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	var filters models.WebhookDeliveryFilterRequest
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid query parameters",
+			Code:    "INVALID_REQUEST",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	deliveries, err := h.webhookService.ListDeliveries(c.Request.Context(), id, &filters)
+	if err != nil {
+		status, code := webhookErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// ReplayWebhookDelivery sends a logged delivery again
+// POST /api/admin/webhooks/deliveries/:delivery_id/replay
+func (h *WebhookHandler) ReplayWebhookDelivery(c *gin.Context) {
+	deliveryID, err := uuid.Parse(c.Param("delivery_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid delivery ID",
+			Code:  "INVALID_ID",
+		})
+		return
+	}
+
+	delivery, err := h.webhookService.ReplayDelivery(c.Request.Context(), deliveryID)
+	if err != nil {
+		status, code := webhookErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	h.audit(c, models.AuditActionUpdate, &delivery.EndpointID, map[string]any{
+		"replayed_delivery_id": deliveryID,
+		"event_id":             delivery.EventID,
+		"event_type":           delivery.EventType,
+	})
+
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+// audit records a change to a webhook endpoint
+func (h *WebhookHandler) audit(c *gin.Context, action models.AuditAction, id *uuid.UUID, details map[string]any) {
+	h.mediaService.LogAuditRaw(c.Request.Context(), &models.AuditLog{
+		EmployeeID:    c.MustGet("employee_id").(uuid.UUID),
+		EmployeeEmail: c.MustGet("employee_email").(string),
+		Action:        action,
+		Severity:      models.SeverityWarning,
+		ResourceType:  "webhook_endpoint",
+		ResourceID:    id,
+		Details:       details,
+	})
+}
+
+// parseWebhookID reads the :id parameter of webhook routes
+func parseWebhookID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid webhook ID",
+			Code:  "INVALID_ID",
+		})
+		return uuid.Nil, false
+	}
+	return id, true
+}
@@ -0,0 +1,85 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Job is a unit of periodic background work
+type Job func(ctx context.Context) error
+
+type entry struct {
+	name     string
+	interval time.Duration
+	run      Job
+}
+
+// Scheduler runs registered jobs on fixed intervals until stopped
+type Scheduler struct {
+	entries []entry
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewScheduler creates an empty scheduler
+func NewScheduler() *Scheduler {
+	return &Scheduler{}
+}
+
+// Every registers a job to run once per interval. Jobs must be registered
+// before Start is called.
+func (s *Scheduler) Every(name string, interval time.Duration, run Job) {
+	if interval <= 0 {
+		log.Printf("[Scheduler] job %s disabled: interval must be positive", name)
+		return
+	}
+	s.entries = append(s.entries, entry{name: name, interval: interval, run: run})
+}
+
+// Start launches every registered job in its own goroutine. Each job runs
+// once immediately and then on its interval.
+func (s *Scheduler) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+	for _, e := range s.entries {
+		s.wg.Add(1)
+		go s.loop(ctx, e)
+	}
+}
+
+// Stop cancels running jobs and waits for them to return
+func (s *Scheduler) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, e entry) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		s.runOnce(ctx, e)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) runOnce(ctx context.Context, e entry) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[Scheduler] job %s panicked: %v", e.name, r)
+		}
+	}()
+
+	if err := e.run(ctx); err != nil && ctx.Err() == nil {
+		log.Printf("[Scheduler] job %s failed: %v", e.name, err)
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"testing"
+
+	"github.com/appnity/media-vault/internal/models"
+)
+
+func TestAPIKeyScopeForDeniesUnlistedRoutes(t *testing.T) {
+	denied := []struct{ method, path string }{
+		{"POST", "/api/admin/employees"},
+		{"PATCH", "/api/admin/employees/:id"},
+		{"POST", "/api/admin/service-accounts/:id/keys"},
+		{"POST", "/api/auth/logout-all"},
+		{"DELETE", "/api/groups/:id"},
+		// A listed path with another method
+		{"DELETE", "/api/storage-accounts/:id"},
+		// Requests matching no route have an empty full path
+		{"GET", ""},
+	}
+	for _, route := range denied {
+		if scope, ok := apiKeyScopeFor(route.method, route.path); ok {
+			t.Errorf("%s %s is open to API keys with scope %q", route.method, route.path, scope)
+		}
+	}
+
+	if scope, ok := apiKeyScopeFor("DELETE", "/api/media/:id"); !ok || scope != models.ScopeMediaDelete {
+		t.Errorf("DELETE /api/media/:id: got %q, %v, want %q", scope, ok, models.ScopeMediaDelete)
+	}
+	if scope, ok := apiKeyScopeFor("GET", "/api/auth/me"); !ok || scope != "" {
+		t.Errorf("GET /api/auth/me: got %q, %v, want any key", scope, ok)
+	}
+}
+
+func TestAPIKeyRouteScopesAreValid(t *testing.T) {
+	valid := make(map[string]bool, len(models.APIKeyScopes))
+	for _, scope := range models.APIKeyScopes {
+		valid[scope] = true
+	}
+	for route, scope := range apiKeyRouteScopes {
+		if scope != "" && !valid[scope] {
+			t.Errorf("%s needs unknown scope %q, which no key can hold", route, scope)
+		}
+	}
+}
@@ -7,9 +7,11 @@ import (
 	"github.com/appnity/media-vault/internal/models"
 	"github.com/appnity/media-vault/internal/services"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
-// AuthMiddleware creates JWT authentication middleware
+// AuthMiddleware creates authentication middleware accepting JWT access
+// tokens and service account API keys
 func AuthMiddleware(authService *services.AuthService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
@@ -32,6 +34,11 @@ func AuthMiddleware(authService *services.AuthService) gin.HandlerFunc {
 		}
 
 		token := parts[1]
+		if services.IsAPIKey(token) {
+			authenticateAPIKey(c, authService, token)
+			return
+		}
+
 		claims, err := authService.ValidateToken(token)
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{
@@ -41,19 +48,89 @@ func AuthMiddleware(authService *services.AuthService) gin.HandlerFunc {
 			return
 		}
 
+		if !setPermissions(c, authService, claims.Role, claims.CustomRoleID) {
+			return
+		}
+
 		// Set claims in context
 		c.Set("employee_id", claims.EmployeeID)
 		c.Set("employee_email", claims.Email)
 		c.Set("employee_role", claims.Role)
+		c.Set("session_id", claims.SessionID)
+		if claims.ExpiresAt != nil {
+			c.Set("credentials_expire_at", claims.ExpiresAt.Time)
+		}
 
 		c.Next()
 	}
 }
 
-// RoleMiddleware checks if the user has one of the required roles
-func RoleMiddleware(allowedRoles ...models.Role) gin.HandlerFunc {
+// setPermissions resolves the caller's permissions into the context
+func setPermissions(c *gin.Context, authService *services.AuthService, role models.Role, customRoleID *uuid.UUID) bool {
+	permissions, err := authService.Permissions(c.Request.Context(), role, customRoleID)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to resolve permissions",
+			Code:  "INTERNAL_ERROR",
+		})
+		return false
+	}
+	c.Set("employee_permissions", permissions)
+	return true
+}
+
+// authenticateAPIKey authenticates a request made with an API key, which may
+// only call the endpoints its scopes cover
+func authenticateAPIKey(c *gin.Context, authService *services.AuthService, key string) {
+	account, apiKey, err := authService.AuthenticateAPIKey(c.Request.Context(), key, c.ClientIP())
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error: "Invalid, expired or revoked API key",
+			Code:  "INVALID_API_KEY",
+		})
+		return
+	}
+
+	scope, allowed := apiKeyScopeFor(c.Request.Method, c.FullPath())
+	if !allowed {
+		c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{
+			Error: "This endpoint cannot be used with an API key",
+			Code:  "API_KEY_NOT_ALLOWED",
+		})
+		return
+	}
+
+	if scope != "" && !apiKey.HasScope(scope) {
+		c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{
+			Error:   "API key is missing a required scope",
+			Code:    "INSUFFICIENT_SCOPE",
+			Details: scope,
+		})
+		return
+	}
+
+	if !setPermissions(c, authService, account.Role, account.CustomRoleID) {
+		return
+	}
+
+	c.Set("employee_id", account.ID)
+	c.Set("employee_email", account.Email)
+	c.Set("employee_role", account.Role)
+	c.Set("api_key_id", apiKey.ID)
+	if apiKey.ExpiresAt != nil {
+		c.Set("credentials_expire_at", *apiKey.ExpiresAt)
+	}
+	if account.KeyLimits != nil {
+		c.Set("api_key_limits", account.KeyLimits)
+	}
+
+	c.Next()
+}
+
+// RequirePermission checks that the caller holds a permission
+func RequirePermission(permission models.Permission) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		roleVal, exists := c.Get("employee_role")
+		value, exists := c.Get("employee_permissions")
 		if !exists {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{
 				Error: "Authentication required",
@@ -62,36 +139,21 @@ func RoleMiddleware(allowedRoles ...models.Role) gin.HandlerFunc {
 			return
 		}
 
-		role := roleVal.(models.Role)
-		for _, allowed := range allowedRoles {
-			if role == allowed {
+		for _, held := range value.([]models.Permission) {
+			if held == permission {
 				c.Next()
 				return
 			}
 		}
 
 		c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{
-			Error: "Insufficient permissions",
-			Code:  "FORBIDDEN",
+			Error:   "Insufficient permissions",
+			Code:    "FORBIDDEN",
+			Details: permission,
 		})
 	}
 }
 
-// AdminOnly is a shortcut for admin-only routes
-func AdminOnly() gin.HandlerFunc {
-	return RoleMiddleware(models.RoleAdmin)
-}
-
-// DeveloperOrAdmin allows developers and admins
-func DeveloperOrAdmin() gin.HandlerFunc {
-	return RoleMiddleware(models.RoleAdmin, models.RoleDeveloper)
-}
-
-// AllExceptViewer allows all roles except viewers
-func AllExceptViewer() gin.HandlerFunc {
-	return RoleMiddleware(models.RoleAdmin, models.RoleDeveloper, models.RoleMarketing)
-}
-
 // RequestLogger logs request details
 func RequestLogger() gin.HandlerFunc {
 	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
@@ -104,7 +166,7 @@ func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Share-Password")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, PATCH, DELETE")
 
 		if c.Request.Method == "OPTIONS" {
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// SCIMAuth authenticates identity providers calling the SCIM endpoints with
+// the dedicated SCIM bearer token. Employee tokens and API keys are not
+// accepted there.
+func SCIMAuth(token string) gin.HandlerFunc {
+	expected := sha256.Sum256([]byte(token))
+	return func(c *gin.Context) {
+		scheme, presented, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+		hash := sha256.Sum256([]byte(strings.TrimSpace(presented)))
+		if !ok || !strings.EqualFold(scheme, "bearer") || subtle.ConstantTimeCompare(hash[:], expected[:]) != 1 {
+			c.Header("Content-Type", models.SCIMMediaType)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, models.SCIMError{
+				Schemas: []string{models.SCIMErrorSchema},
+				Status:  "401",
+				Detail:  "Invalid or missing SCIM bearer token",
+			})
+			return
+		}
+		c.Next()
+	}
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -22,44 +23,103 @@ type CreateEmployeeRequest struct {
 	Password string `json:"password" binding:"required,min=8"`
 	FullName string `json:"full_name" binding:"required,min=2"`
 	Role     Role   `json:"role" binding:"required,oneof=admin developer marketing viewer"`
+
+	CustomRoleID *uuid.UUID `json:"custom_role_id,omitempty"`
 }
 
-// UpdateEmployeeRequest for updating employee
+// UpdateEmployeeRequest for updating employee. A nil UUID custom role
+// removes the employee's custom role.
 type UpdateEmployeeRequest struct {
-	FullName  *string `json:"full_name,omitempty"`
-	Role      *Role   `json:"role,omitempty"`
-	IsActive  *bool   `json:"is_active,omitempty"`
-	AvatarURL *string `json:"avatar_url,omitempty"`
+	FullName     *string    `json:"full_name,omitempty"`
+	Role         *Role      `json:"role,omitempty" binding:"omitempty,oneof=admin developer marketing viewer"`
+	IsActive     *bool      `json:"is_active,omitempty"`
+	AvatarURL    *string    `json:"avatar_url,omitempty"`
+	CustomRoleID *uuid.UUID `json:"custom_role_id,omitempty"`
+}
+
+// CreateCustomRoleRequest for defining a custom role
+type CreateCustomRoleRequest struct {
+	Name        string       `json:"name" binding:"required,min=2,max=64"`
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions" binding:"required"`
+}
+
+// UpdateCustomRoleRequest for changing a custom role
+type UpdateCustomRoleRequest struct {
+	Name        *string      `json:"name,omitempty" binding:"omitempty,min=2,max=64"`
+	Description *string      `json:"description,omitempty"`
+	Permissions []Permission `json:"permissions,omitempty"`
+}
+
+// CreateServiceAccountRequest for creating a service account. Service
+// accounts cannot be admins.
+type CreateServiceAccountRequest struct {
+	Name string `json:"name" binding:"required,min=2"`
+	Role Role   `json:"role" binding:"required,oneof=developer marketing viewer"`
+}
+
+// MFAVerifyRequest completes a login with a TOTP or recovery code
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// MFASetupRequest starts enrollment during a login that requires it
+type MFASetupRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+}
+
+// MFACodeRequest confirms a two-factor change with a current code
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// OIDCCallbackRequest completes a single sign-on login with the parameters
+// the identity provider redirected back with
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
+
+// CreateAPIKeyRequest for issuing an API key to a service account
+type CreateAPIKeyRequest struct {
+	Name              string      `json:"name" binding:"required"`
+	Scopes            []string    `json:"scopes" binding:"required,min=1"`
+	StorageAccountIDs []uuid.UUID `json:"storage_account_ids"`
+	MediaGroupIDs     []uuid.UUID `json:"media_group_ids"`
+	ExpiresAt         *time.Time  `json:"expires_at"`
 }
 
 // CreateStorageAccountRequest for adding storage provider
 type CreateStorageAccountRequest struct {
-	Name          string            `json:"name" binding:"required,min=2"`
-	Provider      ProviderType      `json:"provider" binding:"required,oneof=cloudinary r2 s3 b2"`
-	Credentials   map[string]string `json:"credentials" binding:"required"`
-	BucketName    *string           `json:"bucket_name,omitempty"`
-	Region        *string           `json:"region,omitempty"`
-	EndpointURL   *string           `json:"endpoint_url,omitempty"`
-	PublicURLBase *string           `json:"public_url_base,omitempty"`
-	IsDefault     bool              `json:"is_default"`
-	IsPublic      bool              `json:"is_public"`
-	MaxFileSizeMB int               `json:"max_file_size_mb"`
-	AllowedTypes  []MediaType       `json:"allowed_types"`
+	Name              string            `json:"name" binding:"required,min=2"`
+	Provider          ProviderType      `json:"provider" binding:"required,oneof=cloudinary r2 s3 b2"`
+	Credentials       map[string]string `json:"credentials" binding:"required"`
+	BucketName        *string           `json:"bucket_name,omitempty"`
+	Region            *string           `json:"region,omitempty"`
+	EndpointURL       *string           `json:"endpoint_url,omitempty"`
+	PublicURLBase     *string           `json:"public_url_base,omitempty"`
+	IsDefault         bool              `json:"is_default"`
+	IsPublic          bool              `json:"is_public"`
+	MaxFileSizeMB     int               `json:"max_file_size_mb"`
+	AllowedTypes      []MediaType       `json:"allowed_types"`
+	ObjectLockEnabled bool              `json:"object_lock_enabled"` // Bucket has S3 Object Lock; legal holds are set on objects too
 }
 
 // UpdateStorageAccountRequest for modifying storage account
 type UpdateStorageAccountRequest struct {
-	Name          *string           `json:"name,omitempty"`
-	Credentials   map[string]string `json:"credentials,omitempty"`
-	BucketName    *string           `json:"bucket_name,omitempty"`
-	Region        *string           `json:"region,omitempty"`
-	EndpointURL   *string           `json:"endpoint_url,omitempty"`
-	PublicURLBase *string           `json:"public_url_base,omitempty"`
-	IsDefault     *bool             `json:"is_default,omitempty"`
-	IsPublic      *bool             `json:"is_public,omitempty"`
-	IsActive      *bool             `json:"is_active,omitempty"`
-	MaxFileSizeMB *int              `json:"max_file_size_mb,omitempty"`
-	AllowedTypes  []MediaType       `json:"allowed_types,omitempty"`
+	Name              *string           `json:"name,omitempty"`
+	Credentials       map[string]string `json:"credentials,omitempty"`
+	BucketName        *string           `json:"bucket_name,omitempty"`
+	Region            *string           `json:"region,omitempty"`
+	EndpointURL       *string           `json:"endpoint_url,omitempty"`
+	PublicURLBase     *string           `json:"public_url_base,omitempty"`
+	IsDefault         *bool             `json:"is_default,omitempty"`
+	IsPublic          *bool             `json:"is_public,omitempty"`
+	IsActive          *bool             `json:"is_active,omitempty"`
+	MaxFileSizeMB     *int              `json:"max_file_size_mb,omitempty"`
+	AllowedTypes      []MediaType       `json:"allowed_types,omitempty"`
+	ObjectLockEnabled *bool             `json:"object_lock_enabled,omitempty"`
 }
 
 // GrantStorageAccessRequest for granting user access to a storage account
@@ -109,6 +169,44 @@ type MoveMediaRequest struct {
 	StorageAccountID *uuid.UUID `json:"storage_account_id,omitempty"` // Move to different provider
 }
 
+// ReleaseQuarantineRequest for releasing a file from quarantine after review
+type ReleaseQuarantineRequest struct {
+	Note string `json:"note" binding:"required,min=3"`
+}
+
+// CreateRetentionPolicyRequest for creating a retention policy
+type CreateRetentionPolicyRequest struct {
+	Name             string          `json:"name" binding:"required,min=2"`
+	Scope            RetentionScope  `json:"scope" binding:"required,oneof=group folder media"`
+	TargetID         uuid.UUID       `json:"target_id" binding:"required"`
+	ExpireAfterDays  *int            `json:"expire_after_days,omitempty" binding:"omitempty,min=1"`
+	ExpireAction     RetentionAction `json:"expire_action" binding:"omitempty,oneof=trash purge"`
+	MinRetentionDays *int            `json:"min_retention_days,omitempty" binding:"omitempty,min=1"`
+}
+
+// UpdateRetentionPolicyRequest for modifying a retention policy.
+// Setting a day count to 0 removes that rule.
+type UpdateRetentionPolicyRequest struct {
+	Name             *string          `json:"name,omitempty"`
+	ExpireAfterDays  *int             `json:"expire_after_days,omitempty" binding:"omitempty,min=0"`
+	ExpireAction     *RetentionAction `json:"expire_action,omitempty" binding:"omitempty,oneof=trash purge"`
+	MinRetentionDays *int             `json:"min_retention_days,omitempty" binding:"omitempty,min=0"`
+	IsActive         *bool            `json:"is_active,omitempty"`
+}
+
+// CreateLegalHoldRequest for placing a legal hold
+type CreateLegalHoldRequest struct {
+	Scope    RetentionScope `json:"scope" binding:"required,oneof=group folder media"`
+	TargetID uuid.UUID      `json:"target_id" binding:"required"`
+	Reason   string         `json:"reason" binding:"required,min=3"`
+	OwnerID  *uuid.UUID     `json:"owner_id,omitempty"` // Defaults to the admin placing the hold
+}
+
+// ReleaseLegalHoldRequest for releasing a legal hold
+type ReleaseLegalHoldRequest struct {
+	Reason string `json:"reason" binding:"required,min=3"`
+}
+
 // MediaFilterRequest for listing/searching media
 type MediaFilterRequest struct {
 	StorageAccountID string     `form:"storage_account_id"`
@@ -120,10 +218,60 @@ type MediaFilterRequest struct {
 	MaxSize          *int64     `form:"max_size"`
 	Tags             []string   `form:"tags"`
 	Search           string     `form:"search"`
+	Fuzzy            string     `form:"fuzzy"` // Typo tolerant match on filenames, tags and folder path
+	Q                string     `form:"q"`     // Search query language, see ParseSearchQuery
 	Page             int        `form:"page,default=1"`
 	PageSize         int        `form:"page_size,default=50"`
-	SortBy           string     `form:"sort_by,default=created_at"`
+	SortBy           string     `form:"sort_by"` // Defaults to relevance for fuzzy searches, created_at otherwise
 	SortOrder        string     `form:"sort_order,default=desc"`
+	Cursor           *string    `form:"cursor"` // Switches to cursor pagination; empty for the first page
+	Count            CountMode  `form:"count" binding:"omitempty,oneof=exact estimate none"`
+	Facets           bool       `form:"facets"` // Include facet counts for the filtered set
+
+	Query  *SearchNode   `form:"-" json:"-"` // Parsed form of Q, set by the service
+	After  *PageCursor   `form:"-" json:"-"` // Decoded Cursor, set by the service
+	Limits *APIKeyLimits `form:"-" json:"-"` // Limits of the API key listing, set by the service
+	Access *MediaAccess  `form:"-" json:"-"` // Row-level access of the caller, set by the service
+}
+
+// SearchOp is the kind of a node in a parsed search query
+type SearchOp string
+
+const (
+	SearchOpAnd  SearchOp = "and"
+	SearchOpOr   SearchOp = "or"
+	SearchOpNot  SearchOp = "not"
+	SearchOpTerm SearchOp = "term"
+)
+
+// SearchField is the media attribute a search term matches
+type SearchField string
+
+const (
+	SearchFieldText     SearchField = "text" // Free text over filenames
+	SearchFieldTag      SearchField = "tag"
+	SearchFieldType     SearchField = "type"
+	SearchFieldSize     SearchField = "size"
+	SearchFieldUploaded SearchField = "uploaded"
+	SearchFieldGroup    SearchField = "group"
+	SearchFieldBy       SearchField = "by"
+	SearchFieldExt      SearchField = "ext"
+	SearchFieldName     SearchField = "name"
+	SearchFieldMime     SearchField = "mime"
+)
+
+// SearchNode is a node of a parsed search query. and/or/not nodes combine
+// their children; term nodes hold one normalized condition.
+type SearchNode struct {
+	Op       SearchOp
+	Children []*SearchNode
+
+	Field   SearchField
+	Value   string     // Text value of tag, type, group, by, ext, name, mime and text terms
+	Compare string     // Comparison of size terms: =, >, >=, <, <=
+	Bytes   int64      // Size terms
+	From    *time.Time // Uploaded terms, inclusive
+	Until   *time.Time // Uploaded terms, exclusive
 }
 
 // AuditLogFilterRequest for filtering audit logs
@@ -136,6 +284,28 @@ type AuditLogFilterRequest struct {
 	EndDate      *time.Time     `form:"end_date" time_format:"2006-01-02"`
 	Page         int            `form:"page,default=1"`
 	PageSize     int            `form:"page_size,default=50"`
+	Cursor       *string        `form:"cursor"` // Switches to cursor pagination; empty for the first page
+	Count        CountMode      `form:"count" binding:"omitempty,oneof=exact estimate none"`
+
+	After *PageCursor `form:"-" json:"-"` // Decoded Cursor, set by the service
+}
+
+// CountMode selects how list endpoints compute their total
+type CountMode string
+
+const (
+	CountExact    CountMode = "exact"    // COUNT(*), the default in page mode
+	CountEstimate CountMode = "estimate" // Planner estimate, cheap on large tables
+	CountNone     CountMode = "none"     // No total, the default in cursor mode
+)
+
+// PageCursor is a decoded keyset pagination cursor: the sort key and ID of
+// the last row of the previous page. Clients only see it encoded.
+type PageCursor struct {
+	SortBy string    `json:"s"`
+	Order  string    `json:"o"`
+	Value  string    `json:"v"`
+	ID     uuid.UUID `json:"id"`
 }
 
 // CreateRoutingRuleRequest for smart routing
@@ -161,6 +331,61 @@ type AuthResponse struct {
 	RefreshToken string   `json:"refresh_token"`
 	ExpiresIn    int64    `json:"expires_in"` // seconds
 	Employee     Employee `json:"employee"`
+
+	RecoveryCodes []string `json:"recovery_codes,omitempty"` // Only when the login enrolled two-factor authentication
+}
+
+// MFAChallenge is the response to a correct password when a second factor
+// is needed. The token completes the login at /api/auth/mfa/verify, or at
+// /api/auth/mfa/setup when the employee must enroll first.
+type MFAChallenge struct {
+	MFARequired        bool   `json:"mfa_required"`
+	MFAToken           string `json:"mfa_token"`
+	ExpiresIn          int64  `json:"expires_in"` // seconds
+	EnrollmentRequired bool   `json:"enrollment_required"`
+}
+
+// TOTPEnrollment is a new TOTP secret, shown once. The provisioning URI is
+// meant to be rendered as a QR code for authenticator apps.
+type TOTPEnrollment struct {
+	Secret          string `json:"secret"` // Base32, for manual entry
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// OIDCAuthorization starts a single sign-on login: the client sends the
+// browser to AuthorizationURL and posts the code and state it comes back
+// with to /api/auth/oidc/callback
+type OIDCAuthorization struct {
+	AuthorizationURL string `json:"authorization_url"`
+	State            string `json:"state"`
+	ExpiresIn        int64  `json:"expires_in"`
+}
+
+// RecoveryCodesResponse lists new single-use recovery codes, shown once
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MFAPolicy lists the built-in roles that must use two-factor authentication
+type MFAPolicy struct {
+	RequiredRoles []Role `json:"required_roles" binding:"dive,oneof=admin developer marketing viewer"`
+}
+
+// JWKS is the JSON Web Key Set of the public keys access tokens are signed with
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK is one public signing key (RFC 7517)
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv,omitempty"` // OKP
+	X         string `json:"x,omitempty"`   // OKP
+	N         string `json:"n,omitempty"`   // RSA
+	E         string `json:"e,omitempty"`   // RSA
 }
 
 // PaginatedResponse for list endpoints
@@ -170,6 +395,45 @@ type PaginatedResponse[T any] struct {
 	Page       int   `json:"page"`
 	PageSize   int   `json:"page_size"`
 	TotalPages int   `json:"total_pages"`
+
+	TotalEstimated bool         `json:"total_estimated,omitempty"`
+	Facets         *MediaFacets `json:"facets,omitempty"`
+	DidYouMean     []string     `json:"did_you_mean,omitempty"`
+}
+
+// CursorPage is a page of a list endpoint in cursor pagination mode. Total
+// is only set when requested with count=exact or count=estimate.
+type CursorPage[T any] struct {
+	Data           []T    `json:"data"`
+	NextCursor     string `json:"next_cursor,omitempty"`
+	HasMore        bool   `json:"has_more"`
+	Total          *int64 `json:"total,omitempty"`
+	TotalEstimated bool   `json:"total_estimated,omitempty"`
+
+	Facets     *MediaFacets `json:"facets,omitempty"`
+	DidYouMean []string     `json:"did_you_mean,omitempty"`
+}
+
+// MediaFacets counts the media matching a filter set along each dimension
+// the library can be drilled into
+type MediaFacets struct {
+	MediaTypes      []FacetCount `json:"media_types"`
+	Groups          []FacetCount `json:"groups"`
+	StorageAccounts []FacetCount `json:"storage_accounts"`
+	Uploaders       []FacetCount `json:"uploaders"`
+	Tags            []FacetCount `json:"tags"`          // Most used tags only
+	SizeBuckets     []FacetCount `json:"size_buckets"`  // Smallest first
+	UploadMonths    []FacetCount `json:"upload_months"` // Newest first, as YYYY-MM
+}
+
+// FacetCount is the number of media sharing one facet value. Value is what
+// the matching filter takes, e.g. an ID; Label is its display name.
+type FacetCount struct {
+	Value    string `json:"value"`
+	Label    string `json:"label,omitempty"`
+	Count    int64  `json:"count"`
+	MinBytes *int64 `json:"min_bytes,omitempty"` // Size buckets: inclusive bounds for min_size/max_size
+	MaxBytes *int64 `json:"max_bytes,omitempty"`
 }
 
 // UploadResponse with signed URL details
@@ -182,6 +446,7 @@ type UploadResponse struct {
 	ExpiresAt        int64             `json:"expires_at"` // Unix timestamp
 	Headers          map[string]string `json:"headers,omitempty"`
 	FormData         map[string]string `json:"form_data,omitempty"`
+	Version          int               `json:"version,omitempty"` // Set when uploading a new version
 }
 
 // UploadCompleteRequest for confirming upload completion
@@ -195,6 +460,191 @@ type UploadCompleteRequest struct {
 	PublicURL     string    `json:"public_url,omitempty"`
 }
 
+// InitiateVersionUploadRequest for starting the upload of a new version
+type InitiateVersionUploadRequest struct {
+	ContentType string `json:"content_type" binding:"required"`
+	FileSize    int64  `json:"file_size" binding:"required"`
+}
+
+// VersionCompleteRequest for confirming a new version was uploaded
+type VersionCompleteRequest struct {
+	Version       int    `json:"version" binding:"required"`
+	FileSizeBytes int64  `json:"file_size_bytes" binding:"required"`
+	MimeType      string `json:"mime_type" binding:"required"`
+	Width         *int   `json:"width,omitempty"`
+	Height        *int   `json:"height,omitempty"`
+	Duration      *int   `json:"duration_seconds,omitempty"`
+}
+
+// CreateShareLinkRequest for sharing media with people without an account.
+// MediaIDs holds the item for media links and the items for selection links.
+type CreateShareLinkRequest struct {
+	Name         *string     `json:"name,omitempty"`
+	Scope        ShareScope  `json:"scope" binding:"required,oneof=media folder selection"`
+	FolderID     *uuid.UUID  `json:"folder_id,omitempty"`
+	MediaIDs     []uuid.UUID `json:"media_ids,omitempty"`
+	Password     string      `json:"password,omitempty" binding:"omitempty,min=6"`
+	ExpiresAt    *time.Time  `json:"expires_at,omitempty"`
+	MaxDownloads *int        `json:"max_downloads,omitempty" binding:"omitempty,min=1"`
+}
+
+// ShareLinkResponse is returned once when a share link is created; the
+// token cannot be retrieved afterwards
+type ShareLinkResponse struct {
+	ShareLink
+	HasPassword bool   `json:"has_password"`
+	Token       string `json:"token,omitempty"`
+	URL         string `json:"url,omitempty"`
+}
+
+// SharedContent is what an unauthenticated visitor of a share link sees
+type SharedContent struct {
+	Name               *string      `json:"name,omitempty"`
+	Scope              ShareScope   `json:"scope"`
+	ExpiresAt          *time.Time   `json:"expires_at,omitempty"`
+	DownloadsRemaining *int         `json:"downloads_remaining,omitempty"`
+	Files              []SharedFile `json:"files"`
+}
+
+// SharedFile is a file visible through a share link
+type SharedFile struct {
+	ID            uuid.UUID `json:"id"`
+	Filename      string    `json:"filename"`
+	MimeType      string    `json:"mime_type"`
+	FileSizeBytes int64     `json:"file_size_bytes"`
+}
+
+// CreateUploadRequestRequest for inviting a guest to upload files
+type CreateUploadRequestRequest struct {
+	Name          string      `json:"name" binding:"required,max=255"`
+	Message       *string     `json:"message,omitempty"`
+	MediaGroupID  uuid.UUID   `json:"media_group_id" binding:"required"`
+	FolderPath    string      `json:"folder_path"`
+	ExpiresAt     *time.Time  `json:"expires_at,omitempty"`
+	MaxFiles      *int        `json:"max_files,omitempty" binding:"omitempty,min=1"`
+	MaxFileSizeMB *int        `json:"max_file_size_mb,omitempty" binding:"omitempty,min=1"`
+	AllowedTypes  []MediaType `json:"allowed_types,omitempty"`
+	RequireReview bool        `json:"require_review"`
+}
+
+// UploadRequestResponse is returned once when an upload request is
+// created; the token cannot be retrieved afterwards
+type UploadRequestResponse struct {
+	UploadRequest
+	Token string `json:"token,omitempty"`
+	URL   string `json:"url,omitempty"`
+}
+
+// GuestUploadInfo is what a guest sees before uploading
+type GuestUploadInfo struct {
+	Name           string      `json:"name"`
+	Message        *string     `json:"message,omitempty"`
+	ExpiresAt      *time.Time  `json:"expires_at,omitempty"`
+	MaxFileSizeMB  *int        `json:"max_file_size_mb,omitempty"`
+	AllowedTypes   []MediaType `json:"allowed_types"`
+	FilesRemaining *int        `json:"files_remaining,omitempty"`
+}
+
+// GuestUploadInitRequest for starting a guest upload
+type GuestUploadInitRequest struct {
+	GuestName   string `json:"guest_name" binding:"required,max=255"`
+	Filename    string `json:"filename" binding:"required"`
+	ContentType string `json:"content_type" binding:"required"`
+	FileSize    int64  `json:"file_size" binding:"required"`
+}
+
+// ReviewUploadRequest for approving or rejecting a guest upload
+type ReviewUploadRequest struct {
+	Decision string `json:"decision" binding:"required,oneof=approve reject"`
+}
+
+// CreateSavedSearchRequest for saving a media filter
+type CreateSavedSearchRequest struct {
+	Name          string                `json:"name" binding:"required,max=255"`
+	Description   *string               `json:"description,omitempty"`
+	Filters       SavedSearchFilters    `json:"filters"`
+	Visibility    SavedSearchVisibility `json:"visibility" binding:"omitempty,oneof=private role group"`
+	SharedRole    *Role                 `json:"shared_role,omitempty" binding:"omitempty,oneof=admin developer marketing viewer"`
+	SharedGroupID *uuid.UUID            `json:"shared_group_id,omitempty"`
+	IsCollection  bool                  `json:"is_collection"`
+}
+
+// UpdateSavedSearchRequest for changing a saved search. Filters, when given,
+// replace the saved filters as a whole.
+type UpdateSavedSearchRequest struct {
+	Name          *string                `json:"name,omitempty" binding:"omitempty,min=1,max=255"`
+	Description   *string                `json:"description,omitempty"`
+	Filters       *SavedSearchFilters    `json:"filters,omitempty"`
+	Visibility    *SavedSearchVisibility `json:"visibility,omitempty" binding:"omitempty,oneof=private role group"`
+	SharedRole    *Role                  `json:"shared_role,omitempty" binding:"omitempty,oneof=admin developer marketing viewer"`
+	SharedGroupID *uuid.UUID             `json:"shared_group_id,omitempty"`
+	IsCollection  *bool                  `json:"is_collection,omitempty"`
+}
+
+// CreateCollectionRequest for creating a collection
+type CreateCollectionRequest struct {
+	Name         string      `json:"name" binding:"required,max=255"`
+	Description  *string     `json:"description,omitempty"`
+	CoverMediaID *uuid.UUID  `json:"cover_media_id,omitempty"`
+	MediaIDs     []uuid.UUID `json:"media_ids,omitempty" binding:"max=500"` // Initial items, in order
+}
+
+// UpdateCollectionRequest for changing a collection's details
+type UpdateCollectionRequest struct {
+	Name         *string    `json:"name,omitempty" binding:"omitempty,min=1,max=255"`
+	Description  *string    `json:"description,omitempty"`
+	CoverMediaID *uuid.UUID `json:"cover_media_id,omitempty"`
+	RemoveCover  bool       `json:"remove_cover,omitempty"`
+}
+
+// CollectionItemsRequest names media to add to, remove from or reorder in a
+// collection. Reordering moves the listed items to the front in the given
+// order; the rest keep their relative order after them.
+type CollectionItemsRequest struct {
+	MediaIDs []uuid.UUID `json:"media_ids" binding:"required,min=1,max=500"`
+}
+
+// SetCollectionAccessRequest replaces who may access a collection
+type SetCollectionAccessRequest struct {
+	Grants []CollectionGrantRequest `json:"grants" binding:"dive"`
+}
+
+// CollectionGrantRequest grants a role or an employee, not both
+type CollectionGrantRequest struct {
+	Role       *Role                `json:"role,omitempty" binding:"omitempty,oneof=admin developer marketing viewer"`
+	EmployeeID *uuid.UUID           `json:"employee_id,omitempty"`
+	Permission CollectionPermission `json:"permission" binding:"required,oneof=view edit"`
+}
+
+// CreateWebhookEndpointRequest for subscribing a URL to events
+type CreateWebhookEndpointRequest struct {
+	Name       string   `json:"name" binding:"required,max=255"`
+	URL        string   `json:"url" binding:"required,url"`
+	EventTypes []string `json:"event_types,omitempty"`
+}
+
+// UpdateWebhookEndpointRequest for changing a webhook endpoint
+type UpdateWebhookEndpointRequest struct {
+	Name       *string  `json:"name,omitempty" binding:"omitempty,max=255"`
+	URL        *string  `json:"url,omitempty" binding:"omitempty,url"`
+	EventTypes []string `json:"event_types,omitempty"` // Replaces the filter when set
+	IsActive   *bool    `json:"is_active,omitempty"`
+}
+
+// WebhookEndpointResponse carries the signing secret when an endpoint is
+// created or its secret rotated; it cannot be retrieved afterwards
+type WebhookEndpointResponse struct {
+	WebhookEndpoint
+	Secret string `json:"secret,omitempty"`
+}
+
+// WebhookDeliveryFilterRequest for browsing the delivery log
+type WebhookDeliveryFilterRequest struct {
+	Status   string `form:"status" binding:"omitempty,oneof=pending succeeded failed"`
+	Page     int    `form:"page"`
+	PageSize int    `form:"page_size"`
+}
+
 // SyncResult result of synchronization
 type SyncResult struct {
 	AddedCount   int      `json:"added_count"`
@@ -223,3 +673,104 @@ type DashboardStats struct {
 	RecentUploads       []MediaWithDetails        `json:"recent_uploads"`
 	StorageAccountStats []StorageAccountWithStats `json:"storage_account_stats"`
 }
+
+// =====================================
+// SCIM 2.0 (RFC 7643, RFC 7644)
+// =====================================
+
+// SCIM schema URNs
+const (
+	SCIMUserSchema     = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SCIMGroupSchema    = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SCIMListSchema     = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SCIMPatchOpSchema  = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SCIMErrorSchema    = "urn:ietf:params:scim:api:messages:2.0:Error"
+	SCIMConfigSchema   = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SCIMResourceSchema = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+	SCIMMediaType      = "application/scim+json"
+)
+
+// SCIMUser is an employee as a SCIM resource. userName is their email.
+type SCIMUser struct {
+	Schemas     []string    `json:"schemas"`
+	ID          string      `json:"id,omitempty"`
+	ExternalID  string      `json:"externalId,omitempty"`
+	UserName    string      `json:"userName"`
+	Name        *SCIMName   `json:"name,omitempty"`
+	DisplayName string      `json:"displayName,omitempty"`
+	Emails      []SCIMEmail `json:"emails,omitempty"`
+	Active      *bool       `json:"active,omitempty"`
+	Groups      []SCIMRef   `json:"groups,omitempty"`
+	Meta        *SCIMMeta   `json:"meta,omitempty"`
+}
+
+// SCIMName is the name of a SCIM user
+type SCIMName struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+// SCIMEmail is an email address of a SCIM user
+type SCIMEmail struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// SCIMGroupResource is a SCIM group. Members is omitted when the client
+// excludes it.
+type SCIMGroupResource struct {
+	Schemas     []string  `json:"schemas"`
+	ID          string    `json:"id,omitempty"`
+	ExternalID  string    `json:"externalId,omitempty"`
+	DisplayName string    `json:"displayName"`
+	Members     []SCIMRef `json:"members,omitempty"`
+	Meta        *SCIMMeta `json:"meta,omitempty"`
+}
+
+// SCIMRef refers to another resource, e.g. a group member
+type SCIMRef struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+// SCIMMeta describes a SCIM resource
+type SCIMMeta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	Location     string    `json:"location"`
+}
+
+// SCIMListResponse is a page of SCIM resources. startIndex is 1-based.
+type SCIMListResponse[T any] struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int64    `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []T      `json:"Resources"`
+}
+
+// SCIMPatchRequest changes a SCIM resource
+type SCIMPatchRequest struct {
+	Schemas    []string             `json:"schemas"`
+	Operations []SCIMPatchOperation `json:"Operations"`
+}
+
+// SCIMPatchOperation is one change of a patch. Value is kept raw because
+// providers differ in its shape, e.g. "False" for false.
+type SCIMPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// SCIMError is the body of a failed SCIM request
+type SCIMError struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	SCIMType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail"`
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,6 +17,64 @@ const (
 	RoleViewer    Role = "viewer"
 )
 
+// Permission is a named capability. Roles bundle permissions; employees get
+// those of their custom role, or else the preset of their built-in role.
+type Permission string
+
+const (
+	PermMediaUpload      Permission = "media:upload"       // Upload and edit own media
+	PermMediaEditAny     Permission = "media:edit_any"     // Move and restore versions of anyone's media
+	PermMediaManageAny   Permission = "media:manage_any"   // Delete, restore and review anyone's media
+	PermMediaPurge       Permission = "media:purge"        // Purge media from the trash
+	PermSharesCreate     Permission = "shares:create"      // Create share links and guest upload requests
+	PermSharesManageAny  Permission = "shares:manage_any"  // See and revoke anyone's links and requests
+	PermLibraryManageAny Permission = "library:manage_any" // See and change anyone's collections and saved searches
+	PermStorageViewAll   Permission = "storage:view_all"   // See every storage account and media item without a grant
+	PermStorageManage    Permission = "storage:manage"     // Create, change and sync storage accounts
+	PermStorageAccess    Permission = "storage:access"     // Grant access to storage accounts
+	PermGroupsManage     Permission = "groups:manage"      // Create and change media groups
+	PermEmployeesManage  Permission = "employees:manage"   // Manage employees, their sessions and service accounts
+	PermRolesManage      Permission = "roles:manage"       // Manage custom roles
+	PermAuditRead        Permission = "audit:read"         // Read audit logs
+	PermQuarantineManage Permission = "quarantine:manage"  // Review quarantined media
+	PermComplianceManage Permission = "compliance:manage"  // Manage retention policies and legal holds
+	PermWebhooksManage   Permission = "webhooks:manage"    // Manage webhooks
+)
+
+// AllPermissions lists every permission
+var AllPermissions = []Permission{
+	PermMediaUpload, PermMediaEditAny, PermMediaManageAny, PermMediaPurge,
+	PermSharesCreate, PermSharesManageAny, PermLibraryManageAny,
+	PermStorageViewAll, PermStorageManage, PermStorageAccess, PermGroupsManage,
+	PermEmployeesManage, PermRolesManage, PermAuditRead, PermQuarantineManage,
+	PermComplianceManage, PermWebhooksManage,
+}
+
+// BuiltinRolePermissions are the presets of the built-in roles
+var BuiltinRolePermissions = map[Role][]Permission{
+	RoleAdmin: AllPermissions,
+	RoleDeveloper: {
+		PermMediaUpload, PermMediaEditAny, PermSharesCreate,
+		PermStorageManage, PermGroupsManage,
+	},
+	RoleMarketing: {
+		PermMediaUpload, PermSharesCreate,
+	},
+	RoleViewer: {},
+}
+
+// CustomRole is an admin-defined bundle of permissions
+type CustomRole struct {
+	ID          *uuid.UUID   `json:"id,omitempty" db:"id"` // Unset for built-in roles
+	Name        string       `json:"name" db:"name"`
+	Description string       `json:"description" db:"description"`
+	Permissions []Permission `json:"permissions" db:"permissions"`
+	Builtin     bool         `json:"builtin" db:"-"`
+	CreatedBy   *uuid.UUID   `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt   *time.Time   `json:"created_at,omitempty" db:"created_at"`
+	UpdatedAt   *time.Time   `json:"updated_at,omitempty" db:"updated_at"`
+}
+
 // Provider types for storage
 type ProviderType string
 
@@ -41,13 +100,22 @@ const (
 type AuditAction string
 
 const (
-	AuditActionUpload   AuditAction = "upload"
-	AuditActionDelete   AuditAction = "delete"
-	AuditActionMove     AuditAction = "move"
-	AuditActionUpdate   AuditAction = "update"
-	AuditActionView     AuditAction = "view"
-	AuditActionDownload AuditAction = "download"
-	AuditActionCreate   AuditAction = "create"
+	AuditActionUpload     AuditAction = "upload"
+	AuditActionDelete     AuditAction = "delete"
+	AuditActionMove       AuditAction = "move"
+	AuditActionUpdate     AuditAction = "update"
+	AuditActionView       AuditAction = "view"
+	AuditActionDownload   AuditAction = "download"
+	AuditActionCreate     AuditAction = "create"
+	AuditActionQuarantine AuditAction = "quarantine"
+	AuditActionRelease    AuditAction = "release"
+	AuditActionRestore    AuditAction = "restore"
+	AuditActionPurge      AuditAction = "purge"
+	AuditActionExpire     AuditAction = "expire"
+	AuditActionHold       AuditAction = "hold"
+	AuditActionShare      AuditAction = "share"
+	AuditActionRevoke     AuditAction = "revoke"
+	AuditActionReview     AuditAction = "review"
 )
 
 type AuditSeverity string
@@ -58,6 +126,58 @@ const (
 	SeverityCritical AuditSeverity = "critical"
 )
 
+// ScanStatus is the antivirus verdict for a media item
+type ScanStatus string
+
+const (
+	ScanStatusPending  ScanStatus = "pending"
+	ScanStatusClean    ScanStatus = "clean"
+	ScanStatusInfected ScanStatus = "infected"
+	ScanStatusError    ScanStatus = "error"
+	ScanStatusSkipped  ScanStatus = "skipped"
+)
+
+// Passed reports whether a file may be handed out: it was found clean, or
+// scanning was not enabled when it was uploaded
+func (s ScanStatus) Passed() bool {
+	return s == ScanStatusClean || s == ScanStatusSkipped
+}
+
+// RetentionScope is what a retention policy applies to
+type RetentionScope string
+
+const (
+	RetentionScopeGroup  RetentionScope = "group"
+	RetentionScopeFolder RetentionScope = "folder"
+	RetentionScopeMedia  RetentionScope = "media"
+)
+
+// ShareScope is what a share link gives access to
+type ShareScope string
+
+const (
+	ShareScopeMedia     ShareScope = "media"
+	ShareScopeFolder    ShareScope = "folder"
+	ShareScopeSelection ShareScope = "selection"
+)
+
+// ReviewStatus tracks the review of a guest upload
+type ReviewStatus string
+
+const (
+	ReviewStatusPending  ReviewStatus = "pending"
+	ReviewStatusApproved ReviewStatus = "approved"
+	ReviewStatusRejected ReviewStatus = "rejected"
+)
+
+// RetentionAction is what happens to media when it expires
+type RetentionAction string
+
+const (
+	RetentionActionTrash RetentionAction = "trash"
+	RetentionActionPurge RetentionAction = "purge"
+)
+
 // Employee represents an internal user
 type Employee struct {
 	ID           uuid.UUID  `json:"id" db:"id"`
@@ -71,6 +191,43 @@ type Employee struct {
 	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
 	DeletedAt    *time.Time `json:"-" db:"deleted_at"`
+
+	IsServiceAccount bool       `json:"is_service_account" db:"is_service_account"`    // Authenticates with API keys only
+	CustomRoleID     *uuid.UUID `json:"custom_role_id,omitempty" db:"custom_role_id"`  // Overrides the permissions of Role
+	MFAEnabledAt     *time.Time `json:"mfa_enabled_at,omitempty" db:"totp_enabled_at"` // Set once TOTP two-factor authentication is confirmed
+
+	// Permissions are resolved per request by the authorizer
+	Permissions []Permission `json:"permissions,omitempty" db:"-"`
+
+	// KeyLimits restricts a request made with an API key; nil otherwise
+	KeyLimits *APIKeyLimits `json:"-" db:"-"`
+}
+
+// Can reports whether the employee holds a permission
+func (e *Employee) Can(permission Permission) bool {
+	for _, p := range e.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// MediaAccess returns the row-level rules the employee's media reads and
+// writes are filtered by, or nil when they may see all media
+func (e *Employee) MediaAccess() *MediaAccess {
+	if e.Can(PermStorageViewAll) {
+		return nil
+	}
+	return &MediaAccess{EmployeeID: e.ID, Role: e.Role}
+}
+
+// MediaAccess limits media to what an employee may see: their own uploads,
+// plus media in storage accounts they can use whose group, if any, admits
+// their role
+type MediaAccess struct {
+	EmployeeID uuid.UUID
+	Role       Role
 }
 
 // StorageAccount represents a cloud storage configuration
@@ -89,6 +246,7 @@ type StorageAccount struct {
 	IsPublic             bool         `json:"is_public" db:"is_public"`
 	MaxFileSizeMB        int          `json:"max_file_size_mb" db:"max_file_size_mb"`
 	AllowedTypes         []MediaType  `json:"allowed_types" db:"allowed_types"`
+	ObjectLockEnabled    bool         `json:"object_lock_enabled" db:"object_lock_enabled"`
 	CreatedBy            uuid.UUID    `json:"created_by" db:"created_by"`
 	CreatedAt            time.Time    `json:"created_at" db:"created_at"`
 	UpdatedAt            time.Time    `json:"updated_at" db:"updated_at"`
@@ -155,9 +313,47 @@ type Media struct {
 	UploadedBy       uuid.UUID      `json:"uploaded_by" db:"uploaded_by"`
 	LastAccessedAt   *time.Time     `json:"last_accessed_at,omitempty" db:"last_accessed_at"`
 	DownloadCount    int            `json:"download_count" db:"download_count"`
-	CreatedAt        time.Time      `json:"created_at" db:"created_at"`
-	UpdatedAt        time.Time      `json:"updated_at" db:"updated_at"`
-	DeletedAt        *time.Time     `json:"-" db:"deleted_at"`
+	ScanStatus       ScanStatus     `json:"scan_status" db:"scan_status"`
+	ScanSignature    *string        `json:"scan_signature,omitempty" db:"scan_signature"`
+	ScannedAt        *time.Time     `json:"scanned_at,omitempty" db:"scanned_at"`
+	QuarantinedAt    *time.Time     `json:"quarantined_at,omitempty" db:"quarantined_at"`
+	ExpiresAt        *time.Time     `json:"expires_at,omitempty" db:"expires_at"`
+	RetainUntil      *time.Time     `json:"retain_until,omitempty" db:"retain_until"`
+	LegalHold        bool           `json:"legal_hold" db:"legal_hold"`
+	CurrentVersion   int            `json:"current_version" db:"current_version"`
+	// Who uploaded the current version and when; unset while it is the first
+	VersionUploadedBy *uuid.UUID    `json:"version_uploaded_by,omitempty" db:"version_uploaded_by"`
+	VersionUploadedAt *time.Time    `json:"version_uploaded_at,omitempty" db:"version_uploaded_at"`
+	UploadRequestID   *uuid.UUID    `json:"upload_request_id,omitempty" db:"upload_request_id"`
+	GuestName         *string       `json:"guest_name,omitempty" db:"guest_name"`
+	ReviewStatus      *ReviewStatus `json:"review_status,omitempty" db:"review_status"`
+	CreatedAt         time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time     `json:"updated_at" db:"updated_at"`
+	DeletedAt         *time.Time    `json:"-" db:"deleted_at"`
+	DeletedBy         *uuid.UUID    `json:"deleted_by,omitempty" db:"deleted_by"`
+}
+
+// MediaVersion is a version of a media item's file. Previous versions are
+// archived under their own storage key; the current version is the file at
+// the media item's storage key.
+type MediaVersion struct {
+	MediaID         uuid.UUID  `json:"media_id" db:"media_id"`
+	Version         int        `json:"version" db:"version_number"`
+	StorageKey      string     `json:"storage_key" db:"storage_key"`
+	MimeType        string     `json:"mime_type" db:"mime_type"`
+	FileSizeBytes   int64      `json:"file_size_bytes" db:"file_size_bytes"`
+	Width           *int       `json:"width,omitempty" db:"width"`
+	Height          *int       `json:"height,omitempty" db:"height"`
+	DurationSeconds *int       `json:"duration_seconds,omitempty" db:"duration_seconds"`
+	UploadedBy      uuid.UUID  `json:"uploaded_by" db:"uploaded_by"`
+	UploadedByName  string     `json:"uploaded_by_name"`
+	UploadedAt      time.Time  `json:"uploaded_at" db:"uploaded_at"`
+	ReplacedBy      *uuid.UUID `json:"replaced_by,omitempty" db:"replaced_by"`
+	ReplacedAt      *time.Time `json:"replaced_at,omitempty" db:"replaced_at"`
+	ScanStatus      ScanStatus `json:"scan_status" db:"scan_status"`
+	ScanSignature   *string    `json:"scan_signature,omitempty" db:"scan_signature"`
+	ScannedAt       *time.Time `json:"scanned_at,omitempty" db:"scanned_at"`
+	IsCurrent       bool       `json:"is_current"`
 }
 
 // MediaWithDetails extends Media with joined data
@@ -170,6 +366,367 @@ type MediaWithDetails struct {
 	UploadedByName     string  `json:"uploaded_by_name"`
 	UploadedByEmail    string  `json:"uploaded_by_email"`
 	FolderPath         *string `json:"folder_path,omitempty"`
+
+	Score *float64 `json:"score,omitempty"` // Fuzzy search similarity, 0-1
+}
+
+// TrashedMedia is a deleted media item that can still be restored
+type TrashedMedia struct {
+	MediaWithDetails
+	TrashedAt     time.Time `json:"trashed_at"`
+	TrashedByName string    `json:"trashed_by_name"`
+	PurgeAfter    time.Time `json:"purge_after"`
+}
+
+// RetentionPolicy expires media after a period and/or protects it from
+// deletion for a minimum period
+type RetentionPolicy struct {
+	ID               uuid.UUID       `json:"id" db:"id"`
+	Name             string          `json:"name" db:"name"`
+	Scope            RetentionScope  `json:"scope" db:"scope"`
+	MediaGroupID     *uuid.UUID      `json:"media_group_id,omitempty" db:"media_group_id"`
+	FolderID         *uuid.UUID      `json:"folder_id,omitempty" db:"folder_id"`
+	MediaID          *uuid.UUID      `json:"media_id,omitempty" db:"media_id"`
+	ExpireAfterDays  *int            `json:"expire_after_days,omitempty" db:"expire_after_days"`
+	ExpireAction     RetentionAction `json:"expire_action" db:"expire_action"`
+	MinRetentionDays *int            `json:"min_retention_days,omitempty" db:"min_retention_days"`
+	IsActive         bool            `json:"is_active" db:"is_active"`
+	CreatedBy        uuid.UUID       `json:"created_by" db:"created_by"`
+	CreatedAt        time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// TargetID returns the ID of the group, folder or media item the policy covers
+func (p *RetentionPolicy) TargetID() uuid.UUID {
+	switch {
+	case p.MediaGroupID != nil:
+		return *p.MediaGroupID
+	case p.FolderID != nil:
+		return *p.FolderID
+	case p.MediaID != nil:
+		return *p.MediaID
+	}
+	return uuid.Nil
+}
+
+// LegalHold prevents media from being deleted, moved or overwritten until
+// it is released
+type LegalHold struct {
+	ID            uuid.UUID      `json:"id" db:"id"`
+	Scope         RetentionScope `json:"scope" db:"scope"`
+	MediaGroupID  *uuid.UUID     `json:"media_group_id,omitempty" db:"media_group_id"`
+	FolderID      *uuid.UUID     `json:"folder_id,omitempty" db:"folder_id"`
+	MediaID       *uuid.UUID     `json:"media_id,omitempty" db:"media_id"`
+	Reason        string         `json:"reason" db:"reason"`
+	OwnerID       uuid.UUID      `json:"owner_id" db:"owner_id"`
+	OwnerName     string         `json:"owner_name"`
+	PlacedBy      uuid.UUID      `json:"placed_by" db:"placed_by"`
+	CreatedAt     time.Time      `json:"created_at" db:"created_at"`
+	ReleasedAt    *time.Time     `json:"released_at,omitempty" db:"released_at"`
+	ReleasedBy    *uuid.UUID     `json:"released_by,omitempty" db:"released_by"`
+	ReleaseReason *string        `json:"release_reason,omitempty" db:"release_reason"`
+}
+
+// TargetID returns the ID of the group, folder or media item the hold covers
+func (h *LegalHold) TargetID() uuid.UUID {
+	switch {
+	case h.MediaGroupID != nil:
+		return *h.MediaGroupID
+	case h.FolderID != nil:
+		return *h.FolderID
+	case h.MediaID != nil:
+		return *h.MediaID
+	}
+	return uuid.Nil
+}
+
+// ShareLink gives unauthenticated access to a media item, folder or
+// selection of media through an unguessable token
+type ShareLink struct {
+	ID             uuid.UUID   `json:"id" db:"id"`
+	TokenHash      string      `json:"-" db:"token_hash"`
+	Name           *string     `json:"name,omitempty" db:"name"`
+	Scope          ShareScope  `json:"scope" db:"scope"`
+	FolderID       *uuid.UUID  `json:"folder_id,omitempty" db:"folder_id"`
+	MediaIDs       []uuid.UUID `json:"media_ids,omitempty"`
+	PasswordHash   *string     `json:"-" db:"password_hash"`
+	ExpiresAt      *time.Time  `json:"expires_at,omitempty" db:"expires_at"`
+	MaxDownloads   *int        `json:"max_downloads,omitempty" db:"max_downloads"`
+	DownloadCount  int         `json:"download_count" db:"download_count"`
+	LastAccessedAt *time.Time  `json:"last_accessed_at,omitempty" db:"last_accessed_at"`
+	CreatedBy      uuid.UUID   `json:"created_by" db:"created_by"`
+	CreatedByName  string      `json:"created_by_name"`
+	CreatedAt      time.Time   `json:"created_at" db:"created_at"`
+	RevokedAt      *time.Time  `json:"revoked_at,omitempty" db:"revoked_at"`
+	RevokedBy      *uuid.UUID  `json:"revoked_by,omitempty" db:"revoked_by"`
+
+	// PasswordLockedUntil is set while wrong passwords lock the link
+	PasswordLockedUntil *time.Time `json:"-" db:"password_locked_until"`
+
+	// KeyLimits are the limits of the API key the link was created with
+	KeyLimits *APIKeyLimits `json:"-" db:"-"`
+}
+
+// HasPassword reports whether the link is password protected
+func (l *ShareLink) HasPassword() bool {
+	return l.PasswordHash != nil
+}
+
+// UploadRequest lets someone without an account upload files into a media
+// group and folder through an unguessable token
+type UploadRequest struct {
+	ID            uuid.UUID   `json:"id" db:"id"`
+	TokenHash     string      `json:"-" db:"token_hash"`
+	Name          string      `json:"name" db:"name"`
+	Message       *string     `json:"message,omitempty" db:"message"`
+	MediaGroupID  uuid.UUID   `json:"media_group_id" db:"media_group_id"`
+	GroupName     string      `json:"group_name"`
+	FolderPath    string      `json:"folder_path" db:"folder_path"`
+	ExpiresAt     *time.Time  `json:"expires_at,omitempty" db:"expires_at"`
+	MaxFiles      *int        `json:"max_files,omitempty" db:"max_files"`
+	MaxFileSizeMB *int        `json:"max_file_size_mb,omitempty" db:"max_file_size_mb"`
+	AllowedTypes  []MediaType `json:"allowed_types" db:"allowed_types"`
+	RequireReview bool        `json:"require_review" db:"require_review"`
+	UploadCount   int         `json:"upload_count" db:"upload_count"`
+	CreatedBy     uuid.UUID   `json:"created_by" db:"created_by"`
+	CreatedByName string      `json:"created_by_name"`
+	CreatedAt     time.Time   `json:"created_at" db:"created_at"`
+	RevokedAt     *time.Time  `json:"revoked_at,omitempty" db:"revoked_at"`
+	RevokedBy     *uuid.UUID  `json:"revoked_by,omitempty" db:"revoked_by"`
+}
+
+// SavedSearchVisibility controls who besides its creator can use a saved
+// search
+type SavedSearchVisibility string
+
+const (
+	SavedSearchPrivate SavedSearchVisibility = "private"
+	SavedSearchRole    SavedSearchVisibility = "role"  // Employees with SharedRole
+	SavedSearchGroup   SavedSearchVisibility = "group" // Employees whose role may access SharedGroupID
+)
+
+// SavedSearchFilters are the media listing filters kept by a saved search.
+// Pagination is left to whoever resolves it.
+type SavedSearchFilters struct {
+	StorageAccountID *uuid.UUID `json:"storage_account_id,omitempty"`
+	MediaGroupID     *uuid.UUID `json:"media_group_id,omitempty"`
+	FolderID         *uuid.UUID `json:"folder_id,omitempty"`
+	MediaType        *MediaType `json:"media_type,omitempty"`
+	UploadedBy       *uuid.UUID `json:"uploaded_by,omitempty"`
+	MinSize          *int64     `json:"min_size,omitempty"`
+	MaxSize          *int64     `json:"max_size,omitempty"`
+	Tags             []string   `json:"tags,omitempty"`
+	Search           string     `json:"search,omitempty"`
+	Fuzzy            string     `json:"fuzzy,omitempty"`
+	Q                string     `json:"q,omitempty"`
+	SortBy           string     `json:"sort_by,omitempty"`
+	SortOrder        string     `json:"sort_order,omitempty"`
+}
+
+// ApplyTo sets the saved filters on a listing request, replacing the
+// request's value for every filter the saved search sets
+func (f *SavedSearchFilters) ApplyTo(req *MediaFilterRequest) {
+	if f.StorageAccountID != nil {
+		req.StorageAccountID = f.StorageAccountID.String()
+	}
+	if f.MediaGroupID != nil {
+		req.MediaGroupID = f.MediaGroupID.String()
+	}
+	if f.FolderID != nil {
+		req.FolderID = f.FolderID.String()
+	}
+	if f.MediaType != nil {
+		req.MediaType = f.MediaType
+	}
+	if f.UploadedBy != nil {
+		req.UploadedBy = f.UploadedBy.String()
+	}
+	if f.MinSize != nil {
+		req.MinSize = f.MinSize
+	}
+	if f.MaxSize != nil {
+		req.MaxSize = f.MaxSize
+	}
+	if len(f.Tags) > 0 {
+		req.Tags = f.Tags
+	}
+	if f.Search != "" {
+		req.Search = f.Search
+	}
+	if f.Fuzzy != "" {
+		req.Fuzzy = f.Fuzzy
+	}
+	if f.Q != "" {
+		req.Q = f.Q
+	}
+	if f.SortBy != "" {
+		req.SortBy = f.SortBy
+	}
+	if f.SortOrder != "" {
+		req.SortOrder = f.SortOrder
+	}
+}
+
+// SavedSearch is a named media filter. Pinned ones are shown as smart
+// collections, which always list the media matching now.
+type SavedSearch struct {
+	ID            uuid.UUID             `json:"id" db:"id"`
+	Name          string                `json:"name" db:"name"`
+	Description   *string               `json:"description,omitempty" db:"description"`
+	Filters       SavedSearchFilters    `json:"filters" db:"filters"`
+	Visibility    SavedSearchVisibility `json:"visibility" db:"visibility"`
+	SharedRole    *Role                 `json:"shared_role,omitempty" db:"shared_role"`
+	SharedGroupID *uuid.UUID            `json:"shared_group_id,omitempty" db:"shared_group_id"`
+	GroupName     *string               `json:"group_name,omitempty"`
+	IsCollection  bool                  `json:"is_collection" db:"is_collection"`
+	CreatedBy     uuid.UUID             `json:"created_by" db:"created_by"`
+	CreatedByName string                `json:"created_by_name"`
+	CreatedAt     time.Time             `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time             `json:"updated_at" db:"updated_at"`
+}
+
+// CollectionPermission is what an employee may do with a collection
+type CollectionPermission string
+
+const (
+	CollectionView  CollectionPermission = "view"
+	CollectionEdit  CollectionPermission = "edit"  // Change details and items
+	CollectionOwner CollectionPermission = "owner" // Creator or admin; never granted
+)
+
+// Collection is a curated, ordered set of media. An item can belong to any
+// number of collections without moving it.
+type Collection struct {
+	ID            uuid.UUID  `json:"id" db:"id"`
+	Name          string     `json:"name" db:"name"`
+	Description   *string    `json:"description,omitempty" db:"description"`
+	CoverMediaID  *uuid.UUID `json:"cover_media_id,omitempty" db:"cover_media_id"`
+	CoverURL      *string    `json:"cover_url,omitempty"` // Thumbnail, or the public URL without one
+	ItemCount     int64      `json:"item_count"`
+	CreatedBy     uuid.UUID  `json:"created_by" db:"created_by"`
+	CreatedByName string     `json:"created_by_name"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+
+	Permission CollectionPermission `json:"permission,omitempty"` // The viewer's
+}
+
+// CollectionGrant gives a role or a single employee access to a collection
+type CollectionGrant struct {
+	ID           uuid.UUID            `json:"id" db:"id"`
+	CollectionID uuid.UUID            `json:"collection_id" db:"collection_id"`
+	Role         *Role                `json:"role,omitempty" db:"role"`
+	EmployeeID   *uuid.UUID           `json:"employee_id,omitempty" db:"employee_id"`
+	EmployeeName *string              `json:"employee_name,omitempty"`
+	Permission   CollectionPermission `json:"permission" db:"permission"`
+	CreatedAt    time.Time            `json:"created_at" db:"created_at"`
+}
+
+// WebhookEventType names an event delivered to webhook endpoints
+type WebhookEventType string
+
+const (
+	WebhookEventMediaUploaded        WebhookEventType = "media.uploaded"
+	WebhookEventMediaUpdated         WebhookEventType = "media.updated"
+	WebhookEventMediaMoved           WebhookEventType = "media.moved"
+	WebhookEventMediaDeleted         WebhookEventType = "media.deleted"
+	WebhookEventMediaRestored        WebhookEventType = "media.restored"
+	WebhookEventStorageCreated       WebhookEventType = "storage_account.created"
+	WebhookEventStorageUpdated       WebhookEventType = "storage_account.updated"
+	WebhookEventStorageDeleted       WebhookEventType = "storage_account.deleted"
+	WebhookEventStorageSyncCompleted WebhookEventType = "storage_account.synced"
+	WebhookEventPing                 WebhookEventType = "webhook.ping"
+)
+
+// WebhookEventTypes lists the events endpoints can subscribe to
+var WebhookEventTypes = []WebhookEventType{
+	WebhookEventMediaUploaded, WebhookEventMediaUpdated, WebhookEventMediaMoved,
+	WebhookEventMediaDeleted, WebhookEventMediaRestored,
+	WebhookEventStorageCreated, WebhookEventStorageUpdated, WebhookEventStorageDeleted,
+	WebhookEventStorageSyncCompleted,
+}
+
+// WebhookDeliveryStatus tracks the delivery of an event to an endpoint
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookEndpoint is an admin-managed URL that receives signed events
+type WebhookEndpoint struct {
+	ID              uuid.UUID `json:"id" db:"id"`
+	Name            string    `json:"name" db:"name"`
+	URL             string    `json:"url" db:"url"`
+	EncryptedSecret []byte    `json:"-" db:"encrypted_secret"`
+	SecretNonce     []byte    `json:"-" db:"secret_nonce"`
+	EventTypes      []string  `json:"event_types" db:"event_types"` // Empty subscribes to every event
+	IsActive        bool      `json:"is_active" db:"is_active"`
+	CreatedBy       uuid.UUID `json:"created_by" db:"created_by"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// WebhookEvent is the JSON body posted to webhook endpoints
+type WebhookEvent struct {
+	ID        uuid.UUID        `json:"id"`
+	Type      WebhookEventType `json:"type"`
+	CreatedAt time.Time        `json:"created_at"`
+	Data      any              `json:"data"`
+}
+
+// WebhookDelivery is one event sent, or to be sent, to one endpoint
+type WebhookDelivery struct {
+	ID             uuid.UUID             `json:"id" db:"id"`
+	EndpointID     uuid.UUID             `json:"endpoint_id" db:"endpoint_id"`
+	EventID        uuid.UUID             `json:"event_id" db:"event_id"`
+	EventType      WebhookEventType      `json:"event_type" db:"event_type"`
+	Payload        json.RawMessage       `json:"payload" db:"payload"`
+	ReplayOf       *uuid.UUID            `json:"replay_of,omitempty" db:"replay_of"`
+	Status         WebhookDeliveryStatus `json:"status" db:"status"`
+	Attempts       int                   `json:"attempts" db:"attempts"`
+	NextAttemptAt  time.Time             `json:"next_attempt_at" db:"next_attempt_at"`
+	LastAttemptAt  *time.Time            `json:"last_attempt_at,omitempty" db:"last_attempt_at"`
+	ResponseStatus *int                  `json:"response_status,omitempty" db:"response_status"`
+	ResponseBody   *string               `json:"response_body,omitempty" db:"response_body"`
+	LastError      *string               `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt      time.Time             `json:"created_at" db:"created_at"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty" db:"delivered_at"`
+}
+
+// ActivityEvent is a live update pushed to connected clients over SSE
+type ActivityEvent struct {
+	ID               uuid.UUID      `json:"id"`
+	Type             string         `json:"type"`
+	ResourceType     string         `json:"resource_type"`
+	ResourceID       *uuid.UUID     `json:"resource_id,omitempty"`
+	EmployeeID       uuid.UUID      `json:"employee_id"`
+	EmployeeEmail    string         `json:"employee_email,omitempty"`
+	MediaGroupID     *uuid.UUID     `json:"media_group_id,omitempty"`
+	StorageAccountID *uuid.UUID     `json:"storage_account_id,omitempty"`
+	Details          map[string]any `json:"details,omitempty"`
+	CreatedAt        time.Time      `json:"created_at"`
+}
+
+// MediaAudience describes who may see a media item, used to filter live
+// activity events. It is looked up for trashed media too.
+type MediaAudience struct {
+	MediaGroupID     *uuid.UUID
+	StorageAccountID uuid.UUID
+	AllowedRoles     []Role // Empty when the item is in no group
+	UploadedBy       uuid.UUID
+	Restricted       bool // Quarantined or awaiting review
+}
+
+// ExpiredMedia is a media item whose retention policy has run out
+type ExpiredMedia struct {
+	Media
+	PolicyID        uuid.UUID       `json:"policy_id"`
+	PolicyName      string          `json:"policy_name"`
+	PolicyCreatedBy uuid.UUID       `json:"policy_created_by"`
+	Action          RetentionAction `json:"action"`
 }
 
 // AuditLog for tracking operations
@@ -207,12 +764,191 @@ type StorageRoutingRule struct {
 
 // RefreshToken for JWT auth
 type RefreshToken struct {
-	ID         uuid.UUID  `json:"id" db:"id"`
-	EmployeeID uuid.UUID  `json:"employee_id" db:"employee_id"`
-	TokenHash  string     `json:"-" db:"token_hash"`
-	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
-	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
-	RevokedAt  *time.Time `json:"-" db:"revoked_at"`
+	ID            uuid.UUID  `json:"id" db:"id"`
+	EmployeeID    uuid.UUID  `json:"employee_id" db:"employee_id"`
+	FamilyID      uuid.UUID  `json:"family_id" db:"family_id"` // Shared by the tokens of one login
+	TokenHash     string     `json:"-" db:"token_hash"`
+	ExpiresAt     time.Time  `json:"expires_at" db:"expires_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	RevokedAt     *time.Time `json:"-" db:"revoked_at"`
+	RevokedReason *string    `json:"-" db:"revoked_reason"`
+	ReplacedBy    *uuid.UUID `json:"-" db:"replaced_by"`
+
+	SessionStartedAt time.Time `json:"session_started_at" db:"session_started_at"` // Login time, carried over on rotation
+	IPAddress        *string   `json:"ip_address,omitempty" db:"ip_address"`       // Client that last used the session
+	UserAgent        *string   `json:"user_agent,omitempty" db:"user_agent"`
+}
+
+// Session is a login as seen by its employee: the live refresh token of a
+// token family
+type Session struct {
+	ID         uuid.UUID `json:"id"` // The token family ID
+	EmployeeID uuid.UUID `json:"employee_id"`
+	IPAddress  *string   `json:"ip_address,omitempty"`
+	UserAgent  *string   `json:"user_agent,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"` // The session of the requesting access token
+}
+
+// Reasons a refresh token was revoked
+const (
+	TokenRevokedRotated     = "rotated"
+	TokenRevokedLogout      = "logout"
+	TokenRevokedLogoutAll   = "logout_all"
+	TokenRevokedSession     = "session_revoked"
+	TokenRevokedReuse       = "reuse"
+	TokenRevokedDeactivated = "deactivated"
+)
+
+// TOTPSecret is an employee's encrypted TOTP secret and the state guarding
+// code verification
+type TOTPSecret struct {
+	EncryptedSecret []byte
+	Nonce           []byte
+	EnabledAt       *time.Time // Nil while the secret awaits its first code
+	LastStep        int64      // Time step of the last accepted code
+	LockedUntil     *time.Time // Set after too many wrong codes
+}
+
+// OIDCLoginState is a single sign-on authorization request waiting for the
+// identity provider to redirect back
+type OIDCLoginState struct {
+	StateHash    string
+	CodeVerifier string // PKCE verifier the authorization code is redeemed with
+	Nonce        string // Must come back in the ID token
+	ExpiresAt    time.Time
+}
+
+// SCIMEmployee is an employee as SCIM provisioning sees them
+type SCIMEmployee struct {
+	Employee
+	ExternalID *string               // The identity provider's ID for them
+	Groups     []SCIMGroupMembership // Filled by the service
+}
+
+// SCIMGroupMembership is a group an employee belongs to
+type SCIMGroupMembership struct {
+	GroupID     uuid.UUID
+	DisplayName string
+}
+
+// SCIMGroup is a group pushed by the identity provider. Its display name
+// decides the role of its members.
+type SCIMGroup struct {
+	ID          uuid.UUID
+	DisplayName string
+	ExternalID  *string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Members     []SCIMGroupMember // Filled by the service
+}
+
+// SCIMGroupMember is an employee in a SCIM group
+type SCIMGroupMember struct {
+	EmployeeID uuid.UUID
+	Email      string
+}
+
+// MFAStatus describes an employee's two-factor authentication
+type MFAStatus struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabled_at,omitempty"`
+	Required               bool       `json:"required"` // Their role must use two-factor authentication
+	RecoveryCodesRemaining int        `json:"recovery_codes_remaining"`
+}
+
+// API key scopes. A key can only call the endpoints its scopes cover.
+const (
+	ScopeMediaRead   = "media:read"
+	ScopeMediaWrite  = "media:write"
+	ScopeMediaDelete = "media:delete"
+	ScopeStorageRead = "storage:read"
+	ScopeStorageSync = "storage:sync"
+	ScopeGroupsRead  = "groups:read"
+)
+
+// APIKeyScopes lists every valid API key scope
+var APIKeyScopes = []string{
+	ScopeMediaRead, ScopeMediaWrite, ScopeMediaDelete,
+	ScopeStorageRead, ScopeStorageSync, ScopeGroupsRead,
+}
+
+// APIKey is a long-lived credential of a service account
+type APIKey struct {
+	ID                uuid.UUID   `json:"id" db:"id"`
+	ServiceAccountID  uuid.UUID   `json:"service_account_id" db:"service_account_id"`
+	Name              string      `json:"name" db:"name"`
+	KeyPrefix         string      `json:"key_prefix" db:"key_prefix"`
+	KeyHash           string      `json:"-" db:"key_hash"`
+	Scopes            []string    `json:"scopes" db:"scopes"`
+	StorageAccountIDs []uuid.UUID `json:"storage_account_ids" db:"storage_account_ids"`
+	MediaGroupIDs     []uuid.UUID `json:"media_group_ids" db:"media_group_ids"`
+	ExpiresAt         *time.Time  `json:"expires_at,omitempty" db:"expires_at"`
+	LastUsedAt        *time.Time  `json:"last_used_at,omitempty" db:"last_used_at"`
+	LastUsedIP        *string     `json:"last_used_ip,omitempty" db:"last_used_ip"`
+	CreatedBy         *uuid.UUID  `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt         time.Time   `json:"created_at" db:"created_at"`
+	RevokedAt         *time.Time  `json:"revoked_at,omitempty" db:"revoked_at"`
+
+	Key string `json:"key,omitempty" db:"-"` // The plaintext key, only returned on creation
+}
+
+// HasScope reports whether the key grants a scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Limits returns the storage accounts and groups the key is limited to, or
+// nil when it is not limited
+func (k *APIKey) Limits() *APIKeyLimits {
+	if len(k.StorageAccountIDs) == 0 && len(k.MediaGroupIDs) == 0 {
+		return nil
+	}
+	return &APIKeyLimits{StorageAccountIDs: k.StorageAccountIDs, MediaGroupIDs: k.MediaGroupIDs}
+}
+
+// APIKeyLimits restricts an API key to storage accounts and media groups.
+// An empty list leaves that dimension unrestricted.
+type APIKeyLimits struct {
+	StorageAccountIDs []uuid.UUID
+	MediaGroupIDs     []uuid.UUID
+}
+
+// AllowsStorageAccount reports whether the limits admit a storage account
+func (l *APIKeyLimits) AllowsStorageAccount(id uuid.UUID) bool {
+	if l == nil || len(l.StorageAccountIDs) == 0 {
+		return true
+	}
+	for _, allowed := range l.StorageAccountIDs {
+		if allowed == id {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsMediaGroup reports whether the limits admit a media group. Media
+// outside any group is not admitted when groups are limited.
+func (l *APIKeyLimits) AllowsMediaGroup(id *uuid.UUID) bool {
+	if l == nil || len(l.MediaGroupIDs) == 0 {
+		return true
+	}
+	if id == nil {
+		return false
+	}
+	for _, allowed := range l.MediaGroupIDs {
+		if allowed == *id {
+			return true
+		}
+	}
+	return false
 }
 
 // StorageAccountAccess represents a user's access to a storage account
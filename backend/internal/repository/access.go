@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/google/uuid"
+)
+
+// mediaAccessCondition builds the row-level access condition over the media
+// table aliased m: the employee's own uploads, and media in a storage account
+// they can use whose group, if any, admits their role. It needs no joins, so
+// it also fits count queries.
+func mediaAccessCondition(access *models.MediaAccess, args *[]any) string {
+	*args = append(*args, access.EmployeeID, access.Role)
+	employee, role := len(*args)-1, len(*args)
+
+	return fmt.Sprintf(`(m.uploaded_by = $%[1]d OR (
+		EXISTS (
+			SELECT 1 FROM storage_accounts asa
+			WHERE asa.id = m.storage_account_id AND asa.deleted_at IS NULL
+				AND (asa.created_by = $%[1]d OR asa.is_public = true OR asa.id IN (SELECT storage_account_id FROM storage_account_access WHERE employee_id = $%[1]d))
+		)
+		AND (m.media_group_id IS NULL OR EXISTS (
+			SELECT 1 FROM media_groups amg
+			WHERE amg.id = m.media_group_id AND $%[2]d::role_type = ANY(amg.allowed_roles)
+		))
+	))`, employee, role)
+}
+
+// CanAccessMedia reports whether row-level access rules let an employee see
+// a media item. Trashed items are included, so the rules also guard restores.
+func (r *Repository) CanAccessMedia(ctx context.Context, id uuid.UUID, access *models.MediaAccess) (bool, error) {
+	args := []any{id}
+	query := `SELECT EXISTS (SELECT 1 FROM media m WHERE m.id = $1 AND ` + mediaAccessCondition(access, &args) + `)`
+
+	var ok bool
+	err := r.db.QueryRow(ctx, query, args...).Scan(&ok)
+	return ok, err
+}
+
+// mediaLimitsCondition builds the condition over the media table aliased m
+// that keeps media within the limits of an API key, or "" when there are none
+func mediaLimitsCondition(limits *models.APIKeyLimits, args *[]any) string {
+	if limits == nil {
+		return ""
+	}
+	var conditions []string
+	if len(limits.StorageAccountIDs) > 0 {
+		*args = append(*args, limits.StorageAccountIDs)
+		conditions = append(conditions, fmt.Sprintf("m.storage_account_id = ANY($%d)", len(*args)))
+	}
+	if len(limits.MediaGroupIDs) > 0 {
+		*args = append(*args, limits.MediaGroupIDs)
+		conditions = append(conditions, fmt.Sprintf("m.media_group_id = ANY($%d)", len(*args)))
+	}
+	return strings.Join(conditions, " AND ")
+}
+
+// FolderHasInaccessibleMedia reports whether a folder holds live media that
+// an employee may not see, under access and within the limits of their API key
+func (r *Repository) FolderHasInaccessibleMedia(ctx context.Context, folderID uuid.UUID, access *models.MediaAccess, limits *models.APIKeyLimits) (bool, error) {
+	args := []any{folderID}
+	var conditions []string
+	if access != nil {
+		conditions = append(conditions, mediaAccessCondition(access, &args))
+	}
+	if condition := mediaLimitsCondition(limits, &args); condition != "" {
+		conditions = append(conditions, condition)
+	}
+	if len(conditions) == 0 {
+		return false, nil
+	}
+
+	query := `SELECT EXISTS (
+		SELECT 1 FROM media m
+		WHERE m.folder_id = $1 AND m.deleted_at IS NULL AND NOT COALESCE(` + strings.Join(conditions, " AND ") + `, false)
+	)`
+	var exists bool
+	err := r.db.QueryRow(ctx, query, args...).Scan(&exists)
+	return exists, err
+}
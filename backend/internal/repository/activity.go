@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ==========================================
+// Activity Stream Methods
+// ==========================================
+
+// activityChannel is the Postgres NOTIFY channel live activity events are
+// published on, so every server replica receives every event
+const activityChannel = "media_vault_activity"
+
+// NotifyActivity publishes an encoded activity event to all listeners
+func (r *Repository) NotifyActivity(ctx context.Context, payload string) error {
+	_, err := r.db.Exec(ctx, `SELECT pg_notify($1, $2)`, activityChannel, payload)
+	return err
+}
+
+// ListenActivity receives activity events on a dedicated connection and
+// passes each payload to handle. It blocks until ctx is cancelled or the
+// connection fails.
+func (r *Repository) ListenActivity(ctx context.Context, handle func(payload string)) error {
+	pooled, err := r.db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// A listening connection must not go back to the pool
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, `LISTEN `+activityChannel); err != nil {
+		return err
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		handle(notification.Payload)
+	}
+}
+
+// GetMediaAudience looks up who may see a media item, including trashed items
+func (r *Repository) GetMediaAudience(ctx context.Context, id uuid.UUID) (*models.MediaAudience, error) {
+	query := `
+		SELECT m.media_group_id, m.storage_account_id, COALESCE(mg.allowed_roles::text[], '{}'),
+			m.uploaded_by, (m.quarantined_at IS NOT NULL OR m.review_status = 'pending')
+		FROM media m
+		LEFT JOIN media_groups mg ON m.media_group_id = mg.id
+		WHERE m.id = $1
+	`
+	var audience models.MediaAudience
+	var allowedRolesStr []string
+	err := r.db.QueryRow(ctx, query, id).Scan(
+		&audience.MediaGroupID, &audience.StorageAccountID, &allowedRolesStr,
+		&audience.UploadedBy, &audience.Restricted,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	audience.AllowedRoles = make([]models.Role, len(allowedRolesStr))
+	for i, s := range allowedRolesStr {
+		audience.AllowedRoles[i] = models.Role(s)
+	}
+	return &audience, nil
+}
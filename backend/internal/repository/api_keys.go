@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ==========================================
+// Service Account and API Key Methods
+// ==========================================
+
+const apiKeyColumns = `
+	id, service_account_id, name, key_prefix, key_hash, scopes,
+	storage_account_ids, media_group_ids, expires_at, last_used_at,
+	host(last_used_ip), created_by, created_at, revoked_at
+	FROM api_keys
+`
+
+func scanAPIKey(row pgx.Row, k *models.APIKey) error {
+	return row.Scan(
+		&k.ID, &k.ServiceAccountID, &k.Name, &k.KeyPrefix, &k.KeyHash, &k.Scopes,
+		&k.StorageAccountIDs, &k.MediaGroupIDs, &k.ExpiresAt, &k.LastUsedAt,
+		&k.LastUsedIP, &k.CreatedBy, &k.CreatedAt, &k.RevokedAt,
+	)
+}
+
+// ListServiceAccounts lists service accounts that are not deleted
+func (r *Repository) ListServiceAccounts(ctx context.Context) ([]models.Employee, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, email, password_hash, full_name, role, avatar_url, is_active, last_login_at, created_at, updated_at,
+			is_service_account, custom_role_id, totp_enabled_at
+		FROM employees WHERE is_service_account AND deleted_at IS NULL
+		ORDER BY full_name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := []models.Employee{}
+	for rows.Next() {
+		var emp models.Employee
+		if err := rows.Scan(
+			&emp.ID, &emp.Email, &emp.PasswordHash, &emp.FullName, &emp.Role,
+			&emp.AvatarURL, &emp.IsActive, &emp.LastLoginAt, &emp.CreatedAt, &emp.UpdatedAt,
+			&emp.IsServiceAccount, &emp.CustomRoleID, &emp.MFAEnabledAt,
+		); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, emp)
+	}
+	return accounts, rows.Err()
+}
+
+// CreateAPIKey stores a new API key
+func (r *Repository) CreateAPIKey(ctx context.Context, k *models.APIKey) error {
+	query := `
+		INSERT INTO api_keys (
+			id, service_account_id, name, key_prefix, key_hash, scopes,
+			storage_account_ids, media_group_ids, expires_at, created_by, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+	k.ID = uuid.New()
+	k.CreatedAt = time.Now()
+	if k.StorageAccountIDs == nil {
+		k.StorageAccountIDs = []uuid.UUID{}
+	}
+	if k.MediaGroupIDs == nil {
+		k.MediaGroupIDs = []uuid.UUID{}
+	}
+
+	_, err := r.db.Exec(ctx, query,
+		k.ID, k.ServiceAccountID, k.Name, k.KeyPrefix, k.KeyHash, k.Scopes,
+		k.StorageAccountIDs, k.MediaGroupIDs, k.ExpiresAt, k.CreatedBy, k.CreatedAt,
+	)
+	return err
+}
+
+// GetAPIKeyByHash retrieves an API key by the hash of the key, revoked or not
+func (r *Repository) GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` WHERE key_hash = $1`
+	var k models.APIKey
+	err := scanAPIKey(r.db.QueryRow(ctx, query, keyHash), &k)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return &k, err
+}
+
+// ListAPIKeys lists the keys of a service account, newest first
+func (r *Repository) ListAPIKeys(ctx context.Context, serviceAccountID uuid.UUID) ([]models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` WHERE service_account_id = $1 ORDER BY created_at DESC`
+	rows, err := r.db.Query(ctx, query, serviceAccountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		var k models.APIKey
+		if err := scanAPIKey(rows, &k); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// TouchAPIKey records a use of an API key. Writes are throttled to one a
+// minute per key, which is plenty for last-used tracking.
+func (r *Repository) TouchAPIKey(ctx context.Context, id uuid.UUID, ip *string) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE api_keys SET last_used_at = NOW(), last_used_ip = $2
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')
+	`, id, ip)
+	return err
+}
+
+// RevokeAPIKey revokes a live key of a service account. It returns
+// ErrNotFound when there is no such live key.
+func (r *Repository) RevokeAPIKey(ctx context.Context, serviceAccountID, id uuid.UUID) error {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE api_keys SET revoked_at = NOW()
+		WHERE id = $1 AND service_account_id = $2 AND revoked_at IS NULL
+	`, id, serviceAccountID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RevokeServiceAccountAPIKeys revokes every live key of a service account
+func (r *Repository) RevokeServiceAccountAPIKeys(ctx context.Context, serviceAccountID uuid.UUID) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE api_keys SET revoked_at = NOW()
+		WHERE service_account_id = $1 AND revoked_at IS NULL
+	`, serviceAccountID)
+	return err
+}
@@ -0,0 +1,370 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ==========================================
+// Collection Methods
+// ==========================================
+
+// collectionMediaVisible limits collection items to media that is listed in
+// the library: not trashed, quarantined or awaiting review
+const collectionMediaVisible = `m.deleted_at IS NULL AND m.quarantined_at IS NULL AND (m.review_status IS NULL OR m.review_status = 'approved')`
+
+// collectionColumns selects a collection for a viewer: its cover and item
+// count only take media into account that is listed in the library and, when
+// access is given, within it. Arguments are appended to args.
+func collectionColumns(access *models.MediaAccess, args *[]any) string {
+	visible := collectionMediaVisible
+	if access != nil {
+		visible += " AND " + mediaAccessCondition(access, args)
+	}
+
+	return `
+	c.id, c.name, c.description, c.cover_media_id,
+	(
+		SELECT COALESCE(m.thumbnail_url, m.public_url) FROM media m
+		WHERE m.id = c.cover_media_id AND ` + visible + `
+	) as cover_url,
+	(
+		SELECT COUNT(*) FROM collection_items ci
+		JOIN media m ON m.id = ci.media_id
+		WHERE ci.collection_id = c.id AND ` + visible + `
+	) as item_count,
+	c.created_by, COALESCE(e.full_name, '') as created_by_name,
+	c.created_at, c.updated_at
+	FROM collections c
+	LEFT JOIN employees e ON c.created_by = e.id
+`
+}
+
+// collectionDest returns scan destinations matching collectionColumns
+func collectionDest(c *models.Collection) []any {
+	return []any{
+		&c.ID, &c.Name, &c.Description, &c.CoverMediaID,
+		&c.CoverURL, &c.ItemCount,
+		&c.CreatedBy, &c.CreatedByName,
+		&c.CreatedAt, &c.UpdatedAt,
+	}
+}
+
+func scanCollection(row pgx.Row, c *models.Collection) error {
+	return row.Scan(collectionDest(c)...)
+}
+
+// CreateCollection creates a new collection
+func (r *Repository) CreateCollection(ctx context.Context, c *models.Collection) error {
+	query := `
+		INSERT INTO collections (id, name, description, cover_media_id, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+	`
+	c.ID = uuid.New()
+	c.CreatedAt = time.Now()
+	c.UpdatedAt = c.CreatedAt
+
+	_, err := r.db.Exec(ctx, query, c.ID, c.Name, c.Description, c.CoverMediaID, c.CreatedBy, c.CreatedAt)
+	return err
+}
+
+// GetCollectionByID retrieves a collection by ID, leaving media outside
+// access, when given, out of its cover and item count
+func (r *Repository) GetCollectionByID(ctx context.Context, id uuid.UUID, access *models.MediaAccess) (*models.Collection, error) {
+	args := []any{id}
+	query := `SELECT ` + collectionColumns(access, &args) + ` WHERE c.id = $1 AND c.deleted_at IS NULL`
+	var c models.Collection
+	err := scanCollection(r.db.QueryRow(ctx, query, args...), &c)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return &c, err
+}
+
+// ListCollections lists collections by name, with the permission the
+// employee has on each. When employeeID is nil every collection is listed
+// with owner permission, as for admins; otherwise only those the employee
+// created or was granted, directly or through their role. Media outside
+// access, when given, is left out of covers and item counts.
+func (r *Repository) ListCollections(ctx context.Context, employeeID *uuid.UUID, role models.Role, access *models.MediaAccess) ([]models.Collection, error) {
+	args := []any{employeeID, role}
+	query := `SELECT
+		CASE WHEN $1::uuid IS NULL OR c.created_by = $1 THEN 'owner' ELSE (
+			SELECT MAX(a.permission)::text FROM collection_access a
+			WHERE a.collection_id = c.id AND (a.employee_id = $1 OR a.role = $2::role_type)
+		) END as permission,
+		` + collectionColumns(access, &args) + `
+		WHERE c.deleted_at IS NULL
+			AND (
+				$1::uuid IS NULL
+				OR c.created_by = $1
+				OR EXISTS (
+					SELECT 1 FROM collection_access a
+					WHERE a.collection_id = c.id AND (a.employee_id = $1 OR a.role = $2::role_type)
+				)
+			)
+		ORDER BY lower(c.name), c.id
+	`
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	collections := make([]models.Collection, 0)
+	for rows.Next() {
+		var c models.Collection
+		if err := rows.Scan(append([]any{&c.Permission}, collectionDest(&c)...)...); err != nil {
+			return nil, err
+		}
+		collections = append(collections, c)
+	}
+	return collections, rows.Err()
+}
+
+// UpdateCollection updates a collection's details
+func (r *Repository) UpdateCollection(ctx context.Context, c *models.Collection) error {
+	query := `
+		UPDATE collections SET name = $2, description = $3, cover_media_id = $4, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+	tag, err := r.db.Exec(ctx, query, c.ID, c.Name, c.Description, c.CoverMediaID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SoftDeleteCollection deletes a collection. Its media is left untouched.
+func (r *Repository) SoftDeleteCollection(ctx context.Context, id uuid.UUID) error {
+	tag, err := r.db.Exec(ctx, `UPDATE collections SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// AddCollectionItems appends media to the end of a collection in the given
+// order. Media already in the collection, not listed in the library or
+// outside access, when given, is skipped. Returns how many items were added.
+func (r *Repository) AddCollectionItems(ctx context.Context, collectionID uuid.UUID, mediaIDs []uuid.UUID, addedBy uuid.UUID, access *models.MediaAccess) (int64, error) {
+	args := []any{collectionID, mediaIDs, addedBy}
+	visible := collectionMediaVisible
+	if access != nil {
+		visible += " AND " + mediaAccessCondition(access, &args)
+	}
+
+	query := `
+		WITH added AS (
+			INSERT INTO collection_items (collection_id, media_id, position, added_by)
+			SELECT $1, t.media_id,
+				(SELECT COALESCE(MAX(position), 0) FROM collection_items WHERE collection_id = $1) + t.ord,
+				$3
+			FROM unnest($2::uuid[]) WITH ORDINALITY AS t(media_id, ord)
+			JOIN media m ON m.id = t.media_id
+			WHERE ` + visible + `
+			ON CONFLICT (collection_id, media_id) DO NOTHING
+			RETURNING 1
+		), touched AS (
+			UPDATE collections SET updated_at = NOW() WHERE id = $1
+		)
+		SELECT COUNT(*) FROM added
+	`
+	var added int64
+	err := r.db.QueryRow(ctx, query, args...).Scan(&added)
+	return added, err
+}
+
+// RemoveCollectionItems takes media out of a collection. Returns how many
+// items were removed.
+func (r *Repository) RemoveCollectionItems(ctx context.Context, collectionID uuid.UUID, mediaIDs []uuid.UUID) (int64, error) {
+	query := `
+		WITH removed AS (
+			DELETE FROM collection_items
+			WHERE collection_id = $1 AND media_id = ANY($2::uuid[])
+			RETURNING 1
+		), touched AS (
+			UPDATE collections SET updated_at = NOW() WHERE id = $1
+		)
+		SELECT COUNT(*) FROM removed
+	`
+	var removed int64
+	err := r.db.QueryRow(ctx, query, collectionID, mediaIDs).Scan(&removed)
+	return removed, err
+}
+
+// ReorderCollectionItems moves the given media to the front of a collection
+// in the given order. The other items follow in their current order.
+func (r *Repository) ReorderCollectionItems(ctx context.Context, collectionID uuid.UUID, mediaIDs []uuid.UUID) error {
+	query := `
+		WITH ordered AS (
+			SELECT ci.media_id, ROW_NUMBER() OVER (ORDER BY t.ord NULLS LAST, ci.position, ci.added_at, ci.media_id) AS position
+			FROM collection_items ci
+			LEFT JOIN unnest($2::uuid[]) WITH ORDINALITY AS t(media_id, ord) ON t.media_id = ci.media_id
+			WHERE ci.collection_id = $1
+		), touched AS (
+			UPDATE collections SET updated_at = NOW() WHERE id = $1
+		)
+		UPDATE collection_items ci SET position = o.position
+		FROM ordered o
+		WHERE ci.collection_id = $1 AND ci.media_id = o.media_id
+	`
+	_, err := r.db.Exec(ctx, query, collectionID, mediaIDs)
+	return err
+}
+
+// ListCollectionMedia lists the media of a collection in collection order,
+// leaving out media outside access when given
+func (r *Repository) ListCollectionMedia(ctx context.Context, collectionID uuid.UUID, page, pageSize int, access *models.MediaAccess) ([]models.MediaWithDetails, int64, error) {
+	args := []any{collectionID}
+	visible := collectionMediaVisible
+	if access != nil {
+		visible += " AND " + mediaAccessCondition(access, &args)
+	}
+
+	countQuery := `
+		SELECT COUNT(*) FROM collection_items ci
+		JOIN media m ON m.id = ci.media_id
+		WHERE ci.collection_id = $1 AND ` + visible
+	var total int64
+	if err := r.db.QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf(`SELECT %s%s
+		JOIN collection_items ci ON ci.media_id = m.id
+		WHERE ci.collection_id = $1 AND %s
+		ORDER BY ci.position, ci.added_at, m.id
+		LIMIT $%d OFFSET $%d
+	`, mediaDetailsColumns, mediaDetailsJoins, visible, len(args)+1, len(args)+2)
+	rows, err := r.db.Query(ctx, query, append(args, pageSize, (page-1)*pageSize)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	mediaList := make([]models.MediaWithDetails, 0)
+	for rows.Next() {
+		var media models.MediaWithDetails
+		if err := scanMediaWithDetails(rows, &media); err != nil {
+			return nil, 0, err
+		}
+		mediaList = append(mediaList, media)
+	}
+	return mediaList, total, rows.Err()
+}
+
+// ListCollectionMediaIDs returns the IDs of a collection's media in
+// collection order, leaving out media outside access when given
+func (r *Repository) ListCollectionMediaIDs(ctx context.Context, collectionID uuid.UUID, access *models.MediaAccess) ([]uuid.UUID, error) {
+	args := []any{collectionID}
+	visible := collectionMediaVisible
+	if access != nil {
+		visible += " AND " + mediaAccessCondition(access, &args)
+	}
+
+	query := `
+		SELECT ci.media_id FROM collection_items ci
+		JOIN media m ON m.id = ci.media_id
+		WHERE ci.collection_id = $1 AND ` + visible + `
+		ORDER BY ci.position, ci.added_at, m.id
+	`
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ListCollectionGrants lists who besides the creator may access a collection
+func (r *Repository) ListCollectionGrants(ctx context.Context, collectionID uuid.UUID) ([]models.CollectionGrant, error) {
+	query := `
+		SELECT a.id, a.collection_id, a.role::text, a.employee_id, e.full_name, a.permission::text, a.created_at
+		FROM collection_access a
+		LEFT JOIN employees e ON a.employee_id = e.id
+		WHERE a.collection_id = $1
+		ORDER BY a.role NULLS LAST, e.full_name, a.id
+	`
+	rows, err := r.db.Query(ctx, query, collectionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	grants := make([]models.CollectionGrant, 0)
+	for rows.Next() {
+		var g models.CollectionGrant
+		if err := rows.Scan(&g.ID, &g.CollectionID, &g.Role, &g.EmployeeID, &g.EmployeeName, &g.Permission, &g.CreatedAt); err != nil {
+			return nil, err
+		}
+		grants = append(grants, g)
+	}
+	return grants, rows.Err()
+}
+
+// ReplaceCollectionGrants sets who may access a collection, keeping grants
+// that are unchanged. Grants must not name the same role or employee twice.
+func (r *Repository) ReplaceCollectionGrants(ctx context.Context, collectionID uuid.UUID, grants []models.CollectionGrant) error {
+	roles := make([]*string, len(grants))
+	employees := make([]*uuid.UUID, len(grants))
+	permissions := make([]string, len(grants))
+	for i, g := range grants {
+		if g.Role != nil {
+			role := string(*g.Role)
+			roles[i] = &role
+		}
+		employees[i] = g.EmployeeID
+		permissions[i] = string(g.Permission)
+	}
+
+	// Every part sees the grants as they were before the statement, so rows
+	// are removed, updated and inserted without tripping the unique indexes
+	query := `
+		WITH grants AS (
+			SELECT * FROM unnest($2::role_type[], $3::uuid[], $4::collection_permission[]) AS g(role, employee_id, permission)
+		), removed AS (
+			DELETE FROM collection_access a
+			WHERE a.collection_id = $1 AND NOT EXISTS (
+				SELECT 1 FROM grants g
+				WHERE g.role IS NOT DISTINCT FROM a.role AND g.employee_id IS NOT DISTINCT FROM a.employee_id
+			)
+		), updated AS (
+			UPDATE collection_access a SET permission = g.permission
+			FROM grants g
+			WHERE a.collection_id = $1
+				AND g.role IS NOT DISTINCT FROM a.role AND g.employee_id IS NOT DISTINCT FROM a.employee_id
+		)
+		INSERT INTO collection_access (collection_id, role, employee_id, permission)
+		SELECT $1, g.role, g.employee_id, g.permission
+		FROM grants g
+		WHERE NOT EXISTS (
+			SELECT 1 FROM collection_access a
+			WHERE a.collection_id = $1
+				AND a.role IS NOT DISTINCT FROM g.role AND a.employee_id IS NOT DISTINCT FROM g.employee_id
+		)
+	`
+	_, err := r.db.Exec(ctx, query, collectionID, roles, employees, permissions)
+	return err
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/appnity/media-vault/internal/models"
+)
+
+// ==========================================
+// Media Facets
+// ==========================================
+
+// facetTagLimit caps the tag facet to the most used tags
+const facetTagLimit = 50
+
+// mediaFacetsQuery counts the filtered media per facet in one pass over the
+// matching rows. Each row is (facet, value, label, count, min, max).
+const mediaFacetsQuery = `
+	WITH filtered AS MATERIALIZED (
+		SELECT m.media_type, m.media_group_id, m.storage_account_id, m.uploaded_by,
+			m.tags, m.file_size_bytes, m.created_at
+		FROM media m
+		WHERE %s
+	), size_buckets (value, label, min_bytes, max_bytes) AS (
+		VALUES
+			('lt_1mb', '< 1 MB', 0::bigint, 1048575::bigint),
+			('1mb_10mb', '1 - 10 MB', 1048576, 10485759),
+			('10mb_100mb', '10 - 100 MB', 10485760, 104857599),
+			('100mb_1gb', '100 MB - 1 GB', 104857600, 1073741823),
+			('gte_1gb', '>= 1 GB', 1073741824, NULL)
+	)
+	SELECT 'media_type', f.media_type::text, NULL, COUNT(*), NULL::bigint, NULL::bigint
+	FROM filtered f
+	GROUP BY f.media_type
+
+	UNION ALL
+	SELECT 'group', f.media_group_id::text, g.name, COUNT(*), NULL, NULL
+	FROM filtered f
+	JOIN media_groups g ON g.id = f.media_group_id
+	GROUP BY f.media_group_id, g.name
+
+	UNION ALL
+	SELECT 'storage_account', f.storage_account_id::text, sa.name, COUNT(*), NULL, NULL
+	FROM filtered f
+	JOIN storage_accounts sa ON sa.id = f.storage_account_id
+	GROUP BY f.storage_account_id, sa.name
+
+	UNION ALL
+	SELECT 'uploader', f.uploaded_by::text, e.full_name, COUNT(*), NULL, NULL
+	FROM filtered f
+	JOIN employees e ON e.id = f.uploaded_by
+	GROUP BY f.uploaded_by, e.full_name
+
+	UNION ALL
+	(SELECT 'tag', t.tag, NULL, COUNT(*), NULL, NULL
+	FROM filtered f, unnest(f.tags) AS t(tag)
+	GROUP BY t.tag
+	ORDER BY COUNT(*) DESC, t.tag
+	LIMIT %d)
+
+	UNION ALL
+	SELECT 'size', b.value, b.label, COUNT(*), b.min_bytes, b.max_bytes
+	FROM filtered f
+	JOIN size_buckets b ON f.file_size_bytes >= b.min_bytes
+		AND (b.max_bytes IS NULL OR f.file_size_bytes <= b.max_bytes)
+	GROUP BY b.value, b.label, b.min_bytes, b.max_bytes
+
+	UNION ALL
+	SELECT 'month', to_char(date_trunc('month', f.created_at AT TIME ZONE 'UTC'), 'YYYY-MM'), NULL, COUNT(*), NULL, NULL
+	FROM filtered f
+	GROUP BY 2
+`
+
+// GetMediaFacets counts the media matching filters by type, group, storage
+// account, uploader, tag, size bucket and upload month. Pagination and
+// cursor fields of filters are ignored.
+func (r *Repository) GetMediaFacets(ctx context.Context, filters *models.MediaFilterRequest) (*models.MediaFacets, error) {
+	conditions, args, err := mediaFilterConditions(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(mediaFacetsQuery, strings.Join(conditions, " AND "), facetTagLimit)
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	facets := &models.MediaFacets{
+		MediaTypes:      []models.FacetCount{},
+		Groups:          []models.FacetCount{},
+		StorageAccounts: []models.FacetCount{},
+		Uploaders:       []models.FacetCount{},
+		Tags:            []models.FacetCount{},
+		SizeBuckets:     []models.FacetCount{},
+		UploadMonths:    []models.FacetCount{},
+	}
+	for rows.Next() {
+		var facet string
+		var label *string
+		var fc models.FacetCount
+		if err := rows.Scan(&facet, &fc.Value, &label, &fc.Count, &fc.MinBytes, &fc.MaxBytes); err != nil {
+			return nil, err
+		}
+		if label != nil {
+			fc.Label = *label
+		}
+
+		switch facet {
+		case "media_type":
+			facets.MediaTypes = append(facets.MediaTypes, fc)
+		case "group":
+			facets.Groups = append(facets.Groups, fc)
+		case "storage_account":
+			facets.StorageAccounts = append(facets.StorageAccounts, fc)
+		case "uploader":
+			facets.Uploaders = append(facets.Uploaders, fc)
+		case "tag":
+			facets.Tags = append(facets.Tags, fc)
+		case "size":
+			facets.SizeBuckets = append(facets.SizeBuckets, fc)
+		case "month":
+			facets.UploadMonths = append(facets.UploadMonths, fc)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, counts := range [][]models.FacetCount{
+		facets.MediaTypes, facets.Groups, facets.StorageAccounts, facets.Uploaders, facets.Tags,
+	} {
+		sortFacetCounts(counts)
+	}
+	sort.Slice(facets.SizeBuckets, func(i, j int) bool {
+		return *facets.SizeBuckets[i].MinBytes < *facets.SizeBuckets[j].MinBytes
+	})
+	sort.Slice(facets.UploadMonths, func(i, j int) bool {
+		return facets.UploadMonths[i].Value > facets.UploadMonths[j].Value
+	})
+
+	return facets, nil
+}
+
+// sortFacetCounts orders counts largest first, then by value
+func sortFacetCounts(counts []models.FacetCount) {
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Value < counts[j].Value
+	})
+}
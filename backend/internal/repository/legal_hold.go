@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ==========================================
+// Legal Hold Methods
+// ==========================================
+
+const legalHoldColumns = `
+	h.id, h.scope, h.media_group_id, h.folder_id, h.media_id,
+	h.reason, h.owner_id, COALESCE(o.full_name, '') as owner_name, h.placed_by, h.created_at,
+	h.released_at, h.released_by, h.release_reason
+	FROM legal_holds h
+	LEFT JOIN employees o ON h.owner_id = o.id
+`
+
+func scanLegalHold(row pgx.Row, h *models.LegalHold) error {
+	return row.Scan(
+		&h.ID, &h.Scope, &h.MediaGroupID, &h.FolderID, &h.MediaID,
+		&h.Reason, &h.OwnerID, &h.OwnerName, &h.PlacedBy, &h.CreatedAt,
+		&h.ReleasedAt, &h.ReleasedBy, &h.ReleaseReason,
+	)
+}
+
+// CreateLegalHold places a new legal hold
+func (r *Repository) CreateLegalHold(ctx context.Context, h *models.LegalHold) error {
+	query := `
+		INSERT INTO legal_holds (
+			id, scope, media_group_id, folder_id, media_id,
+			reason, owner_id, placed_by, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	h.ID = uuid.New()
+	h.CreatedAt = time.Now()
+
+	_, err := r.db.Exec(ctx, query,
+		h.ID, h.Scope, h.MediaGroupID, h.FolderID, h.MediaID,
+		h.Reason, h.OwnerID, h.PlacedBy, h.CreatedAt,
+	)
+	return err
+}
+
+// GetLegalHoldByID retrieves a legal hold by ID
+func (r *Repository) GetLegalHoldByID(ctx context.Context, id uuid.UUID) (*models.LegalHold, error) {
+	query := `SELECT ` + legalHoldColumns + ` WHERE h.id = $1`
+	var h models.LegalHold
+	err := scanLegalHold(r.db.QueryRow(ctx, query, id), &h)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return &h, err
+}
+
+// ListLegalHolds lists legal holds, newest first
+func (r *Repository) ListLegalHolds(ctx context.Context, activeOnly bool) ([]models.LegalHold, error) {
+	query := `SELECT ` + legalHoldColumns
+	if activeOnly {
+		query += ` WHERE h.released_at IS NULL`
+	}
+	query += ` ORDER BY h.created_at DESC`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	holds := make([]models.LegalHold, 0)
+	for rows.Next() {
+		var h models.LegalHold
+		if err := scanLegalHold(rows, &h); err != nil {
+			return nil, err
+		}
+		holds = append(holds, h)
+	}
+	return holds, rows.Err()
+}
+
+// ReleaseLegalHold releases an active legal hold
+func (r *Repository) ReleaseLegalHold(ctx context.Context, id, releasedBy uuid.UUID, reason string) error {
+	query := `
+		UPDATE legal_holds SET released_at = NOW(), released_by = $2, release_reason = $3
+		WHERE id = $1 AND released_at IS NULL
+	`
+	tag, err := r.db.Exec(ctx, query, id, releasedBy, reason)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RefreshMediaLegalHold recomputes the legal_hold flag for the media covered
+// by a scope and returns the media whose flag changed
+func (r *Repository) RefreshMediaLegalHold(ctx context.Context, scope models.RetentionScope, targetID uuid.UUID) ([]models.Media, error) {
+	scopeCondition, err := mediaScopeCondition(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`
+		WITH computed AS (
+			SELECT m.id, EXISTS(
+				SELECT 1 FROM legal_holds h
+				WHERE h.released_at IS NULL
+					AND (h.media_id = m.id OR h.folder_id = m.folder_id OR h.media_group_id = m.media_group_id)
+			) AS held
+			FROM media m
+			WHERE m.purged_at IS NULL AND %s
+		)
+		UPDATE media m SET legal_hold = c.held
+		FROM computed c
+		WHERE m.id = c.id AND m.legal_hold IS DISTINCT FROM c.held
+		RETURNING m.id, m.storage_account_id, m.storage_key, m.legal_hold
+	`, scopeCondition)
+
+	rows, err := r.db.Query(ctx, query, targetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changed := make([]models.Media, 0)
+	for rows.Next() {
+		var m models.Media
+		if err := rows.Scan(&m.ID, &m.StorageAccountID, &m.StorageKey, &m.LegalHold); err != nil {
+			return nil, err
+		}
+		changed = append(changed, m)
+	}
+	return changed, rows.Err()
+}
+
+// CountHeldMedia counts media on a storage account that is under legal hold
+func (r *Repository) CountHeldMedia(ctx context.Context, storageAccountID uuid.UUID) (int64, error) {
+	query := `
+		SELECT COUNT(*) FROM media
+		WHERE storage_account_id = $1 AND purged_at IS NULL AND legal_hold
+	`
+	var count int64
+	err := r.db.QueryRow(ctx, query, storageAccountID).Scan(&count)
+	return count, err
+}
@@ -72,17 +72,24 @@ func (r *Repository) GetMediaGroupByID(ctx context.Context, id uuid.UUID) (*mode
 	return &group, nil
 }
 
-// ListMediaGroups lists all media groups
-func (r *Repository) ListMediaGroups(ctx context.Context, role models.Role) ([]models.MediaGroup, error) {
-	query := `
+// ListMediaGroups lists media groups, only those admitting role when set
+func (r *Repository) ListMediaGroups(ctx context.Context, role *models.Role) ([]models.MediaGroup, error) {
+	whereClause := "WHERE deleted_at IS NULL"
+	args := []any{}
+	if role != nil {
+		whereClause += " AND $1::role_type = ANY(allowed_roles)"
+		args = append(args, *role)
+	}
+
+	query := fmt.Sprintf(`
 		SELECT id, name, description, color, icon,
 			default_storage_account_id, allowed_roles::text[],
 			created_by, created_at, updated_at
 		FROM media_groups 
-		WHERE deleted_at IS NULL AND $1::role_type = ANY(allowed_roles)
+		%s
 		ORDER BY name ASC
-	`
-	rows, err := r.db.Query(ctx, query, role)
+	`, whereClause)
+	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -152,12 +159,15 @@ func (r *Repository) CreateMedia(ctx context.Context, media *models.Media) error
 			media_type, mime_type, file_size_bytes,
 			width, height, duration_seconds,
 			public_url, thumbnail_url, provider_id, provider_metadata,
-			tags, uploaded_by, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)
+			tags, uploaded_by, scan_status, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
 	`
 	media.ID = uuid.New()
 	media.CreatedAt = time.Now()
 	media.UpdatedAt = time.Now()
+	if media.ScanStatus == "" {
+		media.ScanStatus = models.ScanStatusPending
+	}
 
 	_, err := r.db.Exec(ctx, query,
 		media.ID, media.StorageAccountID, media.FolderID, media.MediaGroupID,
@@ -165,65 +175,100 @@ func (r *Repository) CreateMedia(ctx context.Context, media *models.Media) error
 		media.MediaType, media.MimeType, media.FileSizeBytes,
 		media.Width, media.Height, media.DurationSeconds,
 		media.PublicURL, media.ThumbnailURL, media.ProviderID, media.ProviderMetadata,
-		media.Tags, media.UploadedBy, media.CreatedAt, media.UpdatedAt,
+		media.Tags, media.UploadedBy, media.ScanStatus, media.CreatedAt, media.UpdatedAt,
 	)
 	return err
 }
 
 // CheckMediaExists checks if media with storage key exists for account
 func (r *Repository) CheckMediaExists(ctx context.Context, storageAccountID uuid.UUID, storageKey string) (bool, error) {
-	query := `SELECT EXISTS(SELECT 1 FROM media WHERE storage_account_id = $1 AND storage_key = $2 AND deleted_at IS NULL)`
+	// Trashed media still owns its object until it is purged, and so do
+	// archived versions
+	query := `
+		SELECT EXISTS(SELECT 1 FROM media WHERE storage_account_id = $1 AND storage_key = $2 AND purged_at IS NULL)
+			OR EXISTS(
+				SELECT 1 FROM media_versions v JOIN media m ON v.media_id = m.id
+				WHERE m.storage_account_id = $1 AND v.storage_key = $2 AND m.purged_at IS NULL
+			)
+	`
 	var exists bool
 	err := r.db.QueryRow(ctx, query, storageAccountID, storageKey).Scan(&exists)
 	return exists, err
 }
 
-// GetMediaByID retrieves media by ID
-func (r *Repository) GetMediaByID(ctx context.Context, id uuid.UUID) (*models.MediaWithDetails, error) {
-	query := `
-		SELECT 
-			m.id, m.storage_account_id, m.folder_id, m.media_group_id,
-			m.filename, m.original_filename, m.storage_key,
-			m.media_type, m.mime_type, m.file_size_bytes,
-			m.width, m.height, m.duration_seconds,
-			m.public_url, m.thumbnail_url, m.provider_id, m.provider_metadata,
-			m.tags, m.uploaded_by, m.last_accessed_at, m.download_count,
-			m.created_at, m.updated_at,
-			sa.name as storage_account_name, sa.provider as storage_provider,
-			mg.name as group_name, mg.color as group_color,
-			e.full_name as uploaded_by_name, e.email as uploaded_by_email,
-			f.path as folder_path
-		FROM media m
-		LEFT JOIN storage_accounts sa ON m.storage_account_id = sa.id
-		LEFT JOIN media_groups mg ON m.media_group_id = mg.id
-		LEFT JOIN employees e ON m.uploaded_by = e.id
-		LEFT JOIN folders f ON m.folder_id = f.id
-		WHERE m.id = $1 AND m.deleted_at IS NULL
-	`
-	var media models.MediaWithDetails
-	err := r.db.QueryRow(ctx, query, id).Scan(
+// mediaDetailsColumns is the column list for queries returning MediaWithDetails
+const mediaDetailsColumns = `
+	m.id, m.storage_account_id, m.folder_id, m.media_group_id,
+	m.filename, m.original_filename, m.storage_key,
+	m.media_type, m.mime_type, m.file_size_bytes,
+	m.width, m.height, m.duration_seconds,
+	m.public_url, m.thumbnail_url, m.provider_id, m.provider_metadata,
+	m.tags, m.uploaded_by, m.last_accessed_at, m.download_count,
+	m.scan_status, m.scan_signature, m.scanned_at, m.quarantined_at,
+	m.expires_at, m.retain_until, m.legal_hold, m.current_version,
+	m.version_uploaded_by, m.version_uploaded_at,
+	m.upload_request_id, m.guest_name, m.review_status,
+	m.created_at, m.updated_at,
+	COALESCE(sa.name, '') as storage_account_name,
+	COALESCE(sa.provider::text, '') as storage_provider,
+	mg.name as group_name, mg.color as group_color,
+	COALESCE(e.full_name, '') as uploaded_by_name,
+	COALESCE(e.email, '') as uploaded_by_email,
+	f.path as folder_path
+`
+
+// mediaDetailsJoins joins the tables needed by mediaDetailsColumns
+const mediaDetailsJoins = `
+	FROM media m
+	LEFT JOIN storage_accounts sa ON m.storage_account_id = sa.id
+	LEFT JOIN media_groups mg ON m.media_group_id = mg.id
+	LEFT JOIN employees e ON m.uploaded_by = e.id
+	LEFT JOIN folders f ON m.folder_id = f.id
+`
+
+// mediaDetailsDest returns scan destinations matching mediaDetailsColumns
+func mediaDetailsDest(media *models.MediaWithDetails) []any {
+	return []any{
 		&media.ID, &media.StorageAccountID, &media.FolderID, &media.MediaGroupID,
 		&media.Filename, &media.OriginalFilename, &media.StorageKey,
 		&media.MediaType, &media.MimeType, &media.FileSizeBytes,
 		&media.Width, &media.Height, &media.DurationSeconds,
 		&media.PublicURL, &media.ThumbnailURL, &media.ProviderID, &media.ProviderMetadata,
 		&media.Tags, &media.UploadedBy, &media.LastAccessedAt, &media.DownloadCount,
+		&media.ScanStatus, &media.ScanSignature, &media.ScannedAt, &media.QuarantinedAt,
+		&media.ExpiresAt, &media.RetainUntil, &media.LegalHold, &media.CurrentVersion,
+		&media.VersionUploadedBy, &media.VersionUploadedAt,
+		&media.UploadRequestID, &media.GuestName, &media.ReviewStatus,
 		&media.CreatedAt, &media.UpdatedAt,
 		&media.StorageAccountName, &media.StorageProvider,
 		&media.GroupName, &media.GroupColor,
 		&media.UploadedByName, &media.UploadedByEmail,
 		&media.FolderPath,
-	)
+	}
+}
+
+// scanMediaWithDetails scans a row selected with mediaDetailsColumns
+func scanMediaWithDetails(row pgx.Row, media *models.MediaWithDetails) error {
+	return row.Scan(mediaDetailsDest(media)...)
+}
+
+// GetMediaByID retrieves media by ID
+func (r *Repository) GetMediaByID(ctx context.Context, id uuid.UUID) (*models.MediaWithDetails, error) {
+	query := `SELECT ` + mediaDetailsColumns + mediaDetailsJoins + `
+		WHERE m.id = $1 AND m.deleted_at IS NULL
+	`
+	var media models.MediaWithDetails
+	err := scanMediaWithDetails(r.db.QueryRow(ctx, query, id), &media)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, ErrNotFound
 	}
 	return &media, err
 }
 
-// ListMedia lists media with filters
-func (r *Repository) ListMedia(ctx context.Context, filters *models.MediaFilterRequest) ([]models.MediaWithDetails, int64, error) {
-	// Build dynamic query
-	conditions := []string{"m.deleted_at IS NULL"}
+// mediaFilterConditions builds the WHERE conditions of a media listing over
+// the media table aliased m, along with their arguments
+func mediaFilterConditions(filters *models.MediaFilterRequest) ([]string, []any, error) {
+	conditions := []string{"m.deleted_at IS NULL", "m.quarantined_at IS NULL", "(m.review_status IS NULL OR m.review_status = 'approved')"}
 	args := []any{}
 	argNum := 1
 
@@ -237,6 +282,16 @@ func (r *Repository) ListMedia(ctx context.Context, filters *models.MediaFilterR
 		args = append(args, filters.MediaGroupID)
 		argNum++
 	}
+	if filters.Limits != nil && len(filters.Limits.StorageAccountIDs) > 0 {
+		conditions = append(conditions, fmt.Sprintf("m.storage_account_id = ANY($%d)", argNum))
+		args = append(args, filters.Limits.StorageAccountIDs)
+		argNum++
+	}
+	if filters.Limits != nil && len(filters.Limits.MediaGroupIDs) > 0 {
+		conditions = append(conditions, fmt.Sprintf("m.media_group_id = ANY($%d)", argNum))
+		args = append(args, filters.Limits.MediaGroupIDs)
+		argNum++
+	}
 	if filters.FolderID != "" {
 		conditions = append(conditions, fmt.Sprintf("m.folder_id = $%d", argNum))
 		args = append(args, filters.FolderID)
@@ -270,18 +325,47 @@ func (r *Repository) ListMedia(ctx context.Context, filters *models.MediaFilterR
 		args = append(args, filters.Search)
 		argNum++
 	}
+	if filters.Fuzzy != "" {
+		conditions = append(conditions, fmt.Sprintf(
+			"($%[1]d <%% media_search_text(m.filename, m.original_filename, m.tags) OR EXISTS (SELECT 1 FROM folders fo WHERE fo.id = m.folder_id AND $%[1]d <%% lower(fo.path)))",
+			argNum,
+		))
+		args = append(args, filters.Fuzzy)
+		argNum++
+	}
 	if len(filters.Tags) > 0 {
 		conditions = append(conditions, fmt.Sprintf("m.tags && $%d", argNum))
 		args = append(args, filters.Tags)
 		argNum++
 	}
+	if filters.Access != nil {
+		conditions = append(conditions, mediaAccessCondition(filters.Access, &args))
+	}
+	if filters.Query != nil {
+		condition, err := compileSearch(filters.Query, &args)
+		if err != nil {
+			return nil, nil, err
+		}
+		conditions = append(conditions, condition)
+	}
+
+	return conditions, args, nil
+}
+
+// ListMedia lists media with filters
+func (r *Repository) ListMedia(ctx context.Context, filters *models.MediaFilterRequest) ([]models.MediaWithDetails, int64, error) {
+	// Build dynamic query
+	conditions, args, err := mediaFilterConditions(filters)
+	if err != nil {
+		return nil, 0, err
+	}
+	argNum := len(args) + 1
 
 	whereClause := strings.Join(conditions, " AND ")
 
 	// Count total
-	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM media m WHERE %s", whereClause)
-	var total int64
-	if err := r.db.QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
+	total, err := r.countRows(ctx, filters.Count, "FROM media m WHERE "+whereClause, args...)
+	if err != nil {
 		return nil, 0, err
 	}
 
@@ -297,34 +381,51 @@ func (r *Repository) ListMedia(ctx context.Context, filters *models.MediaFilterR
 	if strings.ToUpper(filters.SortOrder) == "ASC" {
 		sortOrder = "ASC"
 	}
+	sortExpr := "m." + sortBy
+
+	// Fuzzy searches report how well each item matched and can be ranked by it
+	scoreColumn := ""
+	if filters.Fuzzy != "" {
+		scoreExpr := fmt.Sprintf(
+			"GREATEST(word_similarity($%[1]d, media_search_text(m.filename, m.original_filename, m.tags)), COALESCE((SELECT word_similarity($%[1]d, lower(fo.path)) FROM folders fo WHERE fo.id = m.folder_id), 0))",
+			argNum,
+		)
+		args = append(args, filters.Fuzzy)
+		argNum++
+		scoreColumn = ", " + scoreExpr
+		if filters.SortBy == "relevance" {
+			sortBy, sortExpr = "relevance", scoreExpr
+		}
+	}
+
+	// In cursor mode rows continue after the cursor's sort key and ID rather
+	// than skipping an offset, and one extra row tells whether more follow
+	limit, offset := filters.PageSize, (filters.Page-1)*filters.PageSize
+	if filters.Cursor != nil {
+		limit, offset = filters.PageSize+1, 0
+		if filters.After != nil {
+			value, err := cursorValue(sortBy, filters.After.Value)
+			if err != nil {
+				return nil, 0, err
+			}
+			comparison := "<"
+			if sortOrder == "ASC" {
+				comparison = ">"
+			}
+			whereClause += fmt.Sprintf(" AND (%s, m.id) %s ($%d, $%d)", sortExpr, comparison, argNum, argNum+1)
+			args = append(args, value, filters.After.ID)
+			argNum += 2
+		}
+	}
 
 	// Main query with pagination
-	offset := (filters.Page - 1) * filters.PageSize
 	query := fmt.Sprintf(`
-		SELECT 
-			m.id, m.storage_account_id, m.folder_id, m.media_group_id,
-			m.filename, m.original_filename, m.storage_key,
-			m.media_type, m.mime_type, m.file_size_bytes,
-			m.width, m.height, m.duration_seconds,
-			m.public_url, m.thumbnail_url, m.provider_id, m.provider_metadata,
-			m.tags, m.uploaded_by, m.last_accessed_at, m.download_count,
-			m.created_at, m.updated_at,
-			COALESCE(sa.name, '') as storage_account_name, 
-			COALESCE(sa.provider::text, '') as storage_provider,
-			mg.name as group_name, mg.color as group_color,
-			COALESCE(e.full_name, '') as uploaded_by_name, 
-			COALESCE(e.email, '') as uploaded_by_email,
-			f.path as folder_path
-		FROM media m
-		LEFT JOIN storage_accounts sa ON m.storage_account_id = sa.id
-		LEFT JOIN media_groups mg ON m.media_group_id = mg.id
-		LEFT JOIN employees e ON m.uploaded_by = e.id
-		LEFT JOIN folders f ON m.folder_id = f.id
+		SELECT %s%s %s
 		WHERE %s
-		ORDER BY m.%s %s
+		ORDER BY %s %s, m.id %s
 		LIMIT $%d OFFSET $%d
-	`, whereClause, sortBy, sortOrder, argNum, argNum+1)
-	args = append(args, filters.PageSize, offset)
+	`, mediaDetailsColumns, scoreColumn, mediaDetailsJoins, whereClause, sortExpr, sortOrder, sortOrder, argNum, argNum+1)
+	args = append(args, limit, offset)
 
 	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
@@ -335,19 +436,11 @@ func (r *Repository) ListMedia(ctx context.Context, filters *models.MediaFilterR
 	mediaList := make([]models.MediaWithDetails, 0)
 	for rows.Next() {
 		var media models.MediaWithDetails
-		if err := rows.Scan(
-			&media.ID, &media.StorageAccountID, &media.FolderID, &media.MediaGroupID,
-			&media.Filename, &media.OriginalFilename, &media.StorageKey,
-			&media.MediaType, &media.MimeType, &media.FileSizeBytes,
-			&media.Width, &media.Height, &media.DurationSeconds,
-			&media.PublicURL, &media.ThumbnailURL, &media.ProviderID, &media.ProviderMetadata,
-			&media.Tags, &media.UploadedBy, &media.LastAccessedAt, &media.DownloadCount,
-			&media.CreatedAt, &media.UpdatedAt,
-			&media.StorageAccountName, &media.StorageProvider,
-			&media.GroupName, &media.GroupColor,
-			&media.UploadedByName, &media.UploadedByEmail,
-			&media.FolderPath,
-		); err != nil {
+		dest := mediaDetailsDest(&media)
+		if scoreColumn != "" {
+			dest = append(dest, &media.Score)
+		}
+		if err := rows.Scan(dest...); err != nil {
 			return nil, 0, err
 		}
 		mediaList = append(mediaList, media)
@@ -371,13 +464,174 @@ func (r *Repository) UpdateMedia(ctx context.Context, media *models.Media) error
 	return err
 }
 
-// SoftDeleteMedia soft deletes media
-func (r *Repository) SoftDeleteMedia(ctx context.Context, id uuid.UUID) error {
-	query := `UPDATE media SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+// TrashMedia moves media into the trash; the stored object is kept until purge
+func (r *Repository) TrashMedia(ctx context.Context, id, deletedBy uuid.UUID) error {
+	query := `UPDATE media SET deleted_at = NOW(), deleted_by = $2 WHERE id = $1 AND deleted_at IS NULL`
+	tag, err := r.db.Exec(ctx, query, id, deletedBy)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RestoreMedia takes media back out of the trash
+func (r *Repository) RestoreMedia(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE media SET deleted_at = NULL, deleted_by = NULL, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NOT NULL AND purged_at IS NULL
+	`
+	tag, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrAlreadyExists
+		}
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// MarkMediaPurged records that the stored object has been removed. Media
+// purged straight from the library, without going through the trash, is
+// marked deleted at the same time.
+func (r *Repository) MarkMediaPurged(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE media SET deleted_at = COALESCE(deleted_at, NOW()), purged_at = NOW()
+		WHERE id = $1 AND purged_at IS NULL
+	`
 	_, err := r.db.Exec(ctx, query, id)
 	return err
 }
 
+// trashedMediaColumns extends mediaDetailsColumns for trash listings
+const trashedMediaColumns = mediaDetailsColumns + `,
+	m.deleted_at, m.deleted_by, COALESCE(d.full_name, '') as deleted_by_name
+`
+
+// trashedMediaJoins extends mediaDetailsJoins with the employee who deleted the item
+const trashedMediaJoins = mediaDetailsJoins + `
+	LEFT JOIN employees d ON m.deleted_by = d.id
+`
+
+func scanTrashedMedia(row pgx.Row, media *models.TrashedMedia) error {
+	dest := append(mediaDetailsDest(&media.MediaWithDetails), &media.TrashedAt, &media.DeletedBy, &media.TrashedByName)
+	return row.Scan(dest...)
+}
+
+// GetTrashedMediaByID retrieves a media item that is in the trash
+func (r *Repository) GetTrashedMediaByID(ctx context.Context, id uuid.UUID) (*models.TrashedMedia, error) {
+	query := `SELECT ` + trashedMediaColumns + trashedMediaJoins + `
+		WHERE m.id = $1 AND m.deleted_at IS NOT NULL AND m.purged_at IS NULL
+	`
+	var media models.TrashedMedia
+	err := scanTrashedMedia(r.db.QueryRow(ctx, query, id), &media)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return &media, err
+}
+
+// ListTrashedMedia lists media in the trash, most recently deleted first.
+// When uploadedBy is set only that employee's files are returned.
+func (r *Repository) ListTrashedMedia(ctx context.Context, uploadedBy *uuid.UUID, page, pageSize int) ([]models.TrashedMedia, int64, error) {
+	whereClause := "m.deleted_at IS NOT NULL AND m.purged_at IS NULL"
+	args := []any{}
+	if uploadedBy != nil {
+		whereClause += " AND m.uploaded_by = $1"
+		args = append(args, *uploadedBy)
+	}
+
+	countQuery := "SELECT COUNT(*) FROM media m WHERE " + whereClause
+	var total int64
+	if err := r.db.QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s %s
+		WHERE %s
+		ORDER BY m.deleted_at DESC
+		LIMIT $%d OFFSET $%d
+	`, trashedMediaColumns, trashedMediaJoins, whereClause, len(args)+1, len(args)+2)
+	args = append(args, pageSize, (page-1)*pageSize)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	mediaList := make([]models.TrashedMedia, 0)
+	for rows.Next() {
+		var media models.TrashedMedia
+		if err := scanTrashedMedia(rows, &media); err != nil {
+			return nil, 0, err
+		}
+		mediaList = append(mediaList, media)
+	}
+	return mediaList, total, nil
+}
+
+// ListUnpurgedAccountMedia returns media on a storage account whose objects
+// were not purged yet, including trashed, quarantined and unreviewed items
+func (r *Repository) ListUnpurgedAccountMedia(ctx context.Context, storageAccountID uuid.UUID, limit int) ([]models.Media, error) {
+	query := `
+		SELECT id, storage_account_id, storage_key, original_filename, legal_hold
+		FROM media
+		WHERE storage_account_id = $1 AND purged_at IS NULL
+		ORDER BY created_at
+		LIMIT $2
+	`
+	rows, err := r.db.Query(ctx, query, storageAccountID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mediaList := make([]models.Media, 0)
+	for rows.Next() {
+		var m models.Media
+		if err := rows.Scan(&m.ID, &m.StorageAccountID, &m.StorageKey, &m.OriginalFilename, &m.LegalHold); err != nil {
+			return nil, err
+		}
+		mediaList = append(mediaList, m)
+	}
+	return mediaList, rows.Err()
+}
+
+// ListPurgeableMedia returns trashed media deleted before the given time
+func (r *Repository) ListPurgeableMedia(ctx context.Context, deletedBefore time.Time, limit int) ([]models.Media, error) {
+	query := `
+		SELECT id, storage_account_id, storage_key, original_filename, uploaded_by, deleted_by, deleted_at
+		FROM media
+		WHERE deleted_at IS NOT NULL AND purged_at IS NULL AND deleted_at < $1
+			AND (retain_until IS NULL OR retain_until <= NOW())
+			AND NOT legal_hold
+		ORDER BY deleted_at
+		LIMIT $2
+	`
+	rows, err := r.db.Query(ctx, query, deletedBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mediaList := make([]models.Media, 0)
+	for rows.Next() {
+		var m models.Media
+		if err := rows.Scan(&m.ID, &m.StorageAccountID, &m.StorageKey, &m.OriginalFilename, &m.UploadedBy, &m.DeletedBy, &m.DeletedAt); err != nil {
+			return nil, err
+		}
+		mediaList = append(mediaList, m)
+	}
+	return mediaList, rows.Err()
+}
+
 // IncrementDownloadCount increments the download counter
 func (r *Repository) IncrementDownloadCount(ctx context.Context, id uuid.UUID) error {
 	query := `UPDATE media SET download_count = download_count + 1, last_accessed_at = NOW() WHERE id = $1`
@@ -385,6 +639,161 @@ func (r *Repository) IncrementDownloadCount(ctx context.Context, id uuid.UUID) e
 	return err
 }
 
+// UpdateMediaScanResult records the antivirus verdict for version of a media
+// item. It returns ErrNotFound if version is no longer the current version.
+func (r *Repository) UpdateMediaScanResult(ctx context.Context, id uuid.UUID, version int, status models.ScanStatus, signature *string) error {
+	query := `
+		UPDATE media SET scan_status = $3, scan_signature = $4, scanned_at = NOW()
+		WHERE id = $1 AND current_version = $2 AND deleted_at IS NULL
+	`
+	tag, err := r.db.Exec(ctx, query, id, version, status, signature)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// MarkMediaScanClean records a clean antivirus verdict for version of a
+// media item and publishes the file at publicURL, when given, unless it is a
+// guest upload awaiting review. It returns ErrNotFound if version is no
+// longer the current version or the item was quarantined.
+func (r *Repository) MarkMediaScanClean(ctx context.Context, id uuid.UUID, version int, publicURL *string) error {
+	query := `
+		UPDATE media SET scan_status = 'clean', scan_signature = NULL, scanned_at = NOW(),
+			public_url = CASE WHEN review_status IS NULL OR review_status = 'approved'
+				THEN COALESCE($3, public_url) ELSE public_url END
+		WHERE id = $1 AND current_version = $2 AND deleted_at IS NULL AND quarantined_at IS NULL
+	`
+	tag, err := r.db.Exec(ctx, query, id, version, publicURL)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListMediaAwaitingScan lists uploaded media whose scan is still pending or
+// failed, and has not been attempted since before
+func (r *Repository) ListMediaAwaitingScan(ctx context.Context, before time.Time, limit int) ([]models.MediaWithDetails, error) {
+	query := `SELECT ` + mediaDetailsColumns + mediaDetailsJoins + `
+		WHERE m.scan_status IN ('pending', 'error') AND m.deleted_at IS NULL AND m.quarantined_at IS NULL
+			AND m.file_size_bytes > 0 AND COALESCE(m.scanned_at, m.updated_at) < $1
+		ORDER BY m.updated_at
+		LIMIT $2
+	`
+	return r.queryMediaWithDetails(ctx, query, before, limit)
+}
+
+// ListUnmovedQuarantinedMedia lists quarantined media whose object, or the
+// object of a previous version, could not be moved out of its public
+// location. Held media is never moved.
+func (r *Repository) ListUnmovedQuarantinedMedia(ctx context.Context, limit int) ([]models.MediaWithDetails, error) {
+	query := `SELECT ` + mediaDetailsColumns + mediaDetailsJoins + `
+		WHERE m.quarantined_at IS NOT NULL AND m.deleted_at IS NULL AND m.legal_hold = false
+			AND (m.storage_key NOT LIKE 'quarantine/%' OR EXISTS (
+				SELECT 1 FROM media_versions v
+				WHERE v.media_id = m.id AND v.storage_key NOT LIKE 'quarantine/%'
+			))
+		ORDER BY m.quarantined_at
+		LIMIT $1
+	`
+	return r.queryMediaWithDetails(ctx, query, limit)
+}
+
+// SetQuarantineStorageKey records where the object of a quarantined media
+// item was moved to
+func (r *Repository) SetQuarantineStorageKey(ctx context.Context, id uuid.UUID, storageKey string) error {
+	query := `UPDATE media SET storage_key = $2 WHERE id = $1 AND quarantined_at IS NOT NULL`
+	_, err := r.db.Exec(ctx, query, id, storageKey)
+	return err
+}
+
+// queryMediaWithDetails runs a query selecting mediaDetailsColumns
+func (r *Repository) queryMediaWithDetails(ctx context.Context, query string, args ...any) ([]models.MediaWithDetails, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mediaList := make([]models.MediaWithDetails, 0)
+	for rows.Next() {
+		var media models.MediaWithDetails
+		if err := scanMediaWithDetails(rows, &media); err != nil {
+			return nil, err
+		}
+		mediaList = append(mediaList, media)
+	}
+	return mediaList, rows.Err()
+}
+
+// QuarantineMedia moves a media item into quarantine under a new storage key
+func (r *Repository) QuarantineMedia(ctx context.Context, id uuid.UUID, storageKey string) error {
+	query := `
+		UPDATE media SET
+			quarantined_at = NOW(), storage_key = $2, public_url = NULL,
+			quarantine_released_by = NULL, quarantine_released_at = NULL
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+	_, err := r.db.Exec(ctx, query, id, storageKey)
+	return err
+}
+
+// ReleaseMediaFromQuarantine restores a quarantined media item after review.
+// The reviewer's judgement stands in for a clean scan; the signature is kept.
+func (r *Repository) ReleaseMediaFromQuarantine(ctx context.Context, id uuid.UUID, storageKey string, publicURL *string, releasedBy uuid.UUID) error {
+	query := `
+		UPDATE media SET
+			quarantined_at = NULL, storage_key = $2, public_url = $3, scan_status = 'clean',
+			quarantine_released_by = $4, quarantine_released_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL AND quarantined_at IS NOT NULL
+	`
+	tag, err := r.db.Exec(ctx, query, id, storageKey, publicURL, releasedBy)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListQuarantinedMedia lists media held in quarantine, newest first
+func (r *Repository) ListQuarantinedMedia(ctx context.Context, page, pageSize int) ([]models.MediaWithDetails, int64, error) {
+	countQuery := `SELECT COUNT(*) FROM media m WHERE m.deleted_at IS NULL AND m.quarantined_at IS NOT NULL`
+	var total int64
+	if err := r.db.QueryRow(ctx, countQuery).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT ` + mediaDetailsColumns + mediaDetailsJoins + `
+		WHERE m.deleted_at IS NULL AND m.quarantined_at IS NOT NULL
+		ORDER BY m.quarantined_at DESC
+		LIMIT $1 OFFSET $2
+	`
+	offset := (page - 1) * pageSize
+	rows, err := r.db.Query(ctx, query, pageSize, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	mediaList := make([]models.MediaWithDetails, 0)
+	for rows.Next() {
+		var media models.MediaWithDetails
+		if err := scanMediaWithDetails(rows, &media); err != nil {
+			return nil, 0, err
+		}
+		mediaList = append(mediaList, media)
+	}
+	return mediaList, total, nil
+}
+
 // ==========================================
 // Audit Log Methods
 // ==========================================
@@ -450,21 +859,36 @@ func (r *Repository) ListAuditLogs(ctx context.Context, filter *models.AuditLogF
 	}
 
 	// Count
-	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM audit_logs %s", whereClause)
-	var total int64
-	if err := r.db.QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
+	total, err := r.countRows(ctx, filter.Count, "FROM audit_logs "+whereClause, args...)
+	if err != nil {
 		return nil, 0, err
 	}
 
+	// In cursor mode rows continue after the cursor, with one extra row to
+	// tell whether more follow
+	limit, offset := filter.PageSize, (filter.Page-1)*filter.PageSize
+	if filter.Cursor != nil {
+		limit, offset = filter.PageSize+1, 0
+		if filter.After != nil {
+			after, err := cursorValue("created_at", filter.After.Value)
+			if err != nil {
+				return nil, 0, err
+			}
+			conditions = append(conditions, fmt.Sprintf("(created_at, id) < ($%d, $%d)", argNum, argNum+1))
+			args = append(args, after, filter.After.ID)
+			argNum += 2
+			whereClause = "WHERE " + strings.Join(conditions, " AND ")
+		}
+	}
+
 	// List
-	offset := (filter.Page - 1) * filter.PageSize
 	query := fmt.Sprintf(`
 		SELECT id, employee_id, employee_email, action, severity, resource_type, resource_id, details, ip_address, user_agent, created_at
 		FROM audit_logs %s
-		ORDER BY created_at DESC
+		ORDER BY created_at DESC, id DESC
 		LIMIT $%d OFFSET $%d
 	`, whereClause, argNum, argNum+1)
-	args = append(args, filter.PageSize, offset)
+	args = append(args, limit, offset)
 
 	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
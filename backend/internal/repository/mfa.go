@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ==========================================
+// Two-Factor Authentication Methods
+// ==========================================
+
+// GetTOTPSecret retrieves an employee's TOTP secret, pending or enabled.
+// ErrNotFound means they have none.
+func (r *Repository) GetTOTPSecret(ctx context.Context, employeeID uuid.UUID) (*models.TOTPSecret, error) {
+	query := `
+		SELECT totp_secret, totp_nonce, totp_enabled_at, totp_last_step, mfa_locked_until
+		FROM employees WHERE id = $1 AND deleted_at IS NULL AND totp_secret IS NOT NULL
+	`
+	var secret models.TOTPSecret
+	err := r.db.QueryRow(ctx, query, employeeID).Scan(
+		&secret.EncryptedSecret, &secret.Nonce, &secret.EnabledAt, &secret.LastStep, &secret.LockedUntil,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return &secret, err
+}
+
+// SetPendingTOTPSecret stores a TOTP secret awaiting confirmation, replacing
+// any earlier pending one. Returns ErrAlreadyExists if TOTP is enabled.
+func (r *Repository) SetPendingTOTPSecret(ctx context.Context, employeeID uuid.UUID, encrypted, nonce []byte) error {
+	query := `
+		UPDATE employees
+		SET totp_secret = $2, totp_nonce = $3, totp_last_step = 0
+		WHERE id = $1 AND deleted_at IS NULL AND totp_enabled_at IS NULL
+	`
+	result, err := r.db.Exec(ctx, query, employeeID, encrypted, nonce)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrAlreadyExists
+	}
+	return nil
+}
+
+// EnableTOTP confirms a pending TOTP secret with the time step of the code
+// that proved it, and replaces the recovery codes in the same statement.
+// Returns ErrNotFound if no secret is pending.
+func (r *Repository) EnableTOTP(ctx context.Context, employeeID uuid.UUID, step int64, codeHashes []string) error {
+	query := `
+		WITH enabled AS (
+			UPDATE employees
+			SET totp_enabled_at = NOW(), totp_last_step = $2, mfa_failed_attempts = 0, mfa_locked_until = NULL
+			WHERE id = $1 AND deleted_at IS NULL AND totp_secret IS NOT NULL AND totp_enabled_at IS NULL
+			RETURNING id
+		), cleared AS (
+			DELETE FROM mfa_recovery_codes WHERE employee_id IN (SELECT id FROM enabled)
+		), inserted AS (
+			INSERT INTO mfa_recovery_codes (employee_id, code_hash)
+			SELECT e.id, c.hash FROM enabled e, unnest($3::text[]) AS c(hash)
+		)
+		SELECT COUNT(*) FROM enabled
+	`
+	var enabled int
+	if err := r.db.QueryRow(ctx, query, employeeID, step, codeHashes).Scan(&enabled); err != nil {
+		return err
+	}
+	if enabled == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DisableTOTP removes an employee's TOTP secret and recovery codes
+func (r *Repository) DisableTOTP(ctx context.Context, employeeID uuid.UUID) error {
+	query := `
+		WITH cleared AS (
+			DELETE FROM mfa_recovery_codes WHERE employee_id = $1
+		)
+		UPDATE employees
+		SET totp_secret = NULL, totp_nonce = NULL, totp_enabled_at = NULL, totp_last_step = 0,
+			mfa_failed_attempts = 0, mfa_locked_until = NULL
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+	result, err := r.db.Exec(ctx, query, employeeID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// UseTOTPStep records the time step of an accepted code. It reports false
+// when a code of that step or a later one was already used, which makes a
+// replayed code fail even under concurrent requests.
+func (r *Repository) UseTOTPStep(ctx context.Context, employeeID uuid.UUID, step int64) (bool, error) {
+	query := `UPDATE employees SET totp_last_step = $2 WHERE id = $1 AND totp_last_step < $2`
+	result, err := r.db.Exec(ctx, query, employeeID, step)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+// RecordMFAFailure counts a wrong code. The maxAttempts-th consecutive
+// failure locks code verification until lockUntil and starts a new count.
+func (r *Repository) RecordMFAFailure(ctx context.Context, employeeID uuid.UUID, maxAttempts int, lockUntil time.Time) error {
+	query := `
+		UPDATE employees
+		SET mfa_failed_attempts = CASE WHEN mfa_failed_attempts + 1 >= $2 THEN 0 ELSE mfa_failed_attempts + 1 END,
+			mfa_locked_until = CASE WHEN mfa_failed_attempts + 1 >= $2 THEN $3 ELSE mfa_locked_until END
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(ctx, query, employeeID, maxAttempts, lockUntil)
+	return err
+}
+
+// ResetMFAFailures clears the wrong code count after a correct code
+func (r *Repository) ResetMFAFailures(ctx context.Context, employeeID uuid.UUID) error {
+	query := `UPDATE employees SET mfa_failed_attempts = 0, mfa_locked_until = NULL WHERE id = $1 AND mfa_failed_attempts > 0`
+	_, err := r.db.Exec(ctx, query, employeeID)
+	return err
+}
+
+// ReplaceRecoveryCodes replaces all of an employee's recovery codes
+func (r *Repository) ReplaceRecoveryCodes(ctx context.Context, employeeID uuid.UUID, codeHashes []string) error {
+	query := `
+		WITH cleared AS (
+			DELETE FROM mfa_recovery_codes WHERE employee_id = $1
+		)
+		INSERT INTO mfa_recovery_codes (employee_id, code_hash)
+		SELECT $1, c.hash FROM unnest($2::text[]) AS c(hash)
+	`
+	_, err := r.db.Exec(ctx, query, employeeID, codeHashes)
+	return err
+}
+
+// UseRecoveryCode marks an unused recovery code as used, reporting whether
+// there was one
+func (r *Repository) UseRecoveryCode(ctx context.Context, employeeID uuid.UUID, codeHash string) (bool, error) {
+	query := `
+		UPDATE mfa_recovery_codes SET used_at = NOW()
+		WHERE employee_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`
+	result, err := r.db.Exec(ctx, query, employeeID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+// CountRecoveryCodes counts an employee's unused recovery codes
+func (r *Repository) CountRecoveryCodes(ctx context.Context, employeeID uuid.UUID) (int, error) {
+	query := `SELECT COUNT(*) FROM mfa_recovery_codes WHERE employee_id = $1 AND used_at IS NULL`
+	var count int
+	err := r.db.QueryRow(ctx, query, employeeID).Scan(&count)
+	return count, err
+}
+
+// ListMFARequiredRoles lists the roles that must use two-factor authentication
+func (r *Repository) ListMFARequiredRoles(ctx context.Context) ([]models.Role, error) {
+	rows, err := r.db.Query(ctx, `SELECT role::text FROM mfa_required_roles ORDER BY role`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := make([]models.Role, 0)
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, err
+		}
+		roles = append(roles, models.Role(role))
+	}
+	return roles, rows.Err()
+}
+
+// SetMFARequiredRoles replaces the roles that must use two-factor
+// authentication
+func (r *Repository) SetMFARequiredRoles(ctx context.Context, roles []models.Role, updatedBy uuid.UUID) error {
+	roleStrs := make([]string, len(roles))
+	for i, role := range roles {
+		roleStrs[i] = string(role)
+	}
+
+	query := `
+		WITH cleared AS (
+			DELETE FROM mfa_required_roles WHERE role::text <> ALL($1::text[])
+		)
+		INSERT INTO mfa_required_roles (role, updated_by)
+		SELECT DISTINCT r::role_type, $2 FROM unnest($1::text[]) AS r
+		ON CONFLICT (role) DO UPDATE SET updated_by = EXCLUDED.updated_by, updated_at = NOW()
+	`
+	_, err := r.db.Exec(ctx, query, roleStrs, updatedBy)
+	return err
+}
@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ==========================================
+// Single Sign-On Methods
+// ==========================================
+
+// CreateOIDCLoginState stores a pending single sign-on authorization request
+func (r *Repository) CreateOIDCLoginState(ctx context.Context, state *models.OIDCLoginState) error {
+	query := `
+		INSERT INTO oidc_login_states (state_hash, code_verifier, nonce, expires_at)
+		VALUES ($1, $2, $3, $4)
+	`
+	_, err := r.db.Exec(ctx, query, state.StateHash, state.CodeVerifier, state.Nonce, state.ExpiresAt)
+	return err
+}
+
+// ConsumeOIDCLoginState deletes a pending authorization request and returns
+// it, so each state is used at most once. Returns ErrNotFound if there is no
+// such request or it expired.
+func (r *Repository) ConsumeOIDCLoginState(ctx context.Context, stateHash string) (*models.OIDCLoginState, error) {
+	query := `
+		DELETE FROM oidc_login_states WHERE state_hash = $1
+		RETURNING state_hash, code_verifier, nonce, expires_at
+	`
+	var state models.OIDCLoginState
+	err := r.db.QueryRow(ctx, query, stateHash).Scan(
+		&state.StateHash, &state.CodeVerifier, &state.Nonce, &state.ExpiresAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(state.ExpiresAt) {
+		return nil, ErrNotFound
+	}
+	return &state, nil
+}
+
+// DeleteExpiredOIDCLoginStates deletes authorization requests the provider
+// never redirected back from
+func (r *Repository) DeleteExpiredOIDCLoginStates(ctx context.Context) (int64, error) {
+	result, err := r.db.Exec(ctx, `DELETE FROM oidc_login_states WHERE expires_at < NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+// GetEmployeeByIdentity retrieves the employee linked to an OpenID Connect
+// identity
+func (r *Repository) GetEmployeeByIdentity(ctx context.Context, issuer, subject string) (*models.Employee, error) {
+	query := `
+		SELECT e.id, e.email, e.password_hash, e.full_name, e.role, e.avatar_url, e.is_active, e.last_login_at,
+			e.created_at, e.updated_at, e.is_service_account, e.custom_role_id, e.totp_enabled_at
+		FROM employee_identities i
+		JOIN employees e ON e.id = i.employee_id
+		WHERE i.issuer = $1 AND i.subject = $2 AND e.deleted_at IS NULL
+	`
+	var emp models.Employee
+	err := r.db.QueryRow(ctx, query, issuer, subject).Scan(
+		&emp.ID, &emp.Email, &emp.PasswordHash, &emp.FullName, &emp.Role,
+		&emp.AvatarURL, &emp.IsActive, &emp.LastLoginAt, &emp.CreatedAt, &emp.UpdatedAt,
+		&emp.IsServiceAccount, &emp.CustomRoleID, &emp.MFAEnabledAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return &emp, err
+}
+
+// LinkEmployeeIdentity links an existing employee to an OpenID Connect
+// identity, taking it over from a deleted employee. Returns ErrAlreadyExists
+// if either is already linked.
+func (r *Repository) LinkEmployeeIdentity(ctx context.Context, employeeID uuid.UUID, issuer, subject string) error {
+	query := `
+		INSERT INTO employee_identities (employee_id, issuer, subject) VALUES ($1, $2, $3)
+		ON CONFLICT (issuer, subject) DO UPDATE SET employee_id = EXCLUDED.employee_id, created_at = NOW()
+		WHERE employee_identities.employee_id IN (SELECT id FROM employees WHERE deleted_at IS NOT NULL)
+	`
+	result, err := r.db.Exec(ctx, query, employeeID, issuer, subject)
+	if isUniqueViolation(err) {
+		return ErrAlreadyExists
+	}
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrAlreadyExists
+	}
+	return nil
+}
+
+// CreateEmployeeWithIdentity creates an employee linked to an OpenID Connect
+// identity in one statement. Returns ErrAlreadyExists if the email or the
+// identity is taken.
+func (r *Repository) CreateEmployeeWithIdentity(ctx context.Context, emp *models.Employee, issuer, subject string) error {
+	query := `
+		WITH created AS (
+			INSERT INTO employees (id, email, password_hash, full_name, role, avatar_url, is_active, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			RETURNING id
+		)
+		INSERT INTO employee_identities (employee_id, issuer, subject)
+		SELECT id, $10, $11 FROM created
+	`
+	emp.ID = uuid.New()
+	emp.CreatedAt = time.Now()
+	emp.UpdatedAt = time.Now()
+
+	_, err := r.db.Exec(ctx, query,
+		emp.ID, emp.Email, emp.PasswordHash, emp.FullName, emp.Role,
+		emp.AvatarURL, emp.IsActive, emp.CreatedAt, emp.UpdatedAt,
+		issuer, subject,
+	)
+	if isUniqueViolation(err) {
+		return ErrAlreadyExists
+	}
+	return err
+}
+
+// DeactivateSSOEmployee disables an employee because single sign-on granted
+// them no role, recording that it was single sign-on that disabled them.
+// Reports false when they were already inactive.
+func (r *Repository) DeactivateSSOEmployee(ctx context.Context, id uuid.UUID) (bool, error) {
+	query := `
+		UPDATE employees SET is_active = false, sso_deactivated_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL AND is_active
+	`
+	result, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+// ReactivateSSOEmployee enables an employee again if single sign-on was what
+// disabled them. Reports whether they were.
+func (r *Repository) ReactivateSSOEmployee(ctx context.Context, id uuid.UUID) (bool, error) {
+	query := `
+		UPDATE employees SET is_active = true, sso_deactivated_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL AND NOT is_active AND sso_deactivated_at IS NOT NULL
+	`
+	result, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() == 1, nil
+}
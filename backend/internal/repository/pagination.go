@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/appnity/media-vault/internal/models"
+)
+
+// ==========================================
+// Pagination Helpers
+// ==========================================
+
+// countRows counts the rows of fromWhere ("FROM ... WHERE ...") the way mode
+// asks: exactly, from the query planner's estimate, or not at all
+func (r *Repository) countRows(ctx context.Context, mode models.CountMode, fromWhere string, args ...any) (int64, error) {
+	switch mode {
+	case models.CountNone:
+		return 0, nil
+	case models.CountEstimate:
+		var raw []byte
+		if err := r.db.QueryRow(ctx, "EXPLAIN (FORMAT JSON) SELECT 1 "+fromWhere, args...).Scan(&raw); err != nil {
+			return 0, err
+		}
+		var plan []struct {
+			Plan struct {
+				Rows float64 `json:"Plan Rows"`
+			} `json:"Plan"`
+		}
+		if err := json.Unmarshal(raw, &plan); err != nil || len(plan) == 0 {
+			return 0, fmt.Errorf("failed to read row estimate: %v", err)
+		}
+		return int64(plan[0].Plan.Rows), nil
+	default:
+		var total int64
+		err := r.db.QueryRow(ctx, "SELECT COUNT(*) "+fromWhere, args...).Scan(&total)
+		return total, err
+	}
+}
+
+// cursorValue converts the sort key stored in a cursor back to the type of
+// its column
+func cursorValue(sortBy, value string) (any, error) {
+	switch sortBy {
+	case "created_at", "updated_at":
+		return time.Parse(time.RFC3339Nano, value)
+	case "file_size_bytes":
+		return strconv.ParseInt(value, 10, 64)
+	case "relevance":
+		return strconv.ParseFloat(value, 32)
+	default:
+		return value, nil
+	}
+}
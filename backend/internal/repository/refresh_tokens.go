@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ==========================================
+// Refresh Token Methods
+// ==========================================
+
+const refreshTokenColumns = `
+	id, employee_id, family_id, token_hash, expires_at, created_at,
+	revoked_at, revoked_reason, replaced_by,
+	session_started_at, host(ip_address), user_agent
+	FROM refresh_tokens
+`
+
+func scanRefreshToken(row pgx.Row, t *models.RefreshToken) error {
+	return row.Scan(
+		&t.ID, &t.EmployeeID, &t.FamilyID, &t.TokenHash, &t.ExpiresAt, &t.CreatedAt,
+		&t.RevokedAt, &t.RevokedReason, &t.ReplacedBy,
+		&t.SessionStartedAt, &t.IPAddress, &t.UserAgent,
+	)
+}
+
+// CreateRefreshToken stores the first refresh token of a login. The caller
+// sets ID and FamilyID, since both are embedded in the signed tokens.
+func (r *Repository) CreateRefreshToken(ctx context.Context, t *models.RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (
+			id, employee_id, family_id, token_hash, expires_at, created_at,
+			session_started_at, ip_address, user_agent
+		) VALUES ($1, $2, $3, $4, $5, $6, $6, $7, $8)
+	`
+	t.CreatedAt = time.Now()
+	t.SessionStartedAt = t.CreatedAt
+	_, err := r.db.Exec(ctx, query,
+		t.ID, t.EmployeeID, t.FamilyID, t.TokenHash, t.ExpiresAt, t.CreatedAt,
+		t.IPAddress, t.UserAgent,
+	)
+	return err
+}
+
+// GetRefreshTokenByHash retrieves a refresh token by the hash of the token,
+// revoked or not
+func (r *Repository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	query := `SELECT ` + refreshTokenColumns + ` WHERE token_hash = $1`
+	var t models.RefreshToken
+	err := scanRefreshToken(r.db.QueryRow(ctx, query, tokenHash), &t)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return &t, err
+}
+
+// RotateRefreshToken revokes a refresh token and stores its replacement in
+// the same family, in one statement. The session start carries over; the
+// client is the one refreshing. It returns ErrNotFound when the old token
+// was already revoked, e.g. by a concurrent refresh.
+func (r *Repository) RotateRefreshToken(ctx context.Context, oldID uuid.UUID, next *models.RefreshToken) error {
+	query := `
+		WITH rotated AS (
+			UPDATE refresh_tokens
+			SET revoked_at = NOW(), revoked_reason = $5, replaced_by = $2
+			WHERE id = $1 AND revoked_at IS NULL
+			RETURNING employee_id, family_id, session_started_at
+		)
+		INSERT INTO refresh_tokens (
+			id, employee_id, family_id, token_hash, expires_at, created_at,
+			session_started_at, ip_address, user_agent
+		)
+		SELECT $2, employee_id, family_id, $3, $4, NOW(), session_started_at, $6, $7 FROM rotated
+		RETURNING family_id, created_at, session_started_at
+	`
+	err := r.db.QueryRow(ctx, query,
+		oldID, next.ID, next.TokenHash, next.ExpiresAt, models.TokenRevokedRotated, next.IPAddress, next.UserAgent,
+	).Scan(&next.FamilyID, &next.CreatedAt, &next.SessionStartedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// RevokeRefreshTokenFamily revokes every live token of a login
+func (r *Repository) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID, reason string) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE refresh_tokens SET revoked_at = NOW(), revoked_reason = $2
+		WHERE family_id = $1 AND revoked_at IS NULL
+	`, familyID, reason)
+	return err
+}
+
+// ListSessions lists an employee's live sessions, most recently used first
+func (r *Repository) ListSessions(ctx context.Context, employeeID uuid.UUID) ([]models.Session, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT family_id, employee_id, host(ip_address), user_agent, session_started_at, created_at, expires_at
+		FROM refresh_tokens
+		WHERE employee_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY created_at DESC
+	`, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		var s models.Session
+		if err := rows.Scan(&s.ID, &s.EmployeeID, &s.IPAddress, &s.UserAgent, &s.CreatedAt, &s.LastUsedAt, &s.ExpiresAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// RevokeSession revokes a live session of an employee. It returns
+// ErrNotFound when the employee has no such live session.
+func (r *Repository) RevokeSession(ctx context.Context, employeeID, sessionID uuid.UUID, reason string) error {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE refresh_tokens SET revoked_at = NOW(), revoked_reason = $3
+		WHERE employee_id = $1 AND family_id = $2 AND revoked_at IS NULL
+	`, employeeID, sessionID, reason)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RevokeEmployeeRefreshTokens revokes every live token of an employee,
+// signing them out on all devices
+func (r *Repository) RevokeEmployeeRefreshTokens(ctx context.Context, employeeID uuid.UUID, reason string) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE refresh_tokens SET revoked_at = NOW(), revoked_reason = $2
+		WHERE employee_id = $1 AND revoked_at IS NULL
+	`, employeeID, reason)
+	return err
+}
+
+// DeleteExpiredRefreshTokens removes tokens that expired before the cutoff.
+// Rotated tokens are kept until then so that their reuse is still detected.
+func (r *Repository) DeleteExpiredRefreshTokens(ctx context.Context, expiredBefore time.Time) (int64, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM refresh_tokens WHERE expires_at < $1`, expiredBefore)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/appnity/media-vault/internal/models"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -28,6 +29,12 @@ func NewRepository(db *pgxpool.Pool) *Repository {
 	return &Repository{db: db}
 }
 
+// isUniqueViolation reports whether err is a Postgres unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
 // ==========================================
 // Employee Methods
 // ==========================================
@@ -35,8 +42,10 @@ func NewRepository(db *pgxpool.Pool) *Repository {
 // CreateEmployee creates a new employee
 func (r *Repository) CreateEmployee(ctx context.Context, emp *models.Employee) error {
 	query := `
-		INSERT INTO employees (id, email, password_hash, full_name, role, avatar_url, is_active, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO employees (
+			id, email, password_hash, full_name, role, avatar_url, is_active, created_at, updated_at,
+			is_service_account, custom_role_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	`
 	emp.ID = uuid.New()
 	emp.CreatedAt = time.Now()
@@ -45,6 +54,7 @@ func (r *Repository) CreateEmployee(ctx context.Context, emp *models.Employee) e
 	_, err := r.db.Exec(ctx, query,
 		emp.ID, emp.Email, emp.PasswordHash, emp.FullName, emp.Role,
 		emp.AvatarURL, emp.IsActive, emp.CreatedAt, emp.UpdatedAt,
+		emp.IsServiceAccount, emp.CustomRoleID,
 	)
 	return err
 }
@@ -52,13 +62,15 @@ func (r *Repository) CreateEmployee(ctx context.Context, emp *models.Employee) e
 // GetEmployeeByID retrieves an employee by ID
 func (r *Repository) GetEmployeeByID(ctx context.Context, id uuid.UUID) (*models.Employee, error) {
 	query := `
-		SELECT id, email, password_hash, full_name, role, avatar_url, is_active, last_login_at, created_at, updated_at
+		SELECT id, email, password_hash, full_name, role, avatar_url, is_active, last_login_at, created_at, updated_at,
+			is_service_account, custom_role_id, totp_enabled_at
 		FROM employees WHERE id = $1 AND deleted_at IS NULL
 	`
 	var emp models.Employee
 	err := r.db.QueryRow(ctx, query, id).Scan(
 		&emp.ID, &emp.Email, &emp.PasswordHash, &emp.FullName, &emp.Role,
 		&emp.AvatarURL, &emp.IsActive, &emp.LastLoginAt, &emp.CreatedAt, &emp.UpdatedAt,
+		&emp.IsServiceAccount, &emp.CustomRoleID, &emp.MFAEnabledAt,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, ErrNotFound
@@ -69,13 +81,15 @@ func (r *Repository) GetEmployeeByID(ctx context.Context, id uuid.UUID) (*models
 // GetEmployeeByEmail retrieves an employee by email
 func (r *Repository) GetEmployeeByEmail(ctx context.Context, email string) (*models.Employee, error) {
 	query := `
-		SELECT id, email, password_hash, full_name, role, avatar_url, is_active, last_login_at, created_at, updated_at
+		SELECT id, email, password_hash, full_name, role, avatar_url, is_active, last_login_at, created_at, updated_at,
+			is_service_account, custom_role_id, totp_enabled_at
 		FROM employees WHERE email = $1 AND deleted_at IS NULL
 	`
 	var emp models.Employee
 	err := r.db.QueryRow(ctx, query, email).Scan(
 		&emp.ID, &emp.Email, &emp.PasswordHash, &emp.FullName, &emp.Role,
 		&emp.AvatarURL, &emp.IsActive, &emp.LastLoginAt, &emp.CreatedAt, &emp.UpdatedAt,
+		&emp.IsServiceAccount, &emp.CustomRoleID, &emp.MFAEnabledAt,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, ErrNotFound
@@ -92,7 +106,8 @@ func (r *Repository) ListEmployees(ctx context.Context, page, pageSize int) ([]m
 	}
 
 	query := `
-		SELECT id, email, password_hash, full_name, role, avatar_url, is_active, last_login_at, created_at, updated_at
+		SELECT id, email, password_hash, full_name, role, avatar_url, is_active, last_login_at, created_at, updated_at,
+			is_service_account, custom_role_id, totp_enabled_at
 		FROM employees WHERE deleted_at IS NULL
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2
@@ -110,6 +125,7 @@ func (r *Repository) ListEmployees(ctx context.Context, page, pageSize int) ([]m
 		if err := rows.Scan(
 			&emp.ID, &emp.Email, &emp.PasswordHash, &emp.FullName, &emp.Role,
 			&emp.AvatarURL, &emp.IsActive, &emp.LastLoginAt, &emp.CreatedAt, &emp.UpdatedAt,
+			&emp.IsServiceAccount, &emp.CustomRoleID, &emp.MFAEnabledAt,
 		); err != nil {
 			return nil, 0, err
 		}
@@ -118,14 +134,16 @@ func (r *Repository) ListEmployees(ctx context.Context, page, pageSize int) ([]m
 	return employees, total, nil
 }
 
-// UpdateEmployee updates an employee
+// UpdateEmployee updates an employee. Changing their active state clears
+// that single sign-on disabled them.
 func (r *Repository) UpdateEmployee(ctx context.Context, emp *models.Employee) error {
 	query := `
 		UPDATE employees 
-		SET full_name = $2, role = $3, avatar_url = $4, is_active = $5, updated_at = NOW()
+		SET full_name = $2, role = $3, avatar_url = $4, is_active = $5, custom_role_id = $6, updated_at = NOW(),
+			sso_deactivated_at = CASE WHEN is_active = $5 THEN sso_deactivated_at END
 		WHERE id = $1 AND deleted_at IS NULL
 	`
-	_, err := r.db.Exec(ctx, query, emp.ID, emp.FullName, emp.Role, emp.AvatarURL, emp.IsActive)
+	_, err := r.db.Exec(ctx, query, emp.ID, emp.FullName, emp.Role, emp.AvatarURL, emp.IsActive, emp.CustomRoleID)
 	return err
 }
 
@@ -153,9 +171,9 @@ func (r *Repository) CreateStorageAccount(ctx context.Context, acc *models.Stora
 		INSERT INTO storage_accounts (
 			id, name, provider, encrypted_credentials, credentials_nonce,
 			bucket_name, region, endpoint_url, public_url_base,
-			is_default, is_active, is_public, max_file_size_mb, allowed_types,
+			is_default, is_active, is_public, max_file_size_mb, allowed_types, object_lock_enabled,
 			created_by, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14::media_type[], $15, $16, $17)
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14::media_type[], $15, $16, $17, $18)
 	`
 	acc.ID = uuid.New()
 	acc.CreatedAt = time.Now()
@@ -170,7 +188,7 @@ func (r *Repository) CreateStorageAccount(ctx context.Context, acc *models.Stora
 	_, err := r.db.Exec(ctx, query,
 		acc.ID, acc.Name, acc.Provider, acc.EncryptedCredentials, acc.CredentialsNonce,
 		acc.BucketName, acc.Region, acc.EndpointURL, acc.PublicURLBase,
-		acc.IsDefault, acc.IsActive, acc.IsPublic, acc.MaxFileSizeMB, allowedTypesStr,
+		acc.IsDefault, acc.IsActive, acc.IsPublic, acc.MaxFileSizeMB, allowedTypesStr, acc.ObjectLockEnabled,
 		acc.CreatedBy, acc.CreatedAt, acc.UpdatedAt,
 	)
 	return err
@@ -181,7 +199,7 @@ func (r *Repository) GetStorageAccountByID(ctx context.Context, id uuid.UUID) (*
 	query := `
 		SELECT id, name, provider, encrypted_credentials, credentials_nonce,
 			bucket_name, region, endpoint_url, public_url_base,
-			is_default, is_active, is_public, max_file_size_mb, allowed_types::text[],
+			is_default, is_active, is_public, max_file_size_mb, allowed_types::text[], object_lock_enabled,
 			created_by, created_at, updated_at
 		FROM storage_accounts WHERE id = $1 AND deleted_at IS NULL
 	`
@@ -190,7 +208,7 @@ func (r *Repository) GetStorageAccountByID(ctx context.Context, id uuid.UUID) (*
 	err := r.db.QueryRow(ctx, query, id).Scan(
 		&acc.ID, &acc.Name, &acc.Provider, &acc.EncryptedCredentials, &acc.CredentialsNonce,
 		&acc.BucketName, &acc.Region, &acc.EndpointURL, &acc.PublicURLBase,
-		&acc.IsDefault, &acc.IsActive, &acc.IsPublic, &acc.MaxFileSizeMB, &allowedTypesStr,
+		&acc.IsDefault, &acc.IsActive, &acc.IsPublic, &acc.MaxFileSizeMB, &allowedTypesStr, &acc.ObjectLockEnabled,
 		&acc.CreatedBy, &acc.CreatedAt, &acc.UpdatedAt,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
@@ -212,7 +230,7 @@ func (r *Repository) GetStorageAccountWithStatsByID(ctx context.Context, id uuid
 	query := `
 		SELECT sa.id, sa.name, sa.provider, sa.encrypted_credentials, sa.credentials_nonce,
 			sa.bucket_name, sa.region, sa.endpoint_url, sa.public_url_base,
-			sa.is_default, sa.is_active, sa.is_public, sa.max_file_size_mb, sa.allowed_types::text[],
+			sa.is_default, sa.is_active, sa.is_public, sa.max_file_size_mb, sa.allowed_types::text[], sa.object_lock_enabled,
 			sa.created_by, sa.created_at, sa.updated_at,
 			COUNT(m.id) as media_count,
 			COALESCE(SUM(m.file_size_bytes), 0) as total_size_bytes
@@ -226,7 +244,7 @@ func (r *Repository) GetStorageAccountWithStatsByID(ctx context.Context, id uuid
 	err := r.db.QueryRow(ctx, query, id).Scan(
 		&acc.ID, &acc.Name, &acc.Provider, &acc.EncryptedCredentials, &acc.CredentialsNonce,
 		&acc.BucketName, &acc.Region, &acc.EndpointURL, &acc.PublicURLBase,
-		&acc.IsDefault, &acc.IsActive, &acc.IsPublic, &acc.MaxFileSizeMB, &allowedTypesStr,
+		&acc.IsDefault, &acc.IsActive, &acc.IsPublic, &acc.MaxFileSizeMB, &allowedTypesStr, &acc.ObjectLockEnabled,
 		&acc.CreatedBy, &acc.CreatedAt, &acc.UpdatedAt,
 		&acc.MediaCount, &acc.TotalSizeBytes,
 	)
@@ -256,7 +274,7 @@ func (r *Repository) ListStorageAccounts(ctx context.Context, employeeID *uuid.U
 		SELECT 
 			sa.id, sa.name, sa.provider::text, sa.encrypted_credentials, sa.credentials_nonce,
 			sa.bucket_name, sa.region, sa.endpoint_url, sa.public_url_base,
-			sa.is_default, sa.is_active, sa.is_public, sa.max_file_size_mb, sa.allowed_types::text[],
+			sa.is_default, sa.is_active, sa.is_public, sa.max_file_size_mb, sa.allowed_types::text[], sa.object_lock_enabled,
 			sa.created_by, sa.created_at, sa.updated_at,
 			COUNT(m.id) as media_count,
 			COALESCE(SUM(m.file_size_bytes), 0)::BIGINT as total_size_bytes,
@@ -267,7 +285,7 @@ func (r *Repository) ListStorageAccounts(ctx context.Context, employeeID *uuid.U
 		GROUP BY 
 			sa.id, sa.name, sa.provider, sa.encrypted_credentials, sa.credentials_nonce,
 			sa.bucket_name, sa.region, sa.endpoint_url, sa.public_url_base,
-			sa.is_default, sa.is_active, sa.is_public, sa.max_file_size_mb, sa.allowed_types,
+			sa.is_default, sa.is_active, sa.is_public, sa.max_file_size_mb, sa.allowed_types, sa.object_lock_enabled,
 			sa.created_by, sa.created_at, sa.updated_at
 		ORDER BY sa.created_at DESC
 	`, whereClause)
@@ -285,7 +303,7 @@ func (r *Repository) ListStorageAccounts(ctx context.Context, employeeID *uuid.U
 		if err := rows.Scan(
 			&acc.ID, &acc.Name, &acc.Provider, &acc.EncryptedCredentials, &acc.CredentialsNonce,
 			&acc.BucketName, &acc.Region, &acc.EndpointURL, &acc.PublicURLBase,
-			&acc.IsDefault, &acc.IsActive, &acc.IsPublic, &acc.MaxFileSizeMB, &allowedTypesStr,
+			&acc.IsDefault, &acc.IsActive, &acc.IsPublic, &acc.MaxFileSizeMB, &allowedTypesStr, &acc.ObjectLockEnabled,
 			&acc.CreatedBy, &acc.CreatedAt, &acc.UpdatedAt,
 			&acc.MediaCount, &acc.TotalSizeBytes, &acc.LastUploadAt,
 		); err != nil {
@@ -301,6 +319,21 @@ func (r *Repository) ListStorageAccounts(ctx context.Context, employeeID *uuid.U
 	return accounts, nil
 }
 
+// HasStorageAccess reports whether a non-admin employee can use a storage
+// account: they created it, it is public, or they were granted access
+func (r *Repository) HasStorageAccess(ctx context.Context, accountID, employeeID uuid.UUID) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM storage_accounts sa
+			WHERE sa.id = $1 AND sa.deleted_at IS NULL
+				AND (sa.created_by = $2 OR sa.is_public = true OR sa.id IN (SELECT storage_account_id FROM storage_account_access WHERE employee_id = $2))
+		)
+	`
+	var ok bool
+	err := r.db.QueryRow(ctx, query, accountID, employeeID).Scan(&ok)
+	return ok, err
+}
+
 // GrantStorageAccess grants an employee access to a storage account
 func (r *Repository) GrantStorageAccess(ctx context.Context, accountID, employeeID uuid.UUID) error {
 	query := `INSERT INTO storage_account_access (storage_account_id, employee_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
@@ -347,7 +380,7 @@ func (r *Repository) GetDefaultStorageAccount(ctx context.Context) (*models.Stor
 	query := `
 		SELECT id, name, provider, encrypted_credentials, credentials_nonce,
 			bucket_name, region, endpoint_url, public_url_base,
-			is_default, is_active, is_public, max_file_size_mb, allowed_types::text[],
+			is_default, is_active, is_public, max_file_size_mb, allowed_types::text[], object_lock_enabled,
 			created_by, created_at, updated_at
 		FROM storage_accounts WHERE is_default = true AND is_active = true AND deleted_at IS NULL
 		LIMIT 1
@@ -357,7 +390,7 @@ func (r *Repository) GetDefaultStorageAccount(ctx context.Context) (*models.Stor
 	err := r.db.QueryRow(ctx, query).Scan(
 		&acc.ID, &acc.Name, &acc.Provider, &acc.EncryptedCredentials, &acc.CredentialsNonce,
 		&acc.BucketName, &acc.Region, &acc.EndpointURL, &acc.PublicURLBase,
-		&acc.IsDefault, &acc.IsActive, &acc.IsPublic, &acc.MaxFileSizeMB, &allowedTypesStr,
+		&acc.IsDefault, &acc.IsActive, &acc.IsPublic, &acc.MaxFileSizeMB, &allowedTypesStr, &acc.ObjectLockEnabled,
 		&acc.CreatedBy, &acc.CreatedAt, &acc.UpdatedAt,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
@@ -381,7 +414,7 @@ func (r *Repository) UpdateStorageAccount(ctx context.Context, acc *models.Stora
 			name = $2, encrypted_credentials = $3, credentials_nonce = $4,
 			bucket_name = $5, region = $6, endpoint_url = $7, public_url_base = $8,
 			is_default = $9, is_active = $10, is_public = $11, max_file_size_mb = $12, allowed_types = $13::media_type[],
-			updated_at = NOW()
+			object_lock_enabled = $14, updated_at = NOW()
 		WHERE id = $1 AND deleted_at IS NULL
 	`
 	// Convert MediaType slice to string slice for PostgreSQL ENUM array
@@ -394,6 +427,7 @@ func (r *Repository) UpdateStorageAccount(ctx context.Context, acc *models.Stora
 		acc.ID, acc.Name, acc.EncryptedCredentials, acc.CredentialsNonce,
 		acc.BucketName, acc.Region, acc.EndpointURL, acc.PublicURLBase,
 		acc.IsDefault, acc.IsActive, acc.IsPublic, acc.MaxFileSizeMB, allowedTypesStr,
+		acc.ObjectLockEnabled,
 	)
 	return err
 }
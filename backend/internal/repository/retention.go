@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ==========================================
+// Retention Policy Methods
+// ==========================================
+
+const retentionPolicyColumns = `
+	id, name, scope, media_group_id, folder_id, media_id,
+	expire_after_days, expire_action, min_retention_days,
+	is_active, created_by, created_at, updated_at
+`
+
+func scanRetentionPolicy(row pgx.Row, p *models.RetentionPolicy) error {
+	return row.Scan(
+		&p.ID, &p.Name, &p.Scope, &p.MediaGroupID, &p.FolderID, &p.MediaID,
+		&p.ExpireAfterDays, &p.ExpireAction, &p.MinRetentionDays,
+		&p.IsActive, &p.CreatedBy, &p.CreatedAt, &p.UpdatedAt,
+	)
+}
+
+// CreateRetentionPolicy creates a new retention policy
+func (r *Repository) CreateRetentionPolicy(ctx context.Context, p *models.RetentionPolicy) error {
+	query := `
+		INSERT INTO retention_policies (
+			id, name, scope, media_group_id, folder_id, media_id,
+			expire_after_days, expire_action, min_retention_days,
+			is_active, created_by, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	`
+	p.ID = uuid.New()
+	p.CreatedAt = time.Now()
+	p.UpdatedAt = time.Now()
+
+	_, err := r.db.Exec(ctx, query,
+		p.ID, p.Name, p.Scope, p.MediaGroupID, p.FolderID, p.MediaID,
+		p.ExpireAfterDays, p.ExpireAction, p.MinRetentionDays,
+		p.IsActive, p.CreatedBy, p.CreatedAt, p.UpdatedAt,
+	)
+	return err
+}
+
+// GetRetentionPolicyByID retrieves a retention policy by ID
+func (r *Repository) GetRetentionPolicyByID(ctx context.Context, id uuid.UUID) (*models.RetentionPolicy, error) {
+	query := `SELECT ` + retentionPolicyColumns + ` FROM retention_policies WHERE id = $1`
+	var p models.RetentionPolicy
+	err := scanRetentionPolicy(r.db.QueryRow(ctx, query, id), &p)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return &p, err
+}
+
+// ListRetentionPolicies lists all retention policies
+func (r *Repository) ListRetentionPolicies(ctx context.Context) ([]models.RetentionPolicy, error) {
+	query := `SELECT ` + retentionPolicyColumns + ` FROM retention_policies ORDER BY created_at DESC`
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := make([]models.RetentionPolicy, 0)
+	for rows.Next() {
+		var p models.RetentionPolicy
+		if err := scanRetentionPolicy(rows, &p); err != nil {
+			return nil, err
+		}
+		policies = append(policies, p)
+	}
+	return policies, rows.Err()
+}
+
+// UpdateRetentionPolicy updates the rules of a retention policy
+func (r *Repository) UpdateRetentionPolicy(ctx context.Context, p *models.RetentionPolicy) error {
+	query := `
+		UPDATE retention_policies SET
+			name = $2, expire_after_days = $3, expire_action = $4,
+			min_retention_days = $5, is_active = $6, updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(ctx, query,
+		p.ID, p.Name, p.ExpireAfterDays, p.ExpireAction, p.MinRetentionDays, p.IsActive,
+	)
+	return err
+}
+
+// DeleteRetentionPolicy removes a retention policy
+func (r *Repository) DeleteRetentionPolicy(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM retention_policies WHERE id = $1`, id)
+	return err
+}
+
+// FolderExists checks whether a folder exists
+func (r *Repository) FolderExists(ctx context.Context, id uuid.UUID) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM folders WHERE id = $1 AND deleted_at IS NULL)`
+	var exists bool
+	err := r.db.QueryRow(ctx, query, id).Scan(&exists)
+	return exists, err
+}
+
+// mediaScopeCondition returns the condition on media alias m that selects
+// the media covered by a group, folder or media scope bound to $1
+func mediaScopeCondition(scope models.RetentionScope) (string, error) {
+	switch scope {
+	case models.RetentionScopeGroup:
+		return "m.media_group_id = $1", nil
+	case models.RetentionScopeFolder:
+		return "m.folder_id = $1", nil
+	case models.RetentionScopeMedia:
+		return "m.id = $1", nil
+	}
+	return "", fmt.Errorf("unknown scope %q", scope)
+}
+
+// RefreshMediaRetention recomputes expires_at and retain_until for the media
+// covered by a scope. The most specific expiring policy wins (item, then
+// folder, then group), while the longest minimum retention always applies
+// and pushes expiry back if needed.
+func (r *Repository) RefreshMediaRetention(ctx context.Context, scope models.RetentionScope, targetID uuid.UUID) error {
+	scopeCondition, err := mediaScopeCondition(scope)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(`
+		WITH target AS (
+			SELECT m.id, m.created_at, m.media_group_id, m.folder_id
+			FROM media m
+			WHERE m.purged_at IS NULL AND %s
+		), computed AS (
+			SELECT t.id, exp.policy_id, exp.expires_at, keep.retain_until
+			FROM target t
+			LEFT JOIN LATERAL (
+				SELECT p.id AS policy_id, t.created_at + make_interval(days => p.expire_after_days) AS expires_at
+				FROM retention_policies p
+				WHERE p.is_active AND p.expire_after_days IS NOT NULL
+					AND (p.media_id = t.id OR p.folder_id = t.folder_id OR p.media_group_id = t.media_group_id)
+				ORDER BY CASE p.scope WHEN 'media' THEN 0 WHEN 'folder' THEN 1 ELSE 2 END, p.expire_after_days DESC
+				LIMIT 1
+			) exp ON true
+			LEFT JOIN LATERAL (
+				SELECT MAX(t.created_at + make_interval(days => p.min_retention_days)) AS retain_until
+				FROM retention_policies p
+				WHERE p.is_active AND p.min_retention_days IS NOT NULL
+					AND (p.media_id = t.id OR p.folder_id = t.folder_id OR p.media_group_id = t.media_group_id)
+			) keep ON true
+		)
+		UPDATE media m SET
+			expiry_policy_id = c.policy_id,
+			expires_at = CASE WHEN c.expires_at IS NULL THEN NULL ELSE GREATEST(c.expires_at, c.retain_until) END,
+			retain_until = c.retain_until
+		FROM computed c
+		WHERE m.id = c.id
+	`, scopeCondition)
+
+	_, err = r.db.Exec(ctx, query, targetID)
+	return err
+}
+
+// ListExpiredMedia returns live media whose expiry has passed and that is
+// not under a minimum retention
+func (r *Repository) ListExpiredMedia(ctx context.Context, limit int) ([]models.ExpiredMedia, error) {
+	query := `
+		SELECT m.id, m.storage_account_id, m.storage_key, m.original_filename, m.uploaded_by,
+			m.expires_at, p.id, p.name, p.created_by, p.expire_action
+		FROM media m
+		JOIN retention_policies p ON m.expiry_policy_id = p.id
+		WHERE m.deleted_at IS NULL AND m.expires_at <= NOW()
+			AND (m.retain_until IS NULL OR m.retain_until <= NOW())
+			AND NOT m.legal_hold
+		ORDER BY m.expires_at
+		LIMIT $1
+	`
+	rows, err := r.db.Query(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	expired := make([]models.ExpiredMedia, 0)
+	for rows.Next() {
+		var m models.ExpiredMedia
+		if err := rows.Scan(
+			&m.ID, &m.StorageAccountID, &m.StorageKey, &m.OriginalFilename, &m.UploadedBy,
+			&m.ExpiresAt, &m.PolicyID, &m.PolicyName, &m.PolicyCreatedBy, &m.Action,
+		); err != nil {
+			return nil, err
+		}
+		expired = append(expired, m)
+	}
+	return expired, rows.Err()
+}
+
+// CountRetainedMedia counts media on a storage account that is still under a
+// minimum retention
+func (r *Repository) CountRetainedMedia(ctx context.Context, storageAccountID uuid.UUID) (int64, error) {
+	query := `
+		SELECT COUNT(*) FROM media
+		WHERE storage_account_id = $1 AND purged_at IS NULL AND retain_until > NOW()
+	`
+	var count int64
+	err := r.db.QueryRow(ctx, query, storageAccountID).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ==========================================
+// Custom Role Methods
+// ==========================================
+
+const customRoleColumns = `
+	id, name, description, permissions, created_by, created_at, updated_at
+	FROM custom_roles
+`
+
+func scanCustomRole(row pgx.Row, role *models.CustomRole) error {
+	var permissions []string
+	if err := row.Scan(
+		&role.ID, &role.Name, &role.Description, &permissions,
+		&role.CreatedBy, &role.CreatedAt, &role.UpdatedAt,
+	); err != nil {
+		return err
+	}
+
+	role.Permissions = make([]models.Permission, len(permissions))
+	for i, p := range permissions {
+		role.Permissions[i] = models.Permission(p)
+	}
+	return nil
+}
+
+func permissionStrings(permissions []models.Permission) []string {
+	out := make([]string, len(permissions))
+	for i, p := range permissions {
+		out[i] = string(p)
+	}
+	return out
+}
+
+// CreateCustomRole creates a custom role. It returns ErrAlreadyExists when
+// the name is taken.
+func (r *Repository) CreateCustomRole(ctx context.Context, role *models.CustomRole) error {
+	query := `
+		INSERT INTO custom_roles (id, name, description, permissions, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+	`
+	id := uuid.New()
+	now := time.Now()
+	role.ID = &id
+	role.CreatedAt = &now
+	role.UpdatedAt = &now
+
+	_, err := r.db.Exec(ctx, query,
+		id, role.Name, role.Description, permissionStrings(role.Permissions), role.CreatedBy, now,
+	)
+	if isUniqueViolation(err) {
+		return ErrAlreadyExists
+	}
+	return err
+}
+
+// GetCustomRoleByID retrieves a custom role by ID
+func (r *Repository) GetCustomRoleByID(ctx context.Context, id uuid.UUID) (*models.CustomRole, error) {
+	query := `SELECT ` + customRoleColumns + ` WHERE id = $1`
+	var role models.CustomRole
+	err := scanCustomRole(r.db.QueryRow(ctx, query, id), &role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return &role, err
+}
+
+// ListCustomRoles lists custom roles by name
+func (r *Repository) ListCustomRoles(ctx context.Context) ([]models.CustomRole, error) {
+	rows, err := r.db.Query(ctx, `SELECT `+customRoleColumns+` ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []models.CustomRole{}
+	for rows.Next() {
+		var role models.CustomRole
+		if err := scanCustomRole(rows, &role); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
+
+// UpdateCustomRole updates a custom role's name, description and
+// permissions. It returns ErrAlreadyExists when the new name is taken.
+func (r *Repository) UpdateCustomRole(ctx context.Context, role *models.CustomRole) error {
+	query := `
+		UPDATE custom_roles SET name = $2, description = $3, permissions = $4
+		WHERE id = $1
+		RETURNING updated_at
+	`
+	err := r.db.QueryRow(ctx, query, role.ID, role.Name, role.Description, permissionStrings(role.Permissions)).
+		Scan(&role.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	if isUniqueViolation(err) {
+		return ErrAlreadyExists
+	}
+	return err
+}
+
+// DeleteCustomRole deletes a custom role; its employees fall back to their
+// built-in role
+func (r *Repository) DeleteCustomRole(ctx context.Context, id uuid.UUID) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM custom_roles WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
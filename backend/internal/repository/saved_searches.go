@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ==========================================
+// Saved Search Methods
+// ==========================================
+
+const savedSearchColumns = `
+	s.id, s.name, s.description, s.filters,
+	s.visibility::text, s.shared_role::text, s.shared_group_id, mg.name as group_name,
+	s.is_collection, s.created_by, COALESCE(e.full_name, '') as created_by_name,
+	s.created_at, s.updated_at
+	FROM saved_searches s
+	LEFT JOIN media_groups mg ON s.shared_group_id = mg.id AND mg.deleted_at IS NULL
+	LEFT JOIN employees e ON s.created_by = e.id
+`
+
+func scanSavedSearch(row pgx.Row, s *models.SavedSearch) error {
+	return row.Scan(
+		&s.ID, &s.Name, &s.Description, &s.Filters,
+		&s.Visibility, &s.SharedRole, &s.SharedGroupID, &s.GroupName,
+		&s.IsCollection, &s.CreatedBy, &s.CreatedByName,
+		&s.CreatedAt, &s.UpdatedAt,
+	)
+}
+
+// CreateSavedSearch creates a new saved search
+func (r *Repository) CreateSavedSearch(ctx context.Context, s *models.SavedSearch) error {
+	query := `
+		INSERT INTO saved_searches (
+			id, name, description, filters, visibility, shared_role, shared_group_id,
+			is_collection, created_by, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5::saved_search_visibility, $6::role_type, $7, $8, $9, $10, $10)
+	`
+	s.ID = uuid.New()
+	s.CreatedAt = time.Now()
+	s.UpdatedAt = s.CreatedAt
+
+	_, err := r.db.Exec(ctx, query,
+		s.ID, s.Name, s.Description, s.Filters, s.Visibility, s.SharedRole, s.SharedGroupID,
+		s.IsCollection, s.CreatedBy, s.CreatedAt,
+	)
+	if isUniqueViolation(err) {
+		return ErrAlreadyExists
+	}
+	return err
+}
+
+// GetSavedSearchByID retrieves a saved search by ID
+func (r *Repository) GetSavedSearchByID(ctx context.Context, id uuid.UUID) (*models.SavedSearch, error) {
+	query := `SELECT ` + savedSearchColumns + ` WHERE s.id = $1 AND s.deleted_at IS NULL`
+	var s models.SavedSearch
+	err := scanSavedSearch(r.db.QueryRow(ctx, query, id), &s)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return &s, err
+}
+
+// ListSavedSearches lists the saved searches an employee can use: their own,
+// those shared with their role, and those shared through a media group their
+// role may access. With manageAll every shared search is listed. Collections
+// come first, then by name.
+func (r *Repository) ListSavedSearches(ctx context.Context, employeeID uuid.UUID, role models.Role, manageAll, collectionsOnly bool) ([]models.SavedSearch, error) {
+	query := `SELECT ` + savedSearchColumns + `
+		WHERE s.deleted_at IS NULL
+			AND (
+				s.created_by = $1
+				OR ($4 AND s.visibility <> 'private')
+				OR (s.visibility = 'role' AND s.shared_role = $2::role_type)
+				OR (s.visibility = 'group' AND $2::role_type = ANY(mg.allowed_roles))
+			)
+			AND (NOT $3 OR s.is_collection)
+		ORDER BY s.is_collection DESC, lower(s.name), s.id
+	`
+	rows, err := r.db.Query(ctx, query, employeeID, role, collectionsOnly, manageAll)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	searches := make([]models.SavedSearch, 0)
+	for rows.Next() {
+		var s models.SavedSearch
+		if err := scanSavedSearch(rows, &s); err != nil {
+			return nil, err
+		}
+		searches = append(searches, s)
+	}
+	return searches, rows.Err()
+}
+
+// UpdateSavedSearch updates a saved search
+func (r *Repository) UpdateSavedSearch(ctx context.Context, s *models.SavedSearch) error {
+	query := `
+		UPDATE saved_searches SET
+			name = $2, description = $3, filters = $4,
+			visibility = $5::saved_search_visibility, shared_role = $6::role_type, shared_group_id = $7,
+			is_collection = $8, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+	tag, err := r.db.Exec(ctx, query,
+		s.ID, s.Name, s.Description, s.Filters,
+		s.Visibility, s.SharedRole, s.SharedGroupID, s.IsCollection,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrAlreadyExists
+		}
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SoftDeleteSavedSearch deletes a saved search
+func (r *Repository) SoftDeleteSavedSearch(ctx context.Context, id uuid.UUID) error {
+	tag, err := r.db.Exec(ctx, `UPDATE saved_searches SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
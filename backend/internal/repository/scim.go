@@ -0,0 +1,353 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ==========================================
+// SCIM Provisioning Methods
+// ==========================================
+
+// SCIMFilter narrows a SCIM listing to resources whose attribute equals a
+// value. Attributes are userName, externalId and id for users, and
+// displayName, externalId and id for groups.
+type SCIMFilter struct {
+	Attribute string
+	Value     string
+}
+
+// scimUserFilterColumns and scimGroupFilterColumns map filter attributes to
+// the SQL they compare with. userName and displayName compare case
+// insensitively, like the SCIM schema defines them.
+var (
+	scimUserFilterColumns = map[string]string{
+		"userName":   "LOWER(email) = LOWER(%s)",
+		"externalId": "scim_external_id = %s",
+		"id":         "id::text = %s",
+	}
+	scimGroupFilterColumns = map[string]string{
+		"displayName": "LOWER(display_name) = LOWER(%s)",
+		"externalId":  "external_id = %s",
+		"id":          "id::text = %s",
+	}
+)
+
+// scimFilterCondition turns a filter into an SQL condition on $1
+func scimFilterCondition(filter *SCIMFilter, columns map[string]string) (string, []interface{}, error) {
+	if filter == nil {
+		return "", nil, nil
+	}
+	condition, ok := columns[filter.Attribute]
+	if !ok {
+		return "", nil, fmt.Errorf("unsupported SCIM filter attribute %q", filter.Attribute)
+	}
+	return " AND " + fmt.Sprintf(condition, "$1"), []interface{}{filter.Value}, nil
+}
+
+const scimEmployeeColumns = `
+	id, email, password_hash, full_name, role, avatar_url, is_active, last_login_at, created_at, updated_at,
+	is_service_account, custom_role_id, totp_enabled_at, scim_external_id
+	FROM employees
+`
+
+func scanSCIMEmployee(row pgx.Row, emp *models.SCIMEmployee) error {
+	return row.Scan(
+		&emp.ID, &emp.Email, &emp.PasswordHash, &emp.FullName, &emp.Role,
+		&emp.AvatarURL, &emp.IsActive, &emp.LastLoginAt, &emp.CreatedAt, &emp.UpdatedAt,
+		&emp.IsServiceAccount, &emp.CustomRoleID, &emp.MFAEnabledAt, &emp.ExternalID,
+	)
+}
+
+// ListSCIMUsers lists the employees SCIM manages, i.e. all but service
+// accounts, oldest first
+func (r *Repository) ListSCIMUsers(ctx context.Context, filter *SCIMFilter, offset, limit int) ([]models.SCIMEmployee, int64, error) {
+	condition, args, err := scimFilterCondition(filter, scimUserFilterColumns)
+	if err != nil {
+		return nil, 0, err
+	}
+	where := ` WHERE deleted_at IS NULL AND NOT is_service_account` + condition
+
+	var total int64
+	if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM employees`+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf(`SELECT %s %s ORDER BY created_at, id LIMIT $%d OFFSET $%d`,
+		scimEmployeeColumns, where, len(args)+1, len(args)+2)
+	rows, err := r.db.Query(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	employees := make([]models.SCIMEmployee, 0)
+	for rows.Next() {
+		var emp models.SCIMEmployee
+		if err := scanSCIMEmployee(rows, &emp); err != nil {
+			return nil, 0, err
+		}
+		employees = append(employees, emp)
+	}
+	return employees, total, rows.Err()
+}
+
+// GetSCIMUser retrieves an employee SCIM manages
+func (r *Repository) GetSCIMUser(ctx context.Context, id uuid.UUID) (*models.SCIMEmployee, error) {
+	query := `SELECT ` + scimEmployeeColumns + ` WHERE id = $1 AND deleted_at IS NULL AND NOT is_service_account`
+	var emp models.SCIMEmployee
+	err := scanSCIMEmployee(r.db.QueryRow(ctx, query, id), &emp)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return &emp, err
+}
+
+// CreateSCIMUser creates a provisioned employee. Returns ErrAlreadyExists if
+// the email is taken.
+func (r *Repository) CreateSCIMUser(ctx context.Context, emp *models.SCIMEmployee) error {
+	query := `
+		INSERT INTO employees (id, email, password_hash, full_name, role, is_active, created_at, updated_at, scim_external_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7, $8)
+	`
+	emp.ID = uuid.New()
+	emp.CreatedAt = time.Now()
+	emp.UpdatedAt = emp.CreatedAt
+
+	_, err := r.db.Exec(ctx, query,
+		emp.ID, emp.Email, emp.PasswordHash, emp.FullName, emp.Role, emp.IsActive, emp.CreatedAt, emp.ExternalID,
+	)
+	if isUniqueViolation(err) {
+		return ErrAlreadyExists
+	}
+	return err
+}
+
+// UpdateSCIMUser saves the attributes SCIM manages: email, name, active
+// state and external ID. Returns ErrAlreadyExists if the email is taken.
+func (r *Repository) UpdateSCIMUser(ctx context.Context, emp *models.SCIMEmployee) error {
+	query := `
+		UPDATE employees
+		SET email = $2, full_name = $3, is_active = $4, scim_external_id = $5, updated_at = NOW(),
+			sso_deactivated_at = CASE WHEN is_active = $4 THEN sso_deactivated_at END
+		WHERE id = $1 AND deleted_at IS NULL AND NOT is_service_account
+	`
+	result, err := r.db.Exec(ctx, query, emp.ID, emp.Email, emp.FullName, emp.IsActive, emp.ExternalID)
+	if isUniqueViolation(err) {
+		return ErrAlreadyExists
+	}
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	emp.UpdatedAt = time.Now()
+	return nil
+}
+
+// SetEmployeeRole changes an employee's built-in role
+func (r *Repository) SetEmployeeRole(ctx context.Context, id uuid.UUID, role models.Role) error {
+	query := `UPDATE employees SET role = $2, updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL AND role <> $2`
+	_, err := r.db.Exec(ctx, query, id, role)
+	return err
+}
+
+// ListSCIMGroupMemberships lists the groups of each employee
+func (r *Repository) ListSCIMGroupMemberships(ctx context.Context, employeeIDs []uuid.UUID) (map[uuid.UUID][]models.SCIMGroupMembership, error) {
+	query := `
+		SELECT m.employee_id, g.id, g.display_name
+		FROM scim_group_members m
+		JOIN scim_groups g ON g.id = m.group_id
+		WHERE m.employee_id = ANY($1)
+		ORDER BY g.display_name
+	`
+	rows, err := r.db.Query(ctx, query, employeeIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	memberships := make(map[uuid.UUID][]models.SCIMGroupMembership)
+	for rows.Next() {
+		var employeeID uuid.UUID
+		var m models.SCIMGroupMembership
+		if err := rows.Scan(&employeeID, &m.GroupID, &m.DisplayName); err != nil {
+			return nil, err
+		}
+		memberships[employeeID] = append(memberships[employeeID], m)
+	}
+	return memberships, rows.Err()
+}
+
+const scimGroupColumns = `
+	id, display_name, external_id, created_at, updated_at
+	FROM scim_groups
+`
+
+func scanSCIMGroup(row pgx.Row, g *models.SCIMGroup) error {
+	return row.Scan(&g.ID, &g.DisplayName, &g.ExternalID, &g.CreatedAt, &g.UpdatedAt)
+}
+
+// ListSCIMGroups lists SCIM groups by display name
+func (r *Repository) ListSCIMGroups(ctx context.Context, filter *SCIMFilter, offset, limit int) ([]models.SCIMGroup, int64, error) {
+	condition, args, err := scimFilterCondition(filter, scimGroupFilterColumns)
+	if err != nil {
+		return nil, 0, err
+	}
+	where := ` WHERE TRUE` + condition
+
+	var total int64
+	if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM scim_groups`+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf(`SELECT %s %s ORDER BY display_name LIMIT $%d OFFSET $%d`,
+		scimGroupColumns, where, len(args)+1, len(args)+2)
+	rows, err := r.db.Query(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	groups := make([]models.SCIMGroup, 0)
+	for rows.Next() {
+		var g models.SCIMGroup
+		if err := scanSCIMGroup(rows, &g); err != nil {
+			return nil, 0, err
+		}
+		groups = append(groups, g)
+	}
+	return groups, total, rows.Err()
+}
+
+// GetSCIMGroup retrieves a SCIM group
+func (r *Repository) GetSCIMGroup(ctx context.Context, id uuid.UUID) (*models.SCIMGroup, error) {
+	query := `SELECT ` + scimGroupColumns + ` WHERE id = $1`
+	var g models.SCIMGroup
+	err := scanSCIMGroup(r.db.QueryRow(ctx, query, id), &g)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return &g, err
+}
+
+// CreateSCIMGroup creates a SCIM group. Returns ErrAlreadyExists if the
+// display name is taken.
+func (r *Repository) CreateSCIMGroup(ctx context.Context, g *models.SCIMGroup) error {
+	query := `
+		INSERT INTO scim_groups (id, display_name, external_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $4)
+	`
+	g.ID = uuid.New()
+	g.CreatedAt = time.Now()
+	g.UpdatedAt = g.CreatedAt
+
+	_, err := r.db.Exec(ctx, query, g.ID, g.DisplayName, g.ExternalID, g.CreatedAt)
+	if isUniqueViolation(err) {
+		return ErrAlreadyExists
+	}
+	return err
+}
+
+// UpdateSCIMGroup renames a SCIM group or changes its external ID. Returns
+// ErrAlreadyExists if the display name is taken.
+func (r *Repository) UpdateSCIMGroup(ctx context.Context, g *models.SCIMGroup) error {
+	query := `UPDATE scim_groups SET display_name = $2, external_id = $3, updated_at = NOW() WHERE id = $1`
+	result, err := r.db.Exec(ctx, query, g.ID, g.DisplayName, g.ExternalID)
+	if isUniqueViolation(err) {
+		return ErrAlreadyExists
+	}
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	g.UpdatedAt = time.Now()
+	return nil
+}
+
+// DeleteSCIMGroup deletes a SCIM group and its memberships
+func (r *Repository) DeleteSCIMGroup(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.Exec(ctx, `DELETE FROM scim_groups WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListSCIMGroupMembers lists the members of each group
+func (r *Repository) ListSCIMGroupMembers(ctx context.Context, groupIDs []uuid.UUID) (map[uuid.UUID][]models.SCIMGroupMember, error) {
+	query := `
+		SELECT m.group_id, e.id, e.email
+		FROM scim_group_members m
+		JOIN employees e ON e.id = m.employee_id
+		WHERE m.group_id = ANY($1) AND e.deleted_at IS NULL
+		ORDER BY e.email
+	`
+	rows, err := r.db.Query(ctx, query, groupIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := make(map[uuid.UUID][]models.SCIMGroupMember)
+	for rows.Next() {
+		var groupID uuid.UUID
+		var m models.SCIMGroupMember
+		if err := rows.Scan(&groupID, &m.EmployeeID, &m.Email); err != nil {
+			return nil, err
+		}
+		members[groupID] = append(members[groupID], m)
+	}
+	return members, rows.Err()
+}
+
+// AddSCIMGroupMembers adds employees to a group. IDs of deleted employees
+// and service accounts are skipped.
+func (r *Repository) AddSCIMGroupMembers(ctx context.Context, groupID uuid.UUID, employeeIDs []uuid.UUID) error {
+	query := `
+		INSERT INTO scim_group_members (group_id, employee_id)
+		SELECT $1, id FROM employees
+		WHERE id = ANY($2) AND deleted_at IS NULL AND NOT is_service_account
+		ON CONFLICT DO NOTHING
+	`
+	_, err := r.db.Exec(ctx, query, groupID, employeeIDs)
+	return err
+}
+
+// RemoveSCIMGroupMembers removes employees from a group
+func (r *Repository) RemoveSCIMGroupMembers(ctx context.Context, groupID uuid.UUID, employeeIDs []uuid.UUID) error {
+	query := `DELETE FROM scim_group_members WHERE group_id = $1 AND employee_id = ANY($2)`
+	_, err := r.db.Exec(ctx, query, groupID, employeeIDs)
+	return err
+}
+
+// ReplaceSCIMGroupMembers makes the given employees the only members of a
+// group
+func (r *Repository) ReplaceSCIMGroupMembers(ctx context.Context, groupID uuid.UUID, employeeIDs []uuid.UUID) error {
+	query := `
+		WITH removed AS (
+			DELETE FROM scim_group_members WHERE group_id = $1 AND employee_id <> ALL($2)
+		)
+		INSERT INTO scim_group_members (group_id, employee_id)
+		SELECT $1, id FROM employees
+		WHERE id = ANY($2) AND deleted_at IS NULL AND NOT is_service_account
+		ON CONFLICT DO NOTHING
+	`
+	if employeeIDs == nil {
+		employeeIDs = []uuid.UUID{} // A NULL array would keep every member
+	}
+	_, err := r.db.Exec(ctx, query, groupID, employeeIDs)
+	return err
+}
@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/appnity/media-vault/internal/models"
+)
+
+// ==========================================
+// Search Query Compilation
+// ==========================================
+
+// likeEscaper escapes LIKE wildcards so user input matches literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// compileSearch turns a parsed search query into a SQL condition over the
+// media table aliased m. Values are appended to args and referenced as
+// positional parameters.
+func compileSearch(node *models.SearchNode, args *[]any) (string, error) {
+	switch node.Op {
+	case models.SearchOpAnd, models.SearchOpOr:
+		parts := make([]string, 0, len(node.Children))
+		for _, child := range node.Children {
+			part, err := compileSearch(child, args)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, part)
+		}
+		return "(" + strings.Join(parts, " "+strings.ToUpper(string(node.Op))+" ") + ")", nil
+	case models.SearchOpNot:
+		if len(node.Children) != 1 {
+			return "", fmt.Errorf("search: NOT needs exactly one operand")
+		}
+		part, err := compileSearch(node.Children[0], args)
+		if err != nil {
+			return "", err
+		}
+		return "NOT " + part, nil
+	case models.SearchOpTerm:
+		return compileSearchTerm(node, args)
+	default:
+		return "", fmt.Errorf("search: unknown operator %q", node.Op)
+	}
+}
+
+func compileSearchTerm(node *models.SearchNode, args *[]any) (string, error) {
+	arg := func(v any) string {
+		*args = append(*args, v)
+		return fmt.Sprintf("$%d", len(*args))
+	}
+
+	switch node.Field {
+	case models.SearchFieldText:
+		return fmt.Sprintf(
+			"(to_tsvector('english', COALESCE(m.filename, '') || ' ' || COALESCE(m.original_filename, '')) @@ plainto_tsquery('english', %s) OR m.original_filename ILIKE %s)",
+			arg(node.Value), arg("%"+likeEscaper.Replace(node.Value)+"%"),
+		), nil
+	case models.SearchFieldTag:
+		return fmt.Sprintf("EXISTS (SELECT 1 FROM unnest(m.tags) t WHERE lower(t) = lower(%s))", arg(node.Value)), nil
+	case models.SearchFieldType:
+		return fmt.Sprintf("m.media_type = %s::media_type", arg(node.Value)), nil
+	case models.SearchFieldSize:
+		switch node.Compare {
+		case "=", ">", ">=", "<", "<=":
+			return fmt.Sprintf("m.file_size_bytes %s %s", node.Compare, arg(node.Bytes)), nil
+		}
+		return "", fmt.Errorf("search: unknown size comparison %q", node.Compare)
+	case models.SearchFieldUploaded:
+		var parts []string
+		if node.From != nil {
+			parts = append(parts, "m.created_at >= "+arg(*node.From))
+		}
+		if node.Until != nil {
+			parts = append(parts, "m.created_at < "+arg(*node.Until))
+		}
+		if len(parts) == 0 {
+			return "TRUE", nil
+		}
+		return "(" + strings.Join(parts, " AND ") + ")", nil
+	case models.SearchFieldGroup:
+		return fmt.Sprintf("m.media_group_id IN (SELECT id FROM media_groups WHERE deleted_at IS NULL AND lower(name) = lower(%s))", arg(node.Value)), nil
+	case models.SearchFieldBy:
+		p := arg(node.Value)
+		return fmt.Sprintf(
+			"m.uploaded_by IN (SELECT id FROM employees WHERE lower(email) = lower(%[1]s) OR lower(split_part(email, '@', 1)) = lower(%[1]s) OR lower(full_name) = lower(%[1]s))",
+			p,
+		), nil
+	case models.SearchFieldExt:
+		return fmt.Sprintf("lower(m.original_filename) LIKE %s", arg("%."+likeEscaper.Replace(node.Value))), nil
+	case models.SearchFieldName:
+		return fmt.Sprintf("m.original_filename ILIKE %s", arg("%"+likeEscaper.Replace(node.Value)+"%")), nil
+	case models.SearchFieldMime:
+		// A trailing * matches any subtype, e.g. image/*
+		pattern := likeEscaper.Replace(node.Value)
+		if strings.HasSuffix(pattern, "*") {
+			pattern = strings.TrimSuffix(pattern, "*") + "%"
+		}
+		return fmt.Sprintf("m.mime_type ILIKE %s", arg(pattern)), nil
+	default:
+		return "", fmt.Errorf("search: unknown field %q", node.Field)
+	}
+}
+
+// ==========================================
+// Search Suggestions
+// ==========================================
+
+// suggestionCandidates bounds how many close terms are checked against what
+// a restricted caller may see before the best few are kept
+const suggestionCandidates = 20
+
+// SuggestSearchTerms looks up words missing from the library's vocabulary and
+// returns up to limit similarly spelled known terms for each, closest first.
+// Words that are known have no entry. With access or limits set, both the
+// known words and the suggestions are restricted to terms occurring in media
+// the caller may see, so the vocabulary does not reveal hidden names.
+func (r *Repository) SuggestSearchTerms(ctx context.Context, words []string, limit int, access *models.MediaAccess, limits *models.APIKeyLimits) (map[string][]string, error) {
+	args := []any{words, limit}
+
+	var conditions []string
+	if access != nil {
+		conditions = append(conditions, mediaAccessCondition(access, &args))
+	}
+	if condition := mediaLimitsCondition(limits, &args); condition != "" {
+		conditions = append(conditions, condition)
+	}
+
+	query := `
+		SELECT w.word, t.word
+		FROM unnest($1::text[]) AS w(word)
+		CROSS JOIN LATERAL (
+			SELECT s.word, s.ndoc, s.word <-> w.word AS distance
+			FROM media_search_terms s
+			WHERE s.word % w.word
+			ORDER BY distance, s.ndoc DESC
+			LIMIT $2
+		) t
+		WHERE NOT EXISTS (SELECT 1 FROM media_search_terms k WHERE k.word = w.word)
+		ORDER BY w.word, t.distance, t.ndoc DESC
+	`
+	if len(conditions) > 0 {
+		// visible is true when a term occurs in some media the caller may see
+		visible := func(term string) string {
+			return `EXISTS (
+				SELECT 1 FROM media m
+				LEFT JOIN folders f ON f.id = m.folder_id
+				WHERE m.deleted_at IS NULL AND m.quarantined_at IS NULL
+					AND (m.review_status IS NULL OR m.review_status = 'approved')
+					AND ` + strings.Join(conditions, " AND ") + `
+					AND to_tsvector('simple', media_search_text(m.filename, m.original_filename, m.tags) || ' ' || COALESCE(f.path, ''))
+						@@ plainto_tsquery('simple', ` + term + `)
+			)`
+		}
+		query = fmt.Sprintf(`
+			SELECT w.word, t.word
+			FROM unnest($1::text[]) AS w(word)
+			CROSS JOIN LATERAL (
+				SELECT c.word, c.ndoc, c.distance
+				FROM (
+					SELECT s.word, s.ndoc, s.word <-> w.word AS distance
+					FROM media_search_terms s
+					WHERE s.word %% w.word
+					ORDER BY distance, s.ndoc DESC
+					LIMIT %d
+				) c
+				WHERE %s
+				ORDER BY c.distance, c.ndoc DESC
+				LIMIT $2
+			) t
+			WHERE NOT %s
+			ORDER BY w.word, t.distance, t.ndoc DESC
+		`, suggestionCandidates, visible("c.word"), visible("w.word"))
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suggestions := make(map[string][]string)
+	for rows.Next() {
+		var word, term string
+		if err := rows.Scan(&word, &term); err != nil {
+			return nil, err
+		}
+		suggestions[word] = append(suggestions[word], term)
+	}
+	return suggestions, rows.Err()
+}
+
+// RefreshSearchTerms rebuilds the vocabulary suggestions are drawn from
+func (r *Repository) RefreshSearchTerms(ctx context.Context) error {
+	_, err := r.db.Exec(ctx, "REFRESH MATERIALIZED VIEW CONCURRENTLY media_search_terms")
+	return err
+}
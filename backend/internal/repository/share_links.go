@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ==========================================
+// Share Link Methods
+// ==========================================
+
+const shareLinkColumns = `
+	l.id, l.token_hash, l.name, l.scope, l.folder_id,
+	COALESCE((SELECT array_agg(sm.media_id) FROM share_link_media sm WHERE sm.share_link_id = l.id), '{}') as media_ids,
+	l.password_hash, l.expires_at, l.max_downloads, l.download_count, l.last_accessed_at,
+	l.created_by, COALESCE(e.full_name, '') as created_by_name, l.created_at,
+	l.revoked_at, l.revoked_by, l.key_storage_account_ids, l.key_media_group_ids,
+	l.password_locked_until
+	FROM share_links l
+	LEFT JOIN employees e ON l.created_by = e.id
+`
+
+func scanShareLink(row pgx.Row, l *models.ShareLink) error {
+	var limits models.APIKeyLimits
+	err := row.Scan(
+		&l.ID, &l.TokenHash, &l.Name, &l.Scope, &l.FolderID,
+		&l.MediaIDs,
+		&l.PasswordHash, &l.ExpiresAt, &l.MaxDownloads, &l.DownloadCount, &l.LastAccessedAt,
+		&l.CreatedBy, &l.CreatedByName, &l.CreatedAt,
+		&l.RevokedAt, &l.RevokedBy, &limits.StorageAccountIDs, &limits.MediaGroupIDs,
+		&l.PasswordLockedUntil,
+	)
+	if len(limits.StorageAccountIDs) > 0 || len(limits.MediaGroupIDs) > 0 {
+		l.KeyLimits = &limits
+	}
+	return err
+}
+
+// CreateShareLink creates a share link together with the media it covers
+func (r *Repository) CreateShareLink(ctx context.Context, l *models.ShareLink) error {
+	query := `
+		WITH link AS (
+			INSERT INTO share_links (
+				id, token_hash, name, scope, folder_id,
+				password_hash, expires_at, max_downloads, created_by, created_at,
+				key_storage_account_ids, key_media_group_ids
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $12, $13)
+			RETURNING id
+		)
+		INSERT INTO share_link_media (share_link_id, media_id)
+		SELECT link.id, media_id FROM link, unnest($11::uuid[]) AS media_id
+	`
+	l.ID = uuid.New()
+	l.CreatedAt = time.Now()
+
+	var storageAccountIDs, mediaGroupIDs []uuid.UUID
+	if l.KeyLimits != nil {
+		storageAccountIDs, mediaGroupIDs = l.KeyLimits.StorageAccountIDs, l.KeyLimits.MediaGroupIDs
+	}
+
+	_, err := r.db.Exec(ctx, query,
+		l.ID, l.TokenHash, l.Name, l.Scope, l.FolderID,
+		l.PasswordHash, l.ExpiresAt, l.MaxDownloads, l.CreatedBy, l.CreatedAt,
+		l.MediaIDs, storageAccountIDs, mediaGroupIDs,
+	)
+	return err
+}
+
+// GetShareLinkByID retrieves a share link by ID
+func (r *Repository) GetShareLinkByID(ctx context.Context, id uuid.UUID) (*models.ShareLink, error) {
+	query := `SELECT ` + shareLinkColumns + ` WHERE l.id = $1`
+	var l models.ShareLink
+	err := scanShareLink(r.db.QueryRow(ctx, query, id), &l)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return &l, err
+}
+
+// GetShareLinkByTokenHash retrieves a share link by the hash of its token
+func (r *Repository) GetShareLinkByTokenHash(ctx context.Context, tokenHash string) (*models.ShareLink, error) {
+	query := `SELECT ` + shareLinkColumns + ` WHERE l.token_hash = $1`
+	var l models.ShareLink
+	err := scanShareLink(r.db.QueryRow(ctx, query, tokenHash), &l)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return &l, err
+}
+
+// ListShareLinks lists share links, newest first. When createdBy is set only
+// that employee's links are returned.
+func (r *Repository) ListShareLinks(ctx context.Context, createdBy *uuid.UUID) ([]models.ShareLink, error) {
+	query := `SELECT ` + shareLinkColumns + `
+		WHERE ($1::uuid IS NULL OR l.created_by = $1)
+		ORDER BY l.created_at DESC
+	`
+	rows, err := r.db.Query(ctx, query, createdBy)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := make([]models.ShareLink, 0)
+	for rows.Next() {
+		var l models.ShareLink
+		if err := scanShareLink(rows, &l); err != nil {
+			return nil, err
+		}
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
+
+// RevokeShareLink revokes a share link that is not yet revoked
+func (r *Repository) RevokeShareLink(ctx context.Context, id, revokedBy uuid.UUID) error {
+	query := `UPDATE share_links SET revoked_at = NOW(), revoked_by = $2 WHERE id = $1 AND revoked_at IS NULL`
+	tag, err := r.db.Exec(ctx, query, id, revokedBy)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ClaimShareDownload counts a download against a share link. It returns
+// ErrNotFound when the link is revoked, expired or out of downloads, so
+// concurrent downloads can never exceed the limit.
+func (r *Repository) ClaimShareDownload(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE share_links SET download_count = download_count + 1, last_accessed_at = NOW()
+		WHERE id = $1 AND revoked_at IS NULL
+			AND (expires_at IS NULL OR expires_at > NOW())
+			AND (max_downloads IS NULL OR download_count < max_downloads)
+	`
+	tag, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RecordSharePasswordFailure counts a wrong password. The maxAttempts-th
+// consecutive failure locks the link until lockUntil and starts a new count.
+func (r *Repository) RecordSharePasswordFailure(ctx context.Context, id uuid.UUID, maxAttempts int, lockUntil time.Time) error {
+	query := `
+		UPDATE share_links
+		SET password_failed_attempts = CASE WHEN password_failed_attempts + 1 >= $2 THEN 0 ELSE password_failed_attempts + 1 END,
+			password_locked_until = CASE WHEN password_failed_attempts + 1 >= $2 THEN $3 ELSE password_locked_until END
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(ctx, query, id, maxAttempts, lockUntil)
+	return err
+}
+
+// ResetSharePasswordFailures clears the wrong password count after a
+// correct password
+func (r *Repository) ResetSharePasswordFailures(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE share_links SET password_failed_attempts = 0 WHERE id = $1 AND password_failed_attempts > 0`
+	_, err := r.db.Exec(ctx, query, id)
+	return err
+}
+
+// TouchShareLink records that a share link was opened
+func (r *Repository) TouchShareLink(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.Exec(ctx, `UPDATE share_links SET last_accessed_at = NOW() WHERE id = $1`, id)
+	return err
+}
+
+// ListSharedMedia lists the live media a share link covers that its creator
+// may see under access, and within the API key limits of the link. When
+// mediaID is set only that item is returned, if the link covers it.
+func (r *Repository) ListSharedMedia(ctx context.Context, l *models.ShareLink, access *models.MediaAccess, mediaID *uuid.UUID) ([]models.MediaWithDetails, error) {
+	var scopeCondition string
+	var target any
+	if l.Scope == models.ShareScopeFolder {
+		scopeCondition = "m.folder_id = $1"
+		target = l.FolderID
+	} else {
+		scopeCondition = "m.id IN (SELECT media_id FROM share_link_media WHERE share_link_id = $1)"
+		target = l.ID
+	}
+
+	args := []any{target, mediaID}
+	conditions := []string{scopeCondition}
+	if access != nil {
+		conditions = append(conditions, mediaAccessCondition(access, &args))
+	}
+	if limits := mediaLimitsCondition(l.KeyLimits, &args); limits != "" {
+		conditions = append(conditions, limits)
+	}
+
+	query := `SELECT ` + mediaDetailsColumns + mediaDetailsJoins + `
+		WHERE ` + strings.Join(conditions, " AND ") + `
+			AND ($2::uuid IS NULL OR m.id = $2)
+			AND m.deleted_at IS NULL AND m.quarantined_at IS NULL AND m.scan_status IN ('clean', 'skipped')
+			AND (m.review_status IS NULL OR m.review_status = 'approved')
+		ORDER BY m.original_filename
+	`
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mediaList := make([]models.MediaWithDetails, 0)
+	for rows.Next() {
+		var media models.MediaWithDetails
+		if err := scanMediaWithDetails(rows, &media); err != nil {
+			return nil, err
+		}
+		mediaList = append(mediaList, media)
+	}
+	return mediaList, rows.Err()
+}
@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ==========================================
+// Upload Request Methods
+// ==========================================
+
+const uploadRequestColumns = `
+	u.id, u.token_hash, u.name, u.message,
+	u.media_group_id, COALESCE(mg.name, '') as group_name, u.folder_path,
+	u.expires_at, u.max_files, u.max_file_size_mb, u.allowed_types::text[],
+	u.require_review, u.upload_count,
+	u.created_by, COALESCE(e.full_name, '') as created_by_name, u.created_at,
+	u.revoked_at, u.revoked_by
+	FROM upload_requests u
+	LEFT JOIN media_groups mg ON u.media_group_id = mg.id
+	LEFT JOIN employees e ON u.created_by = e.id
+`
+
+func scanUploadRequest(row pgx.Row, u *models.UploadRequest) error {
+	var allowedTypesStr []string
+	err := row.Scan(
+		&u.ID, &u.TokenHash, &u.Name, &u.Message,
+		&u.MediaGroupID, &u.GroupName, &u.FolderPath,
+		&u.ExpiresAt, &u.MaxFiles, &u.MaxFileSizeMB, &allowedTypesStr,
+		&u.RequireReview, &u.UploadCount,
+		&u.CreatedBy, &u.CreatedByName, &u.CreatedAt,
+		&u.RevokedAt, &u.RevokedBy,
+	)
+	if err != nil {
+		return err
+	}
+
+	u.AllowedTypes = make([]models.MediaType, len(allowedTypesStr))
+	for i, s := range allowedTypesStr {
+		u.AllowedTypes[i] = models.MediaType(s)
+	}
+	return nil
+}
+
+// CreateUploadRequest creates a new upload request
+func (r *Repository) CreateUploadRequest(ctx context.Context, u *models.UploadRequest) error {
+	query := `
+		INSERT INTO upload_requests (
+			id, token_hash, name, message, media_group_id, folder_path,
+			expires_at, max_files, max_file_size_mb, allowed_types,
+			require_review, created_by, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10::media_type[], $11, $12, $13)
+	`
+	u.ID = uuid.New()
+	u.CreatedAt = time.Now()
+
+	allowedTypesStr := make([]string, len(u.AllowedTypes))
+	for i, t := range u.AllowedTypes {
+		allowedTypesStr[i] = string(t)
+	}
+
+	_, err := r.db.Exec(ctx, query,
+		u.ID, u.TokenHash, u.Name, u.Message, u.MediaGroupID, u.FolderPath,
+		u.ExpiresAt, u.MaxFiles, u.MaxFileSizeMB, allowedTypesStr,
+		u.RequireReview, u.CreatedBy, u.CreatedAt,
+	)
+	return err
+}
+
+// GetUploadRequestByID retrieves an upload request by ID
+func (r *Repository) GetUploadRequestByID(ctx context.Context, id uuid.UUID) (*models.UploadRequest, error) {
+	query := `SELECT ` + uploadRequestColumns + ` WHERE u.id = $1`
+	var u models.UploadRequest
+	err := scanUploadRequest(r.db.QueryRow(ctx, query, id), &u)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return &u, err
+}
+
+// GetUploadRequestByTokenHash retrieves an upload request by the hash of its token
+func (r *Repository) GetUploadRequestByTokenHash(ctx context.Context, tokenHash string) (*models.UploadRequest, error) {
+	query := `SELECT ` + uploadRequestColumns + ` WHERE u.token_hash = $1`
+	var u models.UploadRequest
+	err := scanUploadRequest(r.db.QueryRow(ctx, query, tokenHash), &u)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return &u, err
+}
+
+// ListUploadRequests lists upload requests, newest first. When createdBy is
+// set only that employee's requests are returned.
+func (r *Repository) ListUploadRequests(ctx context.Context, createdBy *uuid.UUID) ([]models.UploadRequest, error) {
+	query := `SELECT ` + uploadRequestColumns + `
+		WHERE ($1::uuid IS NULL OR u.created_by = $1)
+		ORDER BY u.created_at DESC
+	`
+	rows, err := r.db.Query(ctx, query, createdBy)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	requests := make([]models.UploadRequest, 0)
+	for rows.Next() {
+		var u models.UploadRequest
+		if err := scanUploadRequest(rows, &u); err != nil {
+			return nil, err
+		}
+		requests = append(requests, u)
+	}
+	return requests, rows.Err()
+}
+
+// RevokeUploadRequest revokes an upload request that is not yet revoked
+func (r *Repository) RevokeUploadRequest(ctx context.Context, id, revokedBy uuid.UUID) error {
+	query := `UPDATE upload_requests SET revoked_at = NOW(), revoked_by = $2 WHERE id = $1 AND revoked_at IS NULL`
+	tag, err := r.db.Exec(ctx, query, id, revokedBy)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ClaimUploadSlot counts an upload against an upload request. It returns
+// ErrNotFound when the request is revoked, expired or full, so concurrent
+// uploads can never exceed the file limit.
+func (r *Repository) ClaimUploadSlot(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE upload_requests SET upload_count = upload_count + 1
+		WHERE id = $1 AND revoked_at IS NULL
+			AND (expires_at IS NULL OR expires_at > NOW())
+			AND (max_files IS NULL OR upload_count < max_files)
+	`
+	tag, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ReleaseUploadSlot gives back a slot claimed by an upload that failed to start
+func (r *Repository) ReleaseUploadSlot(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.Exec(ctx, `UPDATE upload_requests SET upload_count = GREATEST(upload_count - 1, 0) WHERE id = $1`, id)
+	return err
+}
+
+// MarkGuestUpload records which upload request and guest a media item came
+// from, and whether it awaits review
+func (r *Repository) MarkGuestUpload(ctx context.Context, mediaID, uploadRequestID uuid.UUID, guestName string, reviewStatus *models.ReviewStatus) error {
+	query := `UPDATE media SET upload_request_id = $2, guest_name = $3, review_status = $4 WHERE id = $1`
+	_, err := r.db.Exec(ctx, query, mediaID, uploadRequestID, guestName, reviewStatus)
+	return err
+}
+
+// ListPendingReview lists guest uploads awaiting review, oldest first. When
+// createdBy is set only uploads through that employee's requests are returned.
+func (r *Repository) ListPendingReview(ctx context.Context, createdBy *uuid.UUID) ([]models.MediaWithDetails, error) {
+	query := `SELECT ` + mediaDetailsColumns + mediaDetailsJoins + `
+		JOIN upload_requests u ON m.upload_request_id = u.id
+		WHERE m.review_status = 'pending' AND m.deleted_at IS NULL AND m.file_size_bytes > 0
+			AND ($1::uuid IS NULL OR u.created_by = $1)
+		ORDER BY m.created_at
+	`
+	rows, err := r.db.Query(ctx, query, createdBy)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mediaList := make([]models.MediaWithDetails, 0)
+	for rows.Next() {
+		var media models.MediaWithDetails
+		if err := scanMediaWithDetails(rows, &media); err != nil {
+			return nil, err
+		}
+		mediaList = append(mediaList, media)
+	}
+	return mediaList, rows.Err()
+}
+
+// SetMediaReviewStatus records the review decision on a pending guest upload
+// and publishes the file at publicURL, when given
+func (r *Repository) SetMediaReviewStatus(ctx context.Context, id uuid.UUID, status models.ReviewStatus, publicURL *string) error {
+	query := `
+		UPDATE media SET review_status = $2, public_url = COALESCE($3, public_url), updated_at = NOW()
+		WHERE id = $1 AND review_status = 'pending'
+	`
+	tag, err := r.db.Exec(ctx, query, id, status, publicURL)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
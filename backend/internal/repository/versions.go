@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ==========================================
+// Media Version Methods
+// ==========================================
+
+const mediaVersionColumns = `
+	v.media_id, v.version_number, v.storage_key, v.mime_type, v.file_size_bytes,
+	v.width, v.height, v.duration_seconds,
+	v.uploaded_by, COALESCE(e.full_name, '') as uploaded_by_name, v.uploaded_at,
+	v.replaced_by, v.replaced_at, v.scan_status, v.scan_signature, v.scanned_at
+	FROM media_versions v
+	LEFT JOIN employees e ON v.uploaded_by = e.id
+`
+
+func scanMediaVersion(row pgx.Row, v *models.MediaVersion) error {
+	return row.Scan(
+		&v.MediaID, &v.Version, &v.StorageKey, &v.MimeType, &v.FileSizeBytes,
+		&v.Width, &v.Height, &v.DurationSeconds,
+		&v.UploadedBy, &v.UploadedByName, &v.UploadedAt,
+		&v.ReplacedBy, &v.ReplacedAt, &v.ScanStatus, &v.ScanSignature, &v.ScannedAt,
+	)
+}
+
+// ListMediaVersions lists the previous versions of a media item, newest first
+func (r *Repository) ListMediaVersions(ctx context.Context, mediaID uuid.UUID) ([]models.MediaVersion, error) {
+	query := `SELECT ` + mediaVersionColumns + ` WHERE v.media_id = $1 ORDER BY v.version_number DESC`
+	return r.queryMediaVersions(ctx, query, mediaID)
+}
+
+// queryMediaVersions runs a query selecting mediaVersionColumns
+func (r *Repository) queryMediaVersions(ctx context.Context, query string, args ...any) ([]models.MediaVersion, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := make([]models.MediaVersion, 0)
+	for rows.Next() {
+		var v models.MediaVersion
+		if err := scanMediaVersion(rows, &v); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+// GetMediaVersion retrieves a previous version of a media item
+func (r *Repository) GetMediaVersion(ctx context.Context, mediaID uuid.UUID, version int) (*models.MediaVersion, error) {
+	query := `SELECT ` + mediaVersionColumns + ` WHERE v.media_id = $1 AND v.version_number = $2`
+	var v models.MediaVersion
+	err := scanMediaVersion(r.db.QueryRow(ctx, query, mediaID, version), &v)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return &v, err
+}
+
+// ClaimMediaVersion reserves the next version of a media item for one
+// upload until expiresAt. It returns ErrNotFound if the current version is no
+// longer currentVersion or another upload holds the claim.
+func (r *Repository) ClaimMediaVersion(ctx context.Context, mediaID uuid.UUID, currentVersion int, claimID uuid.UUID, expiresAt time.Time) error {
+	query := `
+		UPDATE media SET version_claim_id = $3, version_claim_expires_at = $4
+		WHERE id = $1 AND current_version = $2 AND deleted_at IS NULL
+			AND (version_claim_id IS NULL OR version_claim_expires_at < NOW())
+	`
+	tag, err := r.db.Exec(ctx, query, mediaID, currentVersion, claimID, expiresAt)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ReleaseMediaVersionClaim gives up a claim on the next version
+func (r *Repository) ReleaseMediaVersionClaim(ctx context.Context, mediaID, claimID uuid.UUID) error {
+	query := `
+		UPDATE media SET version_claim_id = NULL, version_claim_expires_at = NULL
+		WHERE id = $1 AND version_claim_id = $2
+	`
+	_, err := r.db.Exec(ctx, query, mediaID, claimID)
+	return err
+}
+
+// ReplaceMediaVersion archives the current version of a media item under
+// archiveKey, with its scan verdict, makes next the current version and
+// releases the claim. It returns ErrNotFound if the claim is no longer held.
+func (r *Repository) ReplaceMediaVersion(ctx context.Context, mediaID, claimID uuid.UUID, archiveKey string, next *models.MediaVersion, publicURL *string) error {
+	query := `
+		WITH archived AS (
+			INSERT INTO media_versions (
+				media_id, version_number, storage_key, mime_type, file_size_bytes,
+				width, height, duration_seconds, uploaded_by, uploaded_at, replaced_by,
+				scan_status, scan_signature, scanned_at
+			)
+			SELECT id, current_version, $3, mime_type, file_size_bytes,
+				width, height, duration_seconds,
+				COALESCE(version_uploaded_by, uploaded_by), COALESCE(version_uploaded_at, created_at), $4,
+				scan_status, scan_signature, scanned_at
+			FROM media
+			WHERE id = $1 AND version_claim_id = $2 AND deleted_at IS NULL
+			RETURNING media_id
+		)
+		UPDATE media SET
+			current_version = current_version + 1,
+			version_uploaded_by = $4, version_uploaded_at = NOW(),
+			version_claim_id = NULL, version_claim_expires_at = NULL,
+			mime_type = $5, file_size_bytes = $6,
+			width = $7, height = $8, duration_seconds = $9,
+			public_url = $10, scan_status = $11::scan_status, scan_signature = NULL,
+			scanned_at = CASE WHEN $11::scan_status = 'clean' THEN NOW() END,
+			updated_at = NOW()
+		WHERE id IN (SELECT media_id FROM archived)
+	`
+	tag, err := r.db.Exec(ctx, query,
+		mediaID, claimID, archiveKey, next.UploadedBy,
+		next.MimeType, next.FileSizeBytes,
+		next.Width, next.Height, next.DurationSeconds,
+		publicURL, next.ScanStatus,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// UpdateMediaVersionScanResult records the antivirus verdict for a previous
+// version of a media item
+func (r *Repository) UpdateMediaVersionScanResult(ctx context.Context, mediaID uuid.UUID, version int, status models.ScanStatus, signature *string) error {
+	query := `
+		UPDATE media_versions SET scan_status = $3, scan_signature = $4, scanned_at = NOW()
+		WHERE media_id = $1 AND version_number = $2
+	`
+	_, err := r.db.Exec(ctx, query, mediaID, version, status, signature)
+	return err
+}
+
+// SetMediaVersionStorageKey records where the object of a previous version
+// was moved to
+func (r *Repository) SetMediaVersionStorageKey(ctx context.Context, mediaID uuid.UUID, version int, storageKey string) error {
+	query := `UPDATE media_versions SET storage_key = $3 WHERE media_id = $1 AND version_number = $2`
+	_, err := r.db.Exec(ctx, query, mediaID, version, storageKey)
+	return err
+}
+
+// ListMediaVersionsAwaitingScan lists previous versions whose scan is still
+// pending or failed, and has not been attempted since before
+func (r *Repository) ListMediaVersionsAwaitingScan(ctx context.Context, before time.Time, limit int) ([]models.MediaVersion, error) {
+	query := `SELECT ` + mediaVersionColumns + `
+		JOIN media m ON m.id = v.media_id
+		WHERE v.scan_status IN ('pending', 'error') AND m.deleted_at IS NULL
+			AND COALESCE(v.scanned_at, v.replaced_at) < $1
+		ORDER BY v.replaced_at
+		LIMIT $2
+	`
+	return r.queryMediaVersions(ctx, query, before, limit)
+}
+
+// ListUnmovedQuarantinedVersions lists infected previous versions whose
+// object could not be moved into quarantine. Held media is never moved.
+func (r *Repository) ListUnmovedQuarantinedVersions(ctx context.Context, limit int) ([]models.MediaVersion, error) {
+	query := `SELECT ` + mediaVersionColumns + `
+		JOIN media m ON m.id = v.media_id
+		WHERE v.scan_status = 'infected' AND m.deleted_at IS NULL AND m.legal_hold = false
+			AND v.storage_key NOT LIKE 'quarantine/%'
+		ORDER BY v.scanned_at
+		LIMIT $1
+	`
+	return r.queryMediaVersions(ctx, query, limit)
+}
+
+// SkipPendingVersionScans marks previous versions awaiting a scan as
+// skipped, for when antivirus scanning is disabled
+func (r *Repository) SkipPendingVersionScans(ctx context.Context) error {
+	query := `UPDATE media_versions SET scan_status = 'skipped' WHERE scan_status IN ('pending', 'error')`
+	_, err := r.db.Exec(ctx, query)
+	return err
+}
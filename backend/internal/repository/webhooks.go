@@ -0,0 +1,263 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ==========================================
+// Webhook Methods
+// ==========================================
+
+const webhookEndpointColumns = `
+	id, name, url, encrypted_secret, secret_nonce, event_types,
+	is_active, created_by, created_at, updated_at
+`
+
+func scanWebhookEndpoint(row pgx.Row, w *models.WebhookEndpoint) error {
+	return row.Scan(
+		&w.ID, &w.Name, &w.URL, &w.EncryptedSecret, &w.SecretNonce, &w.EventTypes,
+		&w.IsActive, &w.CreatedBy, &w.CreatedAt, &w.UpdatedAt,
+	)
+}
+
+const webhookDeliveryColumns = `
+	id, endpoint_id, event_id, event_type, payload, replay_of,
+	status, attempts, next_attempt_at, last_attempt_at,
+	response_status, response_body, last_error, created_at, delivered_at
+`
+
+func scanWebhookDelivery(row pgx.Row, d *models.WebhookDelivery) error {
+	return row.Scan(
+		&d.ID, &d.EndpointID, &d.EventID, &d.EventType, &d.Payload, &d.ReplayOf,
+		&d.Status, &d.Attempts, &d.NextAttemptAt, &d.LastAttemptAt,
+		&d.ResponseStatus, &d.ResponseBody, &d.LastError, &d.CreatedAt, &d.DeliveredAt,
+	)
+}
+
+// CreateWebhookEndpoint creates a new webhook endpoint
+func (r *Repository) CreateWebhookEndpoint(ctx context.Context, w *models.WebhookEndpoint) error {
+	query := `
+		INSERT INTO webhook_endpoints (
+			id, name, url, encrypted_secret, secret_nonce, event_types,
+			is_active, created_by, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	w.ID = uuid.New()
+	w.CreatedAt = time.Now()
+	w.UpdatedAt = time.Now()
+	if w.EventTypes == nil {
+		w.EventTypes = []string{}
+	}
+
+	_, err := r.db.Exec(ctx, query,
+		w.ID, w.Name, w.URL, w.EncryptedSecret, w.SecretNonce, w.EventTypes,
+		w.IsActive, w.CreatedBy, w.CreatedAt, w.UpdatedAt,
+	)
+	return err
+}
+
+// GetWebhookEndpointByID retrieves a webhook endpoint that is not deleted
+func (r *Repository) GetWebhookEndpointByID(ctx context.Context, id uuid.UUID) (*models.WebhookEndpoint, error) {
+	query := `SELECT ` + webhookEndpointColumns + ` FROM webhook_endpoints WHERE id = $1 AND deleted_at IS NULL`
+	var w models.WebhookEndpoint
+	err := scanWebhookEndpoint(r.db.QueryRow(ctx, query, id), &w)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return &w, err
+}
+
+// ListWebhookEndpoints lists webhook endpoints that are not deleted
+func (r *Repository) ListWebhookEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error) {
+	query := `SELECT ` + webhookEndpointColumns + ` FROM webhook_endpoints WHERE deleted_at IS NULL ORDER BY created_at DESC`
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	endpoints := make([]models.WebhookEndpoint, 0)
+	for rows.Next() {
+		var w models.WebhookEndpoint
+		if err := scanWebhookEndpoint(rows, &w); err != nil {
+			return nil, err
+		}
+		endpoints = append(endpoints, w)
+	}
+	return endpoints, rows.Err()
+}
+
+// UpdateWebhookEndpoint updates a webhook endpoint's settings and secret
+func (r *Repository) UpdateWebhookEndpoint(ctx context.Context, w *models.WebhookEndpoint) error {
+	query := `
+		UPDATE webhook_endpoints SET
+			name = $2, url = $3, encrypted_secret = $4, secret_nonce = $5,
+			event_types = $6, is_active = $7, updated_at = $8
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+	w.UpdatedAt = time.Now()
+	tag, err := r.db.Exec(ctx, query,
+		w.ID, w.Name, w.URL, w.EncryptedSecret, w.SecretNonce,
+		w.EventTypes, w.IsActive, w.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SoftDeleteWebhookEndpoint deletes a webhook endpoint and drops its
+// undelivered events; the delivery log is kept
+func (r *Repository) SoftDeleteWebhookEndpoint(ctx context.Context, id uuid.UUID) error {
+	query := `
+		WITH deleted AS (
+			UPDATE webhook_endpoints SET deleted_at = NOW(), is_active = false
+			WHERE id = $1 AND deleted_at IS NULL
+			RETURNING id
+		), dropped AS (
+			UPDATE webhook_deliveries SET status = 'failed', last_error = 'endpoint deleted'
+			WHERE endpoint_id IN (SELECT id FROM deleted) AND status = 'pending'
+		)
+		SELECT COUNT(*) FROM deleted
+	`
+	var deleted int
+	if err := r.db.QueryRow(ctx, query, id).Scan(&deleted); err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// EnqueueWebhookEvent queues an event for every active endpoint subscribed
+// to its type and returns how many deliveries were queued
+func (r *Repository) EnqueueWebhookEvent(ctx context.Context, eventID uuid.UUID, eventType models.WebhookEventType, payload []byte) (int64, error) {
+	query := `
+		INSERT INTO webhook_deliveries (id, endpoint_id, event_id, event_type, payload)
+		SELECT uuid_generate_v4(), id, $1, $2, $3
+		FROM webhook_endpoints
+		WHERE deleted_at IS NULL AND is_active = true
+			AND (event_types = '{}' OR $2 = ANY(event_types))
+	`
+	tag, err := r.db.Exec(ctx, query, eventID, string(eventType), payload)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// CreateWebhookDelivery queues a single delivery, for test pings and replays
+func (r *Repository) CreateWebhookDelivery(ctx context.Context, d *models.WebhookDelivery) error {
+	query := `
+		INSERT INTO webhook_deliveries (id, endpoint_id, event_id, event_type, payload, replay_of, status, next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, 'pending', $7, $7)
+	`
+	d.ID = uuid.New()
+	d.Status = models.WebhookDeliveryPending
+	d.CreatedAt = time.Now()
+	d.NextAttemptAt = d.CreatedAt
+
+	_, err := r.db.Exec(ctx, query,
+		d.ID, d.EndpointID, d.EventID, string(d.EventType), []byte(d.Payload), d.ReplayOf, d.CreatedAt,
+	)
+	return err
+}
+
+// ClaimDueWebhookDeliveries picks up to limit pending deliveries that are
+// due and leases them by pushing their next attempt out, so concurrent
+// workers never send the same delivery twice
+func (r *Repository) ClaimDueWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	query := `
+		UPDATE webhook_deliveries SET next_attempt_at = NOW() + $2 * INTERVAL '1 second'
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + webhookDeliveryColumns
+	rows, err := r.db.Query(ctx, query, limit, int(lease.Seconds()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := make([]models.WebhookDelivery, 0)
+	for rows.Next() {
+		var d models.WebhookDelivery
+		if err := scanWebhookDelivery(rows, &d); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// RecordWebhookAttempt stores the outcome of a delivery attempt. A pending
+// status schedules another attempt at NextAttemptAt.
+func (r *Repository) RecordWebhookAttempt(ctx context.Context, d *models.WebhookDelivery) error {
+	query := `
+		UPDATE webhook_deliveries SET
+			status = $2, attempts = $3, next_attempt_at = $4, last_attempt_at = $5,
+			response_status = $6, response_body = $7, last_error = $8, delivered_at = $9
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(ctx, query,
+		d.ID, d.Status, d.Attempts, d.NextAttemptAt, d.LastAttemptAt,
+		d.ResponseStatus, d.ResponseBody, d.LastError, d.DeliveredAt,
+	)
+	return err
+}
+
+// GetWebhookDeliveryByID retrieves a webhook delivery by ID
+func (r *Repository) GetWebhookDeliveryByID(ctx context.Context, id uuid.UUID) (*models.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE id = $1`
+	var d models.WebhookDelivery
+	err := scanWebhookDelivery(r.db.QueryRow(ctx, query, id), &d)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return &d, err
+}
+
+// ListWebhookDeliveries lists deliveries to an endpoint, newest first,
+// optionally filtered by status
+func (r *Repository) ListWebhookDeliveries(ctx context.Context, endpointID uuid.UUID, status *models.WebhookDeliveryStatus, page, pageSize int) ([]models.WebhookDelivery, int64, error) {
+	where := ` WHERE endpoint_id = $1 AND ($2::webhook_delivery_status IS NULL OR status = $2)`
+
+	var total int64
+	if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM webhook_deliveries`+where, endpointID, status).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries` + where + `
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4
+	`
+	rows, err := r.db.Query(ctx, query, endpointID, status, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	deliveries := make([]models.WebhookDelivery, 0)
+	for rows.Next() {
+		var d models.WebhookDelivery
+		if err := scanWebhookDelivery(rows, &d); err != nil {
+			return nil, 0, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, total, rows.Err()
+}
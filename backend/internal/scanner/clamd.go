@@ -0,0 +1,170 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrScanFailed is returned when clamd could not complete a scan
+	ErrScanFailed = errors.New("antivirus scan failed")
+	// ErrStreamTooLarge is returned when the stream exceeds clamd's StreamMaxLength
+	ErrStreamTooLarge = errors.New("file exceeds antivirus stream size limit")
+)
+
+// defaultChunkSize is the size of each INSTREAM chunk sent to clamd
+const defaultChunkSize = 64 * 1024
+
+// Result contains the verdict of a scan
+type Result struct {
+	Infected  bool   `json:"infected"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// ClamdScanner streams files to a clamd daemon over TCP using the INSTREAM command
+type ClamdScanner struct {
+	address   string
+	timeout   time.Duration
+	chunkSize int
+}
+
+// NewClamdScanner creates a scanner for the clamd daemon at address (host:port)
+func NewClamdScanner(address string, timeout time.Duration) *ClamdScanner {
+	return &ClamdScanner{
+		address:   address,
+		timeout:   timeout,
+		chunkSize: defaultChunkSize,
+	}
+}
+
+// Ping checks that clamd is reachable
+func (s *ClamdScanner) Ping(ctx context.Context) error {
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("zPING\x00")); err != nil {
+		return fmt.Errorf("%w: %v", ErrScanFailed, err)
+	}
+
+	reply, err := readReply(conn)
+	if err != nil {
+		return err
+	}
+	if reply != "PONG" {
+		return fmt.Errorf("%w: unexpected reply %q", ErrScanFailed, reply)
+	}
+	return nil
+}
+
+// Scan streams r to clamd and returns the verdict
+func (s *ClamdScanner) Scan(ctx context.Context, r io.Reader) (*Result, error) {
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrScanFailed, err)
+	}
+
+	// Each chunk is prefixed with its length as a 4-byte big-endian integer,
+	// and a zero-length chunk terminates the stream
+	buf := make([]byte, s.chunkSize)
+	size := make([]byte, 4)
+	for {
+		n, readErr := r.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, err := conn.Write(size); err != nil {
+				return nil, s.writeError(conn, err)
+			}
+			if _, err := conn.Write(buf[:n]); err != nil {
+				return nil, s.writeError(conn, err)
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return nil, fmt.Errorf("%w: failed to read file: %v", ErrScanFailed, readErr)
+		}
+	}
+
+	binary.BigEndian.PutUint32(size, 0)
+	if _, err := conn.Write(size); err != nil {
+		return nil, s.writeError(conn, err)
+	}
+
+	reply, err := readReply(conn)
+	if err != nil {
+		return nil, err
+	}
+	return parseReply(reply)
+}
+
+// dial opens a connection to clamd bounded by the scanner timeout
+func (s *ClamdScanner) dial(ctx context.Context) (net.Conn, error) {
+	dialer := net.Dialer{Timeout: s.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", s.address)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrScanFailed, err)
+	}
+
+	deadline := time.Now().Add(s.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+	return conn, nil
+}
+
+// writeError reports a failed write. clamd closes the connection once the
+// stream limit is reached, so try to read the reason it sent first.
+func (s *ClamdScanner) writeError(conn net.Conn, err error) error {
+	if reply, readErr := readReply(conn); readErr == nil {
+		if _, parseErr := parseReply(reply); parseErr != nil {
+			return parseErr
+		}
+	}
+	return fmt.Errorf("%w: %v", ErrScanFailed, err)
+}
+
+// readReply reads a single null-terminated reply from clamd
+func readReply(conn net.Conn) (string, error) {
+	reply, err := bufio.NewReader(conn).ReadString('\x00')
+	if err != nil && reply == "" {
+		return "", fmt.Errorf("%w: %v", ErrScanFailed, err)
+	}
+	return strings.TrimSpace(strings.TrimRight(reply, "\x00")), nil
+}
+
+// parseReply interprets an INSTREAM reply such as
+// "stream: OK" or "stream: Eicar-Test-Signature FOUND"
+func parseReply(reply string) (*Result, error) {
+	body := strings.TrimPrefix(reply, "stream: ")
+
+	switch {
+	case body == "OK":
+		return &Result{Infected: false}, nil
+	case strings.HasSuffix(body, " FOUND"):
+		return &Result{
+			Infected:  true,
+			Signature: strings.TrimSuffix(body, " FOUND"),
+		}, nil
+	case strings.Contains(body, "size limit exceeded"):
+		return nil, ErrStreamTooLarge
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrScanFailed, body)
+	}
+}
@@ -0,0 +1,323 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	// activityBufferSize is how many events a slow client may fall behind
+	// before further events are dropped for it
+	activityBufferSize = 64
+
+	// activityMaxPayload keeps notifications under the 8000 byte NOTIFY limit
+	activityMaxPayload = 7900
+
+	// activityReconnectDelay is how long to wait before listening again after
+	// the listening connection fails
+	activityReconnectDelay = 5 * time.Second
+)
+
+// activityEventTypes maps audited actions to the live event they produce.
+// Actions missing here are not streamed.
+var activityEventTypes = map[string]map[models.AuditAction]string{
+	"media": {
+		models.AuditActionUpload:     "media.uploaded",
+		models.AuditActionUpdate:     "media.updated",
+		models.AuditActionMove:       "media.moved",
+		models.AuditActionDelete:     "media.deleted",
+		models.AuditActionRestore:    "media.restored",
+		models.AuditActionPurge:      "media.purged",
+		models.AuditActionExpire:     "media.expired",
+		models.AuditActionReview:     "media.reviewed",
+		models.AuditActionQuarantine: "media.quarantined",
+		models.AuditActionRelease:    "media.released",
+	},
+	"storage_account": {
+		models.AuditActionCreate: "storage_account.created",
+		models.AuditActionUpdate: "storage_account.updated",
+		models.AuditActionDelete: "storage_account.deleted",
+	},
+}
+
+// activityNotification is what travels over NOTIFY: the event plus who may
+// see it
+type activityNotification struct {
+	Event        models.ActivityEvent `json:"event"`
+	AllowedRoles []models.Role        `json:"allowed_roles,omitempty"`
+	UploadedBy   *uuid.UUID           `json:"uploaded_by,omitempty"`
+	Restricted   bool                 `json:"restricted,omitempty"`
+}
+
+// ActivityHub fans live activity events out to connected clients. Events are
+// published through Postgres NOTIFY and received with LISTEN, so a client
+// sees events raised on any replica.
+type ActivityHub struct {
+	repo *repository.Repository
+
+	mu          sync.RWMutex
+	subscribers map[*ActivitySubscription]struct{}
+	done        chan struct{}
+}
+
+// ActivitySubscription is one client's view of the activity stream
+type ActivitySubscription struct {
+	hub    *ActivityHub
+	events chan models.ActivityEvent
+
+	// mu guards what the employee may see, which Refresh replaces while
+	// events are dispatched
+	mu              sync.RWMutex
+	employee        *models.Employee
+	storageAccounts map[uuid.UUID]bool // Accounts visible to the employee when last resolved
+}
+
+// NewActivityHub creates an activity hub; Run must be started for it to
+// deliver events
+func NewActivityHub(repo *repository.Repository) *ActivityHub {
+	return &ActivityHub{
+		repo:        repo,
+		subscribers: make(map[*ActivitySubscription]struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+// Run listens for activity events until ctx is cancelled, reconnecting when
+// the listening connection fails. Open streams are ended when it returns.
+func (h *ActivityHub) Run(ctx context.Context) {
+	defer close(h.done)
+
+	for {
+		err := h.repo.ListenActivity(ctx, h.dispatch)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("[ActivityHub] listen failed, retrying in %s: %v", activityReconnectDelay, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(activityReconnectDelay):
+		}
+	}
+}
+
+// Subscribe opens a stream of the events the employee is allowed to see
+func (h *ActivityHub) Subscribe(ctx context.Context, employee *models.Employee) (*ActivitySubscription, error) {
+	sub := &ActivitySubscription{
+		hub:    h,
+		events: make(chan models.ActivityEvent, activityBufferSize),
+	}
+	if err := sub.Refresh(ctx, employee); err != nil {
+		return nil, err
+	}
+
+	h.mu.Lock()
+	h.subscribers[sub] = struct{}{}
+	h.mu.Unlock()
+
+	return sub, nil
+}
+
+// Refresh replaces the employee the subscription filters events for, and
+// lists the storage accounts they can see again, so that revoked grants and
+// role changes apply to a stream that is already open
+func (sub *ActivitySubscription) Refresh(ctx context.Context, employee *models.Employee) error {
+	var visible map[uuid.UUID]bool
+	if !employee.Can(models.PermStorageViewAll) {
+		accounts, err := sub.hub.repo.ListStorageAccounts(ctx, &employee.ID)
+		if err != nil {
+			return err
+		}
+		visible = make(map[uuid.UUID]bool, len(accounts))
+		for _, account := range accounts {
+			visible[account.ID] = true
+		}
+	}
+
+	sub.mu.Lock()
+	sub.employee = employee
+	sub.storageAccounts = visible
+	sub.mu.Unlock()
+	return nil
+}
+
+// EmployeeID returns the employee the subscription belongs to
+func (sub *ActivitySubscription) EmployeeID() uuid.UUID {
+	sub.mu.RLock()
+	defer sub.mu.RUnlock()
+	return sub.employee.ID
+}
+
+// Events delivers the subscriber's events
+func (sub *ActivitySubscription) Events() <-chan models.ActivityEvent {
+	return sub.events
+}
+
+// Done is closed when the hub shuts down
+func (sub *ActivitySubscription) Done() <-chan struct{} {
+	return sub.hub.done
+}
+
+// Close ends the subscription
+func (sub *ActivitySubscription) Close() {
+	sub.hub.mu.Lock()
+	delete(sub.hub.subscribers, sub)
+	sub.hub.mu.Unlock()
+}
+
+// PublishAudit turns an audit entry into a live event when its action is one
+// clients follow. It is safe to call on a nil hub.
+func (h *ActivityHub) PublishAudit(ctx context.Context, entry *models.AuditLog) {
+	if h == nil {
+		return
+	}
+	eventType, ok := activityEventTypes[entry.ResourceType][entry.Action]
+	if !ok {
+		return
+	}
+
+	n := &activityNotification{
+		Event: models.ActivityEvent{
+			ID:            entry.ID,
+			Type:          eventType,
+			ResourceType:  entry.ResourceType,
+			ResourceID:    entry.ResourceID,
+			EmployeeID:    entry.EmployeeID,
+			EmployeeEmail: entry.EmployeeEmail,
+			Details:       entry.Details,
+			CreatedAt:     entry.CreatedAt,
+		},
+	}
+
+	if entry.ResourceID != nil {
+		switch entry.ResourceType {
+		case "media":
+			audience, err := h.repo.GetMediaAudience(ctx, *entry.ResourceID)
+			if err != nil {
+				// Without knowing the audience only admins get the event
+				n.Restricted = true
+				break
+			}
+			n.Event.MediaGroupID = audience.MediaGroupID
+			n.Event.StorageAccountID = &audience.StorageAccountID
+			n.AllowedRoles = audience.AllowedRoles
+			n.UploadedBy = &audience.UploadedBy
+			n.Restricted = audience.Restricted
+		case "storage_account":
+			n.Event.StorageAccountID = entry.ResourceID
+		}
+	}
+
+	h.notify(ctx, n)
+}
+
+// PublishSyncProgress announces progress of a storage account sync. Pass
+// done once the sync has finished.
+func (h *ActivityHub) PublishSyncProgress(ctx context.Context, storageAccountID, employeeID uuid.UUID, result *models.SyncResult, done bool) {
+	if h == nil {
+		return
+	}
+
+	eventType := "storage_account.sync_progress"
+	if done {
+		eventType = "storage_account.sync_completed"
+	}
+
+	h.notify(ctx, &activityNotification{
+		Event: models.ActivityEvent{
+			ID:               uuid.New(),
+			Type:             eventType,
+			ResourceType:     "storage_account",
+			ResourceID:       &storageAccountID,
+			EmployeeID:       employeeID,
+			StorageAccountID: &storageAccountID,
+			Details: map[string]any{
+				"added_count":   result.AddedCount,
+				"skipped_count": result.SkippedCount,
+				"error_count":   len(result.Errors),
+			},
+			CreatedAt: time.Now(),
+		},
+	})
+}
+
+// notify publishes a notification to every replica
+func (h *ActivityHub) notify(ctx context.Context, n *activityNotification) {
+	payload, err := json.Marshal(n)
+	if err == nil && len(payload) > activityMaxPayload {
+		// Details are optional; drop them rather than the event
+		n.Event.Details = nil
+		payload, err = json.Marshal(n)
+	}
+	if err != nil {
+		log.Printf("[ActivityHub] failed to encode %s event: %v", n.Event.Type, err)
+		return
+	}
+
+	if err := h.repo.NotifyActivity(ctx, string(payload)); err != nil {
+		log.Printf("[ActivityHub] failed to publish %s event: %v", n.Event.Type, err)
+	}
+}
+
+// dispatch hands a received notification to every subscriber allowed to
+// see it. Subscribers that fall behind miss events rather than block others.
+func (h *ActivityHub) dispatch(payload string) {
+	var n activityNotification
+	if err := json.Unmarshal([]byte(payload), &n); err != nil {
+		log.Printf("[ActivityHub] ignoring malformed notification: %v", err)
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for sub := range h.subscribers {
+		if !sub.canSee(&n) {
+			continue
+		}
+		select {
+		case sub.events <- n.Event:
+		default:
+		}
+	}
+}
+
+// canSee applies the visibility rules of the media library to an event
+func (sub *ActivitySubscription) canSee(n *activityNotification) bool {
+	sub.mu.RLock()
+	defer sub.mu.RUnlock()
+
+	if sub.employee.Can(models.PermStorageViewAll) {
+		return true
+	}
+
+	if n.Event.ResourceType == "storage_account" {
+		return n.Event.StorageAccountID != nil && sub.storageAccounts[*n.Event.StorageAccountID]
+	}
+
+	if n.Restricted {
+		return n.UploadedBy != nil && *n.UploadedBy == sub.employee.ID
+	}
+	if n.UploadedBy != nil && *n.UploadedBy == sub.employee.ID {
+		return true
+	}
+	if n.Event.StorageAccountID != nil && !sub.storageAccounts[*n.Event.StorageAccountID] {
+		return false
+	}
+	if len(n.AllowedRoles) == 0 {
+		return true
+	}
+	for _, role := range n.AllowedRoles {
+		if role == sub.employee.Role {
+			return true
+		}
+	}
+	return false
+}
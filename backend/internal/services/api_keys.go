@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/appnity/media-vault/internal/crypto"
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrServiceAccountNotFound = errors.New("service account not found")
+	ErrAPIKeyNotFound         = errors.New("API key not found")
+	ErrOutsideKeyLimits       = errors.New("API key is not allowed to access this storage account or group")
+)
+
+// APIKeyPrefix starts every API key, telling keys apart from JWTs
+const APIKeyPrefix = "mvk_"
+
+// apiKeyDisplayLength is how much of a key is kept in clear for listings
+const apiKeyDisplayLength = len(APIKeyPrefix) + 8
+
+// IsAPIKey reports whether a bearer credential is an API key
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, APIKeyPrefix)
+}
+
+// CreateServiceAccount creates a service account. It gets a placeholder
+// email and no usable password, so it can only authenticate with API keys.
+// Its role may only hold permissions the caller holds.
+func (s *AuthService) CreateServiceAccount(ctx context.Context, req *models.CreateServiceAccountRequest, caller *models.Employee) (*models.Employee, error) {
+	if err := s.checkAssignable(ctx, caller, req.Role, nil); err != nil {
+		return nil, err
+	}
+
+	suffix, err := crypto.GenerateRandomString(6)
+	if err != nil {
+		return nil, err
+	}
+
+	account := &models.Employee{
+		Email:            "svc-" + suffix + "@service-accounts.invalid",
+		PasswordHash:     "!",
+		FullName:         req.Name,
+		Role:             req.Role,
+		IsActive:         true,
+		IsServiceAccount: true,
+	}
+	if err := s.repo.CreateEmployee(ctx, account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// ListServiceAccounts lists service accounts
+func (s *AuthService) ListServiceAccounts(ctx context.Context) ([]models.Employee, error) {
+	return s.repo.ListServiceAccounts(ctx)
+}
+
+// DeleteServiceAccount revokes a service account's keys and deletes it
+func (s *AuthService) DeleteServiceAccount(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.serviceAccount(ctx, id); err != nil {
+		return err
+	}
+	if err := s.repo.RevokeServiceAccountAPIKeys(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.SoftDeleteEmployee(ctx, id)
+}
+
+// CreateAPIKey issues an API key to a service account. The plaintext key is
+// only returned here; the database stores its hash.
+func (s *AuthService) CreateAPIKey(ctx context.Context, serviceAccountID uuid.UUID, req *models.CreateAPIKeyRequest, createdBy uuid.UUID) (*models.APIKey, error) {
+	if _, err := s.serviceAccount(ctx, serviceAccountID); err != nil {
+		return nil, err
+	}
+
+	scopes, err := normalizeScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("expires_at must be in the future")
+	}
+
+	random, err := crypto.GenerateRandomString(32)
+	if err != nil {
+		return nil, err
+	}
+	plaintext := APIKeyPrefix + random
+
+	key := &models.APIKey{
+		ServiceAccountID:  serviceAccountID,
+		Name:              req.Name,
+		KeyPrefix:         plaintext[:apiKeyDisplayLength],
+		KeyHash:           crypto.HashToken(plaintext),
+		Scopes:            scopes,
+		StorageAccountIDs: req.StorageAccountIDs,
+		MediaGroupIDs:     req.MediaGroupIDs,
+		ExpiresAt:         req.ExpiresAt,
+		CreatedBy:         &createdBy,
+	}
+	if err := s.repo.CreateAPIKey(ctx, key); err != nil {
+		return nil, err
+	}
+
+	key.Key = plaintext
+	return key, nil
+}
+
+// ListAPIKeys lists a service account's keys, including revoked ones
+func (s *AuthService) ListAPIKeys(ctx context.Context, serviceAccountID uuid.UUID) ([]models.APIKey, error) {
+	if _, err := s.serviceAccount(ctx, serviceAccountID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListAPIKeys(ctx, serviceAccountID)
+}
+
+// RevokeAPIKey revokes a service account's key
+func (s *AuthService) RevokeAPIKey(ctx context.Context, serviceAccountID, keyID uuid.UUID) error {
+	err := s.repo.RevokeAPIKey(ctx, serviceAccountID, keyID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrAPIKeyNotFound
+	}
+	return err
+}
+
+// AuthenticateAPIKey resolves an API key to its service account and records
+// the use. The returned employee carries the key's limits.
+func (s *AuthService) AuthenticateAPIKey(ctx context.Context, plaintext, ipAddress string) (*models.Employee, *models.APIKey, error) {
+	key, err := s.repo.GetAPIKeyByHash(ctx, crypto.HashToken(plaintext))
+	if err != nil {
+		return nil, nil, ErrInvalidToken
+	}
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && time.Now().After(*key.ExpiresAt)) {
+		return nil, nil, ErrInvalidToken
+	}
+
+	account, err := s.repo.GetEmployeeByID(ctx, key.ServiceAccountID)
+	if err != nil || !account.IsServiceAccount {
+		return nil, nil, ErrInvalidToken
+	}
+	if !account.IsActive {
+		return nil, nil, ErrAccountDisabled
+	}
+
+	var ip *string
+	if ipAddress != "" {
+		ip = &ipAddress
+	}
+	if err := s.repo.TouchAPIKey(ctx, key.ID, ip); err != nil {
+		log.Printf("[AuthService] failed to record use of API key %s: %v", key.ID, err)
+	}
+
+	account.KeyLimits = key.Limits()
+	return account, key, nil
+}
+
+// serviceAccount loads an employee that must be a service account
+func (s *AuthService) serviceAccount(ctx context.Context, id uuid.UUID) (*models.Employee, error) {
+	account, err := s.repo.GetEmployeeByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && !account.IsServiceAccount) {
+		return nil, ErrServiceAccountNotFound
+	}
+	return account, err
+}
+
+// normalizeScopes validates scopes and drops duplicates
+func normalizeScopes(requested []string) ([]string, error) {
+	valid := make(map[string]bool, len(models.APIKeyScopes))
+	for _, scope := range models.APIKeyScopes {
+		valid[scope] = true
+	}
+
+	scopes := make([]string, 0, len(requested))
+	seen := make(map[string]bool, len(requested))
+	for _, scope := range requested {
+		if !valid[scope] {
+			return nil, fmt.Errorf("unknown scope %q", scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, nil
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/testdb"
+)
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	repo, pool := testdb.Open(t)
+	ctx := context.Background()
+	s, err := NewAuthService(repo, tokenTestConfig(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin := testdb.Employee(t, repo, models.RoleAdmin)
+	admin.Permissions = models.BuiltinRolePermissions[models.RoleAdmin]
+
+	account, err := s.CreateServiceAccount(ctx, &models.CreateServiceAccountRequest{Name: "ci", Role: models.RoleDeveloper}, admin)
+	if err != nil {
+		t.Fatalf("create service account: %v", err)
+	}
+	newKey := func(name string) *models.APIKey {
+		t.Helper()
+		expires := time.Now().Add(time.Hour)
+		key, err := s.CreateAPIKey(ctx, account.ID, &models.CreateAPIKeyRequest{
+			Name: name, Scopes: []string{models.ScopeMediaRead}, ExpiresAt: &expires,
+		}, admin.ID)
+		if err != nil {
+			t.Fatalf("create API key: %v", err)
+		}
+		return key
+	}
+
+	live := newKey("live")
+	authenticated, key, err := s.AuthenticateAPIKey(ctx, live.Key, "192.0.2.10")
+	if err != nil {
+		t.Fatalf("live key: %v", err)
+	}
+	if authenticated.ID != account.ID || key.ID != live.ID {
+		t.Fatalf("authenticated %s with key %s, want %s with %s", authenticated.ID, key.ID, account.ID, live.ID)
+	}
+	if _, _, err := s.AuthenticateAPIKey(ctx, live.Key+"x", ""); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("unknown key: got %v, want %v", err, ErrInvalidToken)
+	}
+
+	expired := newKey("expired")
+	if _, err := pool.Exec(ctx, `UPDATE api_keys SET expires_at = NOW() - INTERVAL '1 minute' WHERE id = $1`, expired.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.AuthenticateAPIKey(ctx, expired.Key, ""); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expired key: got %v, want %v", err, ErrInvalidToken)
+	}
+
+	revoked := newKey("revoked")
+	if err := s.RevokeAPIKey(ctx, account.ID, revoked.ID); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if _, _, err := s.AuthenticateAPIKey(ctx, revoked.Key, ""); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("revoked key: got %v, want %v", err, ErrInvalidToken)
+	}
+
+	inactive := false
+	if _, err := s.UpdateEmployee(ctx, account.ID, &models.UpdateEmployeeRequest{IsActive: &inactive}, admin); err != nil {
+		t.Fatalf("deactivate: %v", err)
+	}
+	if _, _, err := s.AuthenticateAPIKey(ctx, live.Key, ""); !errors.Is(err, ErrAccountDisabled) {
+		t.Errorf("key of a deactivated account: got %v, want %v", err, ErrAccountDisabled)
+	}
+
+	if err := s.DeleteServiceAccount(ctx, account.ID); err != nil {
+		t.Fatalf("delete service account: %v", err)
+	}
+	if _, _, err := s.AuthenticateAPIKey(ctx, live.Key, ""); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("key of a deleted account: got %v, want %v", err, ErrInvalidToken)
+	}
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"errors"
+	"log"
 	"time"
 
 	"github.com/appnity/media-vault/internal/config"
@@ -17,6 +18,7 @@ var (
 	ErrInvalidCredentials = errors.New("invalid email or password")
 	ErrAccountDisabled    = errors.New("account is disabled")
 	ErrInvalidToken       = errors.New("invalid or expired token")
+	ErrTokenReused        = errors.New("refresh token was already used; this login has been signed out")
 )
 
 // AuthService handles authentication
@@ -24,15 +26,28 @@ type AuthService struct {
 	repo      *repository.Repository
 	cfg       *config.Config
 	encryptor *crypto.Encryptor
+	keys      *tokenKeyring
+	authz     *Authorizer
+	oidc      *oidcProvider // nil when single sign-on is disabled
+	now       func() time.Time
 }
 
-// NewAuthService creates a new auth service
-func NewAuthService(repo *repository.Repository, cfg *config.Config, encryptor *crypto.Encryptor) *AuthService {
+// NewAuthService creates a new auth service, loading the JWT signing keys
+func NewAuthService(repo *repository.Repository, cfg *config.Config, encryptor *crypto.Encryptor) (*AuthService, error) {
+	keys, err := newTokenKeyring(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	return &AuthService{
 		repo:      repo,
 		cfg:       cfg,
 		encryptor: encryptor,
-	}
+		keys:      keys,
+		authz:     NewAuthorizer(repo),
+		oidc:      newOIDCProvider(cfg),
+		now:       time.Now,
+	}, nil
 }
 
 // JWTClaims represents the JWT claims
@@ -40,37 +55,64 @@ type JWTClaims struct {
 	EmployeeID uuid.UUID   `json:"employee_id"`
 	Email      string      `json:"email"`
 	Role       models.Role `json:"role"`
+	TokenType  string      `json:"typ"`
+	SessionID  uuid.UUID   `json:"sid"` // Token family of the login, see ListSessions
+
+	CustomRoleID *uuid.UUID `json:"rid,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// Login authenticates an employee and returns tokens
-func (s *AuthService) Login(ctx context.Context, email, password string) (*models.AuthResponse, error) {
+// Login authenticates an employee with their password. Without two-factor
+// authentication it returns tokens for a new session; otherwise it returns a
+// challenge to be completed with a code.
+func (s *AuthService) Login(ctx context.Context, email, password string, client SessionClient) (*models.AuthResponse, *models.MFAChallenge, error) {
 	employee, err := s.repo.GetEmployeeByEmail(ctx, email)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
-			return nil, ErrInvalidCredentials
+			return nil, nil, ErrInvalidCredentials
 		}
-		return nil, err
+		return nil, nil, err
 	}
 
 	if !employee.IsActive {
-		return nil, ErrAccountDisabled
+		return nil, nil, ErrAccountDisabled
+	}
+
+	// Service accounts authenticate with API keys only
+	if employee.IsServiceAccount {
+		return nil, nil, ErrInvalidCredentials
 	}
 
 	if !crypto.CheckPassword(password, employee.PasswordHash) {
-		return nil, ErrInvalidCredentials
+		return nil, nil, ErrInvalidCredentials
 	}
 
-	// Generate tokens
-	accessToken, err := s.generateAccessToken(employee)
+	challenge, err := s.mfaChallenge(ctx, employee)
+	if err != nil || challenge != nil {
+		return nil, challenge, err
+	}
+
+	response, err := s.startSession(ctx, employee, client)
+	return response, nil, err
+}
+
+// startSession issues the tokens of a new login. A login starts a new token
+// family.
+func (s *AuthService) startSession(ctx context.Context, employee *models.Employee, client SessionClient) (*models.AuthResponse, error) {
+	sessionID := uuid.New()
+	accessToken, err := s.generateAccessToken(employee, sessionID)
 	if err != nil {
 		return nil, err
 	}
 
-	refreshToken, err := s.generateRefreshToken(ctx, employee.ID)
+	stored, refreshToken, err := s.generateRefreshToken(employee.ID, sessionID)
 	if err != nil {
 		return nil, err
 	}
+	client.applyTo(stored)
+	if err := s.repo.CreateRefreshToken(ctx, stored); err != nil {
+		return nil, err
+	}
 
 	// Update last login
 	s.repo.UpdateEmployeeLastLogin(ctx, employee.ID)
@@ -83,38 +125,53 @@ func (s *AuthService) Login(ctx context.Context, email, password string) (*model
 	}, nil
 }
 
-// RefreshTokens refreshes access and refresh tokens
-func (s *AuthService) RefreshTokens(ctx context.Context, refreshToken string) (*models.AuthResponse, error) {
-	// In a full implementation, you'd verify the hashed token from the database
-	// _ = crypto.HashToken(refreshToken)
+// RefreshTokens exchanges a refresh token for new access and refresh tokens.
+// The presented token is rotated out; presenting it again is treated as
+// theft and signs out every token of the same login.
+func (s *AuthService) RefreshTokens(ctx context.Context, refreshToken string, client SessionClient) (*models.AuthResponse, error) {
+	stored, err := s.lookupRefreshToken(ctx, refreshToken)
+	if err != nil {
+		return nil, err
+	}
 
-	claims := &JWTClaims{}
-	token, err := jwt.ParseWithClaims(refreshToken, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(s.cfg.JWTSecret), nil
-	})
-	if err != nil || !token.Valid {
+	if stored.RevokedAt != nil {
+		if stored.RevokedReason != nil && *stored.RevokedReason == models.TokenRevokedRotated {
+			return nil, s.revokeReusedFamily(ctx, stored)
+		}
+		return nil, ErrInvalidToken
+	}
+	if time.Now().After(stored.ExpiresAt) {
 		return nil, ErrInvalidToken
 	}
 
-	employee, err := s.repo.GetEmployeeByID(ctx, claims.EmployeeID)
+	employee, err := s.repo.GetEmployeeByID(ctx, stored.EmployeeID)
 	if err != nil {
 		return nil, ErrInvalidToken
 	}
 
 	if !employee.IsActive {
+		s.repo.RevokeEmployeeRefreshTokens(ctx, employee.ID, models.TokenRevokedDeactivated)
 		return nil, ErrAccountDisabled
 	}
 
 	// Generate new tokens
-	newAccessToken, err := s.generateAccessToken(employee)
+	newAccessToken, err := s.generateAccessToken(employee, stored.FamilyID)
 	if err != nil {
 		return nil, err
 	}
 
-	newRefreshToken, err := s.generateRefreshToken(ctx, employee.ID)
+	next, newRefreshToken, err := s.generateRefreshToken(employee.ID, stored.FamilyID)
 	if err != nil {
 		return nil, err
 	}
+	client.applyTo(next)
+	if err := s.repo.RotateRefreshToken(ctx, stored.ID, next); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			// Another refresh with the same token won the race
+			return nil, s.revokeReusedFamily(ctx, stored)
+		}
+		return nil, err
+	}
 
 	return &models.AuthResponse{
 		AccessToken:  newAccessToken,
@@ -124,57 +181,157 @@ func (s *AuthService) RefreshTokens(ctx context.Context, refreshToken string) (*
 	}, nil
 }
 
+// Logout revokes the refresh tokens of the login a refresh token belongs to.
+// Unknown or already revoked tokens are not an error.
+func (s *AuthService) Logout(ctx context.Context, refreshToken string) error {
+	stored, err := s.lookupRefreshToken(ctx, refreshToken)
+	if err != nil {
+		return nil
+	}
+	return s.repo.RevokeRefreshTokenFamily(ctx, stored.FamilyID, models.TokenRevokedLogout)
+}
+
+// LogoutAll revokes every refresh token of an employee, signing them out on
+// all devices once their access tokens expire
+func (s *AuthService) LogoutAll(ctx context.Context, employeeID uuid.UUID) error {
+	return s.repo.RevokeEmployeeRefreshTokens(ctx, employeeID, models.TokenRevokedLogoutAll)
+}
+
+// PurgeExpiredRefreshTokens deletes refresh tokens past their expiry, which
+// can no longer be used or replayed
+func (s *AuthService) PurgeExpiredRefreshTokens(ctx context.Context) error {
+	deleted, err := s.repo.DeleteExpiredRefreshTokens(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		log.Printf("[AuthService] deleted %d expired refresh tokens", deleted)
+	}
+	return nil
+}
+
+// lookupRefreshToken checks a refresh token's signature and finds its stored
+// record, which may be revoked
+func (s *AuthService) lookupRefreshToken(ctx context.Context, refreshToken string) (*models.RefreshToken, error) {
+	if _, err := s.parseToken(refreshToken, TokenTypeRefresh); err != nil {
+		return nil, err
+	}
+
+	stored, err := s.repo.GetRefreshTokenByHash(ctx, crypto.HashToken(refreshToken))
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	return stored, nil
+}
+
+// revokeReusedFamily signs out a login whose replaced refresh token was
+// presented again: either the token was stolen or the client is misbehaving,
+// and there is no telling which copy is legitimate
+func (s *AuthService) revokeReusedFamily(ctx context.Context, stored *models.RefreshToken) error {
+	log.Printf("[AuthService] refresh token reuse for employee %s, revoking token family %s", stored.EmployeeID, stored.FamilyID)
+	if err := s.repo.RevokeRefreshTokenFamily(ctx, stored.FamilyID, models.TokenRevokedReuse); err != nil {
+		return err
+	}
+	return ErrTokenReused
+}
+
 // ValidateToken validates an access token and returns the claims
 func (s *AuthService) ValidateToken(tokenString string) (*JWTClaims, error) {
-	claims := &JWTClaims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, ErrInvalidToken
-		}
-		return []byte(s.cfg.JWTSecret), nil
-	})
+	return s.parseToken(tokenString, TokenTypeAccess)
+}
 
+// JWKS returns the public keys other services can verify access tokens with.
+// The same keys sign refresh and two-factor tokens, so verifiers must also
+// check typ "access", iss and aud.
+func (s *AuthService) JWKS() models.JWKS {
+	return s.keys.jwks()
+}
+
+// parseToken verifies a token's signature, expiry and issuer and that it is
+// of the expected type. Access tokens must also name the audience. Tokens
+// without a type predate typed tokens and are rejected.
+func (s *AuthService) parseToken(tokenString, tokenType string) (*JWTClaims, error) {
+	options := []jwt.ParserOption{jwt.WithIssuer(s.cfg.JWTIssuer), jwt.WithExpirationRequired()}
+	if tokenType == TokenTypeAccess {
+		options = append(options, jwt.WithAudience(s.cfg.JWTAudience))
+	}
+
+	claims := &JWTClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, s.keys.verificationKey, options...)
 	if err != nil || !token.Valid {
 		return nil, ErrInvalidToken
 	}
+	if claims.TokenType != tokenType {
+		return nil, ErrInvalidToken
+	}
 
 	return claims, nil
 }
 
-// generateAccessToken creates a new access token
-func (s *AuthService) generateAccessToken(employee *models.Employee) (string, error) {
+// generateAccessToken creates a new access token for a session
+func (s *AuthService) generateAccessToken(employee *models.Employee, sessionID uuid.UUID) (string, error) {
 	claims := JWTClaims{
 		EmployeeID: employee.ID,
 		Email:      employee.Email,
 		Role:       employee.Role,
+		TokenType:  TokenTypeAccess,
+		SessionID:  sessionID,
+
+		CustomRoleID: employee.CustomRoleID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.cfg.JWTIssuer,
+			Audience:  jwt.ClaimStrings{s.cfg.JWTAudience},
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(s.cfg.AccessTokenExpiry) * time.Minute)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Subject:   employee.ID.String(),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.cfg.JWTSecret))
+	return s.keys.sign(claims)
 }
 
-// generateRefreshToken creates a new refresh token
-func (s *AuthService) generateRefreshToken(_ context.Context, employeeID uuid.UUID) (string, error) {
+// generateRefreshToken creates a new refresh token in a session and the
+// record to store for it. The token ID makes every token unique, even
+// within one second.
+func (s *AuthService) generateRefreshToken(employeeID, sessionID uuid.UUID) (*models.RefreshToken, string, error) {
+	stored := &models.RefreshToken{
+		ID:         uuid.New(),
+		EmployeeID: employeeID,
+		FamilyID:   sessionID,
+		ExpiresAt:  time.Now().Add(time.Duration(s.cfg.RefreshTokenExpiry) * 24 * time.Hour),
+	}
+
 	claims := JWTClaims{
 		EmployeeID: employeeID,
+		TokenType:  TokenTypeRefresh,
+		SessionID:  sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(s.cfg.RefreshTokenExpiry) * 24 * time.Hour)),
+			Issuer:    s.cfg.JWTIssuer,
+			ID:        stored.ID.String(),
+			ExpiresAt: jwt.NewNumericDate(stored.ExpiresAt),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Subject:   employeeID.String(),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.cfg.JWTSecret))
+	token, err := s.keys.sign(claims)
+	if err != nil {
+		return nil, "", err
+	}
+	stored.TokenHash = crypto.HashToken(token)
+	return stored, token, nil
 }
 
-// CreateEmployee creates a new employee (admin only)
-func (s *AuthService) CreateEmployee(ctx context.Context, req *models.CreateEmployeeRequest) (*models.Employee, error) {
+// CreateEmployee creates a new employee (admin only). Their roles may only
+// hold permissions the caller holds.
+func (s *AuthService) CreateEmployee(ctx context.Context, req *models.CreateEmployeeRequest, caller *models.Employee) (*models.Employee, error) {
+	if err := s.checkCustomRole(ctx, req.CustomRoleID); err != nil {
+		return nil, err
+	}
+	if err := s.checkAssignable(ctx, caller, req.Role, req.CustomRoleID); err != nil {
+		return nil, err
+	}
+
 	// Hash password
 	passwordHash, err := crypto.HashPassword(req.Password)
 	if err != nil {
@@ -187,6 +344,7 @@ func (s *AuthService) CreateEmployee(ctx context.Context, req *models.CreateEmpl
 		FullName:     req.FullName,
 		Role:         req.Role,
 		IsActive:     true,
+		CustomRoleID: req.CustomRoleID,
 	}
 
 	if err := s.repo.CreateEmployee(ctx, employee); err != nil {
@@ -222,12 +380,24 @@ func (s *AuthService) ListEmployees(ctx context.Context, page, pageSize int) (*m
 	}, nil
 }
 
-// UpdateEmployee updates an employee
-func (s *AuthService) UpdateEmployee(ctx context.Context, id uuid.UUID, req *models.UpdateEmployeeRequest) (*models.Employee, error) {
+// UpdateEmployee updates an employee. Only callers holding every permission
+// of the employee's roles, before and after the change, may change them, and
+// never their own.
+func (s *AuthService) UpdateEmployee(ctx context.Context, id uuid.UUID, req *models.UpdateEmployeeRequest, caller *models.Employee) (*models.Employee, error) {
+	changesRole := req.Role != nil || req.CustomRoleID != nil
+	if changesRole && id == caller.ID {
+		return nil, ErrOwnRole
+	}
+
 	employee, err := s.repo.GetEmployeeByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if changesRole {
+		if err := s.checkAssignable(ctx, caller, employee.Role, employee.CustomRoleID); err != nil {
+			return nil, err
+		}
+	}
 
 	if req.FullName != nil {
 		employee.FullName = *req.FullName
@@ -241,15 +411,40 @@ func (s *AuthService) UpdateEmployee(ctx context.Context, id uuid.UUID, req *mod
 	if req.AvatarURL != nil {
 		employee.AvatarURL = req.AvatarURL
 	}
+	if req.CustomRoleID != nil {
+		if *req.CustomRoleID == uuid.Nil {
+			employee.CustomRoleID = nil
+		} else {
+			if err := s.checkCustomRole(ctx, req.CustomRoleID); err != nil {
+				return nil, err
+			}
+			employee.CustomRoleID = req.CustomRoleID
+		}
+	}
+	if changesRole {
+		if err := s.checkAssignable(ctx, caller, employee.Role, employee.CustomRoleID); err != nil {
+			return nil, err
+		}
+	}
 
 	if err := s.repo.UpdateEmployee(ctx, employee); err != nil {
 		return nil, err
 	}
 
+	// A deactivated employee is signed out everywhere right away
+	if !employee.IsActive {
+		if err := s.repo.RevokeEmployeeRefreshTokens(ctx, id, models.TokenRevokedDeactivated); err != nil {
+			return nil, err
+		}
+	}
+
 	return employee, nil
 }
 
-// DeleteEmployee soft deletes an employee
+// DeleteEmployee soft deletes an employee and revokes their refresh tokens
 func (s *AuthService) DeleteEmployee(ctx context.Context, id uuid.UUID) error {
-	return s.repo.SoftDeleteEmployee(ctx, id)
+	if err := s.repo.SoftDeleteEmployee(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.RevokeEmployeeRefreshTokens(ctx, id, models.TokenRevokedDeactivated)
 }
@@ -0,0 +1,327 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/appnity/media-vault/internal/config"
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/testdb"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// tokenTestConfig configures HS256 tokens, which need no key files
+func tokenTestConfig() *config.Config {
+	return &config.Config{
+		JWTSigningAlg:      "HS256",
+		JWTSecret:          strings.Repeat("s", 32),
+		JWTKeyID:           "current",
+		JWTIssuer:          "https://vault.example.com",
+		JWTAudience:        "media-vault-api",
+		AccessTokenExpiry:  15,
+		RefreshTokenExpiry: 7,
+	}
+}
+
+// newTokenService creates an auth service that can only sign and verify
+// tokens
+func newTokenService(t *testing.T, cfg *config.Config) *AuthService {
+	t.Helper()
+	keys, err := newTokenKeyring(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &AuthService{cfg: cfg, keys: keys}
+}
+
+func TestParseTokenChecksIssuerAndAudience(t *testing.T) {
+	cfg := tokenTestConfig()
+	s := newTokenService(t, cfg)
+	employee := &models.Employee{ID: uuid.New(), Email: "e@example.com", Role: models.RoleViewer}
+
+	access, err := s.generateAccessToken(employee, uuid.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := s.ValidateToken(access)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	if claims.Issuer != cfg.JWTIssuer || len(claims.Audience) != 1 || claims.Audience[0] != cfg.JWTAudience {
+		t.Errorf("iss %q aud %v, want %q and [%q]", claims.Issuer, claims.Audience, cfg.JWTIssuer, cfg.JWTAudience)
+	}
+
+	forged := func(edit func(*jwt.RegisteredClaims)) string {
+		c := JWTClaims{
+			EmployeeID: employee.ID,
+			TokenType:  TokenTypeAccess,
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    cfg.JWTIssuer,
+				Audience:  jwt.ClaimStrings{cfg.JWTAudience},
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			},
+		}
+		edit(&c.RegisteredClaims)
+		token, err := s.keys.sign(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	cases := map[string]string{
+		"no issuer":      forged(func(c *jwt.RegisteredClaims) { c.Issuer = "" }),
+		"other issuer":   forged(func(c *jwt.RegisteredClaims) { c.Issuer = "https://other.example.com" }),
+		"no audience":    forged(func(c *jwt.RegisteredClaims) { c.Audience = nil }),
+		"other audience": forged(func(c *jwt.RegisteredClaims) { c.Audience = jwt.ClaimStrings{"billing"} }),
+		"no expiry":      forged(func(c *jwt.RegisteredClaims) { c.ExpiresAt = nil }),
+	}
+	for name, token := range cases {
+		if _, err := s.ValidateToken(token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: got %v, want %v", name, err, ErrInvalidToken)
+		}
+	}
+}
+
+func TestRolesAreOnlyGrantedWithinOwnPermissions(t *testing.T) {
+	s := &AuthService{authz: NewAuthorizer(nil)}
+	ctx := context.Background()
+	manager := &models.Employee{
+		ID:          uuid.New(),
+		Role:        models.RoleDeveloper,
+		Permissions: append([]models.Permission{models.PermEmployeesManage}, models.BuiltinRolePermissions[models.RoleDeveloper]...),
+	}
+
+	_, err := s.CreateEmployee(ctx, &models.CreateEmployeeRequest{
+		Email: "new@example.com", Password: "correct horse battery", FullName: "New", Role: models.RoleAdmin,
+	}, manager)
+	if !errors.Is(err, ErrRoleNotGrantable) {
+		t.Errorf("create an admin: got %v, want %v", err, ErrRoleNotGrantable)
+	}
+
+	admin := models.RoleAdmin
+	if _, err := s.UpdateEmployee(ctx, manager.ID, &models.UpdateEmployeeRequest{Role: &admin}, manager); !errors.Is(err, ErrOwnRole) {
+		t.Errorf("change own role: got %v, want %v", err, ErrOwnRole)
+	}
+
+	if _, err := s.CreateCustomRole(ctx, &models.CreateCustomRoleRequest{
+		Name: "superuser", Permissions: models.AllPermissions,
+	}, manager); !errors.Is(err, ErrRoleNotGrantable) {
+		t.Errorf("create a role with every permission: got %v, want %v", err, ErrRoleNotGrantable)
+	}
+
+	if err := s.checkAssignable(ctx, manager, models.RoleViewer, nil); err != nil {
+		t.Errorf("assign a lesser role: %v", err)
+	}
+	if err := s.checkAssignable(ctx, manager, models.RoleDeveloper, nil); err != nil {
+		t.Errorf("assign an equal role: %v", err)
+	}
+}
+
+// newSessionFixture signs a new employee in and returns the auth service
+// along with the employee and the tokens of their login
+func newSessionFixture(t *testing.T) (*AuthService, *models.Employee, *models.AuthResponse) {
+	t.Helper()
+	repo, _ := testdb.Open(t)
+	s, err := NewAuthService(repo, tokenTestConfig(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	employee := testdb.Employee(t, repo, models.RoleDeveloper)
+	login, err := s.startSession(context.Background(), employee, SessionClient{})
+	if err != nil {
+		t.Fatalf("start session: %v", err)
+	}
+	return s, employee, login
+}
+
+func TestRefreshTokensRotatesAndDetectsReuse(t *testing.T) {
+	s, _, login := newSessionFixture(t)
+	ctx := context.Background()
+
+	rotated, err := s.RefreshTokens(ctx, login.RefreshToken, SessionClient{})
+	if err != nil {
+		t.Fatalf("first refresh: %v", err)
+	}
+	if rotated.RefreshToken == login.RefreshToken {
+		t.Fatal("refresh returned the same refresh token")
+	}
+	next, err := s.RefreshTokens(ctx, rotated.RefreshToken, SessionClient{})
+	if err != nil {
+		t.Fatalf("refresh with the rotated token: %v", err)
+	}
+
+	// Replaying the first token signs the whole login out, including the
+	// token that is still current
+	if _, err := s.RefreshTokens(ctx, login.RefreshToken, SessionClient{}); !errors.Is(err, ErrTokenReused) {
+		t.Fatalf("replayed token: got %v, want %v", err, ErrTokenReused)
+	}
+	if _, err := s.RefreshTokens(ctx, next.RefreshToken, SessionClient{}); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("current token after reuse: got %v, want %v", err, ErrInvalidToken)
+	}
+}
+
+func TestRefreshTokensAllowsOneConcurrentRefresh(t *testing.T) {
+	s, _, login := newSessionFixture(t)
+	ctx := context.Background()
+
+	const racers = 8
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		winners   []*models.AuthResponse
+		failures  []error
+		startLine = make(chan struct{})
+	)
+	for i := 0; i < racers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-startLine
+			response, err := s.RefreshTokens(ctx, login.RefreshToken, SessionClient{})
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failures = append(failures, err)
+			} else {
+				winners = append(winners, response)
+			}
+		}()
+	}
+	close(startLine)
+	wg.Wait()
+
+	if len(winners) != 1 {
+		t.Fatalf("%d refreshes succeeded, want 1", len(winners))
+	}
+	for _, err := range failures {
+		if !errors.Is(err, ErrTokenReused) {
+			t.Errorf("losing refresh: got %v, want %v", err, ErrTokenReused)
+		}
+	}
+	// The losers revoked the login, so the winner's token is refused too
+	if _, err := s.RefreshTokens(ctx, winners[0].RefreshToken, SessionClient{}); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("winning token after the race: got %v, want %v", err, ErrInvalidToken)
+	}
+}
+
+func TestDeactivationRevokesRefreshTokens(t *testing.T) {
+	s, employee, login := newSessionFixture(t)
+	ctx := context.Background()
+	admin := testdb.Employee(t, s.repo, models.RoleAdmin)
+
+	inactive := false
+	if _, err := s.UpdateEmployee(ctx, employee.ID, &models.UpdateEmployeeRequest{IsActive: &inactive}, admin); err != nil {
+		t.Fatalf("deactivate: %v", err)
+	}
+	stored, err := s.lookupRefreshToken(ctx, login.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.RevokedAt == nil || stored.RevokedReason == nil || *stored.RevokedReason != models.TokenRevokedDeactivated {
+		t.Fatalf("refresh token revoked at %v for %v, want revoked as %q", stored.RevokedAt, stored.RevokedReason, models.TokenRevokedDeactivated)
+	}
+	if _, err := s.RefreshTokens(ctx, login.RefreshToken, SessionClient{}); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("refresh after deactivation: got %v, want %v", err, ErrInvalidToken)
+	}
+}
+
+func TestRefreshTokensRefusesDeactivatedEmployees(t *testing.T) {
+	s, employee, login := newSessionFixture(t)
+	ctx := context.Background()
+
+	// Deactivated without going through UpdateEmployee, e.g. by hand
+	employee.IsActive = false
+	if err := s.repo.UpdateEmployee(ctx, employee); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.RefreshTokens(ctx, login.RefreshToken, SessionClient{}); !errors.Is(err, ErrAccountDisabled) {
+		t.Fatalf("refresh of a deactivated employee: got %v, want %v", err, ErrAccountDisabled)
+	}
+	if _, err := s.RefreshTokens(ctx, login.RefreshToken, SessionClient{}); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("second refresh: got %v, want %v", err, ErrInvalidToken)
+	}
+}
+
+func TestParseTokenChecksType(t *testing.T) {
+	s := newTokenService(t, tokenTestConfig())
+	employee := &models.Employee{ID: uuid.New(), Email: "e@example.com", Role: models.RoleViewer}
+
+	access, err := s.generateAccessToken(employee, uuid.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, refresh, err := s.generateRefreshToken(employee.ID, uuid.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.ValidateToken(refresh); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("refresh token as access token: got %v, want %v", err, ErrInvalidToken)
+	}
+	if _, err := s.parseToken(access, TokenTypeRefresh); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("access token as refresh token: got %v, want %v", err, ErrInvalidToken)
+	}
+	if _, err := s.parseToken(refresh, TokenTypeRefresh); err != nil {
+		t.Errorf("refresh token: %v", err)
+	}
+}
+
+func TestVerificationKeyChecksRetiredKeys(t *testing.T) {
+	employee := &models.Employee{ID: uuid.New(), Email: "e@example.com", Role: models.RoleViewer}
+	// signedWith issues an access token with a key named kid
+	signedWith := func(kid, secret string) string {
+		cfg := tokenTestConfig()
+		cfg.JWTKeyID, cfg.JWTSecret = kid, secret
+		token, err := newTokenService(t, cfg).generateAccessToken(employee, uuid.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	cfg := tokenTestConfig()
+	cfg.JWTPreviousKeys = []config.JWTKey{
+		{ID: "recent", Secret: strings.Repeat("r", 32), RetiredAt: time.Now().Add(-time.Hour)},
+		// Retired longer ago than a refresh token lives
+		{ID: "expired", Secret: strings.Repeat("x", 32), RetiredAt: time.Now().Add(-time.Duration(cfg.RefreshTokenExpiry+1) * 24 * time.Hour)},
+	}
+	s := newTokenService(t, cfg)
+
+	if _, err := s.ValidateToken(signedWith("recent", strings.Repeat("r", 32))); err != nil {
+		t.Errorf("recently retired key: %v", err)
+	}
+	if _, err := s.ValidateToken(signedWith("expired", strings.Repeat("x", 32))); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("key retired too long ago: got %v, want %v", err, ErrInvalidToken)
+	}
+	if _, err := s.ValidateToken(signedWith("unknown", strings.Repeat("u", 32))); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("unknown key: got %v, want %v", err, ErrInvalidToken)
+	}
+	// The right secret under another key's name
+	if _, err := s.ValidateToken(signedWith("recent", cfg.JWTSecret)); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("secret of another key: got %v, want %v", err, ErrInvalidToken)
+	}
+
+	// The kid's secret, but not the kid's algorithm
+	token := jwt.NewWithClaims(jwt.SigningMethodHS384, JWTClaims{
+		EmployeeID: employee.ID,
+		TokenType:  TokenTypeAccess,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    cfg.JWTIssuer,
+			Audience:  jwt.ClaimStrings{cfg.JWTAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	})
+	token.Header["kid"] = cfg.JWTKeyID
+	signed, err := token.SignedString([]byte(cfg.JWTSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ValidateToken(signed); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("other algorithm: got %v, want %v", err, ErrInvalidToken)
+	}
+}
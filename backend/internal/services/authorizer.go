@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/repository"
+	"github.com/google/uuid"
+)
+
+// customRoleCacheTTL bounds how long a custom role edited on another
+// instance keeps its old permissions here
+const customRoleCacheTTL = time.Minute
+
+type cachedCustomRole struct {
+	permissions []models.Permission // nil when the role no longer exists
+	loadedAt    time.Time
+}
+
+// Authorizer resolves the permissions of an employee from their custom role,
+// or else the preset of their built-in role. Handlers and services then
+// check them with Employee.Can.
+type Authorizer struct {
+	repo *repository.Repository
+
+	mu    sync.Mutex
+	roles map[uuid.UUID]cachedCustomRole
+}
+
+// NewAuthorizer creates a new authorizer
+func NewAuthorizer(repo *repository.Repository) *Authorizer {
+	return &Authorizer{
+		repo:  repo,
+		roles: make(map[uuid.UUID]cachedCustomRole),
+	}
+}
+
+// Permissions returns the permissions of a built-in role, overridden by a
+// custom role when one is assigned and still exists
+func (a *Authorizer) Permissions(ctx context.Context, role models.Role, customRoleID *uuid.UUID) ([]models.Permission, error) {
+	if customRoleID != nil {
+		permissions, err := a.customRolePermissions(ctx, *customRoleID)
+		if err != nil {
+			return nil, err
+		}
+		if permissions != nil {
+			return permissions, nil
+		}
+	}
+	return models.BuiltinRolePermissions[role], nil
+}
+
+func (a *Authorizer) customRolePermissions(ctx context.Context, id uuid.UUID) ([]models.Permission, error) {
+	a.mu.Lock()
+	cached, ok := a.roles[id]
+	a.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < customRoleCacheTTL {
+		return cached.permissions, nil
+	}
+
+	role, err := a.repo.GetCustomRoleByID(ctx, id)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	cached = cachedCustomRole{loadedAt: time.Now()}
+	if role != nil {
+		cached.permissions = role.Permissions
+		if cached.permissions == nil {
+			cached.permissions = []models.Permission{}
+		}
+	}
+
+	a.mu.Lock()
+	a.roles[id] = cached
+	a.mu.Unlock()
+	return cached.permissions, nil
+}
+
+// forget drops a custom role from the cache after it changed
+func (a *Authorizer) forget(id uuid.UUID) {
+	a.mu.Lock()
+	delete(a.roles, id)
+	a.mu.Unlock()
+}
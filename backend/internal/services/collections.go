@@ -0,0 +1,358 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/repository"
+	"github.com/google/uuid"
+)
+
+// CreateCollection creates a collection, optionally with initial items
+func (s *MediaService) CreateCollection(ctx context.Context, req *models.CreateCollectionRequest, employee *models.Employee) (*models.Collection, error) {
+	collection := &models.Collection{
+		Name:         strings.TrimSpace(req.Name),
+		Description:  req.Description,
+		CoverMediaID: req.CoverMediaID,
+		CreatedBy:    employee.ID,
+	}
+	if collection.Name == "" {
+		return nil, ErrInvalidInput
+	}
+	if err := s.checkCollectionCover(ctx, collection.CoverMediaID, employee); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateCollection(ctx, collection); err != nil {
+		return nil, err
+	}
+
+	added := int64(0)
+	if len(req.MediaIDs) > 0 {
+		var err error
+		if added, err = s.repo.AddCollectionItems(ctx, collection.ID, req.MediaIDs, employee.ID, employee.MediaAccess()); err != nil {
+			return nil, err
+		}
+	}
+
+	s.logAudit(ctx, employee, models.AuditActionCreate, models.SeverityInfo, "collection", &collection.ID, map[string]any{
+		"name":        collection.Name,
+		"added_count": added,
+	})
+
+	return s.GetCollection(ctx, collection.ID, employee)
+}
+
+// ListCollections lists the collections an employee can view; admins see all
+func (s *MediaService) ListCollections(ctx context.Context, employee *models.Employee) ([]models.Collection, error) {
+	var employeeID *uuid.UUID
+	if !employee.Can(models.PermLibraryManageAny) {
+		employeeID = &employee.ID
+	}
+	return s.repo.ListCollections(ctx, employeeID, employee.Role, employee.MediaAccess())
+}
+
+// GetCollection gets a collection the employee can view
+func (s *MediaService) GetCollection(ctx context.Context, id uuid.UUID, employee *models.Employee) (*models.Collection, error) {
+	return s.collectionFor(ctx, id, employee, models.CollectionView)
+}
+
+// UpdateCollection changes the name, description or cover of a collection
+func (s *MediaService) UpdateCollection(ctx context.Context, id uuid.UUID, req *models.UpdateCollectionRequest, employee *models.Employee) (*models.Collection, error) {
+	collection, err := s.collectionFor(ctx, id, employee, models.CollectionEdit)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		collection.Name = strings.TrimSpace(*req.Name)
+		if collection.Name == "" {
+			return nil, ErrInvalidInput
+		}
+	}
+	if req.Description != nil {
+		collection.Description = req.Description
+	}
+	if req.RemoveCover {
+		collection.CoverMediaID = nil
+	} else if req.CoverMediaID != nil {
+		if err := s.checkCollectionCover(ctx, req.CoverMediaID, employee); err != nil {
+			return nil, err
+		}
+		collection.CoverMediaID = req.CoverMediaID
+	}
+
+	if err := s.repo.UpdateCollection(ctx, collection); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrCollectionNotFound
+		}
+		return nil, err
+	}
+
+	s.logAudit(ctx, employee, models.AuditActionUpdate, models.SeverityInfo, "collection", &id, map[string]any{
+		"name":           collection.Name,
+		"cover_media_id": collection.CoverMediaID,
+	})
+
+	return s.GetCollection(ctx, id, employee)
+}
+
+// DeleteCollection deletes a collection; its media stays where it is
+func (s *MediaService) DeleteCollection(ctx context.Context, id uuid.UUID, employee *models.Employee) error {
+	collection, err := s.collectionFor(ctx, id, employee, models.CollectionOwner)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.SoftDeleteCollection(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrCollectionNotFound
+		}
+		return err
+	}
+
+	s.logAudit(ctx, employee, models.AuditActionDelete, models.SeverityWarning, "collection", &id, map[string]any{
+		"name":       collection.Name,
+		"item_count": collection.ItemCount,
+	})
+
+	return nil
+}
+
+// AddCollectionItems appends media to a collection. Items already in it and
+// media that is not listed in the library are skipped.
+func (s *MediaService) AddCollectionItems(ctx context.Context, id uuid.UUID, mediaIDs []uuid.UUID, employee *models.Employee) (*models.Collection, error) {
+	if _, err := s.collectionFor(ctx, id, employee, models.CollectionEdit); err != nil {
+		return nil, err
+	}
+
+	added, err := s.repo.AddCollectionItems(ctx, id, mediaIDs, employee.ID, employee.MediaAccess())
+	if err != nil {
+		return nil, err
+	}
+
+	s.logAudit(ctx, employee, models.AuditActionUpdate, models.SeverityInfo, "collection", &id, map[string]any{
+		"added_count": added,
+		"media_ids":   mediaIDs,
+	})
+
+	return s.GetCollection(ctx, id, employee)
+}
+
+// RemoveCollectionItems takes media out of a collection without touching
+// the media itself
+func (s *MediaService) RemoveCollectionItems(ctx context.Context, id uuid.UUID, mediaIDs []uuid.UUID, employee *models.Employee) (*models.Collection, error) {
+	if _, err := s.collectionFor(ctx, id, employee, models.CollectionEdit); err != nil {
+		return nil, err
+	}
+
+	removed, err := s.repo.RemoveCollectionItems(ctx, id, mediaIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logAudit(ctx, employee, models.AuditActionUpdate, models.SeverityInfo, "collection", &id, map[string]any{
+		"removed_count": removed,
+		"media_ids":     mediaIDs,
+	})
+
+	return s.GetCollection(ctx, id, employee)
+}
+
+// ReorderCollectionItems moves the given media to the front of a collection
+// in the given order
+func (s *MediaService) ReorderCollectionItems(ctx context.Context, id uuid.UUID, mediaIDs []uuid.UUID, employee *models.Employee) error {
+	if _, err := s.collectionFor(ctx, id, employee, models.CollectionEdit); err != nil {
+		return err
+	}
+
+	seen := make(map[uuid.UUID]bool, len(mediaIDs))
+	for _, mediaID := range mediaIDs {
+		if seen[mediaID] {
+			return ErrInvalidInput
+		}
+		seen[mediaID] = true
+	}
+
+	return s.repo.ReorderCollectionItems(ctx, id, mediaIDs)
+}
+
+// ListCollectionMedia lists the media of a collection in collection order
+func (s *MediaService) ListCollectionMedia(ctx context.Context, id uuid.UUID, page, pageSize int, employee *models.Employee) (*models.PaginatedResponse[models.MediaWithDetails], error) {
+	if _, err := s.collectionFor(ctx, id, employee, models.CollectionView); err != nil {
+		return nil, err
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 50
+	}
+
+	mediaList, total, err := s.repo.ListCollectionMedia(ctx, id, page, pageSize, employee.MediaAccess())
+	if err != nil {
+		return nil, err
+	}
+
+	totalPages := int(total) / pageSize
+	if int(total)%pageSize > 0 {
+		totalPages++
+	}
+
+	return &models.PaginatedResponse[models.MediaWithDetails]{
+		Data:       mediaList,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// CollectionExportIDs returns the media of a collection in order, for a ZIP
+// export through BatchDownloadMedia. The export is audited.
+func (s *MediaService) CollectionExportIDs(ctx context.Context, id uuid.UUID, employee *models.Employee) (*models.Collection, []uuid.UUID, error) {
+	collection, err := s.collectionFor(ctx, id, employee, models.CollectionView)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ids, err := s.repo.ListCollectionMediaIDs(ctx, id, employee.MediaAccess())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.logAudit(ctx, employee, models.AuditActionDownload, models.SeverityInfo, "collection", &id, map[string]any{
+		"name":       collection.Name,
+		"item_count": len(ids),
+	})
+
+	return collection, ids, nil
+}
+
+// GetCollectionAccess lists who besides the creator may access a collection
+func (s *MediaService) GetCollectionAccess(ctx context.Context, id uuid.UUID, employee *models.Employee) ([]models.CollectionGrant, error) {
+	if _, err := s.collectionFor(ctx, id, employee, models.CollectionOwner); err != nil {
+		return nil, err
+	}
+	return s.repo.ListCollectionGrants(ctx, id)
+}
+
+// SetCollectionAccess replaces who may view or edit a collection. Only its
+// creator or an admin may.
+func (s *MediaService) SetCollectionAccess(ctx context.Context, id uuid.UUID, req *models.SetCollectionAccessRequest, employee *models.Employee) ([]models.CollectionGrant, error) {
+	if _, err := s.collectionFor(ctx, id, employee, models.CollectionOwner); err != nil {
+		return nil, err
+	}
+
+	grants := make([]models.CollectionGrant, 0, len(req.Grants))
+	roles := make(map[models.Role]bool)
+	employees := make(map[uuid.UUID]bool)
+	for _, g := range req.Grants {
+		switch {
+		case g.Role != nil && g.EmployeeID == nil:
+			if roles[*g.Role] {
+				return nil, ErrInvalidInput
+			}
+			roles[*g.Role] = true
+		case g.EmployeeID != nil && g.Role == nil:
+			if employees[*g.EmployeeID] {
+				return nil, ErrInvalidInput
+			}
+			employees[*g.EmployeeID] = true
+			if _, err := s.repo.GetEmployeeByID(ctx, *g.EmployeeID); err != nil {
+				return nil, ErrEmployeeNotFound
+			}
+		default:
+			return nil, ErrInvalidInput
+		}
+		grants = append(grants, models.CollectionGrant{
+			CollectionID: id,
+			Role:         g.Role,
+			EmployeeID:   g.EmployeeID,
+			Permission:   g.Permission,
+		})
+	}
+
+	if err := s.repo.ReplaceCollectionGrants(ctx, id, grants); err != nil {
+		return nil, err
+	}
+
+	s.logAudit(ctx, employee, models.AuditActionShare, models.SeverityWarning, "collection", &id, map[string]any{
+		"grants": req.Grants,
+	})
+
+	return s.repo.ListCollectionGrants(ctx, id)
+}
+
+// collectionFor loads a collection and checks that the employee has at least
+// the needed permission on it. Collections they cannot see at all are
+// reported as not found.
+func (s *MediaService) collectionFor(ctx context.Context, id uuid.UUID, employee *models.Employee, need models.CollectionPermission) (*models.Collection, error) {
+	collection, err := s.repo.GetCollectionByID(ctx, id, employee.MediaAccess())
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrCollectionNotFound
+		}
+		return nil, err
+	}
+
+	collection.Permission, err = s.collectionPermission(ctx, collection, employee)
+	if err != nil {
+		return nil, err
+	}
+	if collection.Permission == "" {
+		return nil, ErrCollectionNotFound
+	}
+	if collectionPermissionRank[collection.Permission] < collectionPermissionRank[need] {
+		return nil, ErrForbidden
+	}
+	return collection, nil
+}
+
+// collectionPermissionRank orders permissions from least to most
+var collectionPermissionRank = map[models.CollectionPermission]int{
+	models.CollectionView:  1,
+	models.CollectionEdit:  2,
+	models.CollectionOwner: 3,
+}
+
+// collectionPermission works out what an employee may do with a collection:
+// the most permissive of their own grant and their role's
+func (s *MediaService) collectionPermission(ctx context.Context, collection *models.Collection, employee *models.Employee) (models.CollectionPermission, error) {
+	if collection.CreatedBy == employee.ID || employee.Can(models.PermLibraryManageAny) {
+		return models.CollectionOwner, nil
+	}
+
+	grants, err := s.repo.ListCollectionGrants(ctx, collection.ID)
+	if err != nil {
+		return "", err
+	}
+
+	var permission models.CollectionPermission
+	for _, g := range grants {
+		applies := (g.EmployeeID != nil && *g.EmployeeID == employee.ID) || (g.Role != nil && *g.Role == employee.Role)
+		if applies && collectionPermissionRank[g.Permission] > collectionPermissionRank[permission] {
+			permission = g.Permission
+		}
+	}
+	return permission, nil
+}
+
+// checkCollectionCover makes sure a cover image is listed in the library
+// and visible to the employee
+func (s *MediaService) checkCollectionCover(ctx context.Context, mediaID *uuid.UUID, employee *models.Employee) error {
+	if mediaID == nil {
+		return nil
+	}
+	media, err := s.getAccessibleMedia(ctx, *mediaID, employee)
+	if err != nil {
+		return err
+	}
+	if media.QuarantinedAt != nil {
+		return ErrMediaQuarantined
+	}
+	return nil
+}
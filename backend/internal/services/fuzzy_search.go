@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"log"
+	"strings"
+	"unicode"
+
+	"github.com/appnity/media-vault/internal/models"
+)
+
+const (
+	// suggestionCount is how many "did you mean" alternatives are offered
+	suggestionCount = 3
+
+	// suggestionMinWordLength skips words too short for trigram matching to
+	// say anything useful
+	suggestionMinWordLength = 3
+)
+
+// searchSuggestions offers corrected versions of a fuzzy or full-text search
+// whose words are not in the library's vocabulary, drawing only on terms
+// from media the caller may see. Suggestions are best effort: failures are
+// logged and yield none.
+func (s *MediaService) searchSuggestions(ctx context.Context, filters *models.MediaFilterRequest) []string {
+	text := filters.Fuzzy
+	if text == "" {
+		text = filters.Search
+	}
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var lookup []string
+	for _, word := range words {
+		if len(word) >= suggestionMinWordLength {
+			lookup = append(lookup, word)
+		}
+	}
+	if len(lookup) == 0 {
+		return nil
+	}
+
+	corrections, err := s.repo.SuggestSearchTerms(ctx, lookup, suggestionCount, filters.Access, filters.Limits)
+	if err != nil {
+		log.Printf("[MediaService] searchSuggestions: %v", err)
+		return nil
+	}
+	if len(corrections) == 0 {
+		return nil
+	}
+
+	// The nth suggestion uses the nth closest term for every unknown word,
+	// falling back to the closest when a word has fewer alternatives
+	var suggestions []string
+	seen := make(map[string]bool)
+	for n := 0; n < suggestionCount; n++ {
+		phrase := make([]string, len(words))
+		for i, word := range words {
+			phrase[i] = word
+			if terms := corrections[word]; len(terms) > 0 {
+				phrase[i] = terms[min(n, len(terms)-1)]
+			}
+		}
+		suggestion := strings.Join(phrase, " ")
+		if !seen[suggestion] {
+			seen[suggestion] = true
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+	return suggestions
+}
+
+// RefreshSearchTerms rebuilds the vocabulary used for search suggestions so
+// it picks up new filenames and tags
+func (s *MediaService) RefreshSearchTerms(ctx context.Context) error {
+	return s.repo.RefreshSearchTerms(ctx)
+}
@@ -0,0 +1,218 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/repository"
+	"github.com/appnity/media-vault/internal/storage"
+	"github.com/google/uuid"
+)
+
+// ListLegalHolds lists legal holds, optionally only those still in force
+func (s *MediaService) ListLegalHolds(ctx context.Context, activeOnly bool) ([]models.LegalHold, error) {
+	return s.repo.ListLegalHolds(ctx, activeOnly)
+}
+
+// GetLegalHold gets a single legal hold
+func (s *MediaService) GetLegalHold(ctx context.Context, id uuid.UUID) (*models.LegalHold, error) {
+	hold, err := s.repo.GetLegalHoldByID(ctx, id)
+	if err != nil {
+		return nil, ErrHoldNotFound
+	}
+	return hold, nil
+}
+
+// PlaceLegalHold places a legal hold on a media item, folder or group
+func (s *MediaService) PlaceLegalHold(ctx context.Context, req *models.CreateLegalHoldRequest, employee *models.Employee) (*models.LegalHold, error) {
+	if err := s.validateScopeTarget(ctx, req.Scope, req.TargetID); err != nil {
+		return nil, err
+	}
+
+	ownerID := employee.ID
+	if req.OwnerID != nil {
+		if _, err := s.repo.GetEmployeeByID(ctx, *req.OwnerID); err != nil {
+			return nil, ErrEmployeeNotFound
+		}
+		ownerID = *req.OwnerID
+	}
+
+	hold := &models.LegalHold{
+		Scope:    req.Scope,
+		Reason:   req.Reason,
+		OwnerID:  ownerID,
+		PlacedBy: employee.ID,
+	}
+	targetID := req.TargetID
+	switch req.Scope {
+	case models.RetentionScopeGroup:
+		hold.MediaGroupID = &targetID
+	case models.RetentionScopeFolder:
+		hold.FolderID = &targetID
+	case models.RetentionScopeMedia:
+		hold.MediaID = &targetID
+	}
+
+	if err := s.repo.CreateLegalHold(ctx, hold); err != nil {
+		return nil, err
+	}
+
+	changed, err := s.repo.RefreshMediaLegalHold(ctx, hold.Scope, targetID)
+	if err != nil {
+		return nil, err
+	}
+	failures := s.syncObjectLocks(ctx, changed)
+
+	s.logAudit(ctx, employee, models.AuditActionHold, models.SeverityCritical, "legal_hold", &hold.ID, map[string]any{
+		"scope":                hold.Scope,
+		"target_id":            targetID,
+		"reason":               hold.Reason,
+		"owner_id":             hold.OwnerID,
+		"media_count":          len(changed),
+		"object_lock_failures": failures,
+	})
+
+	return s.repo.GetLegalHoldByID(ctx, hold.ID)
+}
+
+// ReleaseLegalHold releases a legal hold. Media stays held if another
+// active hold still covers it.
+func (s *MediaService) ReleaseLegalHold(ctx context.Context, id uuid.UUID, reason string, employee *models.Employee) (*models.LegalHold, error) {
+	hold, err := s.repo.GetLegalHoldByID(ctx, id)
+	if err != nil {
+		return nil, ErrHoldNotFound
+	}
+
+	if err := s.repo.ReleaseLegalHold(ctx, id, employee.ID, reason); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrHoldReleased
+		}
+		return nil, err
+	}
+
+	changed, err := s.repo.RefreshMediaLegalHold(ctx, hold.Scope, hold.TargetID())
+	if err != nil {
+		return nil, err
+	}
+	failures := s.syncObjectLocks(ctx, changed)
+
+	s.logAudit(ctx, employee, models.AuditActionRelease, models.SeverityCritical, "legal_hold", &id, map[string]any{
+		"scope":                hold.Scope,
+		"target_id":            hold.TargetID(),
+		"reason":               hold.Reason,
+		"release_reason":       reason,
+		"media_count":          len(changed),
+		"object_lock_failures": failures,
+	})
+
+	return s.repo.GetLegalHoldByID(ctx, id)
+}
+
+// RefreshMediaPolicies recomputes retention and legal hold for a media item
+// after its group or folder changed
+func (s *MediaService) RefreshMediaPolicies(ctx context.Context, id uuid.UUID) {
+	s.refreshRetention(ctx, id)
+	s.refreshLegalHold(ctx, id)
+}
+
+// checkLegalHold returns ErrLegalHold when a media item must not be deleted,
+// moved or overwritten
+func checkLegalHold(media *models.Media) error {
+	if media.LegalHold {
+		return ErrLegalHold
+	}
+	return nil
+}
+
+// refreshLegalHold recomputes the legal hold for a single media item after
+// it was uploaded or changed group or folder
+func (s *MediaService) refreshLegalHold(ctx context.Context, id uuid.UUID) {
+	changed, err := s.repo.RefreshMediaLegalHold(ctx, models.RetentionScopeMedia, id)
+	if err != nil {
+		log.Printf("[MediaService] failed to refresh legal hold for %s: %v", id, err)
+		return
+	}
+	s.syncObjectLocks(ctx, changed)
+}
+
+// syncObjectLocks mirrors legal hold changes onto the stored objects for
+// accounts with S3 Object Lock enabled: the live or quarantined object of
+// each item and the objects of its previous versions. It returns the number
+// of objects that could not be updated.
+func (s *MediaService) syncObjectLocks(ctx context.Context, changed []models.Media) int {
+	holders := make(map[uuid.UUID]storage.LegalHolder)
+	failures := 0
+
+	for _, media := range changed {
+		holder, seen := holders[media.StorageAccountID]
+		if !seen {
+			holder = s.legalHolder(ctx, media.StorageAccountID)
+			holders[media.StorageAccountID] = holder
+		}
+		if holder == nil {
+			continue
+		}
+
+		keys := []string{media.StorageKey}
+		versions, err := s.repo.ListMediaVersions(ctx, media.ID)
+		if err != nil {
+			log.Printf("[MediaService] failed to list versions of %s for object legal hold: %v", media.ID, err)
+			failures++
+		}
+		for _, v := range versions {
+			keys = append(keys, v.StorageKey)
+		}
+
+		for _, key := range keys {
+			if err := holder.SetLegalHold(ctx, key, media.LegalHold); err != nil {
+				log.Printf("[MediaService] failed to set object legal hold on %s: %v", key, err)
+				failures++
+			}
+		}
+	}
+
+	return failures
+}
+
+// legalHolder returns the adapter for an account if it has Object Lock
+// enabled and its provider supports legal holds, nil otherwise
+func (s *MediaService) legalHolder(ctx context.Context, storageAccountID uuid.UUID) storage.LegalHolder {
+	account, err := s.repo.GetStorageAccountByID(ctx, storageAccountID)
+	if err != nil || !account.ObjectLockEnabled {
+		return nil
+	}
+	adapter, err := s.adapterPool.GetAdapter(ctx, account)
+	if err != nil {
+		return nil
+	}
+	holder, _ := adapter.(storage.LegalHolder)
+	return holder
+}
+
+// validateScopeTarget checks that the group, folder or media item a
+// retention policy or legal hold points at exists
+func (s *MediaService) validateScopeTarget(ctx context.Context, scope models.RetentionScope, targetID uuid.UUID) error {
+	switch scope {
+	case models.RetentionScopeGroup:
+		if _, err := s.repo.GetMediaGroupByID(ctx, targetID); err != nil {
+			return ErrGroupNotFound
+		}
+	case models.RetentionScopeFolder:
+		exists, err := s.repo.FolderExists(ctx, targetID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrInvalidInput
+		}
+	case models.RetentionScopeMedia:
+		if _, err := s.repo.GetMediaByID(ctx, targetID); err != nil {
+			return ErrMediaNotFound
+		}
+	default:
+		return ErrInvalidInput
+	}
+	return nil
+}
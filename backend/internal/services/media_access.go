@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/repository"
+	"github.com/google/uuid"
+)
+
+// getAccessibleMedia loads a media item the employee may see, within the
+// limits of the API key they use. Media outside their access is reported as
+// missing, so its existence does not leak.
+func (s *MediaService) getAccessibleMedia(ctx context.Context, id uuid.UUID, employee *models.Employee) (*models.MediaWithDetails, error) {
+	media, err := s.repo.GetMediaByID(ctx, id)
+	if err != nil {
+		return nil, ErrMediaNotFound
+	}
+	if err := s.checkAccessible(ctx, &media.Media, employee); err != nil {
+		return nil, err
+	}
+	return media, nil
+}
+
+// checkAccessible applies the limits of the employee's API key and the
+// row-level access rules to a loaded media item
+func (s *MediaService) checkAccessible(ctx context.Context, media *models.Media, employee *models.Employee) error {
+	if !employee.KeyLimits.AllowsStorageAccount(media.StorageAccountID) || !employee.KeyLimits.AllowsMediaGroup(media.MediaGroupID) {
+		return ErrMediaNotFound
+	}
+	// Guest uploads are attributed to the upload request's creator, who
+	// reviews them
+	if awaitsReview(media) && media.UploadedBy != employee.ID && !employee.Can(models.PermMediaManageAny) {
+		return ErrMediaNotFound
+	}
+	return s.checkMediaAccess(ctx, media.ID, employee)
+}
+
+// checkMediaAccess makes sure the employee may see a media item: they
+// uploaded it, or they can use its storage account and its group admits
+// their role
+func (s *MediaService) checkMediaAccess(ctx context.Context, id uuid.UUID, employee *models.Employee) error {
+	access := employee.MediaAccess()
+	if access == nil {
+		return nil
+	}
+	ok, err := s.repo.CanAccessMedia(ctx, id, access)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrMediaNotFound
+	}
+	return nil
+}
+
+// CheckUploadTarget makes sure the employee may place media in a storage
+// account and media group; either may be nil when not chosen by them
+func (s *MediaService) CheckUploadTarget(ctx context.Context, storageAccountID, groupID *uuid.UUID, employee *models.Employee) error {
+	access := employee.MediaAccess()
+	if access == nil {
+		return nil
+	}
+
+	if storageAccountID != nil {
+		ok, err := s.repo.HasStorageAccess(ctx, *storageAccountID, employee.ID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrForbidden
+		}
+	}
+
+	if groupID != nil {
+		group, err := s.repo.GetMediaGroupByID(ctx, *groupID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrForbidden
+		}
+		if err != nil {
+			return err
+		}
+		if !roleAllowed(group.AllowedRoles, access.Role) {
+			return ErrForbidden
+		}
+	}
+
+	return nil
+}
+
+// checkDownloadable makes sure the file of a media item may be handed out:
+// it is not quarantined and has passed its antivirus scan
+func checkDownloadable(media *models.Media) error {
+	if media.QuarantinedAt != nil {
+		return ErrMediaQuarantined
+	}
+	if !media.ScanStatus.Passed() {
+		return ErrScanPending
+	}
+	return nil
+}
+
+// awaitsReview reports whether a media item is a guest upload that is still
+// pending review or was rejected
+func awaitsReview(media *models.Media) bool {
+	return media.ReviewStatus != nil && *media.ReviewStatus != models.ReviewStatusApproved
+}
@@ -11,9 +11,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/appnity/media-vault/internal/config"
 	"github.com/appnity/media-vault/internal/crypto"
 	"github.com/appnity/media-vault/internal/models"
 	"github.com/appnity/media-vault/internal/repository"
+	"github.com/appnity/media-vault/internal/scanner"
 	"github.com/appnity/media-vault/internal/storage"
 	"github.com/google/uuid"
 )
@@ -26,22 +28,64 @@ var (
 	ErrMediaNotFound    = errors.New("media not found")
 	ErrGroupNotFound    = errors.New("media group not found")
 	ErrEmployeeNotFound = errors.New("employee not found")
+	ErrMediaQuarantined = errors.New("media is quarantined")
+	ErrScanPending      = errors.New("media has not passed its antivirus scan yet")
+	ErrFileInfected     = errors.New("file did not pass its antivirus scan")
+	ErrScannerDisabled  = errors.New("antivirus scanning is not configured")
+	ErrRestoreConflict  = errors.New("another file already exists at this location")
+	ErrInvalidCursor    = errors.New("invalid pagination cursor")
+	ErrRetentionActive  = errors.New("media is protected by a minimum retention policy")
+	ErrPolicyNotFound   = errors.New("retention policy not found")
+	ErrLegalHold        = errors.New("media is under legal hold")
+	ErrHoldNotFound     = errors.New("legal hold not found")
+	ErrHoldReleased     = errors.New("legal hold is already released")
+	ErrVersionNotFound  = errors.New("media version not found")
+	ErrVersionConflict  = errors.New("media was replaced by another version in the meantime")
+	ErrShareNotFound    = errors.New("share link not found")
+	ErrShareRevoked     = errors.New("share link is already revoked")
+	ErrShareUnavailable = errors.New("share link has expired, been revoked or reached its download limit")
+	ErrSharePassword    = errors.New("share link password is missing or incorrect")
+	ErrShareLocked      = errors.New("too many wrong passwords, try again later")
+
+	ErrUploadRequestNotFound    = errors.New("upload link not found")
+	ErrUploadRequestRevoked     = errors.New("upload link is already revoked")
+	ErrUploadRequestUnavailable = errors.New("upload link has expired, been revoked or reached its file limit")
+	ErrFileNotAllowed           = errors.New("file type or size is not allowed by this upload link")
+	ErrNotPendingReview         = errors.New("media is not awaiting review")
+	ErrAwaitingReview           = errors.New("media is awaiting review")
+
+	ErrWebhookNotFound  = errors.New("webhook endpoint not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+
+	ErrSavedSearchNotFound = errors.New("saved search not found")
+	ErrSavedSearchExists   = errors.New("you already have a saved search with this name")
+	ErrCollectionNotFound  = errors.New("collection not found")
 )
 
 // MediaService handles media operations
 type MediaService struct {
 	repo        *repository.Repository
+	cfg         *config.Config
 	encryptor   *crypto.Encryptor
 	adapterPool *storage.AdapterPool
+	scanner     *scanner.ClamdScanner // nil when antivirus scanning is disabled
+	webhooks    *WebhookService
+	activity    *ActivityHub
+	authz       *Authorizer // permissions of share link creators
 }
 
 // NewMediaService creates a new media service
-func NewMediaService(repo *repository.Repository, encryptor *crypto.Encryptor) *MediaService {
+func NewMediaService(repo *repository.Repository, cfg *config.Config, encryptor *crypto.Encryptor, scanner *scanner.ClamdScanner, webhooks *WebhookService, activity *ActivityHub) *MediaService {
 	factory := storage.NewAdapterFactory(encryptor.Decrypt)
 	return &MediaService{
 		repo:        repo,
+		cfg:         cfg,
 		encryptor:   encryptor,
 		adapterPool: storage.NewAdapterPool(factory),
+		scanner:     scanner,
+		webhooks:    webhooks,
+		activity:    activity,
+		authz:       NewAuthorizer(repo),
 	}
 }
 
@@ -55,6 +99,12 @@ func (s *MediaService) InitiateUpload(ctx context.Context, req *models.UploadMed
 	if err != nil {
 		return nil, err
 	}
+	if !employee.KeyLimits.AllowsStorageAccount(storageAccount.ID) || !employee.KeyLimits.AllowsMediaGroup(req.MediaGroupID) {
+		return nil, ErrOutsideKeyLimits
+	}
+	if err := s.CheckUploadTarget(ctx, req.StorageAccountID, req.MediaGroupID, employee); err != nil {
+		return nil, err
+	}
 
 	// Generate storage key
 	storageKey := s.generateStorageKey(folderPrefix, req.FolderPath, filename)
@@ -76,6 +126,7 @@ func (s *MediaService) InitiateUpload(ctx context.Context, req *models.UploadMed
 	if err := s.repo.CreateMedia(ctx, media); err != nil {
 		return nil, fmt.Errorf("failed to create media record: %w", err)
 	}
+	s.refreshRetention(ctx, media.ID)
 
 	// Get storage adapter and generate signed URL
 	adapter, err := s.adapterPool.GetAdapter(ctx, storageAccount)
@@ -114,7 +165,7 @@ func (s *MediaService) CompleteUpload(ctx context.Context, req *models.UploadCom
 	}
 
 	// Verify ownership
-	if media.UploadedBy != employee.ID && employee.Role != models.RoleAdmin {
+	if media.UploadedBy != employee.ID && !employee.Can(models.PermMediaManageAny) {
 		return nil, ErrForbidden
 	}
 
@@ -129,9 +180,15 @@ func (s *MediaService) CompleteUpload(ctx context.Context, req *models.UploadCom
 		return nil, err
 	}
 
-	publicURL, err := adapter.GetPublicURL(ctx, media.StorageKey)
-	if err != nil {
-		return nil, err
+	// With scanning enabled the file is published once its scan is clean,
+	// and guest uploads awaiting review once they are approved
+	var publicURL *string
+	if s.scanner == nil && !awaitsReview(&media.Media) {
+		url, err := adapter.GetPublicURL(ctx, media.StorageKey)
+		if err != nil {
+			return nil, err
+		}
+		publicURL = &url
 	}
 
 	// Update media record with final details
@@ -140,22 +197,70 @@ func (s *MediaService) CompleteUpload(ctx context.Context, req *models.UploadCom
 	media.Width = req.Width
 	media.Height = req.Height
 	media.DurationSeconds = req.Duration
-	media.PublicURL = &publicURL
+	media.PublicURL = publicURL
 
 	if err := s.repo.UpdateMedia(ctx, &media.Media); err != nil {
 		return nil, err
 	}
+	s.refreshLegalHold(ctx, media.ID)
 
 	// Log the audit
-	s.logAudit(ctx, employee, models.AuditActionUpload, models.SeverityInfo, "media", &media.ID, map[string]any{
+	details := map[string]any{
 		"filename": media.OriginalFilename,
 		"size":     req.FileSizeBytes,
 		"storage":  storageAccount.Name,
-	})
+	}
+	if media.GuestName != nil {
+		details["guest_name"] = *media.GuestName
+		details["upload_request_id"] = media.UploadRequestID
+	}
+	s.logAudit(ctx, employee, models.AuditActionUpload, models.SeverityInfo, "media", &media.ID, details)
+
+	s.scanUploaded(ctx, media, employee)
+
+	// Guest uploads awaiting review are announced once approved
+	if media.ReviewStatus == nil || *media.ReviewStatus == models.ReviewStatusApproved {
+		s.webhooks.Publish(ctx, models.WebhookEventMediaUploaded, media)
+	}
 
 	return media, nil
 }
 
+// scanUploaded scans a freshly uploaded file in the background; the verdict
+// is recorded on the media record
+func (s *MediaService) scanUploaded(ctx context.Context, media *models.MediaWithDetails, employee *models.Employee) {
+	if s.scanner != nil {
+		go func() {
+			scanCtx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+			defer cancel()
+			s.ScanMedia(scanCtx, media.ID, employee)
+		}()
+	} else {
+		_ = s.repo.UpdateMediaScanResult(ctx, media.ID, media.CurrentVersion, models.ScanStatusSkipped, nil)
+		media.ScanStatus = models.ScanStatusSkipped
+	}
+}
+
+// scanSynced scans files added by a storage sync in the background. They are
+// scanned one at a time so a large bucket does not flood clamd; scans that
+// do not finish are picked up by RetryScans.
+func (s *MediaService) scanSynced(ids []uuid.UUID, employeeID uuid.UUID) {
+	if s.scanner == nil || len(ids) == 0 {
+		return
+	}
+	go func() {
+		employee, err := s.repo.GetEmployeeByID(context.Background(), employeeID)
+		if err != nil {
+			employee = &models.Employee{ID: employeeID}
+		}
+		for _, id := range ids {
+			scanCtx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+			s.ScanMedia(scanCtx, id, employee)
+			cancel()
+		}
+	}()
+}
+
 // ListMedia lists media with filters
 func (s *MediaService) ListMedia(ctx context.Context, filters *models.MediaFilterRequest, employee *models.Employee) (*models.PaginatedResponse[models.MediaWithDetails], error) {
 	// Set defaults
@@ -165,6 +270,16 @@ func (s *MediaService) ListMedia(ctx context.Context, filters *models.MediaFilte
 	if filters.PageSize < 1 || filters.PageSize > 100 {
 		filters.PageSize = 50
 	}
+	filters.Fuzzy = strings.TrimSpace(filters.Fuzzy)
+	filters.Limits = employee.KeyLimits
+	filters.Access = employee.MediaAccess()
+	normalizeMediaSort(filters)
+
+	query, err := ParseSearchQuery(filters.Q)
+	if err != nil {
+		return nil, err
+	}
+	filters.Query = query
 
 	mediaList, total, err := s.repo.ListMedia(ctx, filters)
 	if err != nil {
@@ -176,58 +291,168 @@ func (s *MediaService) ListMedia(ctx context.Context, filters *models.MediaFilte
 		totalPages++
 	}
 
-	return &models.PaginatedResponse[models.MediaWithDetails]{
-		Data:       mediaList,
-		Total:      total,
-		Page:       filters.Page,
-		PageSize:   filters.PageSize,
-		TotalPages: totalPages,
-	}, nil
+	response := &models.PaginatedResponse[models.MediaWithDetails]{
+		Data:           mediaList,
+		Total:          total,
+		Page:           filters.Page,
+		PageSize:       filters.PageSize,
+		TotalPages:     totalPages,
+		TotalEstimated: filters.Count == models.CountEstimate,
+	}
+	if filters.Facets {
+		if response.Facets, err = s.repo.GetMediaFacets(ctx, filters); err != nil {
+			return nil, err
+		}
+	}
+	if filters.Page == 1 {
+		response.DidYouMean = s.searchSuggestions(ctx, filters)
+	}
+
+	return response, nil
+}
+
+// ListMediaCursor lists media with keyset pagination. Unlike page numbers,
+// cursors neither repeat nor skip items when uploads arrive while paging.
+func (s *MediaService) ListMediaCursor(ctx context.Context, filters *models.MediaFilterRequest, employee *models.Employee) (*models.CursorPage[models.MediaWithDetails], error) {
+	if filters.PageSize < 1 || filters.PageSize > 100 {
+		filters.PageSize = 50
+	}
+	if filters.Count == "" {
+		filters.Count = models.CountNone
+	}
+	filters.Fuzzy = strings.TrimSpace(filters.Fuzzy)
+	filters.Limits = employee.KeyLimits
+	filters.Access = employee.MediaAccess()
+	normalizeMediaSort(filters)
+
+	query, err := ParseSearchQuery(filters.Q)
+	if err != nil {
+		return nil, err
+	}
+	filters.Query = query
+
+	after, err := decodeCursor(*filters.Cursor, filters.SortBy, filters.SortOrder)
+	if err != nil {
+		return nil, err
+	}
+	filters.After = after
+
+	mediaList, total, err := s.repo.ListMedia(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.CursorPage[models.MediaWithDetails]{Data: mediaList}
+	if len(mediaList) > filters.PageSize {
+		page.Data = mediaList[:filters.PageSize]
+		page.HasMore = true
+		last := &page.Data[len(page.Data)-1]
+		page.NextCursor = encodeCursor(&models.PageCursor{
+			SortBy: filters.SortBy,
+			Order:  filters.SortOrder,
+			Value:  mediaSortValue(last, filters.SortBy),
+			ID:     last.ID,
+		})
+	}
+	page.Total, page.TotalEstimated = cursorTotal(filters.Count, total)
+
+	// Facets and suggestions describe the whole search, so only the first
+	// page has them
+	if filters.After == nil {
+		if filters.Facets {
+			if page.Facets, err = s.repo.GetMediaFacets(ctx, filters); err != nil {
+				return nil, err
+			}
+		}
+		page.DidYouMean = s.searchSuggestions(ctx, filters)
+	}
+
+	return page, nil
 }
 
-// GetMedia gets a single media item
-func (s *MediaService) GetMedia(ctx context.Context, id uuid.UUID) (*models.MediaWithDetails, error) {
-	return s.repo.GetMediaByID(ctx, id)
+// ListAuditLogsCursor lists audit logs, newest first, with keyset pagination
+func (s *MediaService) ListAuditLogsCursor(ctx context.Context, filter *models.AuditLogFilterRequest) (*models.CursorPage[models.AuditLog], error) {
+	if filter.PageSize < 1 || filter.PageSize > 500 {
+		filter.PageSize = 50
+	}
+	if filter.Count == "" {
+		filter.Count = models.CountNone
+	}
+
+	after, err := decodeCursor(*filter.Cursor, "created_at", "desc")
+	if err != nil {
+		return nil, err
+	}
+	filter.After = after
+
+	logs, total, err := s.repo.ListAuditLogs(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.CursorPage[models.AuditLog]{Data: logs}
+	if page.Data == nil {
+		page.Data = []models.AuditLog{}
+	}
+	if len(logs) > filter.PageSize {
+		page.Data = logs[:filter.PageSize]
+		page.HasMore = true
+		last := &page.Data[len(page.Data)-1]
+		page.NextCursor = encodeCursor(&models.PageCursor{
+			SortBy: "created_at",
+			Order:  "desc",
+			Value:  last.CreatedAt.Format(time.RFC3339Nano),
+			ID:     last.ID,
+		})
+	}
+	page.Total, page.TotalEstimated = cursorTotal(filter.Count, total)
+
+	return page, nil
+}
+
+// GetMedia gets a single media item the employee may see
+func (s *MediaService) GetMedia(ctx context.Context, id uuid.UUID, employee *models.Employee) (*models.MediaWithDetails, error) {
+	return s.getAccessibleMedia(ctx, id, employee)
 }
 
-// DeleteMedia soft deletes a media item
+// DeleteMedia moves a media item into the trash. The stored object is kept
+// until the item is purged, so the deletion can be undone.
 func (s *MediaService) DeleteMedia(ctx context.Context, id uuid.UUID, employee *models.Employee) error {
-	media, err := s.repo.GetMediaByID(ctx, id)
+	media, err := s.getAccessibleMedia(ctx, id, employee)
 	if err != nil {
-		return ErrMediaNotFound
+		return err
 	}
 
 	// Check permissions
-	if media.UploadedBy != employee.ID && employee.Role != models.RoleAdmin {
+	if media.UploadedBy != employee.ID && !employee.Can(models.PermMediaManageAny) {
 		return ErrForbidden
 	}
 
-	// Delete from cloud storage
-	storageAccount, err := s.repo.GetStorageAccountByID(ctx, media.StorageAccountID)
-	if err == nil {
-		if adapter, err := s.adapterPool.GetAdapter(ctx, storageAccount); err == nil {
-			if err := adapter.Delete(ctx, media.StorageKey); err != nil {
-				// Log error but proceed? Or fail? User insisted on deletion.
-				// Let's return error to ensure user knows if it failed.
-				return fmt.Errorf("failed to delete from cloud storage: %w", err)
-			}
-		}
+	if err := checkLegalHold(&media.Media); err != nil {
+		return err
+	}
+	if err := checkRetention(&media.Media); err != nil {
+		return err
 	}
 
-	// Soft delete in database
-	if err := s.repo.SoftDeleteMedia(ctx, id); err != nil {
+	if err := s.repo.TrashMedia(ctx, id, employee.ID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrMediaNotFound
+		}
 		return err
 	}
 
 	// Log audit
 	s.logAudit(ctx, employee, models.AuditActionDelete, models.SeverityWarning, "media", &id, map[string]any{
-		"filename": media.OriginalFilename,
+		"filename":    media.OriginalFilename,
+		"purge_after": time.Now().Add(s.trashRetention()),
 	})
+	s.webhooks.Publish(ctx, models.WebhookEventMediaDeleted, media)
 
 	return nil
 }
 
-// BatchDeleteMedia soft deletes multiple media items concurrently for improved performance
+// BatchDeleteMedia moves multiple media items concurrently for improved performance
 func (s *MediaService) BatchDeleteMedia(ctx context.Context, ids []uuid.UUID, employee *models.Employee) error {
 	errChan := make(chan error, len(ids))
 
@@ -247,17 +472,74 @@ func (s *MediaService) BatchDeleteMedia(ctx context.Context, ids []uuid.UUID, em
 	return lastErr
 }
 
+// UpdateMedia changes the group, folder or tags of a media item. Changing
+// group or folder moves the item, which a legal hold forbids, and changes
+// the retention policies and legal holds that apply to it.
+func (s *MediaService) UpdateMedia(ctx context.Context, id uuid.UUID, req *models.UpdateMediaRequest, employee *models.Employee) (*models.MediaWithDetails, error) {
+	media, err := s.getAccessibleMedia(ctx, id, employee)
+	if err != nil {
+		return nil, err
+	}
+
+	if media.UploadedBy != employee.ID && !employee.Can(models.PermMediaEditAny) {
+		return nil, ErrForbidden
+	}
+	// The new group must admit the employee's role
+	if err := s.CheckUploadTarget(ctx, nil, req.MediaGroupID, employee); err != nil {
+		return nil, err
+	}
+
+	moved := req.MediaGroupID != nil || req.FolderID != nil
+	if moved {
+		if err := checkLegalHold(&media.Media); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.MediaGroupID != nil {
+		media.MediaGroupID = req.MediaGroupID
+	}
+	if req.FolderID != nil {
+		media.FolderID = req.FolderID
+	}
+	if req.Tags != nil {
+		media.Tags = req.Tags
+	}
+
+	if err := s.repo.UpdateMedia(ctx, &media.Media); err != nil {
+		return nil, err
+	}
+	if moved {
+		s.RefreshMediaPolicies(ctx, id)
+	}
+
+	updated, err := s.repo.GetMediaByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.webhooks.Publish(ctx, models.WebhookEventMediaUpdated, updated)
+
+	return updated, nil
+}
+
 // MoveMedia moves media to a different group/folder
 func (s *MediaService) MoveMedia(ctx context.Context, id uuid.UUID, req *models.MoveMediaRequest, employee *models.Employee) (*models.MediaWithDetails, error) {
-	media, err := s.repo.GetMediaByID(ctx, id)
+	media, err := s.getAccessibleMedia(ctx, id, employee)
 	if err != nil {
-		return nil, ErrMediaNotFound
+		return nil, err
 	}
 
 	// Check permissions
-	if media.UploadedBy != employee.ID && employee.Role != models.RoleAdmin && employee.Role != models.RoleDeveloper {
+	if media.UploadedBy != employee.ID && !employee.Can(models.PermMediaEditAny) {
 		return nil, ErrForbidden
 	}
+	if err := s.CheckUploadTarget(ctx, req.StorageAccountID, req.MediaGroupID, employee); err != nil {
+		return nil, err
+	}
+
+	if err := checkLegalHold(&media.Media); err != nil {
+		return nil, err
+	}
 
 	// If moving to different storage, need to actually move the file
 	if req.StorageAccountID != nil && *req.StorageAccountID != media.StorageAccountID {
@@ -274,20 +556,34 @@ func (s *MediaService) MoveMedia(ctx context.Context, id uuid.UUID, req *models.
 	if err := s.repo.UpdateMedia(ctx, &media.Media); err != nil {
 		return nil, err
 	}
+	s.RefreshMediaPolicies(ctx, id)
 
 	// Log audit
 	s.logAudit(ctx, employee, models.AuditActionMove, models.SeverityInfo, "media", &id, map[string]any{
 		"new_folder": req.FolderPath,
 	})
 
-	return s.repo.GetMediaByID(ctx, id)
+	moved, err := s.repo.GetMediaByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.webhooks.Publish(ctx, models.WebhookEventMediaMoved, moved)
+
+	return moved, nil
 }
 
 // GetPublicURL gets the public URL for a media item
-func (s *MediaService) GetPublicURL(ctx context.Context, id uuid.UUID) (string, error) {
-	media, err := s.repo.GetMediaByID(ctx, id)
+func (s *MediaService) GetPublicURL(ctx context.Context, id uuid.UUID, employee *models.Employee) (string, error) {
+	media, err := s.getAccessibleMedia(ctx, id, employee)
 	if err != nil {
-		return "", ErrMediaNotFound
+		return "", err
+	}
+
+	if err := checkDownloadable(&media.Media); err != nil {
+		return "", err
+	}
+	if awaitsReview(&media.Media) {
+		return "", ErrAwaitingReview
 	}
 
 	if media.PublicURL != nil && *media.PublicURL != "" {
@@ -309,10 +605,14 @@ func (s *MediaService) GetPublicURL(ctx context.Context, id uuid.UUID) (string,
 }
 
 // DownloadMedia retrieves a file stream for download
-func (s *MediaService) DownloadMedia(ctx context.Context, id uuid.UUID) (*models.MediaWithDetails, io.ReadCloser, error) {
-	media, err := s.repo.GetMediaByID(ctx, id)
+func (s *MediaService) DownloadMedia(ctx context.Context, id uuid.UUID, employee *models.Employee) (*models.MediaWithDetails, io.ReadCloser, error) {
+	media, err := s.getAccessibleMedia(ctx, id, employee)
 	if err != nil {
-		return nil, nil, ErrMediaNotFound
+		return nil, nil, err
+	}
+
+	if err := checkDownloadable(&media.Media); err != nil {
+		return nil, nil, err
 	}
 
 	storageAccount, err := s.repo.GetStorageAccountByID(ctx, media.StorageAccountID)
@@ -336,13 +636,13 @@ func (s *MediaService) DownloadMedia(ctx context.Context, id uuid.UUID) (*models
 	return media, reader, nil
 }
 
-// BatchDownloadMedia creates a ZIP of multiple media items
-func (s *MediaService) BatchDownloadMedia(ctx context.Context, ids []uuid.UUID, w io.Writer) error {
+// BatchDownloadMedia creates a ZIP of the media items the employee may see
+func (s *MediaService) BatchDownloadMedia(ctx context.Context, ids []uuid.UUID, employee *models.Employee, w io.Writer) error {
 	zipWriter := zip.NewWriter(w)
 	defer zipWriter.Close()
 
 	for _, id := range ids {
-		media, reader, err := s.DownloadMedia(ctx, id)
+		media, reader, err := s.DownloadMedia(ctx, id, employee)
 		if err != nil {
 			// Skip files that can't be downloaded, log and continue
 			continue
@@ -545,13 +845,7 @@ func (s *MediaService) logAudit(_ context.Context, employee *models.Employee, ac
 		Details:       details,
 		CreatedAt:     time.Now(),
 	}
-	// Fire and forget - don't block on audit logging
-	go func() {
-		enabled, _ := s.repo.GetFeatureFlag(context.Background(), "enable_audit_logs")
-		if enabled {
-			_ = s.repo.CreateAuditLog(context.Background(), log)
-		}
-	}()
+	s.recordAudit(log)
 }
 
 // LogAuditRaw logs a pre-constructed audit log
@@ -562,14 +856,55 @@ func (s *MediaService) LogAuditRaw(ctx context.Context, log *models.AuditLog) {
 	if log.CreatedAt.IsZero() {
 		log.CreatedAt = time.Now()
 	}
+	s.recordAudit(log)
+}
+
+// recordAudit stores an audit entry and streams it to live clients.
+// Fire and forget - don't block on audit logging.
+func (s *MediaService) recordAudit(log *models.AuditLog) {
 	go func() {
-		enabled, _ := s.repo.GetFeatureFlag(context.Background(), "enable_audit_logs")
+		ctx := context.Background()
+		enabled, _ := s.repo.GetFeatureFlag(ctx, "enable_audit_logs")
 		if enabled {
-			_ = s.repo.CreateAuditLog(context.Background(), log)
+			_ = s.repo.CreateAuditLog(ctx, log)
 		}
+		s.activity.PublishAudit(ctx, log)
 	}()
 }
 
+// SubscribeActivity opens a live stream of the activity the employee can see
+func (s *MediaService) SubscribeActivity(ctx context.Context, employee *models.Employee) (*ActivitySubscription, error) {
+	return s.activity.Subscribe(ctx, employee)
+}
+
+// RefreshActivity resolves again what the employee of an open activity
+// stream may see. It returns ErrAccountDisabled once they were deactivated
+// or deleted, after which the stream must end.
+func (s *MediaService) RefreshActivity(ctx context.Context, sub *ActivitySubscription) error {
+	current, err := s.repo.GetEmployeeByID(ctx, sub.EmployeeID())
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && !current.IsActive) {
+		return ErrAccountDisabled
+	}
+	if err != nil {
+		return err
+	}
+
+	current.Permissions, err = s.authz.Permissions(ctx, current.Role, current.CustomRoleID)
+	if err != nil {
+		return err
+	}
+	sub.mu.RLock()
+	current.KeyLimits = sub.employee.KeyLimits
+	sub.mu.RUnlock()
+	return sub.Refresh(ctx, current)
+}
+
+// PublishMediaEvent queues a webhook event for a media change made outside
+// the service, such as a metadata update
+func (s *MediaService) PublishMediaEvent(ctx context.Context, eventType models.WebhookEventType, media *models.MediaWithDetails) {
+	s.webhooks.Publish(ctx, eventType, media)
+}
+
 // DetermineContentType guesses content type from filename
 func DetermineContentType(filename string) string {
 	ext := filepath.Ext(filename)
@@ -595,6 +930,7 @@ func (s *MediaService) SyncStorageAccount(ctx context.Context, storageAccountID
 	// 3. List Files (pagination loop)
 	var added, skipped int
 	var syncErrors []string
+	var synced []uuid.UUID
 	cursor := ""
 	for {
 		result, err := adapter.List(ctx, "", 100, cursor)
@@ -602,6 +938,12 @@ func (s *MediaService) SyncStorageAccount(ctx context.Context, storageAccountID
 			return nil, fmt.Errorf("failed to list files: %w", err)
 		}
 		for _, file := range result.Files {
+			// Staged and archived versions belong to an existing item
+			if isVersionKey(file.StorageKey) {
+				skipped++
+				continue
+			}
+
 			// Check existence
 			exists, err := s.repo.CheckMediaExists(ctx, account.ID, file.StorageKey)
 			if err != nil {
@@ -646,8 +988,14 @@ func (s *MediaService) SyncStorageAccount(ctx context.Context, storageAccountID
 				}
 			}
 
-			// Get Public URL
-			publicURL, _ := adapter.GetPublicURL(ctx, file.StorageKey)
+			// With scanning enabled the file is published once its scan is clean
+			scanStatus := models.ScanStatusSkipped
+			var publicURL *string
+			if s.scanner != nil {
+				scanStatus = models.ScanStatusPending
+			} else if url, err := adapter.GetPublicURL(ctx, file.StorageKey); err == nil {
+				publicURL = &url
+			}
 
 			// Create media
 			media := &models.Media{
@@ -660,18 +1008,33 @@ func (s *MediaService) SyncStorageAccount(ctx context.Context, storageAccountID
 				MediaType:        s.determineMediaType(mimeType),
 				UploadedBy:       employeeID,
 				Tags:             []string{"synced"},
-				PublicURL:        &publicURL,
+				PublicURL:        publicURL,
+				ScanStatus:       scanStatus,
 			}
 			if err := s.repo.CreateMedia(ctx, media); err != nil {
 				syncErrors = append(syncErrors, fmt.Sprintf("create db error for %s: %v", file.StorageKey, err))
 			} else {
 				added++
+				synced = append(synced, media.ID)
 			}
 		}
 		if !result.HasMore {
 			break
 		}
 		cursor = result.NextCursor
+
+		s.activity.PublishSyncProgress(ctx, storageAccountID, employeeID, &models.SyncResult{
+			AddedCount: added, SkippedCount: skipped, Errors: syncErrors,
+		}, false)
 	}
-	return &models.SyncResult{AddedCount: added, SkippedCount: skipped, Errors: syncErrors}, nil
+
+	s.scanSynced(synced, employeeID)
+
+	syncResult := &models.SyncResult{AddedCount: added, SkippedCount: skipped, Errors: syncErrors}
+	s.activity.PublishSyncProgress(ctx, storageAccountID, employeeID, syncResult, true)
+	s.webhooks.Publish(ctx, models.WebhookEventStorageSyncCompleted, map[string]any{
+		"storage_account_id": storageAccountID,
+		"result":             syncResult,
+	})
+	return syncResult, nil
 }
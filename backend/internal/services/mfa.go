@@ -0,0 +1,392 @@
+package services
+
+import (
+	"context"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/appnity/media-vault/internal/crypto"
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/repository"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrMFAInvalidCode    = errors.New("invalid two-factor authentication code")
+	ErrMFALocked         = errors.New("too many wrong codes, try again later")
+	ErrMFANotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMFANotPending     = errors.New("no two-factor authentication enrollment is pending")
+	ErrMFARequired       = errors.New("your role requires two-factor authentication")
+)
+
+const (
+	mfaChallengeExpiry = 5 * time.Minute
+	mfaMaxAttempts     = 5 // Wrong codes in a row before verification locks
+	mfaLockDuration    = 15 * time.Minute
+	recoveryCodeCount  = 10
+)
+
+// mfaChallenge returns the challenge a login must complete with a second
+// factor, or nil when the password is enough: the employee has not enabled
+// TOTP and their role does not require it
+func (s *AuthService) mfaChallenge(ctx context.Context, employee *models.Employee) (*models.MFAChallenge, error) {
+	required, err := s.mfaRequired(ctx, employee.Role)
+	if err != nil {
+		return nil, err
+	}
+	if employee.MFAEnabledAt == nil && !required {
+		return nil, nil
+	}
+
+	claims := JWTClaims{
+		EmployeeID: employee.ID,
+		TokenType:  TokenTypeMFA,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.cfg.JWTIssuer,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(mfaChallengeExpiry)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Subject:   employee.ID.String(),
+		},
+	}
+	token, err := s.keys.sign(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.MFAChallenge{
+		MFARequired:        true,
+		MFAToken:           token,
+		ExpiresIn:          int64(mfaChallengeExpiry.Seconds()),
+		EnrollmentRequired: employee.MFAEnabledAt == nil,
+	}, nil
+}
+
+// VerifyMFA completes a login with a TOTP or recovery code
+func (s *AuthService) VerifyMFA(ctx context.Context, mfaToken, code string, client SessionClient) (*models.AuthResponse, error) {
+	employee, err := s.challengeEmployee(ctx, mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkMFACode(ctx, employee.ID, code, true); err != nil {
+		return nil, err
+	}
+	return s.startSession(ctx, employee, client)
+}
+
+// BeginMFASetup starts TOTP enrollment during a login whose role requires
+// two-factor authentication the employee has not set up yet
+func (s *AuthService) BeginMFASetup(ctx context.Context, mfaToken string) (*models.TOTPEnrollment, error) {
+	employee, err := s.challengeEmployee(ctx, mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	return s.beginTOTPEnrollment(ctx, employee)
+}
+
+// CompleteMFASetup confirms the TOTP enrollment of a login and completes
+// it. The response carries the new recovery codes.
+func (s *AuthService) CompleteMFASetup(ctx context.Context, mfaToken, code string, client SessionClient) (*models.AuthResponse, error) {
+	employee, err := s.challengeEmployee(ctx, mfaToken)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, err := s.confirmTOTP(ctx, employee.ID, code)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	employee.MFAEnabledAt = &now
+
+	response, err := s.startSession(ctx, employee, client)
+	if err != nil {
+		return nil, err
+	}
+	response.RecoveryCodes = codes
+	return response, nil
+}
+
+// MFAStatus describes an employee's two-factor authentication
+func (s *AuthService) MFAStatus(ctx context.Context, employeeID uuid.UUID) (*models.MFAStatus, error) {
+	employee, err := s.repo.GetEmployeeByID(ctx, employeeID)
+	if err != nil {
+		return nil, ErrEmployeeNotFound
+	}
+	required, err := s.mfaRequired(ctx, employee.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &models.MFAStatus{
+		Enabled:   employee.MFAEnabledAt != nil,
+		EnabledAt: employee.MFAEnabledAt,
+		Required:  required,
+	}
+	if status.Enabled {
+		if status.RecoveryCodesRemaining, err = s.repo.CountRecoveryCodes(ctx, employeeID); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// BeginTOTPEnrollment creates a new TOTP secret for a signed-in employee. It
+// takes effect once ConfirmTOTPEnrollment receives a code generated from it.
+func (s *AuthService) BeginTOTPEnrollment(ctx context.Context, employeeID uuid.UUID) (*models.TOTPEnrollment, error) {
+	employee, err := s.repo.GetEmployeeByID(ctx, employeeID)
+	if err != nil {
+		return nil, ErrEmployeeNotFound
+	}
+	return s.beginTOTPEnrollment(ctx, employee)
+}
+
+// ConfirmTOTPEnrollment enables TOTP with a first code and returns the
+// recovery codes
+func (s *AuthService) ConfirmTOTPEnrollment(ctx context.Context, employeeID uuid.UUID, code string) ([]string, error) {
+	return s.confirmTOTP(ctx, employeeID, code)
+}
+
+// RegenerateRecoveryCodes replaces an employee's recovery codes, after
+// checking a current code
+func (s *AuthService) RegenerateRecoveryCodes(ctx context.Context, employeeID uuid.UUID, code string) ([]string, error) {
+	if err := s.checkMFACode(ctx, employeeID, code, true); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.ReplaceRecoveryCodes(ctx, employeeID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableMFA turns off an employee's two-factor authentication, after
+// checking a current code. Employees whose role requires it cannot.
+func (s *AuthService) DisableMFA(ctx context.Context, employeeID uuid.UUID, code string) error {
+	employee, err := s.repo.GetEmployeeByID(ctx, employeeID)
+	if err != nil {
+		return ErrEmployeeNotFound
+	}
+	required, err := s.mfaRequired(ctx, employee.Role)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrMFARequired
+	}
+
+	if err := s.checkMFACode(ctx, employeeID, code, true); err != nil {
+		return err
+	}
+	return s.repo.DisableTOTP(ctx, employeeID)
+}
+
+// ResetMFA removes an employee's two-factor authentication, for when they
+// lost their device and recovery codes (admin only). If their role requires
+// it, they enroll again at their next login.
+func (s *AuthService) ResetMFA(ctx context.Context, employeeID uuid.UUID) error {
+	err := s.repo.DisableTOTP(ctx, employeeID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrEmployeeNotFound
+	}
+	return err
+}
+
+// GetMFAPolicy lists the roles that must use two-factor authentication
+func (s *AuthService) GetMFAPolicy(ctx context.Context) (*models.MFAPolicy, error) {
+	roles, err := s.repo.ListMFARequiredRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &models.MFAPolicy{RequiredRoles: roles}, nil
+}
+
+// SetMFAPolicy replaces the roles that must use two-factor authentication.
+// Their employees without it enroll at their next login.
+func (s *AuthService) SetMFAPolicy(ctx context.Context, policy *models.MFAPolicy, updatedBy uuid.UUID) (*models.MFAPolicy, error) {
+	if err := s.repo.SetMFARequiredRoles(ctx, policy.RequiredRoles, updatedBy); err != nil {
+		return nil, err
+	}
+	return s.GetMFAPolicy(ctx)
+}
+
+// mfaRequired reports whether a role must use two-factor authentication
+func (s *AuthService) mfaRequired(ctx context.Context, role models.Role) (bool, error) {
+	roles, err := s.repo.ListMFARequiredRoles(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, r := range roles {
+		if r == role {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// challengeEmployee finds the employee of an MFA challenge token
+func (s *AuthService) challengeEmployee(ctx context.Context, mfaToken string) (*models.Employee, error) {
+	claims, err := s.parseToken(mfaToken, TokenTypeMFA)
+	if err != nil {
+		return nil, err
+	}
+
+	employee, err := s.repo.GetEmployeeByID(ctx, claims.EmployeeID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	if !employee.IsActive {
+		return nil, ErrAccountDisabled
+	}
+	return employee, nil
+}
+
+// beginTOTPEnrollment stores a new pending TOTP secret, replacing an
+// unconfirmed one
+func (s *AuthService) beginTOTPEnrollment(ctx context.Context, employee *models.Employee) (*models.TOTPEnrollment, error) {
+	if employee.MFAEnabledAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := crypto.GenerateRandomBytes(totpSecretSize)
+	if err != nil {
+		return nil, err
+	}
+	encrypted, nonce, err := s.encryptor.Encrypt(secret)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetPendingTOTPSecret(ctx, employee.ID, encrypted, nonce); err != nil {
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			return nil, ErrMFAAlreadyEnabled
+		}
+		return nil, err
+	}
+
+	return &models.TOTPEnrollment{
+		Secret:          totpEncoding.EncodeToString(secret),
+		ProvisioningURI: totpProvisioningURI(s.cfg.MFAIssuer, employee.Email, secret),
+	}, nil
+}
+
+// confirmTOTP enables a pending TOTP secret with a code generated from it
+// and returns new recovery codes
+func (s *AuthService) confirmTOTP(ctx context.Context, employeeID uuid.UUID, code string) ([]string, error) {
+	stored, err := s.repo.GetTOTPSecret(ctx, employeeID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrMFANotPending
+	}
+	if err != nil {
+		return nil, err
+	}
+	if stored.EnabledAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if stored.LockedUntil != nil && s.now().Before(*stored.LockedUntil) {
+		return nil, ErrMFALocked
+	}
+
+	secret, err := s.encryptor.Decrypt(stored.EncryptedSecret, stored.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	step, ok := matchTOTP(secret, normalizeTOTPCode(code), s.now())
+	if !ok {
+		return nil, s.mfaFailure(ctx, employeeID)
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.EnableTOTP(ctx, employeeID, step, hashes); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrMFANotPending
+		}
+		return nil, err
+	}
+	return codes, nil
+}
+
+// checkMFACode verifies a code of an employee with TOTP enabled. A TOTP
+// code is accepted once; with allowRecovery an unused recovery code is
+// accepted and used up instead. Wrong codes count towards a lockout.
+func (s *AuthService) checkMFACode(ctx context.Context, employeeID uuid.UUID, code string, allowRecovery bool) error {
+	stored, err := s.repo.GetTOTPSecret(ctx, employeeID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrMFANotEnabled
+	}
+	if err != nil {
+		return err
+	}
+	if stored.EnabledAt == nil {
+		return ErrMFANotEnabled
+	}
+	if stored.LockedUntil != nil && s.now().Before(*stored.LockedUntil) {
+		return ErrMFALocked
+	}
+
+	secret, err := s.encryptor.Decrypt(stored.EncryptedSecret, stored.Nonce)
+	if err != nil {
+		return err
+	}
+
+	ok := false
+	if step, matched := matchTOTP(secret, normalizeTOTPCode(code), s.now()); matched && step > stored.LastStep {
+		if ok, err = s.repo.UseTOTPStep(ctx, employeeID, step); err != nil {
+			return err
+		}
+	}
+	if !ok && allowRecovery {
+		if ok, err = s.repo.UseRecoveryCode(ctx, employeeID, crypto.HashToken(normalizeRecoveryCode(code))); err != nil {
+			return err
+		}
+	}
+	if !ok {
+		return s.mfaFailure(ctx, employeeID)
+	}
+
+	return s.repo.ResetMFAFailures(ctx, employeeID)
+}
+
+// mfaFailure counts a wrong code and returns the error to report
+func (s *AuthService) mfaFailure(ctx context.Context, employeeID uuid.UUID) error {
+	if err := s.repo.RecordMFAFailure(ctx, employeeID, mfaMaxAttempts, s.now().Add(mfaLockDuration)); err != nil {
+		return err
+	}
+	return ErrMFAInvalidCode
+}
+
+// newRecoveryCodes generates recovery codes and the hashes to store for them
+func newRecoveryCodes() ([]string, []string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b, err := crypto.GenerateRandomBytes(10)
+		if err != nil {
+			return nil, nil, err
+		}
+		code := strings.ToLower(encoding.EncodeToString(b))[:10]
+		codes[i] = code[:5] + "-" + code[5:]
+		hashes[i] = crypto.HashToken(code)
+	}
+	return codes, hashes, nil
+}
+
+// normalizeTOTPCode drops the spaces authenticator apps group digits with
+func normalizeTOTPCode(code string) string {
+	return strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+}
+
+// normalizeRecoveryCode makes recovery codes case and dash insensitive
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/appnity/media-vault/internal/crypto"
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/testdb"
+)
+
+// mfaFixture is an employee who enrolled in TOTP at now, which only moves
+// when a test advances it
+type mfaFixture struct {
+	s        *AuthService
+	now      time.Time
+	employee *models.Employee
+	secret   []byte
+	recovery []string
+}
+
+func newMFAFixture(t *testing.T) *mfaFixture {
+	t.Helper()
+	repo, _ := testdb.Open(t)
+	ctx := context.Background()
+
+	encryptor, err := crypto.NewEncryptor([]byte(strings.Repeat("k", 32)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewAuthService(repo, tokenTestConfig(), encryptor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Start of a time step, so that codes of the next step are 30s away
+	f := &mfaFixture{s: s, now: time.Unix(56_666_667*totpPeriod, 0)}
+	s.now = func() time.Time { return f.now }
+
+	f.employee = testdb.Employee(t, repo, models.RoleDeveloper)
+	enrollment, err := s.BeginTOTPEnrollment(ctx, f.employee.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.secret, err = totpEncoding.DecodeString(enrollment.Secret); err != nil {
+		t.Fatal(err)
+	}
+	if f.recovery, err = s.ConfirmTOTPEnrollment(ctx, f.employee.ID, f.code(0)); err != nil {
+		t.Fatalf("confirm enrollment: %v", err)
+	}
+	if f.employee, err = repo.GetEmployeeByID(ctx, f.employee.ID); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+// code is the TOTP code of the step offset steps from now
+func (f *mfaFixture) code(offset int64) string {
+	return totpCode(f.secret, totpStep(f.now)+offset)
+}
+
+// wrongCode is a code that matches no step accepted now
+func (f *mfaFixture) wrongCode() string {
+	for _, code := range []string{"000000", "111111", "222222", "333333"} {
+		if code != f.code(-1) && code != f.code(0) && code != f.code(1) {
+			return code
+		}
+	}
+	panic("unreachable")
+}
+
+// advance moves the clock on
+func (f *mfaFixture) advance(d time.Duration) {
+	f.now = f.now.Add(d)
+}
+
+// check verifies a code like a login does
+func (f *mfaFixture) check(code string) error {
+	return f.s.checkMFACode(context.Background(), f.employee.ID, code, true)
+}
+
+func TestCheckMFACodeRejectsReplayedCodes(t *testing.T) {
+	f := newMFAFixture(t)
+
+	if err := f.check(f.code(0)); !errors.Is(err, ErrMFAInvalidCode) {
+		t.Fatalf("code used to enroll: got %v, want %v", err, ErrMFAInvalidCode)
+	}
+
+	f.advance(totpPeriod * time.Second)
+	if err := f.check(f.code(0)); err != nil {
+		t.Fatalf("code of a new step: %v", err)
+	}
+	if err := f.check(f.code(0)); !errors.Is(err, ErrMFAInvalidCode) {
+		t.Fatalf("same code again: got %v, want %v", err, ErrMFAInvalidCode)
+	}
+	// Within the accepted drift, but older than the last code used
+	if err := f.check(f.code(-1)); !errors.Is(err, ErrMFAInvalidCode) {
+		t.Fatalf("code of an earlier step: got %v, want %v", err, ErrMFAInvalidCode)
+	}
+	// A code of the next step is accepted early, and then blocks the
+	// current one
+	if err := f.check(f.code(1)); err != nil {
+		t.Fatalf("code of the next step: %v", err)
+	}
+	f.advance(totpPeriod * time.Second)
+	if err := f.check(f.code(0)); !errors.Is(err, ErrMFAInvalidCode) {
+		t.Fatalf("code of a step already used early: got %v, want %v", err, ErrMFAInvalidCode)
+	}
+}
+
+func TestCheckMFACodeLocksAfterWrongCodes(t *testing.T) {
+	f := newMFAFixture(t)
+
+	// A correct code starts the count again
+	for i := 0; i < mfaMaxAttempts-1; i++ {
+		if err := f.check(f.wrongCode()); !errors.Is(err, ErrMFAInvalidCode) {
+			t.Fatalf("wrong code %d: got %v, want %v", i+1, err, ErrMFAInvalidCode)
+		}
+	}
+	f.advance(totpPeriod * time.Second)
+	if err := f.check(f.code(0)); err != nil {
+		t.Fatalf("correct code before the lock: %v", err)
+	}
+
+	for i := 0; i < mfaMaxAttempts; i++ {
+		if err := f.check(f.wrongCode()); !errors.Is(err, ErrMFAInvalidCode) {
+			t.Fatalf("wrong code %d: got %v, want %v", i+1, err, ErrMFAInvalidCode)
+		}
+	}
+	f.advance(totpPeriod * time.Second)
+	if err := f.check(f.code(0)); !errors.Is(err, ErrMFALocked) {
+		t.Fatalf("correct code while locked: got %v, want %v", err, ErrMFALocked)
+	}
+	if err := f.check(f.recovery[0]); !errors.Is(err, ErrMFALocked) {
+		t.Fatalf("recovery code while locked: got %v, want %v", err, ErrMFALocked)
+	}
+
+	f.advance(mfaLockDuration)
+	if err := f.check(f.code(0)); err != nil {
+		t.Fatalf("correct code after the lock expired: %v", err)
+	}
+}
+
+func TestCheckMFACodeUsesUpRecoveryCodes(t *testing.T) {
+	f := newMFAFixture(t)
+	ctx := context.Background()
+
+	if len(f.recovery) != recoveryCodeCount {
+		t.Fatalf("got %d recovery codes, want %d", len(f.recovery), recoveryCodeCount)
+	}
+	if err := f.s.checkMFACode(ctx, f.employee.ID, f.recovery[0], false); !errors.Is(err, ErrMFAInvalidCode) {
+		t.Fatalf("recovery code where only TOTP is allowed: got %v, want %v", err, ErrMFAInvalidCode)
+	}
+	if err := f.check(f.recovery[0]); err != nil {
+		t.Fatalf("recovery code: %v", err)
+	}
+	if err := f.check(f.recovery[0]); !errors.Is(err, ErrMFAInvalidCode) {
+		t.Fatalf("recovery code used twice: got %v, want %v", err, ErrMFAInvalidCode)
+	}
+	// Codes are case and dash insensitive
+	if err := f.check(strings.ToUpper(strings.ReplaceAll(f.recovery[1], "-", ""))); err != nil {
+		t.Fatalf("recovery code typed differently: %v", err)
+	}
+
+	status, err := f.s.MFAStatus(ctx, f.employee.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.RecoveryCodesRemaining != recoveryCodeCount-2 {
+		t.Fatalf("%d recovery codes remaining, want %d", status.RecoveryCodesRemaining, recoveryCodeCount-2)
+	}
+}
+
+func TestMFAChallengeTokenOnlyCompletesTheLogin(t *testing.T) {
+	f := newMFAFixture(t)
+	ctx := context.Background()
+
+	challenge, err := f.s.mfaChallenge(ctx, f.employee)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if challenge == nil || challenge.EnrollmentRequired {
+		t.Fatalf("challenge = %+v, want one for an enrolled employee", challenge)
+	}
+	if _, err := f.s.ValidateToken(challenge.MFAToken); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("challenge token used as access token: got %v, want %v", err, ErrInvalidToken)
+	}
+	if _, err := f.s.RefreshTokens(ctx, challenge.MFAToken, SessionClient{}); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("challenge token used as refresh token: got %v, want %v", err, ErrInvalidToken)
+	}
+
+	f.advance(totpPeriod * time.Second)
+	response, err := f.s.VerifyMFA(ctx, challenge.MFAToken, f.code(0), SessionClient{})
+	if err != nil {
+		t.Fatalf("VerifyMFA: %v", err)
+	}
+
+	f.advance(totpPeriod * time.Second)
+	for name, token := range map[string]string{"access": response.AccessToken, "refresh": response.RefreshToken} {
+		if _, err := f.s.VerifyMFA(ctx, token, f.code(0), SessionClient{}); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s token used as challenge token: got %v, want %v", name, err, ErrInvalidToken)
+		}
+	}
+}
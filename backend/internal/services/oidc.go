@@ -0,0 +1,346 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/appnity/media-vault/internal/crypto"
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/repository"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrOIDCDisabled        = errors.New("single sign-on is not configured")
+	ErrOIDCNotAllowed      = errors.New("your account may not sign in to Media Vault")
+	ErrOIDCAccountConflict = errors.New("an account with this email cannot be signed in to with single sign-on")
+)
+
+// oidcLoginExpiry is how long the browser may take at the identity provider
+const oidcLoginExpiry = 10 * time.Minute
+
+// oidcIdentity is who an ID token says the employee is
+type oidcIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool // The provider asserted email_verified
+	FullName      string
+	Role          models.Role // Empty when no role is granted
+}
+
+// BeginOIDCLogin starts a single sign-on login (authorization code flow with
+// PKCE). The code verifier and nonce stay on the server, keyed by the state.
+func (s *AuthService) BeginOIDCLogin(ctx context.Context) (*models.OIDCAuthorization, error) {
+	if s.oidc == nil {
+		return nil, ErrOIDCDisabled
+	}
+
+	state, err := crypto.GenerateRandomString(32)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := crypto.GenerateRandomString(16)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := crypto.GenerateRandomString(32)
+	if err != nil {
+		return nil, err
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+
+	authorizationURL, err := s.oidc.authorizationURL(ctx, state, nonce, base64.RawURLEncoding.EncodeToString(challenge[:]))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateOIDCLoginState(ctx, &models.OIDCLoginState{
+		StateHash:    crypto.HashToken(state),
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		ExpiresAt:    time.Now().Add(oidcLoginExpiry),
+	}); err != nil {
+		return nil, err
+	}
+
+	return &models.OIDCAuthorization{
+		AuthorizationURL: authorizationURL,
+		State:            state,
+		ExpiresIn:        int64(oidcLoginExpiry.Seconds()),
+	}, nil
+}
+
+// CompleteOIDCLogin redeems the authorization code the provider redirected
+// back with and starts a session. Employees are created on their first
+// login, and their name and role follow the provider at every login.
+// Like Login, it returns a challenge instead when the employee has to
+// complete two-factor authentication, unless OIDC_TRUST_PROVIDER_MFA is set
+// and the provider reports having checked a second factor itself.
+func (s *AuthService) CompleteOIDCLogin(ctx context.Context, code, state string, client SessionClient) (*models.AuthResponse, *models.MFAChallenge, error) {
+	if s.oidc == nil {
+		return nil, nil, ErrOIDCDisabled
+	}
+
+	stored, err := s.repo.ConsumeOIDCLoginState(ctx, crypto.HashToken(state))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	idToken, err := s.oidc.exchange(ctx, code, stored.CodeVerifier)
+	if err != nil {
+		return nil, nil, err
+	}
+	claims, err := s.oidc.verifyIDToken(ctx, idToken, stored.Nonce)
+	if err != nil {
+		return nil, nil, err
+	}
+	identity, err := s.oidcIdentity(claims)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	employee, err := s.provisionOIDCEmployee(ctx, identity)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !employee.IsActive {
+		return nil, nil, ErrAccountDisabled
+	}
+
+	if !s.oidcProviderMFA(claims) {
+		challenge, err := s.mfaChallenge(ctx, employee)
+		if err != nil || challenge != nil {
+			return nil, challenge, err
+		}
+	}
+
+	response, err := s.startSession(ctx, employee, client)
+	return response, nil, err
+}
+
+// PurgeExpiredOIDCLogins deletes single sign-on logins that were never
+// completed
+func (s *AuthService) PurgeExpiredOIDCLogins(ctx context.Context) error {
+	deleted, err := s.repo.DeleteExpiredOIDCLoginStates(ctx)
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		log.Printf("[AuthService] deleted %d expired single sign-on logins", deleted)
+	}
+	return nil
+}
+
+// oidcIdentity reads the employee from verified ID token claims. The email
+// must be in an allowed domain and must not be marked unverified; whether it
+// was positively verified is recorded for linking existing accounts.
+func (s *AuthService) oidcIdentity(claims jwt.MapClaims) (*oidcIdentity, error) {
+	subject, _ := claims["sub"].(string)
+	email, _ := claims["email"].(string)
+	email = strings.ToLower(strings.TrimSpace(email))
+	if subject == "" || email == "" {
+		return nil, ErrOIDCNotAllowed
+	}
+	verified, ok := claims["email_verified"].(bool)
+	if ok && !verified {
+		return nil, ErrOIDCNotAllowed
+	}
+
+	if len(s.cfg.OIDCAllowedDomains) > 0 {
+		_, domain, _ := strings.Cut(email, "@")
+		allowed := false
+		for _, d := range s.cfg.OIDCAllowedDomains {
+			if domain == d {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return nil, ErrOIDCNotAllowed
+		}
+	}
+
+	fullName, _ := claims["name"].(string)
+	if fullName == "" {
+		fullName = email
+	}
+
+	return &oidcIdentity{
+		Subject:       subject,
+		Email:         email,
+		EmailVerified: verified,
+		FullName:      fullName,
+		Role:          s.oidcRole(claims[s.cfg.OIDCRoleClaim]),
+	}, nil
+}
+
+// oidcProviderMFA reports whether the provider is trusted to have checked a
+// second factor, which it reports with "mfa" in the amr claim (RFC 8176)
+func (s *AuthService) oidcProviderMFA(claims jwt.MapClaims) bool {
+	if !s.cfg.OIDCTrustMFA {
+		return false
+	}
+	methods, _ := claims["amr"].([]interface{})
+	for _, method := range methods {
+		if method == "mfa" {
+			return true
+		}
+	}
+	return false
+}
+
+// oidcRole maps the role claim, a string or a list of strings, to a role
+// with the first matching OIDC_ROLE_MAPPING entry, falling back to the
+// default role
+func (s *AuthService) oidcRole(claim interface{}) models.Role {
+	var values []string
+	switch v := claim.(type) {
+	case string:
+		values = strings.Fields(v)
+	case []interface{}:
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				values = append(values, str)
+			}
+		}
+	}
+
+	if role := s.mappedRole(values); role != "" {
+		return role
+	}
+	return models.Role(s.cfg.OIDCDefaultRole)
+}
+
+// mappedRole is the role of the first OIDC_ROLE_MAPPING entry matching one
+// of the values, which are group names or roles of the identity provider
+func (s *AuthService) mappedRole(values []string) models.Role {
+	for _, mapping := range s.cfg.OIDCRoleMapping {
+		for _, value := range values {
+			if value == mapping.Value {
+				return models.Role(mapping.Role)
+			}
+		}
+	}
+	return ""
+}
+
+// provisionOIDCEmployee finds or creates the employee of an identity. An
+// existing employee with the same email is linked on their first single
+// sign-on login if the provider verified the email. With a role mapping
+// configured, the employee's role is kept in sync with the provider: losing
+// every role disables them, and a login with a role enables them again.
+// Employees disabled by an admin or SCIM stay disabled.
+func (s *AuthService) provisionOIDCEmployee(ctx context.Context, identity *oidcIdentity) (*models.Employee, error) {
+	issuer := s.cfg.OIDCIssuerURL
+	employee, err := s.repo.GetEmployeeByIdentity(ctx, issuer, identity.Subject)
+	if errors.Is(err, repository.ErrNotFound) {
+		employee, err = s.linkOIDCEmployee(ctx, identity)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if identity.Role == "" {
+		disabled, err := s.repo.DeactivateSSOEmployee(ctx, employee.ID)
+		if err != nil {
+			return nil, err
+		}
+		if disabled {
+			if err := s.repo.RevokeEmployeeRefreshTokens(ctx, employee.ID, models.TokenRevokedDeactivated); err != nil {
+				return nil, err
+			}
+			log.Printf("[AuthService] disabled %s: single sign-on grants no role", employee.Email)
+		}
+		return nil, ErrOIDCNotAllowed
+	}
+
+	if !employee.IsActive {
+		enabled, err := s.repo.ReactivateSSOEmployee(ctx, employee.ID)
+		if err != nil {
+			return nil, err
+		}
+		if enabled {
+			employee.IsActive = true
+			log.Printf("[AuthService] enabled %s again: single sign-on grants a role", employee.Email)
+		}
+	}
+
+	changed := employee.FullName != identity.FullName
+	employee.FullName = identity.FullName
+	if len(s.cfg.OIDCRoleMapping) > 0 && employee.Role != identity.Role {
+		employee.Role = identity.Role
+		changed = true
+	}
+	if changed {
+		if err := s.repo.UpdateEmployee(ctx, employee); err != nil {
+			return nil, err
+		}
+	}
+	return employee, nil
+}
+
+// linkOIDCEmployee links an identity seen for the first time to the
+// employee with its email, creating the employee if there is none. Linking
+// needs a verified email, or anyone able to set an unverified address at the
+// provider could take over the account.
+func (s *AuthService) linkOIDCEmployee(ctx context.Context, identity *oidcIdentity) (*models.Employee, error) {
+	issuer := s.cfg.OIDCIssuerURL
+	employee, err := s.repo.GetEmployeeByEmail(ctx, identity.Email)
+	if err == nil {
+		if employee.IsServiceAccount || !identity.EmailVerified {
+			return nil, ErrOIDCAccountConflict
+		}
+		if err := s.repo.LinkEmployeeIdentity(ctx, employee.ID, issuer, identity.Subject); err != nil {
+			if errors.Is(err, repository.ErrAlreadyExists) {
+				return nil, ErrOIDCAccountConflict
+			}
+			return nil, err
+		}
+		return employee, nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+	if identity.Role == "" {
+		return nil, ErrOIDCNotAllowed
+	}
+
+	// Single sign-on employees have no password of their own
+	password, err := crypto.GenerateRandomString(32)
+	if err != nil {
+		return nil, err
+	}
+	passwordHash, err := crypto.HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	employee = &models.Employee{
+		Email:        identity.Email,
+		PasswordHash: passwordHash,
+		FullName:     identity.FullName,
+		Role:         identity.Role,
+		IsActive:     true,
+	}
+	err = s.repo.CreateEmployeeWithIdentity(ctx, employee, issuer, identity.Subject)
+	if errors.Is(err, repository.ErrAlreadyExists) {
+		// A concurrent first login of the same identity got there first
+		if existing, lookupErr := s.repo.GetEmployeeByIdentity(ctx, issuer, identity.Subject); lookupErr == nil {
+			return existing, nil
+		}
+		return nil, ErrOIDCAccountConflict
+	}
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[AuthService] provisioned %s as %s via single sign-on", employee.Email, employee.Role)
+	return employee, nil
+}
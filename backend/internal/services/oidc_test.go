@@ -0,0 +1,575 @@
+package services
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/appnity/media-vault/internal/config"
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/testdb"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// testIdP is a stand-in OpenID Connect provider serving discovery, its key
+// set and the token endpoint. The browser's visit to the authorization
+// endpoint is played by authorize.
+type testIdP struct {
+	t        *testing.T
+	server   *httptest.Server
+	key      ed25519.PrivateKey
+	clientID string
+
+	mu    sync.Mutex
+	codes map[string]testGrant
+}
+
+// testGrant is what an authorization code was issued for
+type testGrant struct {
+	challenge string
+	claims    jwt.MapClaims
+}
+
+func newTestIdP(t *testing.T) *testIdP {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &testIdP{t: t, key: key, clientID: "media-vault", codes: make(map[string]testGrant)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(oidcMetadata{
+			Issuer:                idp.server.URL,
+			AuthorizationEndpoint: idp.server.URL + "/authorize",
+			TokenEndpoint:         idp.server.URL + "/token",
+			JWKSURI:               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		public := idp.key.Public().(ed25519.PublicKey)
+		json.NewEncoder(w).Encode(map[string]any{"keys": []oidcJWK{{
+			KeyType: "OKP", KeyID: "idp-key", Use: "sig", Curve: "Ed25519",
+			X: base64.RawURLEncoding.EncodeToString(public),
+		}}})
+	})
+	mux.HandleFunc("/token", idp.token)
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+// token redeems a code once, checking the PKCE verifier against the
+// challenge it was issued for
+func (idp *testIdP) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ParseForm() != nil {
+		http.Error(w, `{"error":"invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+	idp.mu.Lock()
+	grant, ok := idp.codes[r.PostForm.Get("code")]
+	delete(idp.codes, r.PostForm.Get("code"))
+	idp.mu.Unlock()
+
+	challenge := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || r.PostForm.Get("grant_type") != "authorization_code" || r.PostForm.Get("client_id") != idp.clientID ||
+		base64.RawURLEncoding.EncodeToString(challenge[:]) != grant.challenge {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]string{"id_token": idp.sign(grant.claims)})
+}
+
+// sign issues an ID token with the provider's key
+func (idp *testIdP) sign(claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = "idp-key"
+	signed, err := token.SignedString(idp.key)
+	if err != nil {
+		idp.t.Fatal(err)
+	}
+	return signed
+}
+
+// authorize plays the employee signing in at the provider: it checks the
+// authorization URL and returns the state and a code for an ID token with
+// the given claims on top of the standard ones
+func (idp *testIdP) authorize(authorizationURL string, claims jwt.MapClaims) (state, code string) {
+	idp.t.Helper()
+	u, err := url.Parse(authorizationURL)
+	if err != nil {
+		idp.t.Fatal(err)
+	}
+	q := u.Query()
+	if u.Path != "/authorize" || q.Get("client_id") != idp.clientID || q.Get("response_type") != "code" ||
+		q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" || q.Get("state") == "" || q.Get("nonce") == "" {
+		idp.t.Fatalf("unexpected authorization URL %s", authorizationURL)
+	}
+
+	full := jwt.MapClaims{
+		"iss":   idp.server.URL,
+		"aud":   idp.clientID,
+		"nonce": q.Get("nonce"),
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(5 * time.Minute).Unix(),
+	}
+	for k, v := range claims {
+		full[k] = v
+	}
+
+	code = base64.RawURLEncoding.EncodeToString([]byte(q.Get("state")))
+	idp.mu.Lock()
+	idp.codes[code] = testGrant{challenge: q.Get("code_challenge"), claims: full}
+	idp.mu.Unlock()
+	return q.Get("state"), code
+}
+
+// testOIDCConfig configures single sign-on against idp: vault-admins and
+// design groups map to admin and marketing, and no other group is let in
+func testOIDCConfig(idp *testIdP) *config.Config {
+	return &config.Config{
+		JWTSigningAlg:      "HS256",
+		JWTSecret:          "test-secret-that-is-long-enough-for-hs256",
+		JWTKeyID:           "test",
+		AccessTokenExpiry:  15,
+		RefreshTokenExpiry: 7,
+		OIDCIssuerURL:      idp.server.URL,
+		OIDCClientID:       idp.clientID,
+		OIDCRedirectURL:    "https://vault.example.com/sso/callback",
+		OIDCScopes:         []string{"openid", "email", "profile"},
+		OIDCRoleClaim:      "groups",
+		OIDCRoleMapping: []config.OIDCRoleMapping{
+			{Value: "vault-admins", Role: "admin"},
+			{Value: "design", Role: "marketing"},
+		},
+		OIDCAllowedDomains: []string{"example.com"},
+	}
+}
+
+func pkcePair(t *testing.T) (verifier, challenge string) {
+	t.Helper()
+	buf := make([]byte, 32)
+	rand.Read(buf)
+	verifier = base64.RawURLEncoding.EncodeToString(buf)
+	sum := sha256.Sum256([]byte(verifier))
+	return verifier, base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func TestOIDCProviderRoundTrip(t *testing.T) {
+	idp := newTestIdP(t)
+	p := newOIDCProvider(testOIDCConfig(idp))
+	ctx := context.Background()
+
+	verifier, challenge := pkcePair(t)
+	authorizationURL, err := p.authorizationURL(ctx, "the-state", "the-nonce", challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, code := idp.authorize(authorizationURL, jwt.MapClaims{"sub": "u-1", "email": "ada@example.com"})
+	if state != "the-state" {
+		t.Fatalf("state = %q", state)
+	}
+
+	idToken, err := p.exchange(ctx, code, verifier)
+	if err != nil {
+		t.Fatalf("exchange: %v", err)
+	}
+	claims, err := p.verifyIDToken(ctx, idToken, "the-nonce")
+	if err != nil {
+		t.Fatalf("verifyIDToken: %v", err)
+	}
+	if claims["sub"] != "u-1" {
+		t.Errorf("sub = %v", claims["sub"])
+	}
+
+	if _, err := p.exchange(ctx, code, verifier); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("redeeming a code twice: err = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestOIDCProviderRejectsWrongVerifier(t *testing.T) {
+	idp := newTestIdP(t)
+	p := newOIDCProvider(testOIDCConfig(idp))
+	ctx := context.Background()
+
+	_, challenge := pkcePair(t)
+	authorizationURL, err := p.authorizationURL(ctx, "s", "n", challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, code := idp.authorize(authorizationURL, jwt.MapClaims{"sub": "u-1", "email": "ada@example.com"})
+
+	other, _ := pkcePair(t)
+	if _, err := p.exchange(ctx, code, other); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("err = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestOIDCProviderVerifiesIDToken(t *testing.T) {
+	idp := newTestIdP(t)
+	p := newOIDCProvider(testOIDCConfig(idp))
+	ctx := context.Background()
+
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss": idp.server.URL, "aud": idp.clientID, "sub": "u-1", "nonce": "n",
+			"exp": time.Now().Add(time.Minute).Unix(),
+		}
+	}
+	tests := []struct {
+		name   string
+		modify func(jwt.MapClaims)
+		nonce  string
+		ok     bool
+	}{
+		{"valid", func(jwt.MapClaims) {}, "n", true},
+		{"nonce mismatch", func(jwt.MapClaims) {}, "other", false},
+		{"no nonce", func(c jwt.MapClaims) { delete(c, "nonce") }, "n", false},
+		{"other issuer", func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }, "n", false},
+		{"other audience", func(c jwt.MapClaims) { c["aud"] = "someone-else" }, "n", false},
+		{"issued to another party", func(c jwt.MapClaims) { c["azp"] = "someone-else" }, "n", false},
+		{"expired", func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() }, "n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := valid()
+			tt.modify(claims)
+			_, err := p.verifyIDToken(ctx, idp.sign(claims), tt.nonce)
+			if tt.ok && err != nil {
+				t.Fatalf("err = %v", err)
+			}
+			if !tt.ok && !errors.Is(err, ErrInvalidToken) {
+				t.Fatalf("err = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+
+	// A token signed with a key the provider does not publish
+	_, forger, _ := ed25519.GenerateKey(rand.Reader)
+	forged := jwt.NewWithClaims(jwt.SigningMethodEdDSA, valid())
+	forged.Header["kid"] = "idp-key"
+	signed, _ := forged.SignedString(forger)
+	if _, err := p.verifyIDToken(ctx, signed, "n"); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("forged token: err = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestOIDCIdentity(t *testing.T) {
+	s := &AuthService{cfg: &config.Config{
+		OIDCRoleClaim:      "groups",
+		OIDCRoleMapping:    []config.OIDCRoleMapping{{Value: "design", Role: "marketing"}},
+		OIDCDefaultRole:    "viewer",
+		OIDCAllowedDomains: []string{"example.com"},
+	}}
+
+	tests := []struct {
+		name     string
+		claims   jwt.MapClaims
+		allowed  bool
+		verified bool
+	}{
+		{"allowed domain", jwt.MapClaims{"sub": "1", "email": "Ada@Example.com", "email_verified": true}, true, true},
+		{"verification not stated", jwt.MapClaims{"sub": "1", "email": "ada@example.com"}, true, false},
+		{"unverified email", jwt.MapClaims{"sub": "1", "email": "ada@example.com", "email_verified": false}, false, false},
+		{"other domain", jwt.MapClaims{"sub": "1", "email": "ada@example.org", "email_verified": true}, false, false},
+		{"subdomain", jwt.MapClaims{"sub": "1", "email": "ada@mail.example.com", "email_verified": true}, false, false},
+		{"lookalike domain", jwt.MapClaims{"sub": "1", "email": "ada@evilexample.com", "email_verified": true}, false, false},
+		{"no email", jwt.MapClaims{"sub": "1"}, false, false},
+		{"no subject", jwt.MapClaims{"email": "ada@example.com"}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := s.oidcIdentity(tt.claims)
+			if !tt.allowed {
+				if !errors.Is(err, ErrOIDCNotAllowed) {
+					t.Fatalf("err = %v, want ErrOIDCNotAllowed", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("err = %v", err)
+			}
+			if identity.Email != "ada@example.com" || identity.EmailVerified != tt.verified {
+				t.Fatalf("identity = %+v", identity)
+			}
+		})
+	}
+}
+
+func TestOIDCRole(t *testing.T) {
+	mapping := []config.OIDCRoleMapping{
+		{Value: "vault-admins", Role: "admin"},
+		{Value: "design", Role: "marketing"},
+	}
+	tests := []struct {
+		name        string
+		claim       interface{}
+		defaultRole string
+		want        models.Role
+	}{
+		{"first mapping wins", []interface{}{"design", "vault-admins"}, "viewer", models.RoleAdmin},
+		{"space separated string", "staff design", "viewer", models.RoleMarketing},
+		{"no match uses default", []interface{}{"staff"}, "viewer", models.RoleViewer},
+		{"missing claim uses default", nil, "viewer", models.RoleViewer},
+		{"no match without default", []interface{}{"staff"}, "", ""},
+		{"non-string entries are ignored", []interface{}{42, "design"}, "", models.RoleMarketing},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &AuthService{cfg: &config.Config{OIDCRoleMapping: mapping, OIDCDefaultRole: tt.defaultRole}}
+			if got := s.oidcRole(tt.claim); got != tt.want {
+				t.Fatalf("oidcRole = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// oidcLogin runs a whole single sign-on login through the auth service
+func oidcLogin(t *testing.T, s *AuthService, idp *testIdP, claims jwt.MapClaims) (*models.AuthResponse, error) {
+	t.Helper()
+	ctx := context.Background()
+	authorization, err := s.BeginOIDCLogin(ctx)
+	if err != nil {
+		t.Fatalf("BeginOIDCLogin: %v", err)
+	}
+	state, code := idp.authorize(authorization.AuthorizationURL, claims)
+	if state != authorization.State {
+		t.Fatalf("state = %q, want %q", state, authorization.State)
+	}
+	response, challenge, err := s.CompleteOIDCLogin(ctx, code, state, SessionClient{})
+	if challenge != nil {
+		t.Fatal("login asked for a second factor")
+	}
+	return response, err
+}
+
+func newTestOIDCAuthService(t *testing.T) (*AuthService, *testIdP) {
+	t.Helper()
+	repo, _ := testdb.Open(t)
+	idp := newTestIdP(t)
+	s, err := NewAuthService(repo, testOIDCConfig(idp), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, idp
+}
+
+func TestCompleteOIDCLoginProvisionsAndSyncsRole(t *testing.T) {
+	s, idp := newTestOIDCAuthService(t)
+	ctx := context.Background()
+	claims := jwt.MapClaims{"sub": "u-1", "email": "ada@example.com", "name": "Ada", "groups": []string{"design"}}
+
+	resp, err := oidcLogin(t, s, idp, claims)
+	if err != nil {
+		t.Fatalf("first login: %v", err)
+	}
+	if resp.AccessToken == "" || resp.RefreshToken == "" {
+		t.Fatal("no tokens issued")
+	}
+	if resp.Employee.Role != models.RoleMarketing || resp.Employee.FullName != "Ada" {
+		t.Fatalf("provisioned %+v", resp.Employee)
+	}
+
+	claims["groups"] = []string{"vault-admins"}
+	claims["name"] = "Ada Lovelace"
+	resp2, err := oidcLogin(t, s, idp, claims)
+	if err != nil {
+		t.Fatalf("second login: %v", err)
+	}
+	if resp2.Employee.ID != resp.Employee.ID {
+		t.Fatal("second login created another employee")
+	}
+	stored, err := s.repo.GetEmployeeByID(ctx, resp.Employee.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Role != models.RoleAdmin || stored.FullName != "Ada Lovelace" {
+		t.Fatalf("role and name not synced: %+v", stored)
+	}
+}
+
+func TestCompleteOIDCLoginRejectsReplayedState(t *testing.T) {
+	s, idp := newTestOIDCAuthService(t)
+	ctx := context.Background()
+
+	authorization, err := s.BeginOIDCLogin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := jwt.MapClaims{"sub": "u-1", "email": "ada@example.com", "groups": []string{"design"}}
+	state, code := idp.authorize(authorization.AuthorizationURL, claims)
+	if _, _, err := s.CompleteOIDCLogin(ctx, code, state, SessionClient{}); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+
+	_, code = idp.authorize(authorization.AuthorizationURL, claims)
+	if _, _, err := s.CompleteOIDCLogin(ctx, code, state, SessionClient{}); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("replayed state: err = %v, want ErrInvalidToken", err)
+	}
+	if _, _, err := s.CompleteOIDCLogin(ctx, code, "made-up-state", SessionClient{}); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("unknown state: err = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestCompleteOIDCLoginEnforcesDomain(t *testing.T) {
+	s, idp := newTestOIDCAuthService(t)
+
+	_, err := oidcLogin(t, s, idp, jwt.MapClaims{"sub": "u-1", "email": "ada@example.org", "groups": []string{"design"}})
+	if !errors.Is(err, ErrOIDCNotAllowed) {
+		t.Fatalf("err = %v, want ErrOIDCNotAllowed", err)
+	}
+	if _, err := s.repo.GetEmployeeByEmail(context.Background(), "ada@example.org"); err == nil {
+		t.Fatal("employee was provisioned for a disallowed domain")
+	}
+}
+
+func TestCompleteOIDCLoginDisablesOnRoleRemoval(t *testing.T) {
+	s, idp := newTestOIDCAuthService(t)
+	ctx := context.Background()
+	claims := jwt.MapClaims{"sub": "u-1", "email": "ada@example.com", "groups": []string{"design"}}
+
+	resp, err := oidcLogin(t, s, idp, claims)
+	if err != nil {
+		t.Fatalf("first login: %v", err)
+	}
+
+	claims["groups"] = []string{"staff"}
+	if _, err := oidcLogin(t, s, idp, claims); !errors.Is(err, ErrOIDCNotAllowed) {
+		t.Fatalf("login without a role: err = %v, want ErrOIDCNotAllowed", err)
+	}
+	stored, err := s.repo.GetEmployeeByID(ctx, resp.Employee.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.IsActive {
+		t.Fatal("employee stayed active after losing every role")
+	}
+	if _, err := s.RefreshTokens(ctx, resp.RefreshToken, SessionClient{}); err == nil {
+		t.Fatal("refresh token still works after the employee was disabled")
+	}
+
+	// Regaining a role enables them again
+	claims["groups"] = []string{"design"}
+	if _, err := oidcLogin(t, s, idp, claims); err != nil {
+		t.Fatalf("login after regaining a role: %v", err)
+	}
+
+	// unlike when an admin disabled them
+	stored.IsActive = false
+	if err := s.repo.UpdateEmployee(ctx, stored); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := oidcLogin(t, s, idp, claims); !errors.Is(err, ErrAccountDisabled) {
+		t.Fatalf("login after an admin disabled them: err = %v, want ErrAccountDisabled", err)
+	}
+	claims["groups"] = []string{"staff"}
+	if _, err := oidcLogin(t, s, idp, claims); !errors.Is(err, ErrOIDCNotAllowed) {
+		t.Fatalf("login without a role: err = %v, want ErrOIDCNotAllowed", err)
+	}
+	claims["groups"] = []string{"design"}
+	if _, err := oidcLogin(t, s, idp, claims); !errors.Is(err, ErrAccountDisabled) {
+		t.Fatalf("login with a role after an admin disabled them: err = %v, want ErrAccountDisabled", err)
+	}
+}
+
+func TestCompleteOIDCLoginLinksOnlyVerifiedEmail(t *testing.T) {
+	s, idp := newTestOIDCAuthService(t)
+	ctx := context.Background()
+	existing := testdb.Employee(t, s.repo, models.RoleDeveloper)
+
+	claims := jwt.MapClaims{"sub": "u-1", "email": existing.Email, "groups": []string{"design"}}
+	if _, err := oidcLogin(t, s, idp, claims); !errors.Is(err, ErrOIDCAccountConflict) {
+		t.Fatalf("unverified email: err = %v, want ErrOIDCAccountConflict", err)
+	}
+	if _, err := s.repo.GetEmployeeByIdentity(ctx, idp.server.URL, "u-1"); err == nil {
+		t.Fatal("identity was linked without a verified email")
+	}
+
+	claims["email_verified"] = true
+	resp, err := oidcLogin(t, s, idp, claims)
+	if err != nil {
+		t.Fatalf("verified email: %v", err)
+	}
+	if resp.Employee.ID != existing.ID {
+		t.Fatal("verified email did not link the existing employee")
+	}
+}
+
+func TestOIDCProviderMFA(t *testing.T) {
+	tests := []struct {
+		name  string
+		trust bool
+		amr   interface{}
+		want  bool
+	}{
+		{"trusted with mfa", true, []interface{}{"pwd", "mfa"}, true},
+		{"trusted without mfa", true, []interface{}{"pwd"}, false},
+		{"trusted without amr", true, nil, false},
+		{"amr as a string", true, "mfa", false},
+		{"not trusted", false, []interface{}{"pwd", "mfa"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &AuthService{cfg: &config.Config{OIDCTrustMFA: tt.trust}}
+			claims := jwt.MapClaims{}
+			if tt.amr != nil {
+				claims["amr"] = tt.amr
+			}
+			if got := s.oidcProviderMFA(claims); got != tt.want {
+				t.Fatalf("oidcProviderMFA = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompleteOIDCLoginRequiresMFA(t *testing.T) {
+	s, idp := newTestOIDCAuthService(t)
+	ctx := context.Background()
+	admin := testdb.Employee(t, s.repo, models.RoleAdmin)
+	if err := s.repo.SetMFARequiredRoles(ctx, []models.Role{models.RoleMarketing}, admin.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	login := func(claims jwt.MapClaims) (*models.AuthResponse, *models.MFAChallenge) {
+		t.Helper()
+		authorization, err := s.BeginOIDCLogin(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		state, code := idp.authorize(authorization.AuthorizationURL, claims)
+		response, challenge, err := s.CompleteOIDCLogin(ctx, code, state, SessionClient{})
+		if err != nil {
+			t.Fatalf("login: %v", err)
+		}
+		return response, challenge
+	}
+	claims := jwt.MapClaims{"sub": "u-1", "email": "ada@example.com", "groups": []string{"design"}, "amr": []string{"pwd", "mfa"}}
+
+	response, challenge := login(claims)
+	if response != nil || challenge == nil || !challenge.EnrollmentRequired {
+		t.Fatalf("role requiring MFA: got tokens %v and challenge %+v, want an enrollment challenge", response != nil, challenge)
+	}
+	if _, err := s.ValidateToken(challenge.MFAToken); err == nil {
+		t.Fatal("challenge token accepted as access token")
+	}
+
+	s.cfg.OIDCTrustMFA = true
+	if response, challenge = login(claims); response == nil || challenge != nil {
+		t.Fatalf("trusted provider MFA: got challenge %+v, want tokens", challenge)
+	}
+	claims["amr"] = []string{"pwd"}
+	if response, challenge = login(claims); response != nil || challenge == nil {
+		t.Fatal("trusted provider without MFA: got tokens, want a challenge")
+	}
+}
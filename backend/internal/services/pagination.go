@@ -0,0 +1,104 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/google/uuid"
+)
+
+// mediaSortKeys are the columns media can be sorted and paged by
+var mediaSortKeys = map[string]bool{
+	"created_at": true, "filename": true, "file_size_bytes": true, "updated_at": true,
+}
+
+// normalizeMediaSort applies the sort defaults, so cursors can be matched
+// against the sort they were issued for. Fuzzy searches may also be ranked
+// by relevance, which is their default.
+func normalizeMediaSort(filters *models.MediaFilterRequest) {
+	switch {
+	case filters.Fuzzy != "" && (filters.SortBy == "" || filters.SortBy == "relevance"):
+		filters.SortBy = "relevance"
+	case !mediaSortKeys[filters.SortBy]:
+		filters.SortBy = "created_at"
+	}
+	if strings.ToLower(filters.SortOrder) == "asc" {
+		filters.SortOrder = "asc"
+	} else {
+		filters.SortOrder = "desc"
+	}
+}
+
+// mediaSortValue returns the sort key of a media item as stored in cursors
+func mediaSortValue(media *models.MediaWithDetails, sortBy string) string {
+	switch sortBy {
+	case "filename":
+		return media.Filename
+	case "file_size_bytes":
+		return strconv.FormatInt(media.FileSizeBytes, 10)
+	case "updated_at":
+		return media.UpdatedAt.Format(time.RFC3339Nano)
+	case "relevance":
+		// Scores are single precision in Postgres; keep them exact
+		if media.Score == nil {
+			return "0"
+		}
+		return strconv.FormatFloat(*media.Score, 'g', -1, 32)
+	default:
+		return media.CreatedAt.Format(time.RFC3339Nano)
+	}
+}
+
+// encodeCursor returns the opaque form of a cursor handed to clients
+func encodeCursor(cursor *models.PageCursor) string {
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeCursor parses a cursor and checks that it was issued for the same
+// sort. An empty token starts from the first page.
+func decodeCursor(token, sortBy, order string) (*models.PageCursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor models.PageCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.SortBy != sortBy || cursor.Order != order || cursor.ID == uuid.Nil {
+		return nil, ErrInvalidCursor
+	}
+
+	switch sortBy {
+	case "created_at", "updated_at":
+		if _, err := time.Parse(time.RFC3339Nano, cursor.Value); err != nil {
+			return nil, ErrInvalidCursor
+		}
+	case "file_size_bytes":
+		if _, err := strconv.ParseInt(cursor.Value, 10, 64); err != nil {
+			return nil, ErrInvalidCursor
+		}
+	case "relevance":
+		if _, err := strconv.ParseFloat(cursor.Value, 32); err != nil {
+			return nil, ErrInvalidCursor
+		}
+	}
+	return &cursor, nil
+}
+
+// cursorTotal reports the total of a cursor page when one was requested
+func cursorTotal(mode models.CountMode, total int64) (*int64, bool) {
+	if mode == models.CountNone {
+		return nil, false
+	}
+	return &total, mode == models.CountEstimate
+}
@@ -0,0 +1,404 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/repository"
+	"github.com/appnity/media-vault/internal/scanner"
+	"github.com/appnity/media-vault/internal/storage"
+	"github.com/google/uuid"
+)
+
+// quarantinePrefix is prepended to the storage key of infected files so they
+// are no longer reachable at their public URL
+const quarantinePrefix = "quarantine/"
+
+const (
+	// scanRetryBatchSize limits how many files one retry run handles
+	scanRetryBatchSize = 50
+	// scanRetryAfter is how long a scan may run before it is retried
+	scanRetryAfter = 15 * time.Minute
+)
+
+// ScanMedia streams a media item to clamd and records the verdict.
+// Infected files are moved into quarantine.
+func (s *MediaService) ScanMedia(ctx context.Context, id uuid.UUID, employee *models.Employee) (*models.MediaWithDetails, error) {
+	if s.scanner == nil {
+		return nil, ErrScannerDisabled
+	}
+
+	media, err := s.repo.GetMediaByID(ctx, id)
+	if err != nil {
+		return nil, ErrMediaNotFound
+	}
+
+	storageAccount, err := s.repo.GetStorageAccountByID(ctx, media.StorageAccountID)
+	if err != nil {
+		return nil, ErrStorageNotFound
+	}
+
+	adapter, err := s.adapterPool.GetAdapter(ctx, storageAccount)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.scanObject(ctx, adapter, media.StorageKey)
+	if err != nil {
+		s.recordScanError(ctx, media, err)
+		return nil, err
+	}
+
+	if !result.Infected {
+		// The file is published now that it is known to be clean
+		var publicURL *string
+		if url, err := adapter.GetPublicURL(ctx, media.StorageKey); err == nil {
+			publicURL = &url
+		}
+		err := s.repo.MarkMediaScanClean(ctx, id, media.CurrentVersion, publicURL)
+		if errors.Is(err, repository.ErrNotFound) {
+			// A new version replaced the scanned one in the meantime
+			err = s.repo.UpdateMediaVersionScanResult(ctx, id, media.CurrentVersion, models.ScanStatusClean, nil)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return s.repo.GetMediaByID(ctx, id)
+	}
+
+	err = s.repo.UpdateMediaScanResult(ctx, id, media.CurrentVersion, models.ScanStatusInfected, &result.Signature)
+	if errors.Is(err, repository.ErrNotFound) {
+		// A new version replaced the scanned one in the meantime
+		v, err := s.repo.GetMediaVersion(ctx, id, media.CurrentVersion)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.recordVersionVerdict(ctx, adapter, &media.Media, v, result, employee); err != nil {
+			return nil, err
+		}
+		return s.repo.GetMediaByID(ctx, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Flag the record first, so the file is blocked even if the object cannot
+	// be moved out of its public location. A failed move is retried by
+	// RetryScans. Held objects must not be moved; they are only flagged.
+	if err := s.repo.QuarantineMedia(ctx, id, media.StorageKey); err != nil {
+		return nil, err
+	}
+	if !media.LegalHold {
+		if err := s.moveIntoQuarantine(ctx, adapter, &media.Media); err != nil {
+			log.Printf("[MediaService] ScanMedia: failed to move %s into quarantine, will retry: %v", media.StorageKey, err)
+		}
+	}
+
+	s.logAudit(ctx, employee, models.AuditActionQuarantine, models.SeverityCritical, "media", &id, map[string]any{
+		"filename":  media.OriginalFilename,
+		"signature": result.Signature,
+		"storage":   storageAccount.Name,
+	})
+
+	return s.repo.GetMediaByID(ctx, id)
+}
+
+// moveIntoQuarantine moves the objects of a quarantined media item, its
+// previous versions included, out of their public location
+func (s *MediaService) moveIntoQuarantine(ctx context.Context, adapter storage.StorageAdapter, media *models.Media) error {
+	if !strings.HasPrefix(media.StorageKey, quarantinePrefix) {
+		quarantineKey := quarantinePrefix + media.StorageKey
+		if err := adapter.Move(ctx, media.StorageKey, quarantineKey); err != nil {
+			return err
+		}
+		if err := s.repo.SetQuarantineStorageKey(ctx, media.ID, quarantineKey); err != nil {
+			return err
+		}
+	}
+
+	versions, err := s.repo.ListMediaVersions(ctx, media.ID)
+	if err != nil {
+		return err
+	}
+	for i := range versions {
+		if err := s.moveVersionIntoQuarantine(ctx, adapter, &versions[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanObject streams an object to clamd
+func (s *MediaService) scanObject(ctx context.Context, adapter storage.StorageAdapter, storageKey string) (*scanner.Result, error) {
+	reader, err := adapter.Download(ctx, storageKey)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return s.scanner.Scan(ctx, reader)
+}
+
+// scanMediaVersion scans a previous version of a media item
+func (s *MediaService) scanMediaVersion(ctx context.Context, v *models.MediaVersion) error {
+	media, err := s.repo.GetMediaByID(ctx, v.MediaID)
+	if err != nil {
+		return ErrMediaNotFound
+	}
+	adapter, err := s.mediaAdapter(ctx, &media.Media)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.scanObject(ctx, adapter, v.StorageKey)
+	if err != nil {
+		log.Printf("[MediaService] scan of version %d of %s failed: %v", v.Version, v.MediaID, err)
+		_ = s.repo.UpdateMediaVersionScanResult(ctx, v.MediaID, v.Version, models.ScanStatusError, nil)
+		return err
+	}
+	return s.recordVersionVerdict(ctx, adapter, &media.Media, v, result, s.systemActor(ctx, &media.Media))
+}
+
+// recordVersionVerdict records the antivirus verdict for a previous version.
+// Infected versions are moved into quarantine unless the item is held; a
+// failed move is retried by RetryScans.
+func (s *MediaService) recordVersionVerdict(ctx context.Context, adapter storage.StorageAdapter, media *models.Media, v *models.MediaVersion, result *scanner.Result, employee *models.Employee) error {
+	if !result.Infected {
+		return s.repo.UpdateMediaVersionScanResult(ctx, media.ID, v.Version, models.ScanStatusClean, nil)
+	}
+
+	if err := s.repo.UpdateMediaVersionScanResult(ctx, media.ID, v.Version, models.ScanStatusInfected, &result.Signature); err != nil {
+		return err
+	}
+	if !media.LegalHold {
+		if err := s.moveVersionIntoQuarantine(ctx, adapter, v); err != nil {
+			log.Printf("[MediaService] failed to move %s into quarantine, will retry: %v", v.StorageKey, err)
+		}
+	}
+
+	s.logAudit(ctx, employee, models.AuditActionQuarantine, models.SeverityCritical, "media", &media.ID, map[string]any{
+		"filename":  media.OriginalFilename,
+		"signature": result.Signature,
+		"version":   v.Version,
+	})
+	return nil
+}
+
+// moveVersionIntoQuarantine moves the object of a previous version out of
+// its location next to the live object
+func (s *MediaService) moveVersionIntoQuarantine(ctx context.Context, adapter storage.StorageAdapter, v *models.MediaVersion) error {
+	if strings.HasPrefix(v.StorageKey, quarantinePrefix) {
+		return nil
+	}
+	quarantineKey := quarantinePrefix + v.StorageKey
+	if err := adapter.Move(ctx, v.StorageKey, quarantineKey); err != nil {
+		return err
+	}
+	return s.repo.SetMediaVersionStorageKey(ctx, v.MediaID, v.Version, quarantineKey)
+}
+
+// RetryScans rescans files and previous versions whose scan is still pending
+// or failed, and moves infected files into quarantine where that failed
+// before. It is run periodically by the job scheduler.
+func (s *MediaService) RetryScans(ctx context.Context) error {
+	unmoved, err := s.repo.ListUnmovedQuarantinedMedia(ctx, scanRetryBatchSize)
+	if err != nil {
+		return err
+	}
+	for i := range unmoved {
+		media := &unmoved[i].Media
+		adapter, err := s.mediaAdapter(ctx, media)
+		if err == nil {
+			err = s.moveIntoQuarantine(ctx, adapter, media)
+		}
+		if err != nil {
+			log.Printf("[MediaService] RetryScans: failed to move %s into quarantine: %v", media.StorageKey, err)
+		}
+	}
+
+	unmovedVersions, err := s.repo.ListUnmovedQuarantinedVersions(ctx, scanRetryBatchSize)
+	if err != nil {
+		return err
+	}
+	for i := range unmovedVersions {
+		v := &unmovedVersions[i]
+		media, err := s.repo.GetMediaByID(ctx, v.MediaID)
+		if err != nil {
+			continue
+		}
+		adapter, err := s.mediaAdapter(ctx, &media.Media)
+		if err == nil {
+			err = s.moveVersionIntoQuarantine(ctx, adapter, v)
+		}
+		if err != nil {
+			log.Printf("[MediaService] RetryScans: failed to move %s into quarantine: %v", v.StorageKey, err)
+		}
+	}
+
+	if s.scanner == nil {
+		// Without a scanner previous versions are served unscanned, like
+		// new uploads
+		return s.repo.SkipPendingVersionScans(ctx)
+	}
+	// Leave scans started by uploads time to finish
+	before := time.Now().Add(-scanRetryAfter)
+	awaiting, err := s.repo.ListMediaAwaitingScan(ctx, before, scanRetryBatchSize)
+	if err != nil {
+		return err
+	}
+	for i := range awaiting {
+		media := &awaiting[i].Media
+		// Failures are recorded on the media and retried on the next run
+		_, _ = s.ScanMedia(ctx, media.ID, s.systemActor(ctx, media))
+	}
+
+	versions, err := s.repo.ListMediaVersionsAwaitingScan(ctx, before, scanRetryBatchSize)
+	if err != nil {
+		return err
+	}
+	for i := range versions {
+		_ = s.scanMediaVersion(ctx, &versions[i])
+	}
+	return nil
+}
+
+// recordScanError marks a scan as failed without quarantining the file
+func (s *MediaService) recordScanError(ctx context.Context, media *models.MediaWithDetails, scanErr error) {
+	log.Printf("[MediaService] ScanMedia: scan of %s failed: %v", media.ID, scanErr)
+	err := s.repo.UpdateMediaScanResult(ctx, media.ID, media.CurrentVersion, models.ScanStatusError, nil)
+	if errors.Is(err, repository.ErrNotFound) {
+		// A new version replaced the scanned one in the meantime
+		_ = s.repo.UpdateMediaVersionScanResult(ctx, media.ID, media.CurrentVersion, models.ScanStatusError, nil)
+	}
+}
+
+// ListQuarantinedMedia lists media held in quarantine for admin review
+func (s *MediaService) ListQuarantinedMedia(ctx context.Context, page, pageSize int) (*models.PaginatedResponse[models.MediaWithDetails], error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 50
+	}
+
+	mediaList, total, err := s.repo.ListQuarantinedMedia(ctx, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	totalPages := int(total) / pageSize
+	if int(total)%pageSize > 0 {
+		totalPages++
+	}
+
+	return &models.PaginatedResponse[models.MediaWithDetails]{
+		Data:       mediaList,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// ReleaseFromQuarantine restores a quarantined item after an admin has
+// reviewed it, e.g. for a false positive
+func (s *MediaService) ReleaseFromQuarantine(ctx context.Context, id uuid.UUID, note string, employee *models.Employee) (*models.MediaWithDetails, error) {
+	media, err := s.repo.GetMediaByID(ctx, id)
+	if err != nil {
+		return nil, ErrMediaNotFound
+	}
+	if media.QuarantinedAt == nil {
+		return nil, ErrInvalidInput
+	}
+
+	storageAccount, err := s.repo.GetStorageAccountByID(ctx, media.StorageAccountID)
+	if err != nil {
+		return nil, ErrStorageNotFound
+	}
+
+	adapter, err := s.adapterPool.GetAdapter(ctx, storageAccount)
+	if err != nil {
+		return nil, err
+	}
+
+	storageKey := media.StorageKey
+	if strings.HasPrefix(storageKey, quarantinePrefix) {
+		originalKey := strings.TrimPrefix(storageKey, quarantinePrefix)
+		if err := adapter.Move(ctx, storageKey, originalKey); err != nil {
+			return nil, err
+		}
+		storageKey = originalKey
+	}
+
+	// Previous versions come back with the item, unless they were found
+	// infected themselves
+	versions, err := s.repo.ListMediaVersions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range versions {
+		if v.ScanStatus == models.ScanStatusInfected || !strings.HasPrefix(v.StorageKey, quarantinePrefix) {
+			continue
+		}
+		originalKey := strings.TrimPrefix(v.StorageKey, quarantinePrefix)
+		if err := adapter.Move(ctx, v.StorageKey, originalKey); err != nil {
+			return nil, err
+		}
+		if err := s.repo.SetMediaVersionStorageKey(ctx, id, v.Version, originalKey); err != nil {
+			return nil, err
+		}
+	}
+
+	var publicURL *string
+	if url, err := adapter.GetPublicURL(ctx, storageKey); err == nil {
+		publicURL = &url
+	}
+
+	if err := s.repo.ReleaseMediaFromQuarantine(ctx, id, storageKey, publicURL, employee.ID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrMediaNotFound
+		}
+		return nil, err
+	}
+
+	s.logAudit(ctx, employee, models.AuditActionRelease, models.SeverityCritical, "media", &id, map[string]any{
+		"filename":  media.OriginalFilename,
+		"signature": media.ScanSignature,
+		"note":      note,
+	})
+
+	return s.repo.GetMediaByID(ctx, id)
+}
+
+// DeleteQuarantinedMedia removes an infected file from storage and the vault
+func (s *MediaService) DeleteQuarantinedMedia(ctx context.Context, id uuid.UUID, employee *models.Employee) error {
+	media, err := s.repo.GetMediaByID(ctx, id)
+	if err != nil {
+		return ErrMediaNotFound
+	}
+	if media.QuarantinedAt == nil {
+		return ErrInvalidInput
+	}
+	if err := checkLegalHold(&media.Media); err != nil {
+		return err
+	}
+	if err := checkRetention(&media.Media); err != nil {
+		return err
+	}
+
+	if err := s.purgeObject(ctx, &media.Media); err != nil {
+		return err
+	}
+
+	s.logAudit(ctx, employee, models.AuditActionDelete, models.SeverityCritical, "media", &id, map[string]any{
+		"filename":    media.OriginalFilename,
+		"signature":   media.ScanSignature,
+		"quarantined": true,
+	})
+	s.webhooks.Publish(ctx, models.WebhookEventMediaDeleted, media)
+
+	return nil
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/google/uuid"
+)
+
+// sweepBatchSize limits how many expired items one sweep handles per query
+const sweepBatchSize = 100
+
+// ListRetentionPolicies lists all retention policies
+func (s *MediaService) ListRetentionPolicies(ctx context.Context) ([]models.RetentionPolicy, error) {
+	return s.repo.ListRetentionPolicies(ctx)
+}
+
+// CreateRetentionPolicy creates a retention policy and applies it to the
+// media it covers
+func (s *MediaService) CreateRetentionPolicy(ctx context.Context, req *models.CreateRetentionPolicyRequest, employee *models.Employee) (*models.RetentionPolicy, error) {
+	if req.ExpireAfterDays == nil && req.MinRetentionDays == nil {
+		return nil, ErrInvalidInput
+	}
+
+	policy := &models.RetentionPolicy{
+		Name:             req.Name,
+		Scope:            req.Scope,
+		ExpireAfterDays:  req.ExpireAfterDays,
+		ExpireAction:     req.ExpireAction,
+		MinRetentionDays: req.MinRetentionDays,
+		IsActive:         true,
+		CreatedBy:        employee.ID,
+	}
+	if policy.ExpireAction == "" {
+		policy.ExpireAction = models.RetentionActionTrash
+	}
+
+	if err := s.validateScopeTarget(ctx, req.Scope, req.TargetID); err != nil {
+		return nil, err
+	}
+
+	targetID := req.TargetID
+	switch req.Scope {
+	case models.RetentionScopeGroup:
+		policy.MediaGroupID = &targetID
+	case models.RetentionScopeFolder:
+		policy.FolderID = &targetID
+	case models.RetentionScopeMedia:
+		policy.MediaID = &targetID
+	}
+
+	if err := s.repo.CreateRetentionPolicy(ctx, policy); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.RefreshMediaRetention(ctx, policy.Scope, targetID); err != nil {
+		return nil, err
+	}
+
+	s.logAudit(ctx, employee, models.AuditActionCreate, models.SeverityWarning, "retention_policy", &policy.ID, map[string]any{
+		"name":               policy.Name,
+		"scope":              policy.Scope,
+		"target_id":          targetID,
+		"expire_after_days":  policy.ExpireAfterDays,
+		"expire_action":      policy.ExpireAction,
+		"min_retention_days": policy.MinRetentionDays,
+	})
+
+	return policy, nil
+}
+
+// UpdateRetentionPolicy changes the rules of a retention policy and
+// recomputes retention for the media it covers
+func (s *MediaService) UpdateRetentionPolicy(ctx context.Context, id uuid.UUID, req *models.UpdateRetentionPolicyRequest, employee *models.Employee) (*models.RetentionPolicy, error) {
+	policy, err := s.repo.GetRetentionPolicyByID(ctx, id)
+	if err != nil {
+		return nil, ErrPolicyNotFound
+	}
+
+	if req.Name != nil {
+		policy.Name = *req.Name
+	}
+	if req.ExpireAfterDays != nil {
+		policy.ExpireAfterDays = req.ExpireAfterDays
+		if *req.ExpireAfterDays == 0 {
+			policy.ExpireAfterDays = nil
+		}
+	}
+	if req.ExpireAction != nil {
+		policy.ExpireAction = *req.ExpireAction
+	}
+	if req.MinRetentionDays != nil {
+		policy.MinRetentionDays = req.MinRetentionDays
+		if *req.MinRetentionDays == 0 {
+			policy.MinRetentionDays = nil
+		}
+	}
+	if req.IsActive != nil {
+		policy.IsActive = *req.IsActive
+	}
+
+	if policy.ExpireAfterDays == nil && policy.MinRetentionDays == nil {
+		return nil, ErrInvalidInput
+	}
+
+	if err := s.repo.UpdateRetentionPolicy(ctx, policy); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.RefreshMediaRetention(ctx, policy.Scope, policy.TargetID()); err != nil {
+		return nil, err
+	}
+
+	// Loosening a policy can allow deletions that were blocked before
+	s.logAudit(ctx, employee, models.AuditActionUpdate, models.SeverityCritical, "retention_policy", &id, map[string]any{
+		"name":               policy.Name,
+		"expire_after_days":  policy.ExpireAfterDays,
+		"expire_action":      policy.ExpireAction,
+		"min_retention_days": policy.MinRetentionDays,
+		"is_active":          policy.IsActive,
+	})
+
+	return s.repo.GetRetentionPolicyByID(ctx, id)
+}
+
+// DeleteRetentionPolicy removes a retention policy and recomputes retention
+// for the media it covered
+func (s *MediaService) DeleteRetentionPolicy(ctx context.Context, id uuid.UUID, employee *models.Employee) error {
+	policy, err := s.repo.GetRetentionPolicyByID(ctx, id)
+	if err != nil {
+		return ErrPolicyNotFound
+	}
+
+	if err := s.repo.DeleteRetentionPolicy(ctx, id); err != nil {
+		return err
+	}
+
+	if err := s.repo.RefreshMediaRetention(ctx, policy.Scope, policy.TargetID()); err != nil {
+		return err
+	}
+
+	s.logAudit(ctx, employee, models.AuditActionDelete, models.SeverityCritical, "retention_policy", &id, map[string]any{
+		"name":               policy.Name,
+		"scope":              policy.Scope,
+		"target_id":          policy.TargetID(),
+		"min_retention_days": policy.MinRetentionDays,
+	})
+
+	return nil
+}
+
+// SweepExpiredMedia trashes or purges media whose retention policy has
+// expired. It is run periodically by the job scheduler.
+func (s *MediaService) SweepExpiredMedia(ctx context.Context) error {
+	for {
+		batch, err := s.repo.ListExpiredMedia(ctx, sweepBatchSize)
+		if err != nil {
+			return err
+		}
+
+		handled := 0
+		for i := range batch {
+			media := &batch[i]
+			actor := &models.Employee{ID: media.PolicyCreatedBy}
+			if employee, err := s.repo.GetEmployeeByID(ctx, media.PolicyCreatedBy); err == nil {
+				actor = employee
+			}
+
+			switch media.Action {
+			case models.RetentionActionPurge:
+				err = s.purgeObject(ctx, &media.Media)
+			default:
+				err = s.repo.TrashMedia(ctx, media.ID, actor.ID)
+			}
+			if err != nil {
+				log.Printf("[MediaService] SweepExpiredMedia: failed to expire %s: %v", media.ID, err)
+				continue
+			}
+			handled++
+
+			s.logAudit(ctx, actor, models.AuditActionExpire, models.SeverityWarning, "media", &media.ID, map[string]any{
+				"filename":    media.OriginalFilename,
+				"policy_id":   media.PolicyID,
+				"policy_name": media.PolicyName,
+				"action":      media.Action,
+				"expires_at":  media.ExpiresAt,
+				"trigger":     "scheduled",
+			})
+			s.webhooks.Publish(ctx, models.WebhookEventMediaDeleted, &media.Media)
+		}
+
+		if len(batch) < sweepBatchSize || handled == 0 {
+			return nil
+		}
+	}
+}
+
+// checkRetention returns ErrRetentionActive when a media item must not be
+// deleted yet
+func checkRetention(media *models.Media) error {
+	if media.RetainUntil != nil && media.RetainUntil.After(time.Now()) {
+		return ErrRetentionActive
+	}
+	return nil
+}
+
+// refreshRetention recomputes retention for a single media item after its
+// group or folder changed
+func (s *MediaService) refreshRetention(ctx context.Context, id uuid.UUID) {
+	if err := s.repo.RefreshMediaRetention(ctx, models.RetentionScopeMedia, id); err != nil {
+		log.Printf("[MediaService] failed to refresh retention for %s: %v", id, err)
+	}
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrCustomRoleNotFound = errors.New("custom role not found")
+	ErrCustomRoleExists   = errors.New("a role with this name already exists")
+	ErrRoleNotGrantable   = errors.New("cannot grant permissions you do not hold")
+	ErrOwnRole            = errors.New("cannot change your own role")
+)
+
+// Permissions resolves the permissions of an employee's roles
+func (s *AuthService) Permissions(ctx context.Context, role models.Role, customRoleID *uuid.UUID) ([]models.Permission, error) {
+	return s.authz.Permissions(ctx, role, customRoleID)
+}
+
+// ListRoles lists the built-in role presets followed by the custom roles
+func (s *AuthService) ListRoles(ctx context.Context) ([]models.CustomRole, error) {
+	builtin := []models.Role{models.RoleAdmin, models.RoleDeveloper, models.RoleMarketing, models.RoleViewer}
+	roles := make([]models.CustomRole, 0, len(builtin))
+	for _, role := range builtin {
+		roles = append(roles, models.CustomRole{
+			Name:        string(role),
+			Description: "Built-in role",
+			Permissions: models.BuiltinRolePermissions[role],
+			Builtin:     true,
+		})
+	}
+
+	custom, err := s.repo.ListCustomRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return append(roles, custom...), nil
+}
+
+// CreateCustomRole defines a custom role. Its name may not shadow a
+// built-in role, and it may only hold permissions the caller holds.
+func (s *AuthService) CreateCustomRole(ctx context.Context, req *models.CreateCustomRoleRequest, caller *models.Employee) (*models.CustomRole, error) {
+	if _, builtin := models.BuiltinRolePermissions[models.Role(req.Name)]; builtin {
+		return nil, ErrCustomRoleExists
+	}
+	permissions, err := normalizePermissions(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkGrantable(caller, permissions); err != nil {
+		return nil, err
+	}
+
+	role := &models.CustomRole{
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: permissions,
+		CreatedBy:   &caller.ID,
+	}
+	if err := s.repo.CreateCustomRole(ctx, role); err != nil {
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			return nil, ErrCustomRoleExists
+		}
+		return nil, err
+	}
+	return role, nil
+}
+
+// UpdateCustomRole changes a custom role. Employees holding it get the new
+// permissions with their next request. Only callers holding every permission
+// of the role, before and after the change, may change its permissions.
+func (s *AuthService) UpdateCustomRole(ctx context.Context, id uuid.UUID, req *models.UpdateCustomRoleRequest, caller *models.Employee) (*models.CustomRole, error) {
+	role, err := s.repo.GetCustomRoleByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrCustomRoleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		if _, builtin := models.BuiltinRolePermissions[models.Role(*req.Name)]; builtin {
+			return nil, ErrCustomRoleExists
+		}
+		role.Name = *req.Name
+	}
+	if req.Description != nil {
+		role.Description = *req.Description
+	}
+	if req.Permissions != nil {
+		if err := checkGrantable(caller, role.Permissions); err != nil {
+			return nil, err
+		}
+		if role.Permissions, err = normalizePermissions(req.Permissions); err != nil {
+			return nil, err
+		}
+		if err := checkGrantable(caller, role.Permissions); err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.repo.UpdateCustomRole(ctx, role)
+	s.authz.forget(id)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return nil, ErrCustomRoleNotFound
+	case errors.Is(err, repository.ErrAlreadyExists):
+		return nil, ErrCustomRoleExists
+	case err != nil:
+		return nil, err
+	}
+	return role, nil
+}
+
+// DeleteCustomRole deletes a custom role; its employees fall back to their
+// built-in role
+func (s *AuthService) DeleteCustomRole(ctx context.Context, id uuid.UUID) error {
+	err := s.repo.DeleteCustomRole(ctx, id)
+	s.authz.forget(id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrCustomRoleNotFound
+	}
+	return err
+}
+
+// checkCustomRole makes sure a custom role about to be assigned exists
+func (s *AuthService) checkCustomRole(ctx context.Context, id *uuid.UUID) error {
+	if id == nil {
+		return nil
+	}
+	_, err := s.repo.GetCustomRoleByID(ctx, *id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrCustomRoleNotFound
+	}
+	return err
+}
+
+// checkAssignable makes sure the caller holds every permission of a role and
+// custom role they are about to give or take away, so that managing
+// employees cannot be used to gain permissions
+func (s *AuthService) checkAssignable(ctx context.Context, caller *models.Employee, role models.Role, customRoleID *uuid.UUID) error {
+	permissions, err := s.authz.Permissions(ctx, role, customRoleID)
+	if err != nil {
+		return err
+	}
+	return checkGrantable(caller, permissions)
+}
+
+// checkGrantable makes sure the caller holds all of permissions
+func checkGrantable(caller *models.Employee, permissions []models.Permission) error {
+	for _, p := range permissions {
+		if !caller.Can(p) {
+			return ErrRoleNotGrantable
+		}
+	}
+	return nil
+}
+
+// normalizePermissions validates permissions, drops duplicates and sorts them
+func normalizePermissions(requested []models.Permission) ([]models.Permission, error) {
+	valid := make(map[models.Permission]bool, len(models.AllPermissions))
+	for _, p := range models.AllPermissions {
+		valid[p] = true
+	}
+
+	seen := make(map[models.Permission]bool, len(requested))
+	permissions := make([]models.Permission, 0, len(requested))
+	for _, p := range requested {
+		if !valid[p] {
+			return nil, fmt.Errorf("unknown permission %q", p)
+		}
+		if !seen[p] {
+			seen[p] = true
+			permissions = append(permissions, p)
+		}
+	}
+
+	sort.Slice(permissions, func(i, j int) bool { return permissions[i] < permissions[j] })
+	return permissions, nil
+}
@@ -0,0 +1,282 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/repository"
+	"github.com/google/uuid"
+)
+
+// CreateSavedSearch saves a media filter under a name
+func (s *MediaService) CreateSavedSearch(ctx context.Context, req *models.CreateSavedSearchRequest, employee *models.Employee) (*models.SavedSearch, error) {
+	search := &models.SavedSearch{
+		Name:          strings.TrimSpace(req.Name),
+		Description:   req.Description,
+		Filters:       req.Filters,
+		Visibility:    req.Visibility,
+		SharedRole:    req.SharedRole,
+		SharedGroupID: req.SharedGroupID,
+		IsCollection:  req.IsCollection,
+		CreatedBy:     employee.ID,
+	}
+	if search.Visibility == "" {
+		search.Visibility = models.SavedSearchPrivate
+	}
+
+	if err := s.validateSavedSearch(ctx, search, employee); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateSavedSearch(ctx, search); err != nil {
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			return nil, ErrSavedSearchExists
+		}
+		return nil, err
+	}
+
+	s.logAudit(ctx, employee, models.AuditActionCreate, models.SeverityInfo, "saved_search", &search.ID, map[string]any{
+		"name":          search.Name,
+		"visibility":    search.Visibility,
+		"is_collection": search.IsCollection,
+	})
+
+	return s.repo.GetSavedSearchByID(ctx, search.ID)
+}
+
+// ListSavedSearches lists the saved searches an employee can use
+func (s *MediaService) ListSavedSearches(ctx context.Context, employee *models.Employee, collectionsOnly bool) ([]models.SavedSearch, error) {
+	return s.repo.ListSavedSearches(ctx, employee.ID, employee.Role, employee.Can(models.PermLibraryManageAny), collectionsOnly)
+}
+
+// GetSavedSearch gets a saved search the employee can use. Searches they
+// cannot see are reported as not found.
+func (s *MediaService) GetSavedSearch(ctx context.Context, id uuid.UUID, employee *models.Employee) (*models.SavedSearch, error) {
+	search, err := s.repo.GetSavedSearchByID(ctx, id)
+	if err != nil {
+		return nil, ErrSavedSearchNotFound
+	}
+
+	visible, err := s.canUseSavedSearch(ctx, search, employee)
+	if err != nil {
+		return nil, err
+	}
+	if !visible {
+		return nil, ErrSavedSearchNotFound
+	}
+	return search, nil
+}
+
+// UpdateSavedSearch changes a saved search; only its creator or an admin may
+func (s *MediaService) UpdateSavedSearch(ctx context.Context, id uuid.UUID, req *models.UpdateSavedSearchRequest, employee *models.Employee) (*models.SavedSearch, error) {
+	search, err := s.GetSavedSearch(ctx, id, employee)
+	if err != nil {
+		return nil, err
+	}
+	if search.CreatedBy != employee.ID && !employee.Can(models.PermLibraryManageAny) {
+		return nil, ErrForbidden
+	}
+
+	if req.Name != nil {
+		search.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		search.Description = req.Description
+	}
+	if req.Filters != nil {
+		search.Filters = *req.Filters
+	}
+	if req.Visibility != nil {
+		// Changing who it is shared with starts from a clean slate
+		search.Visibility = *req.Visibility
+		search.SharedRole = nil
+		search.SharedGroupID = nil
+	}
+	if req.SharedRole != nil {
+		search.SharedRole = req.SharedRole
+	}
+	if req.SharedGroupID != nil {
+		search.SharedGroupID = req.SharedGroupID
+	}
+	if req.IsCollection != nil {
+		search.IsCollection = *req.IsCollection
+	}
+
+	if err := s.validateSavedSearch(ctx, search, employee); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateSavedSearch(ctx, search); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrSavedSearchNotFound
+		}
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			return nil, ErrSavedSearchExists
+		}
+		return nil, err
+	}
+
+	s.logAudit(ctx, employee, models.AuditActionUpdate, models.SeverityInfo, "saved_search", &id, map[string]any{
+		"name":          search.Name,
+		"visibility":    search.Visibility,
+		"is_collection": search.IsCollection,
+	})
+
+	return s.repo.GetSavedSearchByID(ctx, id)
+}
+
+// DeleteSavedSearch deletes a saved search; only its creator or an admin may
+func (s *MediaService) DeleteSavedSearch(ctx context.Context, id uuid.UUID, employee *models.Employee) error {
+	search, err := s.GetSavedSearch(ctx, id, employee)
+	if err != nil {
+		return err
+	}
+	if search.CreatedBy != employee.ID && !employee.Can(models.PermLibraryManageAny) {
+		return ErrForbidden
+	}
+
+	if err := s.repo.SoftDeleteSavedSearch(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrSavedSearchNotFound
+		}
+		return err
+	}
+
+	s.logAudit(ctx, employee, models.AuditActionDelete, models.SeverityInfo, "saved_search", &id, map[string]any{
+		"name": search.Name,
+	})
+
+	return nil
+}
+
+// ApplySavedSearch sets a saved search's filters on a listing request, to be
+// resolved live through ListMedia. The search runs with the viewer's
+// permissions: a shared search naming a storage account or group the viewer
+// cannot access is refused rather than widening what they can list.
+func (s *MediaService) ApplySavedSearch(ctx context.Context, id uuid.UUID, filters *models.MediaFilterRequest, employee *models.Employee) (*models.SavedSearch, error) {
+	search, err := s.GetSavedSearch(ctx, id, employee)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkFilterAccess(ctx, &search.Filters, employee); err != nil {
+		return nil, err
+	}
+
+	search.Filters.ApplyTo(filters)
+	return search, nil
+}
+
+// validateSavedSearch checks a saved search before it is stored
+func (s *MediaService) validateSavedSearch(ctx context.Context, search *models.SavedSearch, employee *models.Employee) error {
+	if search.Name == "" {
+		return ErrInvalidInput
+	}
+
+	f := &search.Filters
+	if f.MinSize != nil && f.MaxSize != nil && *f.MinSize > *f.MaxSize {
+		return ErrInvalidInput
+	}
+	if f.SortBy != "" && !mediaSortKeys[f.SortBy] && !(f.SortBy == "relevance" && f.Fuzzy != "") {
+		return ErrInvalidInput
+	}
+	if order := strings.ToLower(f.SortOrder); order != "" && order != "asc" && order != "desc" {
+		return ErrInvalidInput
+	}
+	if _, err := ParseSearchQuery(f.Q); err != nil {
+		return err
+	}
+
+	switch search.Visibility {
+	case models.SavedSearchPrivate:
+		if search.SharedRole != nil || search.SharedGroupID != nil {
+			return ErrInvalidInput
+		}
+	case models.SavedSearchRole:
+		if search.SharedRole == nil || search.SharedGroupID != nil {
+			return ErrInvalidInput
+		}
+	case models.SavedSearchGroup:
+		if search.SharedGroupID == nil || search.SharedRole != nil {
+			return ErrInvalidInput
+		}
+		group, err := s.repo.GetMediaGroupByID(ctx, *search.SharedGroupID)
+		if err != nil {
+			return ErrGroupNotFound
+		}
+		if !roleAllowed(group.AllowedRoles, employee.Role) {
+			return ErrForbidden
+		}
+	default:
+		return ErrInvalidInput
+	}
+
+	// The creator must be able to run what they save
+	return s.checkFilterAccess(ctx, f, employee)
+}
+
+// canUseSavedSearch applies the sharing rules of a saved search
+func (s *MediaService) canUseSavedSearch(ctx context.Context, search *models.SavedSearch, employee *models.Employee) (bool, error) {
+	if search.CreatedBy == employee.ID {
+		return true, nil
+	}
+
+	switch search.Visibility {
+	case models.SavedSearchRole:
+		return employee.Can(models.PermLibraryManageAny) || *search.SharedRole == employee.Role, nil
+	case models.SavedSearchGroup:
+		if employee.Can(models.PermLibraryManageAny) {
+			return true, nil
+		}
+		group, err := s.repo.GetMediaGroupByID(ctx, *search.SharedGroupID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return roleAllowed(group.AllowedRoles, employee.Role), nil
+	default:
+		return false, nil
+	}
+}
+
+// checkFilterAccess makes sure the employee may list the storage account and
+// media group that filters narrow to
+func (s *MediaService) checkFilterAccess(ctx context.Context, f *models.SavedSearchFilters, employee *models.Employee) error {
+	if employee.Can(models.PermStorageViewAll) {
+		return nil
+	}
+
+	if f.StorageAccountID != nil {
+		ok, err := s.repo.HasStorageAccess(ctx, *f.StorageAccountID, employee.ID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrForbidden
+		}
+	}
+	if f.MediaGroupID != nil {
+		group, err := s.repo.GetMediaGroupByID(ctx, *f.MediaGroupID)
+		if err != nil {
+			return ErrGroupNotFound
+		}
+		if !roleAllowed(group.AllowedRoles, employee.Role) {
+			return ErrForbidden
+		}
+	}
+	return nil
+}
+
+// roleAllowed reports whether role is among a media group's allowed roles
+func roleAllowed(allowed []models.Role, role models.Role) bool {
+	for _, r := range allowed {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,950 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/appnity/media-vault/internal/config"
+	"github.com/appnity/media-vault/internal/crypto"
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrSCIMUserExists    = errors.New("a user with this userName already exists")
+	ErrSCIMGroupExists   = errors.New("a group with this displayName already exists")
+	ErrSCIMGroupNotFound = errors.New("group not found")
+	ErrSCIMInvalidFilter = errors.New("unsupported filter")
+	ErrSCIMInvalidValue  = errors.New("invalid attribute value")
+)
+
+const (
+	scimDefaultCount = 100
+	scimMaxCount     = 200
+)
+
+// scimFilterPattern matches the only filter form supported: attribute eq
+// "value", which is what identity providers use to look resources up
+var scimFilterPattern = regexp.MustCompile(`(?i)^\s*([a-z][a-z0-9.]*)\s+eq\s+("(?:[^"\\]|\\.)*")\s*$`)
+
+// scimUserFilters and scimGroupFilters map the attributes resources can be
+// filtered on, lowercased, to repository filter attributes. userName is the
+// email, so filtering on emails is the same.
+var (
+	scimUserFilters = map[string]string{
+		"username":     "userName",
+		"emails.value": "userName",
+		"emails":       "userName",
+		"externalid":   "externalId",
+		"id":           "id",
+	}
+	scimGroupFilters = map[string]string{
+		"displayname": "displayName",
+		"externalid":  "externalId",
+		"id":          "id",
+	}
+)
+
+// SCIMService provisions employees and groups for identity providers
+// (SCIM 2.0). A user is an employee whose userName is their email. A group
+// gives its members the role its display name maps to in OIDC_ROLE_MAPPING.
+type SCIMService struct {
+	repo *repository.Repository
+	cfg  *config.Config
+	auth *AuthService
+}
+
+// NewSCIMService creates a new SCIM service
+func NewSCIMService(repo *repository.Repository, cfg *config.Config, auth *AuthService) *SCIMService {
+	return &SCIMService{repo: repo, cfg: cfg, auth: auth}
+}
+
+// ServiceProviderConfig describes the SCIM features supported
+func (s *SCIMService) ServiceProviderConfig() map[string]any {
+	unsupported := map[string]any{"supported": false}
+	return map[string]any{
+		"schemas":        []string{models.SCIMConfigSchema},
+		"patch":          map[string]any{"supported": true},
+		"bulk":           map[string]any{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]any{"supported": true, "maxResults": scimMaxCount},
+		"changePassword": unsupported,
+		"sort":           unsupported,
+		"etag":           unsupported,
+		"authenticationSchemes": []map[string]any{{
+			"type":        "oauthbearertoken",
+			"name":        "Bearer token",
+			"description": "The SCIM_TOKEN configured on the server",
+		}},
+	}
+}
+
+// ResourceTypes lists the SCIM resource types served
+func (s *SCIMService) ResourceTypes() *models.SCIMListResponse[map[string]any] {
+	types := []map[string]any{
+		{"schemas": []string{models.SCIMResourceSchema}, "id": "User", "name": "User", "endpoint": "/Users", "schema": models.SCIMUserSchema},
+		{"schemas": []string{models.SCIMResourceSchema}, "id": "Group", "name": "Group", "endpoint": "/Groups", "schema": models.SCIMGroupSchema},
+	}
+	return &models.SCIMListResponse[map[string]any]{
+		Schemas:      []string{models.SCIMListSchema},
+		TotalResults: int64(len(types)),
+		StartIndex:   1,
+		ItemsPerPage: len(types),
+		Resources:    types,
+	}
+}
+
+// ==========================================
+// Users
+// ==========================================
+
+// ListUsers lists employees, optionally filtered. startIndex is 1-based.
+func (s *SCIMService) ListUsers(ctx context.Context, filter string, startIndex, count int) (*models.SCIMListResponse[models.SCIMUser], error) {
+	f, err := parseSCIMFilter(filter, scimUserFilters)
+	if err != nil {
+		return nil, err
+	}
+	offset, limit := scimPage(startIndex, count)
+
+	employees, total, err := s.repo.ListSCIMUsers(ctx, f, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachGroups(ctx, employees); err != nil {
+		return nil, err
+	}
+
+	resources := make([]models.SCIMUser, len(employees))
+	for i := range employees {
+		resources[i] = *s.userResource(&employees[i])
+	}
+	return &models.SCIMListResponse[models.SCIMUser]{
+		Schemas:      []string{models.SCIMListSchema},
+		TotalResults: total,
+		StartIndex:   offset + 1,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}, nil
+}
+
+// GetUser gets an employee
+func (s *SCIMService) GetUser(ctx context.Context, id uuid.UUID) (*models.SCIMUser, error) {
+	employee, err := s.getUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.userResource(employee), nil
+}
+
+// CreateUser provisions an employee. They get the default role until a
+// group grants another, and have no password: they sign in through single
+// sign-on.
+func (s *SCIMService) CreateUser(ctx context.Context, user *models.SCIMUser) (*models.SCIMUser, error) {
+	email, err := scimUserEmail(user)
+	if err != nil {
+		return nil, err
+	}
+
+	password, err := crypto.GenerateRandomString(32)
+	if err != nil {
+		return nil, err
+	}
+	passwordHash, err := crypto.HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	employee := &models.SCIMEmployee{
+		Employee: models.Employee{
+			Email:        email,
+			PasswordHash: passwordHash,
+			FullName:     scimFullName(user, email),
+			Role:         s.defaultRole(),
+			IsActive:     user.Active == nil || *user.Active,
+		},
+		ExternalID: optionalString(user.ExternalID),
+	}
+	if err := s.repo.CreateSCIMUser(ctx, employee); err != nil {
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			return nil, ErrSCIMUserExists
+		}
+		return nil, err
+	}
+
+	log.Printf("[SCIM] provisioned %s", employee.Email)
+	return s.userResource(employee), nil
+}
+
+// ReplaceUser replaces an employee's email, name, active state and external
+// ID
+func (s *SCIMService) ReplaceUser(ctx context.Context, id uuid.UUID, user *models.SCIMUser) (*models.SCIMUser, error) {
+	employee, err := s.getUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	wasActive := employee.IsActive
+
+	email, err := scimUserEmail(user)
+	if err != nil {
+		return nil, err
+	}
+	employee.Email = email
+	employee.FullName = scimFullName(user, email)
+	employee.ExternalID = optionalString(user.ExternalID)
+	if user.Active != nil {
+		employee.IsActive = *user.Active
+	}
+
+	return s.saveUser(ctx, employee, wasActive)
+}
+
+// PatchUser applies patch operations to an employee. Attributes the service
+// does not store, e.g. phone numbers, are ignored.
+func (s *SCIMService) PatchUser(ctx context.Context, id uuid.UUID, patch *models.SCIMPatchRequest) (*models.SCIMUser, error) {
+	employee, err := s.getUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	wasActive := employee.IsActive
+
+	for _, op := range patch.Operations {
+		if err := applyUserPatch(employee, op); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.saveUser(ctx, employee, wasActive)
+}
+
+// DeleteUser deletes an employee and signs them out
+func (s *SCIMService) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	employee, err := s.getUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.auth.DeleteEmployee(ctx, id); err != nil {
+		return err
+	}
+	log.Printf("[SCIM] deprovisioned %s", employee.Email)
+	return nil
+}
+
+// getUser loads an employee with their groups
+func (s *SCIMService) getUser(ctx context.Context, id uuid.UUID) (*models.SCIMEmployee, error) {
+	employee, err := s.repo.GetSCIMUser(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrEmployeeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	employees := []models.SCIMEmployee{*employee}
+	if err := s.attachGroups(ctx, employees); err != nil {
+		return nil, err
+	}
+	return &employees[0], nil
+}
+
+// saveUser stores an employee's SCIM attributes. A deactivated employee is
+// signed out everywhere right away.
+func (s *SCIMService) saveUser(ctx context.Context, employee *models.SCIMEmployee, wasActive bool) (*models.SCIMUser, error) {
+	if err := s.repo.UpdateSCIMUser(ctx, employee); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrAlreadyExists):
+			return nil, ErrSCIMUserExists
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, ErrEmployeeNotFound
+		}
+		return nil, err
+	}
+
+	if wasActive && !employee.IsActive {
+		if err := s.repo.RevokeEmployeeRefreshTokens(ctx, employee.ID, models.TokenRevokedDeactivated); err != nil {
+			return nil, err
+		}
+		log.Printf("[SCIM] deactivated %s", employee.Email)
+	}
+	return s.userResource(employee), nil
+}
+
+// applyUserPatch applies one patch operation to an employee
+func applyUserPatch(employee *models.SCIMEmployee, op models.SCIMPatchOperation) error {
+	remove := false
+	switch strings.ToLower(op.Op) {
+	case "add", "replace":
+	case "remove":
+		remove = true
+	default:
+		return fmt.Errorf("%w: unknown op %q", ErrSCIMInvalidValue, op.Op)
+	}
+
+	if op.Path != "" {
+		return applyUserAttribute(employee, op.Path, op.Value, remove)
+	}
+
+	// Without a path the value holds the attributes to set
+	var attributes map[string]json.RawMessage
+	if remove || json.Unmarshal(op.Value, &attributes) != nil {
+		return fmt.Errorf("%w: %s without a path needs an object value", ErrSCIMInvalidValue, op.Op)
+	}
+	for path, value := range attributes {
+		if err := applyUserAttribute(employee, path, value, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyUserAttribute sets or removes one attribute of an employee
+func applyUserAttribute(employee *models.SCIMEmployee, path string, value json.RawMessage, remove bool) error {
+	given, family := splitFullName(employee.FullName)
+
+	switch strings.ToLower(path) {
+	case "active":
+		if remove {
+			return fmt.Errorf("%w: active cannot be removed", ErrSCIMInvalidValue)
+		}
+		active, err := scimBool(value)
+		if err != nil {
+			return err
+		}
+		employee.IsActive = active
+	case "username":
+		email, err := scimString(value)
+		if err != nil || remove {
+			return fmt.Errorf("%w: userName must be an email address", ErrSCIMInvalidValue)
+		}
+		if email = strings.ToLower(strings.TrimSpace(email)); !strings.Contains(email, "@") {
+			return fmt.Errorf("%w: userName must be an email address", ErrSCIMInvalidValue)
+		}
+		employee.Email = email
+	case "externalid":
+		if remove {
+			employee.ExternalID = nil
+			return nil
+		}
+		externalID, err := scimString(value)
+		if err != nil {
+			return err
+		}
+		employee.ExternalID = optionalString(externalID)
+	case "displayname", "name.formatted":
+		if remove {
+			return nil
+		}
+		name, err := scimString(value)
+		if err != nil {
+			return err
+		}
+		if name = strings.TrimSpace(name); name != "" {
+			employee.FullName = name
+		}
+	case "name.givenname", "name.familyname":
+		if remove {
+			return nil
+		}
+		part, err := scimString(value)
+		if err != nil {
+			return err
+		}
+		if strings.EqualFold(path, "name.givenName") {
+			given = part
+		} else {
+			family = part
+		}
+		if name := strings.TrimSpace(given + " " + family); name != "" {
+			employee.FullName = name
+		}
+	case "name":
+		if remove {
+			return nil
+		}
+		var name models.SCIMName
+		if err := json.Unmarshal(value, &name); err != nil {
+			return fmt.Errorf("%w: name must be an object", ErrSCIMInvalidValue)
+		}
+		if fullName := scimNameString(&name); fullName != "" {
+			employee.FullName = fullName
+		}
+	}
+	return nil
+}
+
+// ==========================================
+// Groups
+// ==========================================
+
+// ListGroups lists groups, optionally filtered and without members
+func (s *SCIMService) ListGroups(ctx context.Context, filter string, startIndex, count int, withMembers bool) (*models.SCIMListResponse[models.SCIMGroupResource], error) {
+	f, err := parseSCIMFilter(filter, scimGroupFilters)
+	if err != nil {
+		return nil, err
+	}
+	offset, limit := scimPage(startIndex, count)
+
+	groups, total, err := s.repo.ListSCIMGroups(ctx, f, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	if withMembers {
+		if err := s.attachMembers(ctx, groups); err != nil {
+			return nil, err
+		}
+	}
+
+	resources := make([]models.SCIMGroupResource, len(groups))
+	for i := range groups {
+		resources[i] = *s.groupResource(&groups[i])
+	}
+	return &models.SCIMListResponse[models.SCIMGroupResource]{
+		Schemas:      []string{models.SCIMListSchema},
+		TotalResults: total,
+		StartIndex:   offset + 1,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}, nil
+}
+
+// GetGroup gets a group
+func (s *SCIMService) GetGroup(ctx context.Context, id uuid.UUID, withMembers bool) (*models.SCIMGroupResource, error) {
+	group, err := s.repo.GetSCIMGroup(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrSCIMGroupNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if withMembers {
+		groups := []models.SCIMGroup{*group}
+		if err := s.attachMembers(ctx, groups); err != nil {
+			return nil, err
+		}
+		group = &groups[0]
+	}
+	return s.groupResource(group), nil
+}
+
+// CreateGroup creates a group with its members
+func (s *SCIMService) CreateGroup(ctx context.Context, resource *models.SCIMGroupResource) (*models.SCIMGroupResource, error) {
+	displayName := strings.TrimSpace(resource.DisplayName)
+	if displayName == "" {
+		return nil, fmt.Errorf("%w: displayName is required", ErrSCIMInvalidValue)
+	}
+	memberIDs, err := scimMemberIDs(resource.Members)
+	if err != nil {
+		return nil, err
+	}
+
+	group := &models.SCIMGroup{DisplayName: displayName, ExternalID: optionalString(resource.ExternalID)}
+	if err := s.repo.CreateSCIMGroup(ctx, group); err != nil {
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			return nil, ErrSCIMGroupExists
+		}
+		return nil, err
+	}
+	if len(memberIDs) > 0 {
+		if err := s.repo.AddSCIMGroupMembers(ctx, group.ID, memberIDs); err != nil {
+			return nil, err
+		}
+		if err := s.syncRoles(ctx, memberIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.GetGroup(ctx, group.ID, true)
+}
+
+// ReplaceGroup replaces a group's name, external ID and members
+func (s *SCIMService) ReplaceGroup(ctx context.Context, id uuid.UUID, resource *models.SCIMGroupResource) (*models.SCIMGroupResource, error) {
+	displayName := strings.TrimSpace(resource.DisplayName)
+	if displayName == "" {
+		return nil, fmt.Errorf("%w: displayName is required", ErrSCIMInvalidValue)
+	}
+	memberIDs, err := scimMemberIDs(resource.Members)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := s.groupMemberIDs(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	group := &models.SCIMGroup{ID: id, DisplayName: displayName, ExternalID: optionalString(resource.ExternalID)}
+	if err := s.updateGroup(ctx, group); err != nil {
+		return nil, err
+	}
+	if err := s.repo.ReplaceSCIMGroupMembers(ctx, id, memberIDs); err != nil {
+		return nil, err
+	}
+	if err := s.syncRoles(ctx, append(previous, memberIDs...)); err != nil {
+		return nil, err
+	}
+
+	return s.GetGroup(ctx, id, true)
+}
+
+// PatchGroup applies patch operations to a group: renaming it and adding,
+// removing or replacing members
+func (s *SCIMService) PatchGroup(ctx context.Context, id uuid.UUID, patch *models.SCIMPatchRequest) (*models.SCIMGroupResource, error) {
+	group, err := s.repo.GetSCIMGroup(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrSCIMGroupNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Renaming changes the role of every member
+	affected, err := s.groupMemberIDs(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	renamed := *group
+
+	for _, op := range patch.Operations {
+		changed, err := s.applyGroupPatch(ctx, &renamed, op)
+		if err != nil {
+			return nil, err
+		}
+		affected = append(affected, changed...)
+	}
+
+	if renamed.DisplayName != group.DisplayName || !equalOptional(renamed.ExternalID, group.ExternalID) {
+		if err := s.updateGroup(ctx, &renamed); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.syncRoles(ctx, affected); err != nil {
+		return nil, err
+	}
+
+	return s.GetGroup(ctx, id, true)
+}
+
+// DeleteGroup deletes a group. Its members lose the role it granted.
+func (s *SCIMService) DeleteGroup(ctx context.Context, id uuid.UUID) error {
+	members, err := s.groupMemberIDs(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteSCIMGroup(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrSCIMGroupNotFound
+		}
+		return err
+	}
+	return s.syncRoles(ctx, members)
+}
+
+// applyGroupPatch applies one patch operation to a group. Name changes are
+// made on group and saved by the caller; member changes are saved here and
+// the employees they touch are returned.
+func (s *SCIMService) applyGroupPatch(ctx context.Context, group *models.SCIMGroup, op models.SCIMPatchOperation) ([]uuid.UUID, error) {
+	kind := strings.ToLower(op.Op)
+	if kind != "add" && kind != "replace" && kind != "remove" {
+		return nil, fmt.Errorf("%w: unknown op %q", ErrSCIMInvalidValue, op.Op)
+	}
+	path := strings.ToLower(strings.TrimSpace(op.Path))
+
+	switch {
+	case path == "":
+		// Without a path the value holds the attributes to set
+		var attributes map[string]json.RawMessage
+		if kind == "remove" || json.Unmarshal(op.Value, &attributes) != nil {
+			return nil, fmt.Errorf("%w: %s without a path needs an object value", ErrSCIMInvalidValue, op.Op)
+		}
+		var changed []uuid.UUID
+		for name, value := range attributes {
+			ids, err := s.applyGroupPatch(ctx, group, models.SCIMPatchOperation{Op: op.Op, Path: name, Value: value})
+			if err != nil {
+				return nil, err
+			}
+			changed = append(changed, ids...)
+		}
+		return changed, nil
+
+	case path == "displayname":
+		name, err := scimString(op.Value)
+		if err != nil || kind == "remove" || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("%w: displayName is required", ErrSCIMInvalidValue)
+		}
+		group.DisplayName = strings.TrimSpace(name)
+		return nil, nil
+
+	case path == "id":
+		// Some providers echo the id back; it cannot change
+		return nil, nil
+
+	case path == "externalid":
+		if kind == "remove" {
+			group.ExternalID = nil
+			return nil, nil
+		}
+		externalID, err := scimString(op.Value)
+		if err != nil {
+			return nil, err
+		}
+		group.ExternalID = optionalString(externalID)
+		return nil, nil
+
+	case path == "members":
+		var refs []models.SCIMRef
+		if len(op.Value) > 0 && json.Unmarshal(op.Value, &refs) != nil {
+			return nil, fmt.Errorf("%w: members must be a list", ErrSCIMInvalidValue)
+		}
+		ids, err := scimMemberIDs(refs)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case kind == "add":
+			return ids, s.repo.AddSCIMGroupMembers(ctx, group.ID, ids)
+		case kind == "remove" && len(ids) > 0:
+			return ids, s.repo.RemoveSCIMGroupMembers(ctx, group.ID, ids)
+		default:
+			// replace, or remove without a value: the members become ids
+			return ids, s.repo.ReplaceSCIMGroupMembers(ctx, group.ID, ids)
+		}
+
+	case strings.HasPrefix(path, "members[") && strings.HasSuffix(path, "]") && kind == "remove":
+		// members[value eq "id"]
+		inner := strings.TrimSpace(op.Path)
+		f, err := parseSCIMFilter(inner[len("members["):len(inner)-1], map[string]string{"value": "value"})
+		if err != nil || f == nil {
+			return nil, fmt.Errorf("%w: unsupported member path %q", ErrSCIMInvalidFilter, op.Path)
+		}
+		id, err := uuid.Parse(f.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: member %q is not a user id", ErrSCIMInvalidValue, f.Value)
+		}
+		return []uuid.UUID{id}, s.repo.RemoveSCIMGroupMembers(ctx, group.ID, []uuid.UUID{id})
+
+	default:
+		return nil, fmt.Errorf("%w: unsupported path %q", ErrSCIMInvalidValue, op.Path)
+	}
+}
+
+// updateGroup saves a group's name and external ID
+func (s *SCIMService) updateGroup(ctx context.Context, group *models.SCIMGroup) error {
+	err := s.repo.UpdateSCIMGroup(ctx, group)
+	switch {
+	case errors.Is(err, repository.ErrAlreadyExists):
+		return ErrSCIMGroupExists
+	case errors.Is(err, repository.ErrNotFound):
+		return ErrSCIMGroupNotFound
+	}
+	return err
+}
+
+// groupMemberIDs lists the employees in a group
+func (s *SCIMService) groupMemberIDs(ctx context.Context, groupID uuid.UUID) ([]uuid.UUID, error) {
+	members, err := s.repo.ListSCIMGroupMembers(ctx, []uuid.UUID{groupID})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uuid.UUID, 0, len(members[groupID]))
+	for _, m := range members[groupID] {
+		ids = append(ids, m.EmployeeID)
+	}
+	return ids, nil
+}
+
+// syncRoles gives employees the role their groups map to, or the default
+// role when none does. Without a role mapping roles are managed by admins
+// and groups change nothing.
+func (s *SCIMService) syncRoles(ctx context.Context, employeeIDs []uuid.UUID) error {
+	if len(s.cfg.OIDCRoleMapping) == 0 || len(employeeIDs) == 0 {
+		return nil
+	}
+
+	memberships, err := s.repo.ListSCIMGroupMemberships(ctx, employeeIDs)
+	if err != nil {
+		return err
+	}
+	seen := make(map[uuid.UUID]bool)
+	for _, id := range employeeIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		names := make([]string, len(memberships[id]))
+		for i, m := range memberships[id] {
+			names[i] = m.DisplayName
+		}
+		role := s.auth.mappedRole(names)
+		if role == "" {
+			role = s.defaultRole()
+		}
+		if err := s.repo.SetEmployeeRole(ctx, id, role); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// defaultRole is the role of employees no group grants one. SCIM leaves
+// deactivation to the active attribute, so a default of none means viewer.
+func (s *SCIMService) defaultRole() models.Role {
+	if s.cfg.OIDCDefaultRole == "" {
+		return models.RoleViewer
+	}
+	return models.Role(s.cfg.OIDCDefaultRole)
+}
+
+// ==========================================
+// Resources
+// ==========================================
+
+// attachGroups fills in the groups of employees
+func (s *SCIMService) attachGroups(ctx context.Context, employees []models.SCIMEmployee) error {
+	if len(employees) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, len(employees))
+	for i, e := range employees {
+		ids[i] = e.ID
+	}
+	memberships, err := s.repo.ListSCIMGroupMemberships(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range employees {
+		employees[i].Groups = memberships[employees[i].ID]
+	}
+	return nil
+}
+
+// attachMembers fills in the members of groups
+func (s *SCIMService) attachMembers(ctx context.Context, groups []models.SCIMGroup) error {
+	if len(groups) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, len(groups))
+	for i, g := range groups {
+		ids[i] = g.ID
+	}
+	members, err := s.repo.ListSCIMGroupMembers(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range groups {
+		groups[i].Members = members[groups[i].ID]
+	}
+	return nil
+}
+
+// userResource describes an employee as a SCIM user
+func (s *SCIMService) userResource(employee *models.SCIMEmployee) *models.SCIMUser {
+	given, family := splitFullName(employee.FullName)
+	active := employee.IsActive
+
+	user := &models.SCIMUser{
+		Schemas:     []string{models.SCIMUserSchema},
+		ID:          employee.ID.String(),
+		UserName:    employee.Email,
+		Name:        &models.SCIMName{Formatted: employee.FullName, GivenName: given, FamilyName: family},
+		DisplayName: employee.FullName,
+		Emails:      []models.SCIMEmail{{Value: employee.Email, Type: "work", Primary: true}},
+		Active:      &active,
+		Meta: &models.SCIMMeta{
+			ResourceType: "User",
+			Created:      employee.CreatedAt,
+			LastModified: employee.UpdatedAt,
+			Location:     s.location("Users", employee.ID),
+		},
+	}
+	if employee.ExternalID != nil {
+		user.ExternalID = *employee.ExternalID
+	}
+	for _, g := range employee.Groups {
+		user.Groups = append(user.Groups, models.SCIMRef{
+			Value:   g.GroupID.String(),
+			Display: g.DisplayName,
+			Ref:     s.location("Groups", g.GroupID),
+		})
+	}
+	return user
+}
+
+// groupResource describes a group as a SCIM group
+func (s *SCIMService) groupResource(group *models.SCIMGroup) *models.SCIMGroupResource {
+	resource := &models.SCIMGroupResource{
+		Schemas:     []string{models.SCIMGroupSchema},
+		ID:          group.ID.String(),
+		DisplayName: group.DisplayName,
+		Meta: &models.SCIMMeta{
+			ResourceType: "Group",
+			Created:      group.CreatedAt,
+			LastModified: group.UpdatedAt,
+			Location:     s.location("Groups", group.ID),
+		},
+	}
+	if group.ExternalID != nil {
+		resource.ExternalID = *group.ExternalID
+	}
+	for _, m := range group.Members {
+		resource.Members = append(resource.Members, models.SCIMRef{
+			Value:   m.EmployeeID.String(),
+			Display: m.Email,
+			Ref:     s.location("Users", m.EmployeeID),
+		})
+	}
+	return resource
+}
+
+// location is the URL of a SCIM resource
+func (s *SCIMService) location(resourceType string, id uuid.UUID) string {
+	return strings.TrimSuffix(s.cfg.PublicBaseURL, "/") + "/scim/v2/" + resourceType + "/" + id.String()
+}
+
+// ==========================================
+// Helpers
+// ==========================================
+
+// parseSCIMFilter parses an `attribute eq "value"` filter. attributes maps
+// the lowercased attributes allowed to repository filter attributes.
+func parseSCIMFilter(filter string, attributes map[string]string) (*repository.SCIMFilter, error) {
+	if strings.TrimSpace(filter) == "" {
+		return nil, nil
+	}
+	match := scimFilterPattern.FindStringSubmatch(filter)
+	if match == nil {
+		return nil, fmt.Errorf("%w: only `attribute eq \"value\"` filters are supported", ErrSCIMInvalidFilter)
+	}
+	attribute, ok := attributes[strings.ToLower(match[1])]
+	if !ok {
+		return nil, fmt.Errorf("%w: cannot filter on %s", ErrSCIMInvalidFilter, match[1])
+	}
+	var value string
+	if err := json.Unmarshal([]byte(match[2]), &value); err != nil {
+		return nil, fmt.Errorf("%w: invalid string %s", ErrSCIMInvalidFilter, match[2])
+	}
+	return &repository.SCIMFilter{Attribute: attribute, Value: value}, nil
+}
+
+// scimPage turns a 1-based startIndex and count into an offset and limit
+func scimPage(startIndex, count int) (int, int) {
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	if count < 0 {
+		count = scimDefaultCount
+	}
+	if count > scimMaxCount {
+		count = scimMaxCount
+	}
+	return startIndex - 1, count
+}
+
+// scimUserEmail is the email of a user: their userName, or their primary
+// email when the userName is not an address
+func scimUserEmail(user *models.SCIMUser) (string, error) {
+	email := user.UserName
+	if !strings.Contains(email, "@") {
+		for i, e := range user.Emails {
+			if i == 0 || e.Primary {
+				email = e.Value
+			}
+		}
+	}
+	email = strings.ToLower(strings.TrimSpace(email))
+	if !strings.Contains(email, "@") {
+		return "", fmt.Errorf("%w: userName must be an email address", ErrSCIMInvalidValue)
+	}
+	return email, nil
+}
+
+// scimFullName is the name of a user: their displayName, else their name,
+// else their email
+func scimFullName(user *models.SCIMUser, email string) string {
+	if name := strings.TrimSpace(user.DisplayName); name != "" {
+		return name
+	}
+	if user.Name != nil {
+		if name := scimNameString(user.Name); name != "" {
+			return name
+		}
+	}
+	return email
+}
+
+// scimNameString is the full name of a SCIM name
+func scimNameString(name *models.SCIMName) string {
+	if formatted := strings.TrimSpace(name.Formatted); formatted != "" {
+		return formatted
+	}
+	return strings.TrimSpace(name.GivenName + " " + name.FamilyName)
+}
+
+// splitFullName splits a full name into given and family name at the last
+// space
+func splitFullName(fullName string) (string, string) {
+	if i := strings.LastIndex(fullName, " "); i >= 0 {
+		return fullName[:i], fullName[i+1:]
+	}
+	return fullName, ""
+}
+
+// scimMemberIDs reads the employee IDs of member references
+func scimMemberIDs(refs []models.SCIMRef) ([]uuid.UUID, error) {
+	ids := make([]uuid.UUID, 0, len(refs))
+	for _, ref := range refs {
+		id, err := uuid.Parse(ref.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: member %q is not a user id", ErrSCIMInvalidValue, ref.Value)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// scimBool reads a boolean, which some providers send as "True" or "False"
+func scimBool(value json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		switch strings.ToLower(s) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	}
+	return false, fmt.Errorf("%w: expected a boolean", ErrSCIMInvalidValue)
+}
+
+// scimString reads a string
+func scimString(value json.RawMessage) (string, error) {
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return "", fmt.Errorf("%w: expected a string", ErrSCIMInvalidValue)
+	}
+	return s, nil
+}
+
+// optionalString is nil for an empty string
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// equalOptional reports whether two optional strings are the same
+func equalOptional(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/appnity/media-vault/internal/config"
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/repository"
+	"github.com/appnity/media-vault/internal/testdb"
+	"github.com/google/uuid"
+)
+
+// newSCIMFixture creates a SCIM service whose groups map to roles
+func newSCIMFixture(t *testing.T) (*SCIMService, *repository.Repository) {
+	t.Helper()
+	repo, _ := testdb.Open(t)
+	cfg := tokenTestConfig()
+	cfg.OIDCRoleMapping = []config.OIDCRoleMapping{
+		{Value: "vault-admins", Role: string(models.RoleAdmin)},
+		{Value: "vault-developers", Role: string(models.RoleDeveloper)},
+	}
+	auth, err := NewAuthService(repo, cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewSCIMService(repo, cfg, auth), repo
+}
+
+// scimUser provisions a user and returns their employee ID
+func scimUser(t *testing.T, s *SCIMService, userName string) uuid.UUID {
+	t.Helper()
+	user, err := s.CreateUser(context.Background(), &models.SCIMUser{UserName: userName})
+	if err != nil {
+		t.Fatalf("create user %s: %v", userName, err)
+	}
+	return uuid.MustParse(user.ID)
+}
+
+func TestSCIMDeactivationRevokesRefreshTokens(t *testing.T) {
+	s, repo := newSCIMFixture(t)
+	ctx := context.Background()
+
+	id := scimUser(t, s, "leaver@example.com")
+	employee, err := repo.GetEmployeeByID(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	login, err := s.auth.startSession(ctx, employee, SessionClient{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Some providers send booleans as strings
+	if _, err := s.PatchUser(ctx, id, &models.SCIMPatchRequest{Operations: []models.SCIMPatchOperation{
+		{Op: "Replace", Path: "active", Value: json.RawMessage(`"False"`)},
+	}}); err != nil {
+		t.Fatalf("deactivate: %v", err)
+	}
+	if _, err := s.auth.RefreshTokens(ctx, login.RefreshToken, SessionClient{}); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("refresh after deactivation: got %v, want %v", err, ErrInvalidToken)
+	}
+
+	// Activating them again does not bring old logins back
+	active := true
+	if _, err := s.ReplaceUser(ctx, id, &models.SCIMUser{UserName: "leaver@example.com", Active: &active}); err != nil {
+		t.Fatalf("reactivate: %v", err)
+	}
+	if _, err := s.auth.RefreshTokens(ctx, login.RefreshToken, SessionClient{}); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("refresh after reactivation: got %v, want %v", err, ErrInvalidToken)
+	}
+}
+
+func TestSCIMGroupsSyncRoles(t *testing.T) {
+	s, repo := newSCIMFixture(t)
+	ctx := context.Background()
+
+	first := scimUser(t, s, "first@example.com")
+	second := scimUser(t, s, "second@example.com")
+	wantRoles := func(step string, want map[uuid.UUID]models.Role) {
+		t.Helper()
+		for id, role := range want {
+			employee, err := repo.GetEmployeeByID(ctx, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if employee.Role != role {
+				t.Errorf("%s: %s has role %s, want %s", step, employee.Email, employee.Role, role)
+			}
+		}
+	}
+	wantRoles("provisioned", map[uuid.UUID]models.Role{first: models.RoleViewer, second: models.RoleViewer})
+
+	group, err := s.CreateGroup(ctx, &models.SCIMGroupResource{
+		DisplayName: "vault-developers",
+		Members:     []models.SCIMRef{{Value: first.String()}},
+	})
+	if err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+	groupID := uuid.MustParse(group.ID)
+	wantRoles("group created", map[uuid.UUID]models.Role{first: models.RoleDeveloper, second: models.RoleViewer})
+
+	patch := func(ops ...models.SCIMPatchOperation) {
+		t.Helper()
+		if _, err := s.PatchGroup(ctx, groupID, &models.SCIMPatchRequest{Operations: ops}); err != nil {
+			t.Fatalf("patch group: %v", err)
+		}
+	}
+	patch(models.SCIMPatchOperation{Op: "add", Path: "members", Value: json.RawMessage(`[{"value":"` + second.String() + `"}]`)})
+	wantRoles("member added", map[uuid.UUID]models.Role{first: models.RoleDeveloper, second: models.RoleDeveloper})
+
+	patch(models.SCIMPatchOperation{Op: "replace", Path: "displayName", Value: json.RawMessage(`"vault-admins"`)})
+	wantRoles("group renamed", map[uuid.UUID]models.Role{first: models.RoleAdmin, second: models.RoleAdmin})
+
+	patch(models.SCIMPatchOperation{Op: "remove", Path: `members[value eq "` + first.String() + `"]`})
+	wantRoles("member removed", map[uuid.UUID]models.Role{first: models.RoleViewer, second: models.RoleAdmin})
+
+	if err := s.DeleteGroup(ctx, groupID); err != nil {
+		t.Fatalf("delete group: %v", err)
+	}
+	wantRoles("group deleted", map[uuid.UUID]models.Role{first: models.RoleViewer, second: models.RoleViewer})
+}
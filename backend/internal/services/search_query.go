@@ -0,0 +1,464 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/appnity/media-vault/internal/models"
+)
+
+const (
+	// maxSearchQueryLength and maxSearchTerms bound the work a single query
+	// can cause in the database
+	maxSearchQueryLength = 1000
+	maxSearchTerms       = 50
+)
+
+var (
+	// searchFieldPattern matches the field and operator at the start of a term
+	searchFieldPattern = regexp.MustCompile(`^([A-Za-z]+)(:|>=|<=|>|<|=)`)
+
+	// searchSizePattern matches sizes such as 500, 2mb or 1.5g
+	searchSizePattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)(b|k|kb|m|mb|g|gb|t|tb)?$`)
+
+	searchSizeUnits = map[string]float64{
+		"": 1, "b": 1,
+		"k": 1 << 10, "kb": 1 << 10,
+		"m": 1 << 20, "mb": 1 << 20,
+		"g": 1 << 30, "gb": 1 << 30,
+		"t": 1 << 40, "tb": 1 << 40,
+	}
+)
+
+// QueryError reports a syntax error in a search query
+type QueryError struct {
+	Position int // 1-based character offset of the offending token
+	Message  string
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("invalid search query at position %d: %s", e.Position, e.Message)
+}
+
+func queryErrorf(pos int, format string, args ...any) *QueryError {
+	return &QueryError{Position: pos, Message: fmt.Sprintf(format, args...)}
+}
+
+// ParseSearchQuery parses the media search language into a query tree.
+//
+// Terms are free text or field:value pairs, combined with AND (implicit
+// between terms), OR, NOT or a leading '-', and grouped with parentheses.
+// Values with spaces are quoted. Supported fields:
+//
+//	tag:hero  type:image  group:"Website"  by:alice  ext:png  name:logo  mime:image/*
+//	size>2mb  size<=500kb  size:1mb..10mb
+//	uploaded:2026-01  uploaded:2026-01..2026-03  uploaded>=2026-02-15
+//
+// An empty query returns a nil tree.
+func ParseSearchQuery(query string) (*models.SearchNode, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, nil
+	}
+	if len([]rune(query)) > maxSearchQueryLength {
+		return nil, queryErrorf(maxSearchQueryLength, "query is longer than %d characters", maxSearchQueryLength)
+	}
+
+	tokens, err := lexSearchQuery([]rune(query))
+	if err != nil {
+		return nil, err
+	}
+
+	p := &searchParser{tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != searchTokenEOF {
+		return nil, queryErrorf(tok.pos, "unexpected %s", tok.describe())
+	}
+	return node, nil
+}
+
+type searchTokenKind int
+
+const (
+	searchTokenWord searchTokenKind = iota
+	searchTokenLParen
+	searchTokenRParen
+	searchTokenNot
+	searchTokenAnd
+	searchTokenOr
+	searchTokenEOF
+)
+
+type searchToken struct {
+	kind searchTokenKind
+	pos  int
+	text string // Word text with quotes removed
+	head string // Part of the word before its first quote; fields are only recognized here
+}
+
+func (t searchToken) describe() string {
+	switch t.kind {
+	case searchTokenLParen:
+		return "'('"
+	case searchTokenRParen:
+		return "')'"
+	case searchTokenNot:
+		return "NOT"
+	case searchTokenAnd:
+		return "AND"
+	case searchTokenOr:
+		return "OR"
+	case searchTokenEOF:
+		return "end of query"
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+// lexSearchQuery splits a query into tokens
+func lexSearchQuery(q []rune) ([]searchToken, error) {
+	var tokens []searchToken
+	i := 0
+	for i < len(q) {
+		c := q[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			tokens = append(tokens, searchToken{kind: searchTokenLParen, pos: i + 1})
+			i++
+		case c == ')':
+			tokens = append(tokens, searchToken{kind: searchTokenRParen, pos: i + 1})
+			i++
+		case c == '-' && i+1 < len(q) && !unicode.IsSpace(q[i+1]) && q[i+1] != ')':
+			tokens = append(tokens, searchToken{kind: searchTokenNot, pos: i + 1})
+			i++
+		default:
+			start := i
+			var text []rune
+			headLen := -1
+			for i < len(q) && !unicode.IsSpace(q[i]) && q[i] != '(' && q[i] != ')' {
+				if q[i] != '"' {
+					text = append(text, q[i])
+					i++
+					continue
+				}
+				if headLen < 0 {
+					headLen = len(text)
+				}
+				end := i + 1
+				for end < len(q) && q[end] != '"' {
+					end++
+				}
+				if end == len(q) {
+					return nil, queryErrorf(i+1, "unterminated quote")
+				}
+				text = append(text, q[i+1:end]...)
+				i = end + 1
+			}
+			if headLen < 0 {
+				headLen = len(text)
+			}
+
+			tok := searchToken{kind: searchTokenWord, pos: start + 1, text: string(text), head: string(text[:headLen])}
+			if headLen == len(text) {
+				switch tok.text {
+				case "AND":
+					tok.kind = searchTokenAnd
+				case "OR":
+					tok.kind = searchTokenOr
+				case "NOT":
+					tok.kind = searchTokenNot
+				}
+			}
+			tokens = append(tokens, tok)
+		}
+	}
+	return append(tokens, searchToken{kind: searchTokenEOF, pos: len(q) + 1}), nil
+}
+
+// searchParser is a recursive descent parser over the grammar
+//
+//	or    = and { "OR" and }
+//	and   = unary { ["AND"] unary }
+//	unary = ("NOT" | "-") unary | "(" or ")" | term
+type searchParser struct {
+	tokens []searchToken
+	pos    int
+	terms  int
+}
+
+func (p *searchParser) peek() searchToken {
+	return p.tokens[p.pos]
+}
+
+func (p *searchParser) next() searchToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != searchTokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *searchParser) parseOr() (*models.SearchNode, error) {
+	first, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	children := []*models.SearchNode{first}
+	for p.peek().kind == searchTokenOr {
+		p.next()
+		next, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, next)
+	}
+	if len(children) == 1 {
+		return first, nil
+	}
+	return &models.SearchNode{Op: models.SearchOpOr, Children: children}, nil
+}
+
+func (p *searchParser) parseAnd() (*models.SearchNode, error) {
+	first, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	children := []*models.SearchNode{first}
+	for {
+		switch p.peek().kind {
+		case searchTokenAnd:
+			p.next()
+		case searchTokenWord, searchTokenNot, searchTokenLParen:
+			// Implicit AND between adjacent terms
+		default:
+			if len(children) == 1 {
+				return first, nil
+			}
+			return &models.SearchNode{Op: models.SearchOpAnd, Children: children}, nil
+		}
+		next, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, next)
+	}
+}
+
+func (p *searchParser) parseUnary() (*models.SearchNode, error) {
+	tok := p.next()
+	switch tok.kind {
+	case searchTokenNot:
+		child, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &models.SearchNode{Op: models.SearchOpNot, Children: []*models.SearchNode{child}}, nil
+	case searchTokenLParen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != searchTokenRParen {
+			return nil, queryErrorf(tok.pos, "missing ')' for this '('")
+		}
+		return node, nil
+	case searchTokenWord:
+		p.terms++
+		if p.terms > maxSearchTerms {
+			return nil, queryErrorf(tok.pos, "too many terms, at most %d are allowed", maxSearchTerms)
+		}
+		return parseSearchTerm(tok)
+	default:
+		return nil, queryErrorf(tok.pos, "expected a search term but found %s", tok.describe())
+	}
+}
+
+// parseSearchTerm turns a word into a free text or field term
+func parseSearchTerm(tok searchToken) (*models.SearchNode, error) {
+	m := searchFieldPattern.FindStringSubmatch(tok.head)
+	if m == nil {
+		return &models.SearchNode{Op: models.SearchOpTerm, Field: models.SearchFieldText, Value: tok.text}, nil
+	}
+
+	field := models.SearchField(strings.ToLower(m[1]))
+	op := m[2]
+	value := tok.text[len(m[0]):]
+	if value == "" {
+		return nil, queryErrorf(tok.pos, "missing value for %s", field)
+	}
+
+	switch field {
+	case models.SearchFieldTag, models.SearchFieldGroup, models.SearchFieldBy,
+		models.SearchFieldExt, models.SearchFieldName, models.SearchFieldMime:
+		if op != ":" && op != "=" {
+			return nil, queryErrorf(tok.pos, "%s does not support %q, use %s:value", field, op, field)
+		}
+		if field == models.SearchFieldExt {
+			value = strings.ToLower(strings.TrimPrefix(value, "."))
+		}
+		return &models.SearchNode{Op: models.SearchOpTerm, Field: field, Value: value}, nil
+
+	case models.SearchFieldType:
+		if op != ":" && op != "=" {
+			return nil, queryErrorf(tok.pos, "type does not support %q, use type:value", op)
+		}
+		switch mediaType := models.MediaType(strings.ToLower(value)); mediaType {
+		case models.MediaTypeImage, models.MediaTypeVideo, models.MediaTypeAudio, models.MediaTypeDocument, models.MediaTypeOther:
+			return &models.SearchNode{Op: models.SearchOpTerm, Field: field, Value: string(mediaType)}, nil
+		default:
+			return nil, queryErrorf(tok.pos, "unknown type %q, expected image, video, audio, document or other", value)
+		}
+
+	case models.SearchFieldSize:
+		return parseSizeTerm(tok, op, value)
+
+	case models.SearchFieldUploaded:
+		return parseUploadedTerm(tok, op, value)
+
+	default:
+		return nil, queryErrorf(tok.pos, "unknown field %q; quote the term to search for it as text", m[1])
+	}
+}
+
+// parseSizeTerm parses size comparisons and size:low..high ranges
+func parseSizeTerm(tok searchToken, op, value string) (*models.SearchNode, error) {
+	sizeNode := func(compare, raw string) (*models.SearchNode, error) {
+		bytes, err := parseSearchSize(raw)
+		if err != nil {
+			return nil, queryErrorf(tok.pos, "invalid size %q, expected a number with an optional unit such as 500kb or 2mb", raw)
+		}
+		return &models.SearchNode{Op: models.SearchOpTerm, Field: models.SearchFieldSize, Compare: compare, Bytes: bytes}, nil
+	}
+
+	if op != ":" && op != "=" {
+		return sizeNode(op, value)
+	}
+
+	low, high, isRange := strings.Cut(value, "..")
+	if !isRange {
+		return sizeNode("=", value)
+	}
+	if low == "" && high == "" {
+		return nil, queryErrorf(tok.pos, "size range needs at least one bound")
+	}
+
+	var children []*models.SearchNode
+	if low != "" {
+		node, err := sizeNode(">=", low)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, node)
+	}
+	if high != "" {
+		node, err := sizeNode("<=", high)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, node)
+	}
+	if len(children) == 1 {
+		return children[0], nil
+	}
+	return &models.SearchNode{Op: models.SearchOpAnd, Children: children}, nil
+}
+
+// parseSearchSize converts a size such as 2mb into bytes
+func parseSearchSize(raw string) (int64, error) {
+	m := searchSizePattern.FindStringSubmatch(strings.ToLower(raw))
+	if m == nil {
+		return 0, fmt.Errorf("invalid size %q", raw)
+	}
+	n, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, err
+	}
+	return int64(n * searchSizeUnits[m[2]]), nil
+}
+
+// parseUploadedTerm parses upload date comparisons and ranges. A date
+// covers its whole year, month or day, so uploaded:2026-01 matches all of
+// January and uploaded>2026-01 starts in February.
+func parseUploadedTerm(tok searchToken, op, value string) (*models.SearchNode, error) {
+	node := &models.SearchNode{Op: models.SearchOpTerm, Field: models.SearchFieldUploaded}
+
+	period := func(raw string) (time.Time, time.Time, error) {
+		start, end, err := parseSearchDate(raw)
+		if err != nil {
+			return start, end, queryErrorf(tok.pos, "invalid date %q, expected YYYY, YYYY-MM or YYYY-MM-DD", raw)
+		}
+		return start, end, nil
+	}
+
+	if op != ":" && op != "=" {
+		start, end, err := period(value)
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case ">":
+			node.From = &end
+		case ">=":
+			node.From = &start
+		case "<":
+			node.Until = &start
+		case "<=":
+			node.Until = &end
+		}
+		return node, nil
+	}
+
+	low, high, isRange := strings.Cut(value, "..")
+	if !isRange {
+		start, end, err := period(value)
+		if err != nil {
+			return nil, err
+		}
+		node.From, node.Until = &start, &end
+		return node, nil
+	}
+	if low == "" && high == "" {
+		return nil, queryErrorf(tok.pos, "date range needs at least one bound")
+	}
+	if low != "" {
+		start, _, err := period(low)
+		if err != nil {
+			return nil, err
+		}
+		node.From = &start
+	}
+	if high != "" {
+		_, end, err := period(high)
+		if err != nil {
+			return nil, err
+		}
+		node.Until = &end
+	}
+	if node.From != nil && node.Until != nil && !node.Until.After(*node.From) {
+		return nil, queryErrorf(tok.pos, "date range %q ends before it starts", value)
+	}
+	return node, nil
+}
+
+// parseSearchDate returns the UTC period a YYYY, YYYY-MM or YYYY-MM-DD date covers
+func parseSearchDate(raw string) (time.Time, time.Time, error) {
+	if t, err := time.Parse("2006-01-02", raw); err == nil {
+		return t, t.AddDate(0, 0, 1), nil
+	}
+	if t, err := time.Parse("2006-01", raw); err == nil {
+		return t, t.AddDate(0, 1, 0), nil
+	}
+	if t, err := time.Parse("2006", raw); err == nil {
+		return t, t.AddDate(1, 0, 0), nil
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("invalid date %q", raw)
+}
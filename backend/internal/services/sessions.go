@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/repository"
+	"github.com/google/uuid"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// maxUserAgentLength matches the user_agent column
+const maxUserAgentLength = 500
+
+// SessionClient identifies the client signing in or refreshing a session
+type SessionClient struct {
+	IPAddress string
+	UserAgent string
+}
+
+// applyTo records the client on a refresh token
+func (c SessionClient) applyTo(t *models.RefreshToken) {
+	if c.IPAddress != "" {
+		ip := c.IPAddress
+		t.IPAddress = &ip
+	}
+	if c.UserAgent != "" {
+		ua := truncateUTF8(strings.ToValidUTF8(c.UserAgent, ""), maxUserAgentLength)
+		t.UserAgent = &ua
+	}
+}
+
+// truncateUTF8 shortens s to at most n bytes without splitting a character,
+// as Postgres rejects invalid UTF-8
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// ListSessions lists an employee's live sessions. currentSessionID marks the
+// session of the caller, and is uuid.Nil when listing someone else's.
+func (s *AuthService) ListSessions(ctx context.Context, employeeID, currentSessionID uuid.UUID) ([]models.Session, error) {
+	sessions, err := s.repo.ListSessions(ctx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = currentSessionID != uuid.Nil && sessions[i].ID == currentSessionID
+	}
+	return sessions, nil
+}
+
+// RevokeSession signs a session out. Its access tokens stay valid until they
+// expire, which is at most AccessTokenExpiry minutes.
+func (s *AuthService) RevokeSession(ctx context.Context, employeeID, sessionID uuid.UUID) error {
+	err := s.repo.RevokeSession(ctx, employeeID, sessionID, models.TokenRevokedSession)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrSessionNotFound
+	}
+	return err
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestSessionsAreListedAndRevoked(t *testing.T) {
+	s, employee, login := newSessionFixture(t)
+	ctx := context.Background()
+
+	client := SessionClient{IPAddress: "192.0.2.10", UserAgent: "phone\xff" + strings.Repeat("x", maxUserAgentLength)}
+	phone, err := s.startSession(ctx, employee, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := s.ValidateToken(phone.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Refreshing keeps the session
+	if phone, err = s.RefreshTokens(ctx, phone.RefreshToken, client); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+
+	sessions, err := s.ListSessions(ctx, employee.ID, claims.SessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("got %d sessions, want 2", len(sessions))
+	}
+	for _, session := range sessions {
+		if session.Current != (session.ID == claims.SessionID) {
+			t.Errorf("session %s current = %v", session.ID, session.Current)
+		}
+		if session.ID != claims.SessionID {
+			continue
+		}
+		if session.IPAddress == nil || *session.IPAddress != client.IPAddress {
+			t.Errorf("ip address = %v, want %s", session.IPAddress, client.IPAddress)
+		}
+		if session.UserAgent == nil || len(*session.UserAgent) != maxUserAgentLength {
+			t.Errorf("user agent = %v, want it cut to %d bytes", session.UserAgent, maxUserAgentLength)
+		}
+	}
+
+	if err := s.RevokeSession(ctx, uuid.New(), claims.SessionID); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("revoke someone else's session: got %v, want %v", err, ErrSessionNotFound)
+	}
+	if err := s.RevokeSession(ctx, employee.ID, claims.SessionID); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if err := s.RevokeSession(ctx, employee.ID, claims.SessionID); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("revoke twice: got %v, want %v", err, ErrSessionNotFound)
+	}
+	if _, err := s.RefreshTokens(ctx, phone.RefreshToken, client); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("refresh of a revoked session: got %v, want %v", err, ErrInvalidToken)
+	}
+	// Other sessions are untouched
+	if _, err := s.RefreshTokens(ctx, login.RefreshToken, SessionClient{}); err != nil {
+		t.Fatalf("refresh of another session: %v", err)
+	}
+
+	sessions, err = s.ListSessions(ctx, employee.ID, uuid.Nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 || sessions[0].ID == claims.SessionID || sessions[0].Current {
+		t.Fatalf("sessions after revoking = %+v, want only the other, not current", sessions)
+	}
+}
@@ -0,0 +1,404 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/appnity/media-vault/internal/crypto"
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	// maxShareSelection caps the number of media items in a selection link
+	maxShareSelection = 500
+
+	// shareSignedURLExpiry is how long a signed download URL handed out
+	// through a share link stays valid
+	shareSignedURLExpiry = 5 * time.Minute
+
+	sharePasswordMaxAttempts  = 10 // Wrong passwords in a row before the link locks
+	sharePasswordLockDuration = 15 * time.Minute
+)
+
+// ShareVisitor identifies the unauthenticated client using a share link,
+// for the audit log
+type ShareVisitor struct {
+	IPAddress string
+	UserAgent string
+}
+
+// CreateShareLink creates a share link for a media item, folder or selection.
+// The token is only returned here; the database stores its hash.
+func (s *MediaService) CreateShareLink(ctx context.Context, req *models.CreateShareLinkRequest, employee *models.Employee) (*models.ShareLinkResponse, error) {
+	link := &models.ShareLink{
+		Name:         req.Name,
+		Scope:        req.Scope,
+		ExpiresAt:    req.ExpiresAt,
+		MaxDownloads: req.MaxDownloads,
+		CreatedBy:    employee.ID,
+		KeyLimits:    employee.KeyLimits,
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidInput
+	}
+
+	switch req.Scope {
+	case models.ShareScopeFolder:
+		if req.FolderID == nil || len(req.MediaIDs) > 0 {
+			return nil, ErrInvalidInput
+		}
+		exists, err := s.repo.FolderExists(ctx, *req.FolderID)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, ErrInvalidInput
+		}
+		// Visitors must not get at files the creator cannot see
+		hidden, err := s.repo.FolderHasInaccessibleMedia(ctx, *req.FolderID, employee.MediaAccess(), employee.KeyLimits)
+		if err != nil {
+			return nil, err
+		}
+		if hidden {
+			return nil, ErrForbidden
+		}
+		link.FolderID = req.FolderID
+	case models.ShareScopeMedia, models.ShareScopeSelection:
+		if req.FolderID != nil || len(req.MediaIDs) == 0 || len(req.MediaIDs) > maxShareSelection {
+			return nil, ErrInvalidInput
+		}
+		if req.Scope == models.ShareScopeMedia && len(req.MediaIDs) != 1 {
+			return nil, ErrInvalidInput
+		}
+		seen := make(map[uuid.UUID]bool, len(req.MediaIDs))
+		for _, id := range req.MediaIDs {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			media, err := s.getAccessibleMedia(ctx, id, employee)
+			if err != nil {
+				return nil, err
+			}
+			if media.QuarantinedAt != nil {
+				return nil, ErrMediaQuarantined
+			}
+			link.MediaIDs = append(link.MediaIDs, id)
+		}
+	default:
+		return nil, ErrInvalidInput
+	}
+
+	if req.Password != "" {
+		hash, err := crypto.HashPassword(req.Password)
+		if err != nil {
+			return nil, err
+		}
+		link.PasswordHash = &hash
+	}
+
+	token, err := crypto.GenerateRandomString(32)
+	if err != nil {
+		return nil, err
+	}
+	link.TokenHash = crypto.HashToken(token)
+
+	if err := s.repo.CreateShareLink(ctx, link); err != nil {
+		return nil, err
+	}
+
+	s.logAudit(ctx, employee, models.AuditActionShare, models.SeverityWarning, "share_link", &link.ID, map[string]any{
+		"scope":         link.Scope,
+		"folder_id":     link.FolderID,
+		"media_ids":     link.MediaIDs,
+		"expires_at":    link.ExpiresAt,
+		"max_downloads": link.MaxDownloads,
+		"password":      link.HasPassword(),
+	})
+
+	created, err := s.repo.GetShareLinkByID(ctx, link.ID)
+	if err != nil {
+		return nil, err
+	}
+	response := shareLinkResponse(created)
+	response.Token = token
+	response.URL = strings.TrimRight(s.cfg.PublicBaseURL, "/") + "/s/" + token
+	return response, nil
+}
+
+// ListShareLinks lists share links; admins see everyone's, others their own
+func (s *MediaService) ListShareLinks(ctx context.Context, employee *models.Employee) ([]models.ShareLinkResponse, error) {
+	var createdBy *uuid.UUID
+	if !employee.Can(models.PermSharesManageAny) {
+		createdBy = &employee.ID
+	}
+
+	links, err := s.repo.ListShareLinks(ctx, createdBy)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]models.ShareLinkResponse, 0, len(links))
+	for i := range links {
+		responses = append(responses, *shareLinkResponse(&links[i]))
+	}
+	return responses, nil
+}
+
+// RevokeShareLink revokes a share link so its token stops working
+func (s *MediaService) RevokeShareLink(ctx context.Context, id uuid.UUID, employee *models.Employee) error {
+	link, err := s.repo.GetShareLinkByID(ctx, id)
+	if err != nil {
+		return ErrShareNotFound
+	}
+
+	if link.CreatedBy != employee.ID && !employee.Can(models.PermSharesManageAny) {
+		return ErrForbidden
+	}
+
+	if err := s.repo.RevokeShareLink(ctx, id, employee.ID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrShareRevoked
+		}
+		return err
+	}
+
+	s.logAudit(ctx, employee, models.AuditActionRevoke, models.SeverityWarning, "share_link", &id, map[string]any{
+		"scope":          link.Scope,
+		"download_count": link.DownloadCount,
+	})
+
+	return nil
+}
+
+// ResolveShareLink looks up a share link by token and checks that it is
+// still usable and that the password, if any, matches. Failed attempts on
+// an existing link are audited, and repeated wrong passwords lock it for a
+// while.
+func (s *MediaService) ResolveShareLink(ctx context.Context, token, password string, visitor ShareVisitor) (*models.ShareLink, error) {
+	link, err := s.repo.GetShareLinkByTokenHash(ctx, crypto.HashToken(token))
+	if err != nil {
+		return nil, ErrShareNotFound
+	}
+
+	if !shareLinkUsable(link) {
+		s.logShareAccess(ctx, link, models.AuditActionView, nil, visitor, map[string]any{"result": "unavailable"})
+		return nil, ErrShareUnavailable
+	}
+
+	if !link.HasPassword() {
+		return link, nil
+	}
+	if link.PasswordLockedUntil != nil && time.Now().Before(*link.PasswordLockedUntil) {
+		s.logShareAccess(ctx, link, models.AuditActionView, nil, visitor, map[string]any{"result": "locked"})
+		return nil, ErrShareLocked
+	}
+	if password == "" {
+		s.logShareAccess(ctx, link, models.AuditActionView, nil, visitor, map[string]any{"result": "password_required"})
+		return nil, ErrSharePassword
+	}
+	if !crypto.CheckPassword(password, *link.PasswordHash) {
+		if err := s.repo.RecordSharePasswordFailure(ctx, link.ID, sharePasswordMaxAttempts, time.Now().Add(sharePasswordLockDuration)); err != nil {
+			return nil, err
+		}
+		s.logShareAccess(ctx, link, models.AuditActionView, nil, visitor, map[string]any{"result": "wrong_password"})
+		return nil, ErrSharePassword
+	}
+	_ = s.repo.ResetSharePasswordFailures(ctx, link.ID)
+
+	return link, nil
+}
+
+// GetSharedContent lists the files a share link gives access to
+func (s *MediaService) GetSharedContent(ctx context.Context, link *models.ShareLink, visitor ShareVisitor) (*models.SharedContent, error) {
+	mediaList, err := s.listSharedMedia(ctx, link, nil)
+	if err != nil {
+		return nil, err
+	}
+	_ = s.repo.TouchShareLink(ctx, link.ID)
+
+	content := &models.SharedContent{
+		Name:      link.Name,
+		Scope:     link.Scope,
+		ExpiresAt: link.ExpiresAt,
+		Files:     make([]models.SharedFile, 0, len(mediaList)),
+	}
+	if link.MaxDownloads != nil {
+		remaining := *link.MaxDownloads - link.DownloadCount
+		content.DownloadsRemaining = &remaining
+	}
+	for _, media := range mediaList {
+		content.Files = append(content.Files, models.SharedFile{
+			ID:            media.ID,
+			Filename:      media.OriginalFilename,
+			MimeType:      media.MimeType,
+			FileSizeBytes: media.FileSizeBytes,
+		})
+	}
+
+	s.logShareAccess(ctx, link, models.AuditActionView, nil, visitor, map[string]any{
+		"result":     "listed",
+		"file_count": len(content.Files),
+	})
+
+	return content, nil
+}
+
+// DownloadShared streams a file through a share link. mediaID may be nil
+// for links that cover a single file.
+func (s *MediaService) DownloadShared(ctx context.Context, link *models.ShareLink, mediaID *uuid.UUID, visitor ShareVisitor) (*models.MediaWithDetails, io.ReadCloser, error) {
+	media, err := s.claimSharedMedia(ctx, link, mediaID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	adapter, err := s.mediaAdapter(ctx, &media.Media)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reader, err := adapter.Download(ctx, media.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	go s.repo.IncrementDownloadCount(context.Background(), media.ID)
+	s.logShareAccess(ctx, link, models.AuditActionDownload, &media.ID, visitor, map[string]any{
+		"result":   "streamed",
+		"filename": media.OriginalFilename,
+	})
+
+	return media, reader, nil
+}
+
+// SignSharedDownload returns a short-lived signed URL for a file behind a
+// share link instead of streaming it through the server
+func (s *MediaService) SignSharedDownload(ctx context.Context, link *models.ShareLink, mediaID *uuid.UUID, visitor ShareVisitor) (string, error) {
+	media, err := s.claimSharedMedia(ctx, link, mediaID)
+	if err != nil {
+		return "", err
+	}
+
+	adapter, err := s.mediaAdapter(ctx, &media.Media)
+	if err != nil {
+		return "", err
+	}
+
+	url, err := adapter.GenerateSignedDownloadURL(ctx, media.StorageKey, shareSignedURLExpiry)
+	if err != nil {
+		return "", err
+	}
+
+	go s.repo.IncrementDownloadCount(context.Background(), media.ID)
+	s.logShareAccess(ctx, link, models.AuditActionDownload, &media.ID, visitor, map[string]any{
+		"result":   "signed_url",
+		"filename": media.OriginalFilename,
+	})
+
+	return url, nil
+}
+
+// claimSharedMedia finds the file a share link download refers to and
+// counts the download against the link's limit
+func (s *MediaService) claimSharedMedia(ctx context.Context, link *models.ShareLink, mediaID *uuid.UUID) (*models.MediaWithDetails, error) {
+	mediaList, err := s.listSharedMedia(ctx, link, mediaID)
+	if err != nil {
+		return nil, err
+	}
+	if len(mediaList) == 0 {
+		return nil, ErrMediaNotFound
+	}
+	// Without a file ID the link must point at exactly one file
+	if mediaID == nil && len(mediaList) != 1 {
+		return nil, ErrInvalidInput
+	}
+
+	if err := s.repo.ClaimShareDownload(ctx, link.ID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrShareUnavailable
+		}
+		return nil, err
+	}
+
+	return &mediaList[0], nil
+}
+
+// listSharedMedia lists the media a share link covers that its creator may
+// still see. Links of employees who were deleted or deactivated, by an
+// admin, SCIM or single sign-on, give access to nothing.
+func (s *MediaService) listSharedMedia(ctx context.Context, link *models.ShareLink, mediaID *uuid.UUID) ([]models.MediaWithDetails, error) {
+	creator, err := s.repo.GetEmployeeByID(ctx, link.CreatedBy)
+	if errors.Is(err, repository.ErrNotFound) {
+		return []models.MediaWithDetails{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !creator.IsActive {
+		return []models.MediaWithDetails{}, nil
+	}
+	if creator.Permissions, err = s.authz.Permissions(ctx, creator.Role, creator.CustomRoleID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListSharedMedia(ctx, link, creator.MediaAccess(), mediaID)
+}
+
+// logShareAccess audits use of a share link. Visitors have no account, so
+// the entry is attributed to the employee who created the link.
+func (s *MediaService) logShareAccess(ctx context.Context, link *models.ShareLink, action models.AuditAction, mediaID *uuid.UUID, visitor ShareVisitor, details map[string]any) {
+	creator, err := s.repo.GetEmployeeByID(ctx, link.CreatedBy)
+	if err != nil {
+		creator = &models.Employee{ID: link.CreatedBy}
+	}
+
+	details["share_link_id"] = link.ID
+	if mediaID != nil {
+		details["media_id"] = *mediaID
+	}
+
+	entry := &models.AuditLog{
+		EmployeeID:    creator.ID,
+		EmployeeEmail: creator.Email,
+		Action:        action,
+		Severity:      models.SeverityInfo,
+		ResourceType:  "share_link",
+		ResourceID:    &link.ID,
+		Details:       details,
+	}
+	if visitor.IPAddress != "" {
+		entry.IPAddress = &visitor.IPAddress
+	}
+	if visitor.UserAgent != "" {
+		entry.UserAgent = &visitor.UserAgent
+	}
+	s.LogAuditRaw(ctx, entry)
+}
+
+// shareLinkUsable reports whether a share link is neither revoked, expired
+// nor out of downloads
+func shareLinkUsable(link *models.ShareLink) bool {
+	if link.RevokedAt != nil {
+		return false
+	}
+	if link.ExpiresAt != nil && !link.ExpiresAt.After(time.Now()) {
+		return false
+	}
+	if link.MaxDownloads != nil && link.DownloadCount >= *link.MaxDownloads {
+		return false
+	}
+	return true
+}
+
+// shareLinkResponse wraps a share link for API responses
+func shareLinkResponse(link *models.ShareLink) *models.ShareLinkResponse {
+	return &models.ShareLinkResponse{
+		ShareLink:   *link,
+		HasPassword: link.HasPassword(),
+	}
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 
 	"github.com/appnity/media-vault/internal/crypto"
 	"github.com/appnity/media-vault/internal/models"
@@ -16,15 +17,19 @@ type StorageService struct {
 	repo        *repository.Repository
 	encryptor   *crypto.Encryptor
 	adapterPool *storage.AdapterPool
+	webhooks    *WebhookService
+	media       *MediaService
 }
 
 // NewStorageService creates a new storage service
-func NewStorageService(repo *repository.Repository, encryptor *crypto.Encryptor) *StorageService {
+func NewStorageService(repo *repository.Repository, encryptor *crypto.Encryptor, webhooks *WebhookService, media *MediaService) *StorageService {
 	factory := storage.NewAdapterFactory(encryptor.Decrypt)
 	return &StorageService{
 		repo:        repo,
 		encryptor:   encryptor,
 		adapterPool: storage.NewAdapterPool(factory),
+		webhooks:    webhooks,
+		media:       media,
 	}
 }
 
@@ -66,6 +71,7 @@ func (s *StorageService) CreateStorageAccount(ctx context.Context, req *models.C
 		IsPublic:             req.IsPublic,
 		MaxFileSizeMB:        req.MaxFileSizeMB,
 		AllowedTypes:         req.AllowedTypes,
+		ObjectLockEnabled:    req.ObjectLockEnabled,
 		CreatedBy:            employeeID,
 	}
 
@@ -84,6 +90,7 @@ func (s *StorageService) CreateStorageAccount(ctx context.Context, req *models.C
 	if err := s.repo.CreateStorageAccount(ctx, account); err != nil {
 		return nil, err
 	}
+	s.webhooks.Publish(ctx, models.WebhookEventStorageCreated, account)
 
 	// Return with stats
 	return &models.StorageAccountWithStats{
@@ -93,10 +100,11 @@ func (s *StorageService) CreateStorageAccount(ctx context.Context, req *models.C
 	}, nil
 }
 
-// ListStorageAccounts lists storage accounts based on permissions
-func (s *StorageService) ListStorageAccounts(ctx context.Context, employeeID uuid.UUID, role models.Role) ([]models.StorageAccountWithStats, error) {
+// ListStorageAccounts lists storage accounts based on permissions; viewAll
+// lists every account rather than the ones the employee was granted
+func (s *StorageService) ListStorageAccounts(ctx context.Context, employeeID uuid.UUID, viewAll bool) ([]models.StorageAccountWithStats, error) {
 	var uidPtr *uuid.UUID
-	if role != models.RoleAdmin {
+	if !viewAll {
 		uidPtr = &employeeID
 	}
 	return s.repo.ListStorageAccounts(ctx, uidPtr)
@@ -117,6 +125,15 @@ func (s *StorageService) GetStorageAccountAccess(ctx context.Context, accountID
 	return s.repo.GetStorageAccountAccessList(ctx, accountID)
 }
 
+// CanAccessStorageAccount reports whether an employee may use a storage
+// account; viewAll skips the grant check
+func (s *StorageService) CanAccessStorageAccount(ctx context.Context, id, employeeID uuid.UUID, viewAll bool) (bool, error) {
+	if viewAll {
+		return true, nil
+	}
+	return s.repo.HasStorageAccess(ctx, id, employeeID)
+}
+
 // GetStorageAccount gets a storage account by ID
 func (s *StorageService) GetStorageAccount(ctx context.Context, id uuid.UUID) (*models.StorageAccountWithStats, error) {
 	return s.repo.GetStorageAccountWithStatsByID(ctx, id)
@@ -173,10 +190,14 @@ func (s *StorageService) UpdateStorageAccount(ctx context.Context, id uuid.UUID,
 	if len(req.AllowedTypes) > 0 {
 		account.AllowedTypes = req.AllowedTypes
 	}
+	if req.ObjectLockEnabled != nil {
+		account.ObjectLockEnabled = *req.ObjectLockEnabled
+	}
 
 	if err := s.repo.UpdateStorageAccount(ctx, account); err != nil {
 		return nil, err
 	}
+	s.webhooks.Publish(ctx, models.WebhookEventStorageUpdated, account)
 
 	return account, nil
 }
@@ -189,22 +210,37 @@ func (s *StorageService) DeleteStorageAccount(ctx context.Context, id uuid.UUID)
 		return ErrStorageNotFound
 	}
 
-	// 2. Get the adapter
-	adapter, err := s.adapterPool.GetAdapter(ctx, account)
-	if err == nil {
-		// 3. Get all media for this account
-		media, _, err := s.repo.ListMedia(ctx, &models.MediaFilterRequest{StorageAccountID: id.String()})
-		if err == nil {
-			for _, m := range media {
-				// Delete each file from cloud
-				_ = adapter.Delete(ctx, m.StorageKey)
-				_ = s.repo.SoftDeleteMedia(ctx, m.ID)
-			}
-		}
+	// Files under legal hold or a minimum retention must outlive the account
+	held, err := s.repo.CountHeldMedia(ctx, id)
+	if err != nil {
+		return err
+	}
+	if held > 0 {
+		return ErrLegalHold
+	}
+
+	retained, err := s.repo.CountRetainedMedia(ctx, id)
+	if err != nil {
+		return err
+	}
+	if retained > 0 {
+		return ErrRetentionActive
+	}
+
+	// Every object goes with the account, previous versions included. The
+	// account is kept while any of them cannot be deleted, so none is
+	// orphaned; deleting it again picks up where this attempt stopped.
+	if err := s.media.PurgeStorageAccountMedia(ctx, id); err != nil {
+		return err
 	}
 
 	s.adapterPool.InvalidateAdapter(id)
-	return s.repo.SoftDeleteStorageAccount(ctx, id)
+	if err := s.repo.SoftDeleteStorageAccount(ctx, id); err != nil {
+		return err
+	}
+	s.webhooks.Publish(ctx, models.WebhookEventStorageDeleted, account)
+
+	return nil
 }
 
 // TestStorageConnection tests the connection to a storage account
@@ -261,9 +297,29 @@ func (s *GroupService) CreateMediaGroup(ctx context.Context, req *models.CreateM
 	return group, nil
 }
 
-// ListMediaGroups lists media groups accessible by the employee's role
-func (s *GroupService) ListMediaGroups(ctx context.Context, role models.Role) ([]models.MediaGroup, error) {
-	return s.repo.ListMediaGroups(ctx, role)
+// ListMediaGroups lists media groups accessible by the employee's role;
+// viewAll lists every group
+func (s *GroupService) ListMediaGroups(ctx context.Context, role models.Role, viewAll bool) ([]models.MediaGroup, error) {
+	if viewAll {
+		return s.repo.ListMediaGroups(ctx, nil)
+	}
+	return s.repo.ListMediaGroups(ctx, &role)
+}
+
+// CanAccessMediaGroup reports whether a media group admits a role; viewAll
+// admits every role
+func (s *GroupService) CanAccessMediaGroup(ctx context.Context, id uuid.UUID, role models.Role, viewAll bool) (bool, error) {
+	if viewAll {
+		return true, nil
+	}
+	group, err := s.repo.GetMediaGroupByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return roleAllowed(group.AllowedRoles, role), nil
 }
 
 // GetMediaGroup gets a media group by ID
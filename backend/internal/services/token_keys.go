@@ -0,0 +1,177 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/appnity/media-vault/internal/config"
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Token types, carried in the typ claim so that one kind of token cannot be
+// presented where another is expected
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+	TokenTypeMFA     = "mfa" // A login waiting for its second factor
+)
+
+// tokenKey is a signing key and the time after which tokens signed with it
+// are no longer accepted. The current key has a zero acceptUntil; retired
+// keys have no signKey.
+type tokenKey struct {
+	method      jwt.SigningMethod
+	signKey     interface{}
+	verifyKey   interface{}
+	acceptUntil time.Time
+}
+
+// tokenKeyring signs tokens with the current key and verifies them with any
+// key that may still have live tokens, identified by the kid header
+type tokenKeyring struct {
+	currentID string
+	keys      map[string]tokenKey
+}
+
+// newTokenKeyring builds the keyring from the configured secrets and key
+// files. A retired key is kept until the longest-lived token it could have
+// signed expires.
+func newTokenKeyring(cfg *config.Config) (*tokenKeyring, error) {
+	maxAge := time.Duration(cfg.RefreshTokenExpiry) * 24 * time.Hour
+	if access := time.Duration(cfg.AccessTokenExpiry) * time.Minute; access > maxAge {
+		maxAge = access
+	}
+
+	k := &tokenKeyring{
+		currentID: cfg.JWTKeyID,
+		keys:      make(map[string]tokenKey),
+	}
+
+	if cfg.JWTSigningAlg == "HS256" {
+		k.keys[cfg.JWTKeyID] = tokenKey{
+			method:    jwt.SigningMethodHS256,
+			signKey:   []byte(cfg.JWTSecret),
+			verifyKey: []byte(cfg.JWTSecret),
+		}
+	} else {
+		key, err := loadKeyFile(cfg.JWTPrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if key.signKey == nil {
+			return nil, fmt.Errorf("JWT_PRIVATE_KEY_FILE %s holds no private key", cfg.JWTPrivateKeyFile)
+		}
+		if key.method.Alg() != cfg.JWTSigningAlg {
+			return nil, fmt.Errorf("JWT_PRIVATE_KEY_FILE %s is a %s key, not %s", cfg.JWTPrivateKeyFile, key.method.Alg(), cfg.JWTSigningAlg)
+		}
+		k.keys[cfg.JWTKeyID] = key
+	}
+
+	for _, retired := range cfg.JWTPreviousKeys {
+		k.keys[retired.ID] = tokenKey{
+			method:      jwt.SigningMethodHS256,
+			verifyKey:   []byte(retired.Secret),
+			acceptUntil: retired.RetiredAt.Add(maxAge),
+		}
+	}
+	for _, retired := range cfg.JWTPreviousKeyFiles {
+		key, err := loadKeyFile(retired.File)
+		if err != nil {
+			return nil, err
+		}
+		key.signKey = nil
+		key.acceptUntil = retired.RetiredAt.Add(maxAge)
+		k.keys[retired.ID] = key
+	}
+
+	return k, nil
+}
+
+// loadKeyFile reads a PEM encoded Ed25519 or RSA key. A private key yields
+// both halves; a public key can only verify.
+func loadKeyFile(path string) (tokenKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return tokenKey{}, fmt.Errorf("failed to read JWT key file: %w", err)
+	}
+
+	if private, err := jwt.ParseEdPrivateKeyFromPEM(data); err == nil {
+		signer := private.(crypto.Signer)
+		return tokenKey{method: jwt.SigningMethodEdDSA, signKey: signer, verifyKey: signer.Public()}, nil
+	}
+	if public, err := jwt.ParseEdPublicKeyFromPEM(data); err == nil {
+		return tokenKey{method: jwt.SigningMethodEdDSA, verifyKey: public}, nil
+	}
+	if private, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+		if private.N.BitLen() < 2048 {
+			return tokenKey{}, fmt.Errorf("JWT key file %s: RSA keys must be at least 2048 bits", path)
+		}
+		return tokenKey{method: jwt.SigningMethodRS256, signKey: private, verifyKey: &private.PublicKey}, nil
+	}
+	if public, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+		return tokenKey{method: jwt.SigningMethodRS256, verifyKey: public}, nil
+	}
+
+	return tokenKey{}, fmt.Errorf("JWT key file %s is not a PEM encoded Ed25519 or RSA key", path)
+}
+
+// sign signs claims with the current key
+func (k *tokenKeyring) sign(claims jwt.Claims) (string, error) {
+	key := k.keys[k.currentID]
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = k.currentID
+	return token.SignedString(key.signKey)
+}
+
+// verificationKey is the jwt.Keyfunc used to parse tokens. The algorithm
+// must be the one of the key named by kid, so a public key can never be
+// used as an HMAC secret.
+func (k *tokenKeyring) verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := k.keys[kid]
+	if !ok || token.Method.Alg() != key.method.Alg() {
+		return nil, ErrInvalidToken
+	}
+	if !key.acceptUntil.IsZero() && time.Now().After(key.acceptUntil) {
+		return nil, ErrInvalidToken
+	}
+	return key.verifyKey, nil
+}
+
+// jwks returns the public halves of the asymmetric keys that are still
+// accepted. HMAC secrets are never published.
+func (k *tokenKeyring) jwks() models.JWKS {
+	set := models.JWKS{Keys: []models.JWK{}}
+	now := time.Now()
+	for kid, key := range k.keys {
+		if !key.acceptUntil.IsZero() && now.After(key.acceptUntil) {
+			continue
+		}
+
+		jwk := models.JWK{KeyID: kid, Use: "sig", Algorithm: key.method.Alg()}
+		switch public := key.verifyKey.(type) {
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].KeyID < set.Keys[j].KeyID })
+	return set
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/repository"
+	"github.com/appnity/media-vault/internal/storage"
+	"github.com/google/uuid"
+)
+
+// purgeBatchSize limits how many trashed items one purge run removes
+const purgeBatchSize = 100
+
+// trashRetention is how long deleted media stays restorable
+func (s *MediaService) trashRetention() time.Duration {
+	return time.Duration(s.cfg.TrashRetentionDays) * 24 * time.Hour
+}
+
+// ListTrash lists trashed media. Admins see everything, other employees only
+// their own uploads.
+func (s *MediaService) ListTrash(ctx context.Context, employee *models.Employee, page, pageSize int) (*models.PaginatedResponse[models.TrashedMedia], error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 50
+	}
+
+	var uploadedBy *uuid.UUID
+	if !employee.Can(models.PermMediaManageAny) {
+		uploadedBy = &employee.ID
+	}
+
+	mediaList, total, err := s.repo.ListTrashedMedia(ctx, uploadedBy, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range mediaList {
+		mediaList[i].PurgeAfter = mediaList[i].TrashedAt.Add(s.trashRetention())
+	}
+
+	totalPages := int(total) / pageSize
+	if int(total)%pageSize > 0 {
+		totalPages++
+	}
+
+	return &models.PaginatedResponse[models.TrashedMedia]{
+		Data:       mediaList,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// RestoreMedia takes a media item back out of the trash
+func (s *MediaService) RestoreMedia(ctx context.Context, id uuid.UUID, employee *models.Employee) (*models.MediaWithDetails, error) {
+	media, err := s.repo.GetTrashedMediaByID(ctx, id)
+	if err != nil {
+		return nil, ErrMediaNotFound
+	}
+
+	if media.UploadedBy != employee.ID && !employee.Can(models.PermMediaManageAny) {
+		return nil, ErrForbidden
+	}
+
+	if err := s.repo.RestoreMedia(ctx, id); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, ErrMediaNotFound
+		case errors.Is(err, repository.ErrAlreadyExists):
+			return nil, ErrRestoreConflict
+		}
+		return nil, err
+	}
+	s.RefreshMediaPolicies(ctx, id)
+
+	s.logAudit(ctx, employee, models.AuditActionRestore, models.SeverityInfo, "media", &id, map[string]any{
+		"filename":   media.OriginalFilename,
+		"trashed_at": media.TrashedAt,
+	})
+
+	restored, err := s.repo.GetMediaByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.webhooks.Publish(ctx, models.WebhookEventMediaRestored, restored)
+
+	return restored, nil
+}
+
+// PurgeMedia permanently deletes a media item right away, whether or not it
+// is in the trash, without waiting for the retention period to pass (admin
+// only)
+func (s *MediaService) PurgeMedia(ctx context.Context, id uuid.UUID, employee *models.Employee) error {
+	if !employee.Can(models.PermMediaPurge) {
+		return ErrForbidden
+	}
+
+	var media *models.MediaWithDetails
+	var trashedAt *time.Time
+	if trashed, err := s.repo.GetTrashedMediaByID(ctx, id); err == nil {
+		media, trashedAt = &trashed.MediaWithDetails, &trashed.TrashedAt
+	} else if live, err := s.repo.GetMediaByID(ctx, id); err == nil {
+		media = live
+	} else {
+		return ErrMediaNotFound
+	}
+
+	if err := s.checkAccessible(ctx, &media.Media, employee); err != nil {
+		return err
+	}
+	if err := checkLegalHold(&media.Media); err != nil {
+		return err
+	}
+	if err := checkRetention(&media.Media); err != nil {
+		return err
+	}
+
+	if err := s.purgeObject(ctx, &media.Media); err != nil {
+		return err
+	}
+
+	s.logAudit(ctx, employee, models.AuditActionPurge, models.SeverityCritical, "media", &id, map[string]any{
+		"filename":   media.OriginalFilename,
+		"trashed_at": trashedAt,
+		"trigger":    "manual",
+	})
+	if trashedAt == nil {
+		s.webhooks.Publish(ctx, models.WebhookEventMediaDeleted, media)
+	}
+
+	return nil
+}
+
+// PurgeExpiredTrash removes media whose trash retention has passed. It is
+// run periodically by the job scheduler.
+func (s *MediaService) PurgeExpiredTrash(ctx context.Context) error {
+	cutoff := time.Now().Add(-s.trashRetention())
+
+	for {
+		batch, err := s.repo.ListPurgeableMedia(ctx, cutoff, purgeBatchSize)
+		if err != nil {
+			return err
+		}
+
+		purged := 0
+		for i := range batch {
+			media := &batch[i]
+			if err := s.purgeObject(ctx, media); err != nil {
+				log.Printf("[MediaService] PurgeExpiredTrash: failed to purge %s: %v", media.ID, err)
+				continue
+			}
+			purged++
+
+			s.logAudit(ctx, s.systemActor(ctx, media), models.AuditActionPurge, models.SeverityWarning, "media", &media.ID, map[string]any{
+				"filename":   media.OriginalFilename,
+				"trashed_at": media.DeletedAt,
+				"trigger":    "scheduled",
+			})
+		}
+
+		// Stop when the backlog is drained or nothing in this batch could be purged
+		if len(batch) < purgeBatchSize || purged == 0 {
+			return nil
+		}
+	}
+}
+
+// PurgeStorageAccountMedia deletes the objects of every media item on a
+// storage account, previous versions included, and marks the items purged.
+// It stops at the first failure, so no item is marked purged while its
+// objects are left behind.
+func (s *MediaService) PurgeStorageAccountMedia(ctx context.Context, storageAccountID uuid.UUID) error {
+	for {
+		batch, err := s.repo.ListUnpurgedAccountMedia(ctx, storageAccountID, purgeBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		for i := range batch {
+			if err := s.purgeObject(ctx, &batch[i]); err != nil {
+				return fmt.Errorf("failed to delete %s: %w", batch[i].StorageKey, err)
+			}
+		}
+	}
+}
+
+// purgeObject deletes the stored objects of a media item, including its
+// previous versions, and marks the row as purged. Media on a storage
+// account that no longer exists is marked purged without touching storage.
+func (s *MediaService) purgeObject(ctx context.Context, media *models.Media) error {
+	if err := checkLegalHold(media); err != nil {
+		return err
+	}
+
+	storageAccount, err := s.repo.GetStorageAccountByID(ctx, media.StorageAccountID)
+	if err == nil {
+		adapter, err := s.adapterPool.GetAdapter(ctx, storageAccount)
+		if err != nil {
+			return err
+		}
+		if err := adapter.Delete(ctx, media.StorageKey); err != nil && !errors.Is(err, storage.ErrFileNotFound) {
+			return err
+		}
+
+		// Archived versions go with the item
+		versions, err := s.repo.ListMediaVersions(ctx, media.ID)
+		if err != nil {
+			return err
+		}
+		for _, v := range versions {
+			if err := adapter.Delete(ctx, v.StorageKey); err != nil && !errors.Is(err, storage.ErrFileNotFound) {
+				return err
+			}
+		}
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+
+	return s.repo.MarkMediaPurged(ctx, media.ID)
+}
+
+// systemActor picks the employee that background work on a media item is
+// attributed to in the audit log: whoever deleted it, else the uploader
+func (s *MediaService) systemActor(ctx context.Context, media *models.Media) *models.Employee {
+	actorID := media.UploadedBy
+	if media.DeletedBy != nil {
+		actorID = *media.DeletedBy
+	}
+	if employee, err := s.repo.GetEmployeeByID(ctx, actorID); err == nil {
+		return employee
+	}
+	return &models.Employee{ID: actorID}
+}
@@ -0,0 +1,334 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/appnity/media-vault/internal/crypto"
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/repository"
+	"github.com/google/uuid"
+)
+
+// guestUploadTag marks media uploaded through an upload request
+const guestUploadTag = "guest-upload"
+
+// CreateUploadRequest creates an upload link for a media group and folder.
+// The token is only returned here; the database stores its hash.
+func (s *MediaService) CreateUploadRequest(ctx context.Context, req *models.CreateUploadRequestRequest, employee *models.Employee) (*models.UploadRequestResponse, error) {
+	if _, err := s.repo.GetMediaGroupByID(ctx, req.MediaGroupID); err != nil {
+		return nil, ErrGroupNotFound
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidInput
+	}
+	for _, t := range req.AllowedTypes {
+		switch t {
+		case models.MediaTypeImage, models.MediaTypeVideo, models.MediaTypeAudio, models.MediaTypeDocument, models.MediaTypeOther:
+		default:
+			return nil, ErrInvalidInput
+		}
+	}
+
+	token, err := crypto.GenerateRandomString(32)
+	if err != nil {
+		return nil, err
+	}
+
+	uploadRequest := &models.UploadRequest{
+		TokenHash:     crypto.HashToken(token),
+		Name:          req.Name,
+		Message:       req.Message,
+		MediaGroupID:  req.MediaGroupID,
+		FolderPath:    strings.Trim(req.FolderPath, "/"),
+		ExpiresAt:     req.ExpiresAt,
+		MaxFiles:      req.MaxFiles,
+		MaxFileSizeMB: req.MaxFileSizeMB,
+		AllowedTypes:  req.AllowedTypes,
+		RequireReview: req.RequireReview,
+		CreatedBy:     employee.ID,
+	}
+	if err := s.repo.CreateUploadRequest(ctx, uploadRequest); err != nil {
+		return nil, err
+	}
+
+	s.logAudit(ctx, employee, models.AuditActionCreate, models.SeverityWarning, "upload_request", &uploadRequest.ID, map[string]any{
+		"name":           uploadRequest.Name,
+		"media_group_id": uploadRequest.MediaGroupID,
+		"folder_path":    uploadRequest.FolderPath,
+		"expires_at":     uploadRequest.ExpiresAt,
+		"max_files":      uploadRequest.MaxFiles,
+		"require_review": uploadRequest.RequireReview,
+	})
+
+	created, err := s.repo.GetUploadRequestByID(ctx, uploadRequest.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.UploadRequestResponse{
+		UploadRequest: *created,
+		Token:         token,
+		URL:           strings.TrimRight(s.cfg.PublicBaseURL, "/") + "/u/" + token,
+	}, nil
+}
+
+// ListUploadRequests lists upload requests; admins see everyone's, others their own
+func (s *MediaService) ListUploadRequests(ctx context.Context, employee *models.Employee) ([]models.UploadRequest, error) {
+	var createdBy *uuid.UUID
+	if !employee.Can(models.PermSharesManageAny) {
+		createdBy = &employee.ID
+	}
+	return s.repo.ListUploadRequests(ctx, createdBy)
+}
+
+// RevokeUploadRequest revokes an upload request so its token stops working
+func (s *MediaService) RevokeUploadRequest(ctx context.Context, id uuid.UUID, employee *models.Employee) error {
+	uploadRequest, err := s.repo.GetUploadRequestByID(ctx, id)
+	if err != nil {
+		return ErrUploadRequestNotFound
+	}
+
+	if uploadRequest.CreatedBy != employee.ID && !employee.Can(models.PermSharesManageAny) {
+		return ErrForbidden
+	}
+
+	if err := s.repo.RevokeUploadRequest(ctx, id, employee.ID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrUploadRequestRevoked
+		}
+		return err
+	}
+
+	s.logAudit(ctx, employee, models.AuditActionRevoke, models.SeverityWarning, "upload_request", &id, map[string]any{
+		"name":         uploadRequest.Name,
+		"upload_count": uploadRequest.UploadCount,
+	})
+
+	return nil
+}
+
+// ResolveUploadRequest looks up an upload request by token and checks that
+// it still accepts uploads
+func (s *MediaService) ResolveUploadRequest(ctx context.Context, token string) (*models.UploadRequest, error) {
+	uploadRequest, err := s.repo.GetUploadRequestByTokenHash(ctx, crypto.HashToken(token))
+	if err != nil {
+		return nil, ErrUploadRequestNotFound
+	}
+
+	if uploadRequest.RevokedAt != nil ||
+		(uploadRequest.ExpiresAt != nil && !uploadRequest.ExpiresAt.After(time.Now())) ||
+		(uploadRequest.MaxFiles != nil && uploadRequest.UploadCount >= *uploadRequest.MaxFiles) {
+		return nil, ErrUploadRequestUnavailable
+	}
+
+	return uploadRequest, nil
+}
+
+// GetUploadRequestForCompletion looks up an upload request by token for
+// finishing an upload that was already started. The file limit and expiry
+// were checked when the upload began, so only revocation is checked here.
+func (s *MediaService) GetUploadRequestForCompletion(ctx context.Context, token string) (*models.UploadRequest, error) {
+	uploadRequest, err := s.repo.GetUploadRequestByTokenHash(ctx, crypto.HashToken(token))
+	if err != nil {
+		return nil, ErrUploadRequestNotFound
+	}
+	if uploadRequest.RevokedAt != nil {
+		return nil, ErrUploadRequestUnavailable
+	}
+	return uploadRequest, nil
+}
+
+// GetGuestUploadInfo describes an upload request to the guest using it
+func (s *MediaService) GetGuestUploadInfo(uploadRequest *models.UploadRequest) *models.GuestUploadInfo {
+	info := &models.GuestUploadInfo{
+		Name:          uploadRequest.Name,
+		Message:       uploadRequest.Message,
+		ExpiresAt:     uploadRequest.ExpiresAt,
+		MaxFileSizeMB: uploadRequest.MaxFileSizeMB,
+		AllowedTypes:  uploadRequest.AllowedTypes,
+	}
+	if uploadRequest.MaxFiles != nil {
+		remaining := *uploadRequest.MaxFiles - uploadRequest.UploadCount
+		info.FilesRemaining = &remaining
+	}
+	return info
+}
+
+// InitiateGuestUpload starts an upload through an upload request. It goes
+// through the regular routing and signed URL flow, attributed to the
+// request's creator.
+func (s *MediaService) InitiateGuestUpload(ctx context.Context, uploadRequest *models.UploadRequest, req *models.GuestUploadInitRequest) (*models.UploadResponse, error) {
+	if !guestFileAllowed(uploadRequest, s.determineMediaType(req.ContentType), req.FileSize) {
+		return nil, ErrFileNotAllowed
+	}
+
+	creator, err := s.repo.GetEmployeeByID(ctx, uploadRequest.CreatedBy)
+	if err != nil || !creator.IsActive {
+		return nil, ErrUploadRequestUnavailable
+	}
+
+	if err := s.repo.ClaimUploadSlot(ctx, uploadRequest.ID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrUploadRequestUnavailable
+		}
+		return nil, err
+	}
+
+	groupID := uploadRequest.MediaGroupID
+	response, err := s.InitiateUpload(ctx, &models.UploadMediaRequest{
+		MediaGroupID: &groupID,
+		FolderPath:   uploadRequest.FolderPath,
+		Tags:         []string{guestUploadTag},
+	}, req.Filename, req.ContentType, req.FileSize, creator)
+	if err != nil {
+		_ = s.repo.ReleaseUploadSlot(ctx, uploadRequest.ID)
+		return nil, err
+	}
+
+	var reviewStatus *models.ReviewStatus
+	if uploadRequest.RequireReview {
+		pending := models.ReviewStatusPending
+		reviewStatus = &pending
+	}
+	if err := s.repo.MarkGuestUpload(ctx, response.MediaID, uploadRequest.ID, req.GuestName, reviewStatus); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// CompleteGuestUpload finalizes an upload started through an upload request
+func (s *MediaService) CompleteGuestUpload(ctx context.Context, uploadRequest *models.UploadRequest, req *models.UploadCompleteRequest) (*models.SharedFile, error) {
+	media, err := s.repo.GetMediaByID(ctx, req.MediaID)
+	if err != nil || media.UploadRequestID == nil || *media.UploadRequestID != uploadRequest.ID {
+		return nil, ErrMediaNotFound
+	}
+	if media.FileSizeBytes > 0 {
+		// Already completed
+		return nil, ErrInvalidInput
+	}
+
+	// Trust the stored object over the size the guest reports
+	adapter, err := s.mediaAdapter(ctx, &media.Media)
+	if err != nil {
+		return nil, err
+	}
+	if meta, err := adapter.GetMetadata(ctx, media.StorageKey); err == nil && meta.Size > 0 {
+		req.FileSizeBytes = meta.Size
+	}
+	if !guestFileAllowed(uploadRequest, s.determineMediaType(req.MimeType), req.FileSizeBytes) {
+		return nil, ErrFileNotAllowed
+	}
+
+	// The link stops working once its creator is deactivated, also for
+	// uploads started before that
+	creator, err := s.repo.GetEmployeeByID(ctx, uploadRequest.CreatedBy)
+	if err != nil || !creator.IsActive {
+		return nil, ErrUploadRequestUnavailable
+	}
+
+	completed, err := s.CompleteUpload(ctx, req, creator)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.SharedFile{
+		ID:            completed.ID,
+		Filename:      completed.OriginalFilename,
+		MimeType:      completed.MimeType,
+		FileSizeBytes: completed.FileSizeBytes,
+	}, nil
+}
+
+// ListPendingReview lists guest uploads awaiting review; admins see all,
+// others those sent through their own upload requests
+func (s *MediaService) ListPendingReview(ctx context.Context, employee *models.Employee) ([]models.MediaWithDetails, error) {
+	var createdBy *uuid.UUID
+	if !employee.Can(models.PermMediaManageAny) {
+		createdBy = &employee.ID
+	}
+	return s.repo.ListPendingReview(ctx, createdBy)
+}
+
+// ReviewGuestUpload approves a guest upload, making it visible in the
+// library, or rejects it, moving it to the trash
+func (s *MediaService) ReviewGuestUpload(ctx context.Context, id uuid.UUID, decision string, employee *models.Employee) (*models.MediaWithDetails, error) {
+	media, err := s.repo.GetMediaByID(ctx, id)
+	if err != nil {
+		return nil, ErrMediaNotFound
+	}
+
+	// Guest uploads are attributed to the upload request's creator
+	if media.UploadedBy != employee.ID && !employee.Can(models.PermMediaManageAny) {
+		return nil, ErrForbidden
+	}
+
+	status := models.ReviewStatusApproved
+	if decision == "reject" {
+		status = models.ReviewStatusRejected
+	}
+
+	// An approved file is published, unless that waits for a clean scan
+	var publicURL *string
+	if status == models.ReviewStatusApproved && media.ScanStatus.Passed() {
+		if adapter, err := s.mediaAdapter(ctx, &media.Media); err == nil {
+			if url, err := adapter.GetPublicURL(ctx, media.StorageKey); err == nil {
+				publicURL = &url
+			}
+		}
+	}
+
+	if err := s.repo.SetMediaReviewStatus(ctx, id, status, publicURL); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrNotPendingReview
+		}
+		return nil, err
+	}
+
+	// Rejected uploads go to the trash unless a hold keeps them in place
+	if status == models.ReviewStatusRejected && checkLegalHold(&media.Media) == nil {
+		if err := s.repo.TrashMedia(ctx, id, employee.ID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+	}
+
+	s.logAudit(ctx, employee, models.AuditActionReview, models.SeverityInfo, "media", &id, map[string]any{
+		"filename":          media.OriginalFilename,
+		"decision":          status,
+		"guest_name":        media.GuestName,
+		"upload_request_id": media.UploadRequestID,
+	})
+
+	if status == models.ReviewStatusRejected {
+		media.ReviewStatus = &status
+		return media, nil
+	}
+
+	approved, err := s.repo.GetMediaByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// Held back from webhooks when the upload completed; announce it now
+	s.webhooks.Publish(ctx, models.WebhookEventMediaUploaded, approved)
+
+	return approved, nil
+}
+
+// guestFileAllowed checks a file against the type and size limits of an
+// upload request
+func guestFileAllowed(uploadRequest *models.UploadRequest, mediaType models.MediaType, fileSize int64) bool {
+	if uploadRequest.MaxFileSizeMB != nil && fileSize > int64(*uploadRequest.MaxFileSizeMB)*1024*1024 {
+		return false
+	}
+	if len(uploadRequest.AllowedTypes) == 0 {
+		return true
+	}
+	for _, t := range uploadRequest.AllowedTypes {
+		if t == mediaType {
+			return true
+		}
+	}
+	return false
+}
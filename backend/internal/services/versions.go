@@ -0,0 +1,443 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/repository"
+	"github.com/appnity/media-vault/internal/storage"
+	"github.com/google/uuid"
+)
+
+// versionsDir is the directory, next to the live object, that holds the
+// files of previous versions
+const versionsDir = ".versions"
+
+// versionClaimTTL is how long a new version may take to be put in place
+// before another upload can claim the media item
+const versionClaimTTL = 15 * time.Minute
+
+// versionStorageKey returns the key a version of a media item is stored
+// under while it is not the current version
+func versionStorageKey(media *models.Media, version int) string {
+	dir, file := path.Split(media.StorageKey)
+	return path.Join(dir, versionsDir, media.ID.String(), fmt.Sprintf("v%d-%s", version, file))
+}
+
+// isVersionKey reports whether a storage key holds a staged or archived
+// version rather than a live object
+func isVersionKey(storageKey string) bool {
+	return strings.HasPrefix(storageKey, versionsDir+"/") || strings.Contains(storageKey, "/"+versionsDir+"/")
+}
+
+// ListMediaVersions lists all versions of a media item, current first
+func (s *MediaService) ListMediaVersions(ctx context.Context, id uuid.UUID, employee *models.Employee) ([]models.MediaVersion, error) {
+	media, err := s.getAccessibleMedia(ctx, id, employee)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := s.repo.ListMediaVersions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	current := models.MediaVersion{
+		MediaID:         media.ID,
+		Version:         media.CurrentVersion,
+		StorageKey:      media.StorageKey,
+		MimeType:        media.MimeType,
+		FileSizeBytes:   media.FileSizeBytes,
+		Width:           media.Width,
+		Height:          media.Height,
+		DurationSeconds: media.DurationSeconds,
+		UploadedBy:      media.UploadedBy,
+		UploadedByName:  media.UploadedByName,
+		UploadedAt:      media.CreatedAt,
+		ScanStatus:      media.ScanStatus,
+		ScanSignature:   media.ScanSignature,
+		ScannedAt:       media.ScannedAt,
+		IsCurrent:       true,
+	}
+	if media.VersionUploadedBy != nil && media.VersionUploadedAt != nil {
+		current.UploadedBy = *media.VersionUploadedBy
+		current.UploadedAt = *media.VersionUploadedAt
+		current.UploadedByName = ""
+		if uploader, err := s.repo.GetEmployeeByID(ctx, current.UploadedBy); err == nil {
+			current.UploadedByName = uploader.FullName
+		}
+	}
+
+	return append([]models.MediaVersion{current}, previous...), nil
+}
+
+// InitiateVersionUpload returns a signed URL for uploading the next version
+// of a media item. The file is staged under its versioned key and only
+// becomes current once the upload is completed.
+func (s *MediaService) InitiateVersionUpload(ctx context.Context, id uuid.UUID, req *models.InitiateVersionUploadRequest, employee *models.Employee) (*models.UploadResponse, error) {
+	media, err := s.versionableMedia(ctx, id, employee)
+	if err != nil {
+		return nil, err
+	}
+
+	// Code referencing the media ID expects the same kind of file
+	mediaType := s.determineMediaType(req.ContentType)
+	if mediaType != media.MediaType {
+		return nil, ErrInvalidInput
+	}
+
+	storageAccount, err := s.repo.GetStorageAccountByID(ctx, media.StorageAccountID)
+	if err != nil {
+		return nil, ErrStorageNotFound
+	}
+	if err := s.validateAccountLimits(storageAccount, mediaType, req.FileSize); err != nil {
+		return nil, err
+	}
+
+	adapter, err := s.adapterPool.GetAdapter(ctx, storageAccount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get storage adapter: %w", err)
+	}
+
+	version := media.CurrentVersion + 1
+	stagingKey := versionStorageKey(&media.Media, version)
+
+	signedResult, err := adapter.GenerateSignedUploadURL(ctx, storage.SignedUploadInput{
+		StorageKey:  stagingKey,
+		ContentType: req.ContentType,
+		Expiry:      15 * time.Minute,
+		MaxSize:     int64(storageAccount.MaxFileSizeMB) * 1024 * 1024,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signed URL: %w", err)
+	}
+
+	return &models.UploadResponse{
+		MediaID:          media.ID,
+		UploadURL:        signedResult.UploadURL,
+		UploadMethod:     signedResult.Method,
+		StorageAccountID: storageAccount.ID,
+		StorageKey:       stagingKey,
+		ExpiresAt:        signedResult.ExpiresAt.Unix(),
+		Headers:          signedResult.Headers,
+		FormData:         signedResult.FormData,
+		Version:          version,
+	}, nil
+}
+
+// CompleteVersionUpload makes an uploaded version the current version of a
+// media item. The previous file is archived under its versioned key.
+func (s *MediaService) CompleteVersionUpload(ctx context.Context, id uuid.UUID, req *models.VersionCompleteRequest, employee *models.Employee) (*models.MediaWithDetails, error) {
+	media, err := s.versionableMedia(ctx, id, employee)
+	if err != nil {
+		return nil, err
+	}
+	if req.Version != media.CurrentVersion+1 {
+		return nil, ErrVersionConflict
+	}
+
+	adapter, err := s.mediaAdapter(ctx, &media.Media)
+	if err != nil {
+		return nil, err
+	}
+
+	stagingKey := versionStorageKey(&media.Media, req.Version)
+	exists, err := adapter.Exists(ctx, stagingKey)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrInvalidInput
+	}
+
+	next := &models.MediaVersion{
+		MimeType:        req.MimeType,
+		FileSizeBytes:   req.FileSizeBytes,
+		Width:           req.Width,
+		Height:          req.Height,
+		DurationSeconds: req.Duration,
+		UploadedBy:      employee.ID,
+	}
+	stage := func(claimedKey string) error {
+		return adapter.Move(ctx, stagingKey, claimedKey)
+	}
+	if err := s.replaceCurrentVersion(ctx, adapter, &media.Media, next, stage, employee); err != nil {
+		return nil, err
+	}
+
+	s.logAudit(ctx, employee, models.AuditActionUpload, models.SeverityInfo, "media", &id, map[string]any{
+		"filename": media.OriginalFilename,
+		"size":     req.FileSizeBytes,
+		"version":  req.Version,
+	})
+
+	updated, err := s.repo.GetMediaByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.webhooks.Publish(ctx, models.WebhookEventMediaUpdated, updated)
+
+	return updated, nil
+}
+
+// RestoreMediaVersion makes a copy of a previous version the new current
+// version. History is kept: the restored file becomes a new version rather
+// than discarding the versions after it.
+func (s *MediaService) RestoreMediaVersion(ctx context.Context, id uuid.UUID, version int, employee *models.Employee) (*models.MediaWithDetails, error) {
+	media, err := s.versionableMedia(ctx, id, employee)
+	if err != nil {
+		return nil, err
+	}
+
+	old, err := s.repo.GetMediaVersion(ctx, id, version)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrVersionNotFound
+		}
+		return nil, err
+	}
+
+	if old.ScanStatus == models.ScanStatusInfected {
+		return nil, ErrFileInfected
+	}
+
+	adapter, err := s.mediaAdapter(ctx, &media.Media)
+	if err != nil {
+		return nil, err
+	}
+
+	next := &models.MediaVersion{
+		MimeType:        old.MimeType,
+		FileSizeBytes:   old.FileSizeBytes,
+		Width:           old.Width,
+		Height:          old.Height,
+		DurationSeconds: old.DurationSeconds,
+		UploadedBy:      employee.ID,
+	}
+	stage := func(claimedKey string) error {
+		reader, err := adapter.Download(ctx, old.StorageKey)
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+
+		_, err = adapter.Upload(ctx, storage.UploadInput{
+			Reader:      reader,
+			StorageKey:  claimedKey,
+			Filename:    media.Filename,
+			ContentType: old.MimeType,
+			ContentSize: old.FileSizeBytes,
+		})
+		return err
+	}
+	if err := s.replaceCurrentVersion(ctx, adapter, &media.Media, next, stage, employee); err != nil {
+		return nil, err
+	}
+
+	s.logAudit(ctx, employee, models.AuditActionRestore, models.SeverityWarning, "media", &id, map[string]any{
+		"filename":      media.OriginalFilename,
+		"restored_from": version,
+		"version":       media.CurrentVersion + 1,
+	})
+
+	updated, err := s.repo.GetMediaByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.webhooks.Publish(ctx, models.WebhookEventMediaUpdated, updated)
+
+	return updated, nil
+}
+
+// DownloadMediaVersion retrieves a file stream for a specific version
+func (s *MediaService) DownloadMediaVersion(ctx context.Context, id uuid.UUID, version int, employee *models.Employee) (*models.MediaWithDetails, *models.MediaVersion, io.ReadCloser, error) {
+	media, err := s.getAccessibleMedia(ctx, id, employee)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if media.QuarantinedAt != nil {
+		return nil, nil, nil, ErrMediaQuarantined
+	}
+
+	var v *models.MediaVersion
+	if version == media.CurrentVersion {
+		if err := checkDownloadable(&media.Media); err != nil {
+			return nil, nil, nil, err
+		}
+		v = &models.MediaVersion{
+			MediaID:       media.ID,
+			Version:       media.CurrentVersion,
+			StorageKey:    media.StorageKey,
+			MimeType:      media.MimeType,
+			FileSizeBytes: media.FileSizeBytes,
+			IsCurrent:     true,
+		}
+	} else {
+		v, err = s.repo.GetMediaVersion(ctx, id, version)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return nil, nil, nil, ErrVersionNotFound
+			}
+			return nil, nil, nil, err
+		}
+		if err := checkVersionDownloadable(v); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	adapter, err := s.mediaAdapter(ctx, &media.Media)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	reader, err := adapter.Download(ctx, v.StorageKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return media, v, reader, nil
+}
+
+// versionableMedia loads a media item whose file the employee may replace
+func (s *MediaService) versionableMedia(ctx context.Context, id uuid.UUID, employee *models.Employee) (*models.MediaWithDetails, error) {
+	media, err := s.getAccessibleMedia(ctx, id, employee)
+	if err != nil {
+		return nil, err
+	}
+
+	if media.UploadedBy != employee.ID && !employee.Can(models.PermMediaEditAny) {
+		return nil, ErrForbidden
+	}
+	if media.QuarantinedAt != nil {
+		return nil, ErrMediaQuarantined
+	}
+	if err := checkLegalHold(&media.Media); err != nil {
+		return nil, err
+	}
+
+	return media, nil
+}
+
+// checkVersionDownloadable returns an error if a previous version did not
+// pass its antivirus scan
+func checkVersionDownloadable(v *models.MediaVersion) error {
+	if v.ScanStatus == models.ScanStatusInfected {
+		return ErrMediaQuarantined
+	}
+	if !v.ScanStatus.Passed() {
+		return ErrScanPending
+	}
+	return nil
+}
+
+// replaceCurrentVersion makes a new file the current version of a media
+// item. The next version is claimed in the database first, so a concurrent
+// upload fails before touching any object. stage then puts the new file
+// under a key private to the claim, which no signed upload URL points at, so
+// it cannot be swapped after its scan. The live key is served at a stable
+// public location, so with scanning enabled the file must scan clean before
+// the live object is archived and the new file moved into its place. The
+// archive move is undone if the new file cannot be put in place.
+func (s *MediaService) replaceCurrentVersion(ctx context.Context, adapter storage.StorageAdapter, media *models.Media, next *models.MediaVersion, stage func(claimedKey string) error, employee *models.Employee) error {
+	claimID := uuid.New()
+	if err := s.repo.ClaimMediaVersion(ctx, media.ID, media.CurrentVersion, claimID, time.Now().Add(versionClaimTTL)); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrVersionConflict
+		}
+		return err
+	}
+	release := func() {
+		if err := s.repo.ReleaseMediaVersionClaim(ctx, media.ID, claimID); err != nil {
+			log.Printf("[MediaService] failed to release version claim on %s: %v", media.ID, err)
+		}
+	}
+
+	claimedKey := versionStorageKey(media, media.CurrentVersion+1) + "." + claimID.String()
+	if err := stage(claimedKey); err != nil {
+		release()
+		return err
+	}
+	discard := func() {
+		if err := adapter.Delete(ctx, claimedKey); err != nil && !errors.Is(err, storage.ErrFileNotFound) {
+			log.Printf("[MediaService] failed to delete staged version %s: %v", claimedKey, err)
+		}
+		release()
+	}
+
+	next.ScanStatus = models.ScanStatusSkipped
+	if s.scanner != nil {
+		if err := s.scanStagedVersion(ctx, adapter, media, claimedKey, employee); err != nil {
+			discard()
+			return err
+		}
+		next.ScanStatus = models.ScanStatusClean
+	}
+
+	archiveKey := versionStorageKey(media, media.CurrentVersion)
+	if err := adapter.Move(ctx, media.StorageKey, archiveKey); err != nil {
+		discard()
+		return err
+	}
+
+	if err := adapter.Move(ctx, claimedKey, media.StorageKey); err != nil {
+		if rbErr := adapter.Move(ctx, archiveKey, media.StorageKey); rbErr != nil {
+			log.Printf("[MediaService] failed to roll back version archive of %s: %v", media.StorageKey, rbErr)
+		}
+		discard()
+		return err
+	}
+
+	// Guest uploads awaiting review are published once approved
+	publicURL := media.PublicURL
+	if !awaitsReview(media) {
+		if url, err := adapter.GetPublicURL(ctx, media.StorageKey); err == nil {
+			publicURL = &url
+		} else {
+			log.Printf("[MediaService] failed to get public URL of %s: %v", media.StorageKey, err)
+		}
+	}
+
+	if err := s.repo.ReplaceMediaVersion(ctx, media.ID, claimID, archiveKey, next, publicURL); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrVersionConflict
+		}
+		return err
+	}
+	return nil
+}
+
+// scanStagedVersion scans a new version before it is put in place. Infected
+// files are left staged for discarding and ErrFileInfected is returned.
+func (s *MediaService) scanStagedVersion(ctx context.Context, adapter storage.StorageAdapter, media *models.Media, stagedKey string, employee *models.Employee) error {
+	result, err := s.scanObject(ctx, adapter, stagedKey)
+	if err != nil {
+		return fmt.Errorf("failed to scan new version: %w", err)
+	}
+	if !result.Infected {
+		return nil
+	}
+
+	s.logAudit(ctx, employee, models.AuditActionQuarantine, models.SeverityCritical, "media", &media.ID, map[string]any{
+		"filename":  media.OriginalFilename,
+		"signature": result.Signature,
+		"version":   media.CurrentVersion + 1,
+		"rejected":  true,
+	})
+	return ErrFileInfected
+}
+
+// mediaAdapter returns the storage adapter for the account a media item is on
+func (s *MediaService) mediaAdapter(ctx context.Context, media *models.Media) (storage.StorageAdapter, error) {
+	storageAccount, err := s.repo.GetStorageAccountByID(ctx, media.StorageAccountID)
+	if err != nil {
+		return nil, ErrStorageNotFound
+	}
+	return s.adapterPool.GetAdapter(ctx, storageAccount)
+}
@@ -0,0 +1,546 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/appnity/media-vault/internal/config"
+	"github.com/appnity/media-vault/internal/crypto"
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	// webhookBatchSize is how many due deliveries one worker run claims at a time
+	webhookBatchSize = 50
+
+	// webhookBaseBackoff and webhookMaxBackoff bound the exponential delay
+	// between attempts of a failing delivery
+	webhookBaseBackoff = 30 * time.Second
+	webhookMaxBackoff  = 6 * time.Hour
+
+	// webhookResponseLimit caps how much of a response body is kept in the
+	// delivery log
+	webhookResponseLimit = 2048
+
+	// Headers sent with every delivery. The signature is the hex HMAC-SHA256
+	// of "<timestamp>.<body>" keyed with the endpoint secret.
+	webhookSignatureHeader = "X-MediaVault-Signature"
+	webhookTimestampHeader = "X-MediaVault-Timestamp"
+	webhookEventHeader     = "X-MediaVault-Event"
+	webhookDeliveryHeader  = "X-MediaVault-Delivery"
+)
+
+// WebhookService manages webhook endpoints and delivers events to them
+type WebhookService struct {
+	repo      *repository.Repository
+	cfg       *config.Config
+	encryptor *crypto.Encryptor
+	client    *http.Client
+}
+
+// NewWebhookService creates a new webhook service
+func NewWebhookService(repo *repository.Repository, cfg *config.Config, encryptor *crypto.Encryptor) *WebhookService {
+	return &WebhookService{
+		repo:      repo,
+		cfg:       cfg,
+		encryptor: encryptor,
+		client: &http.Client{
+			Timeout:   time.Duration(cfg.WebhookTimeoutSecs) * time.Second,
+			Transport: webhookTransport(cfg.WebhookAllowPrivate),
+			// Endpoints must answer directly; redirects are treated as failures
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Publish queues an event for every endpoint subscribed to it. Failures are
+// logged rather than returned so they never fail the change that caused the
+// event. It is safe to call on a nil service.
+func (s *WebhookService) Publish(ctx context.Context, eventType models.WebhookEventType, data any) {
+	if s == nil {
+		return
+	}
+
+	event := models.WebhookEvent{
+		ID:        uuid.New(),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("[Webhooks] failed to encode %s event: %v", eventType, err)
+		return
+	}
+
+	if _, err := s.repo.EnqueueWebhookEvent(ctx, event.ID, eventType, payload); err != nil {
+		log.Printf("[Webhooks] failed to queue %s event: %v", eventType, err)
+	}
+}
+
+// CreateEndpoint creates a webhook endpoint with a new signing secret. The
+// secret is only returned here and when it is rotated.
+func (s *WebhookService) CreateEndpoint(ctx context.Context, req *models.CreateWebhookEndpointRequest, employeeID uuid.UUID) (*models.WebhookEndpointResponse, error) {
+	if err := s.validateWebhookURL(req.URL); err != nil {
+		return nil, err
+	}
+	if err := validateWebhookEventTypes(req.EventTypes); err != nil {
+		return nil, err
+	}
+
+	endpoint := &models.WebhookEndpoint{
+		Name:       req.Name,
+		URL:        req.URL,
+		EventTypes: req.EventTypes,
+		IsActive:   true,
+		CreatedBy:  employeeID,
+	}
+	secret, err := s.newSecret(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateWebhookEndpoint(ctx, endpoint); err != nil {
+		return nil, err
+	}
+
+	return &models.WebhookEndpointResponse{WebhookEndpoint: *endpoint, Secret: secret}, nil
+}
+
+// ListEndpoints lists webhook endpoints
+func (s *WebhookService) ListEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error) {
+	return s.repo.ListWebhookEndpoints(ctx)
+}
+
+// GetEndpoint gets a webhook endpoint by ID
+func (s *WebhookService) GetEndpoint(ctx context.Context, id uuid.UUID) (*models.WebhookEndpoint, error) {
+	endpoint, err := s.repo.GetWebhookEndpointByID(ctx, id)
+	if err != nil {
+		return nil, ErrWebhookNotFound
+	}
+	return endpoint, nil
+}
+
+// UpdateEndpoint updates a webhook endpoint
+func (s *WebhookService) UpdateEndpoint(ctx context.Context, id uuid.UUID, req *models.UpdateWebhookEndpointRequest) (*models.WebhookEndpoint, error) {
+	endpoint, err := s.repo.GetWebhookEndpointByID(ctx, id)
+	if err != nil {
+		return nil, ErrWebhookNotFound
+	}
+
+	if req.Name != nil {
+		endpoint.Name = *req.Name
+	}
+	if req.URL != nil {
+		if err := s.validateWebhookURL(*req.URL); err != nil {
+			return nil, err
+		}
+		endpoint.URL = *req.URL
+	}
+	if req.EventTypes != nil {
+		if err := validateWebhookEventTypes(req.EventTypes); err != nil {
+			return nil, err
+		}
+		endpoint.EventTypes = req.EventTypes
+	}
+	if req.IsActive != nil {
+		endpoint.IsActive = *req.IsActive
+	}
+
+	if err := s.repo.UpdateWebhookEndpoint(ctx, endpoint); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return endpoint, nil
+}
+
+// DeleteEndpoint deletes a webhook endpoint and drops its pending deliveries
+func (s *WebhookService) DeleteEndpoint(ctx context.Context, id uuid.UUID) error {
+	if err := s.repo.SoftDeleteWebhookEndpoint(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrWebhookNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// RotateSecret replaces the signing secret of a webhook endpoint. Deliveries
+// sent from now on, including retries, are signed with the new secret.
+func (s *WebhookService) RotateSecret(ctx context.Context, id uuid.UUID) (*models.WebhookEndpointResponse, error) {
+	endpoint, err := s.repo.GetWebhookEndpointByID(ctx, id)
+	if err != nil {
+		return nil, ErrWebhookNotFound
+	}
+
+	secret, err := s.newSecret(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateWebhookEndpoint(ctx, endpoint); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+
+	return &models.WebhookEndpointResponse{WebhookEndpoint: *endpoint, Secret: secret}, nil
+}
+
+// SendTestEvent queues a webhook.ping event for one endpoint, regardless of
+// its event filter
+func (s *WebhookService) SendTestEvent(ctx context.Context, id uuid.UUID) (*models.WebhookDelivery, error) {
+	endpoint, err := s.repo.GetWebhookEndpointByID(ctx, id)
+	if err != nil {
+		return nil, ErrWebhookNotFound
+	}
+
+	event := models.WebhookEvent{
+		ID:        uuid.New(),
+		Type:      models.WebhookEventPing,
+		CreatedAt: time.Now().UTC(),
+		Data:      map[string]any{"endpoint_id": endpoint.ID, "name": endpoint.Name},
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := &models.WebhookDelivery{
+		EndpointID: endpoint.ID,
+		EventID:    event.ID,
+		EventType:  event.Type,
+		Payload:    payload,
+	}
+	if err := s.repo.CreateWebhookDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// ListDeliveries lists the delivery log of a webhook endpoint
+func (s *WebhookService) ListDeliveries(ctx context.Context, id uuid.UUID, filters *models.WebhookDeliveryFilterRequest) (*models.PaginatedResponse[models.WebhookDelivery], error) {
+	if _, err := s.repo.GetWebhookEndpointByID(ctx, id); err != nil {
+		return nil, ErrWebhookNotFound
+	}
+
+	page, pageSize := filters.Page, filters.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 50
+	}
+
+	var status *models.WebhookDeliveryStatus
+	if filters.Status != "" {
+		st := models.WebhookDeliveryStatus(filters.Status)
+		status = &st
+	}
+
+	deliveries, total, err := s.repo.ListWebhookDeliveries(ctx, id, status, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PaginatedResponse[models.WebhookDelivery]{
+		Data:       deliveries,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}, nil
+}
+
+// ReplayDelivery queues a delivered or failed event again for the same
+// endpoint. The replay keeps the original event ID so receivers can
+// deduplicate it.
+func (s *WebhookService) ReplayDelivery(ctx context.Context, deliveryID uuid.UUID) (*models.WebhookDelivery, error) {
+	original, err := s.repo.GetWebhookDeliveryByID(ctx, deliveryID)
+	if err != nil {
+		return nil, ErrDeliveryNotFound
+	}
+	if _, err := s.repo.GetWebhookEndpointByID(ctx, original.EndpointID); err != nil {
+		return nil, ErrWebhookNotFound
+	}
+
+	replay := &models.WebhookDelivery{
+		EndpointID: original.EndpointID,
+		EventID:    original.EventID,
+		EventType:  original.EventType,
+		Payload:    original.Payload,
+		ReplayOf:   &original.ID,
+	}
+	if err := s.repo.CreateWebhookDelivery(ctx, replay); err != nil {
+		return nil, err
+	}
+	return replay, nil
+}
+
+// DeliverDue sends pending deliveries that are due. It runs as a scheduled
+// job; failed attempts are retried with exponential backoff until
+// WEBHOOK_MAX_ATTEMPTS is reached.
+func (s *WebhookService) DeliverDue(ctx context.Context) error {
+	// The lease keeps a claimed delivery from being picked up again while
+	// its request is in flight
+	lease := s.client.Timeout + time.Minute
+	endpoints := make(map[uuid.UUID]*models.WebhookEndpoint)
+
+	for {
+		deliveries, err := s.repo.ClaimDueWebhookDeliveries(ctx, webhookBatchSize, lease)
+		if err != nil {
+			return err
+		}
+
+		for i := range deliveries {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			s.attemptDelivery(ctx, &deliveries[i], endpoints)
+		}
+
+		if len(deliveries) < webhookBatchSize {
+			return nil
+		}
+	}
+}
+
+// attemptDelivery sends one delivery and records the outcome. endpoints
+// caches endpoint lookups for the current run.
+func (s *WebhookService) attemptDelivery(ctx context.Context, delivery *models.WebhookDelivery, endpoints map[uuid.UUID]*models.WebhookEndpoint) {
+	endpoint, ok := endpoints[delivery.EndpointID]
+	if !ok {
+		endpoint, _ = s.repo.GetWebhookEndpointByID(ctx, delivery.EndpointID)
+		endpoints[delivery.EndpointID] = endpoint
+	}
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.ResponseStatus = nil
+	delivery.ResponseBody = nil
+	delivery.LastError = nil
+
+	var sendErr error
+	if endpoint == nil {
+		sendErr = errors.New("endpoint deleted")
+		delivery.Attempts = s.cfg.WebhookMaxAttempts
+	} else if !endpoint.IsActive {
+		sendErr = errors.New("endpoint disabled")
+		delivery.Attempts = s.cfg.WebhookMaxAttempts
+	} else {
+		sendErr = s.send(ctx, endpoint, delivery)
+	}
+
+	switch {
+	case sendErr == nil:
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.DeliveredAt = &now
+	case delivery.Attempts >= s.cfg.WebhookMaxAttempts:
+		delivery.Status = models.WebhookDeliveryFailed
+	default:
+		delivery.Status = models.WebhookDeliveryPending
+		delivery.NextAttemptAt = now.Add(webhookBackoff(delivery.Attempts))
+	}
+	if sendErr != nil {
+		msg := sendErr.Error()
+		delivery.LastError = &msg
+	}
+
+	if err := s.repo.RecordWebhookAttempt(ctx, delivery); err != nil {
+		log.Printf("[Webhooks] failed to record attempt for delivery %s: %v", delivery.ID, err)
+	}
+}
+
+// send posts a delivery to its endpoint. Any 2xx response counts as success.
+func (s *WebhookService) send(ctx context.Context, endpoint *models.WebhookEndpoint, delivery *models.WebhookDelivery) error {
+	secret, err := s.encryptor.Decrypt(endpoint.EncryptedSecret, endpoint.SecretNonce)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt signing secret: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(delivery.Payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "MediaVault-Webhooks/1.0")
+	req.Header.Set(webhookSignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	req.Header.Set(webhookTimestampHeader, timestamp)
+	req.Header.Set(webhookEventHeader, string(delivery.EventType))
+	req.Header.Set(webhookDeliveryHeader, delivery.ID.String())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	responseBody := string(body)
+	delivery.ResponseStatus = &resp.StatusCode
+	delivery.ResponseBody = &responseBody
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// newSecret generates a signing secret and stores it encrypted on the endpoint
+func (s *WebhookService) newSecret(endpoint *models.WebhookEndpoint) (string, error) {
+	random, err := crypto.GenerateRandomString(24)
+	if err != nil {
+		return "", err
+	}
+	secret := "whsec_" + random
+
+	encrypted, nonce, err := s.encryptor.Encrypt([]byte(secret))
+	if err != nil {
+		return "", err
+	}
+	endpoint.EncryptedSecret = encrypted
+	endpoint.SecretNonce = nonce
+	return secret, nil
+}
+
+// webhookBackoff returns the delay before the next attempt after the given
+// number of failed attempts
+func webhookBackoff(attempts int) time.Duration {
+	delay := webhookBaseBackoff
+	for i := 1; i < attempts && delay < webhookMaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > webhookMaxBackoff {
+		delay = webhookMaxBackoff
+	}
+	return delay
+}
+
+// validateWebhookURL requires an absolute http or https URL. Hosts given
+// as an internal address are refused right away; names are checked when
+// deliveries connect, see webhookTransport.
+func (s *WebhookService) validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ErrInvalidInput
+	}
+	if s.cfg.WebhookAllowPrivate {
+		return nil
+	}
+	if strings.EqualFold(u.Hostname(), "localhost") || strings.HasSuffix(strings.ToLower(u.Hostname()), ".localhost") {
+		return ErrInvalidInput
+	}
+	if addr, err := netip.ParseAddr(u.Hostname()); err == nil && !publicWebhookAddress(addr) {
+		return ErrInvalidInput
+	}
+	return nil
+}
+
+// webhookTransport dials webhook endpoints. Unless private networks are
+// allowed, every address a delivery connects to is checked after DNS
+// resolution, so a name cannot be pointed at an internal service after the
+// endpoint was created. Proxies from the environment are not used, as they
+// would connect on the server's behalf unchecked.
+func webhookTransport(allowPrivate bool) *http.Transport {
+	dialer := &net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}
+	if !allowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !publicWebhookAddress(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", errWebhookAddress, addrPort.Addr())
+			}
+			return nil
+		}
+	}
+	return &http.Transport{
+		DialContext:         dialer.DialContext,
+		ForceAttemptHTTP2:   true,
+		MaxIdleConns:        100,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}
+}
+
+// errWebhookAddress is returned when a delivery would connect to an
+// internal address
+var errWebhookAddress = errors.New("webhook endpoint resolves to a private or reserved address")
+
+// webhookBlockedPrefixes are special-purpose ranges not covered by the
+// netip.Addr predicates
+var webhookBlockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // "this network"
+	netip.MustParsePrefix("100.64.0.0/10"),  // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),  // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),    // reserved, and broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),   // NAT64, which can reach IPv4 internals
+	netip.MustParsePrefix("64:ff9b:1::/48"), // local-use NAT64
+	netip.MustParsePrefix("2002::/16"),      // 6to4, which embeds IPv4 addresses
+	netip.MustParsePrefix("fec0::/10"),      // deprecated site-local
+}
+
+// publicWebhookAddress reports whether an address may receive webhook
+// deliveries: not loopback, private, link-local (which includes cloud
+// metadata endpoints such as 169.254.169.254), multicast or reserved
+func publicWebhookAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsUnspecified() || addr.IsLoopback() || addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range webhookBlockedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// validateWebhookEventTypes rejects event filters naming unknown events
+func validateWebhookEventTypes(eventTypes []string) error {
+	for _, t := range eventTypes {
+		known := false
+		for _, k := range models.WebhookEventTypes {
+			if string(k) == t {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return ErrInvalidInput
+		}
+	}
+	return nil
+}
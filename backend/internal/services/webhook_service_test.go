@@ -0,0 +1,90 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"testing"
+
+	"github.com/appnity/media-vault/internal/config"
+)
+
+func TestPublicWebhookAddress(t *testing.T) {
+	cases := map[string]bool{
+		"93.184.216.34":          true,
+		"2606:4700::1111":        true,
+		"127.0.0.1":              false,
+		"::1":                    false,
+		"10.1.2.3":               false,
+		"172.16.0.1":             false,
+		"192.168.1.1":            false,
+		"169.254.169.254":        false,
+		"fe80::1":                false,
+		"fd00:ec2::254":          false,
+		"100.64.0.1":             false,
+		"0.0.0.0":                false,
+		"255.255.255.255":        false,
+		"224.0.0.1":              false,
+		"::ffff:127.0.0.1":       false,
+		"::ffff:169.254.169.254": false,
+		"64:ff9b::a00:1":         false,
+	}
+	for address, want := range cases {
+		if got := publicWebhookAddress(netip.MustParseAddr(address)); got != want {
+			t.Errorf("publicWebhookAddress(%s) = %v, want %v", address, got, want)
+		}
+	}
+}
+
+func TestValidateWebhookURL(t *testing.T) {
+	s := &WebhookService{cfg: &config.Config{}}
+	cases := map[string]bool{
+		"https://hooks.example.com/media": true,
+		"http://hooks.example.com:8080/x": true,
+		"ftp://hooks.example.com/":        false,
+		"https://":                        false,
+		"https://localhost/hook":          false,
+		"https://api.localhost/hook":      false,
+		"http://127.0.0.1:9000/hook":      false,
+		"http://[::1]/hook":               false,
+		"http://169.254.169.254/latest/":  false,
+		"http://10.0.0.5/hook":            false,
+		"https://93.184.216.34/hook":      true,
+	}
+	for raw, ok := range cases {
+		if err := s.validateWebhookURL(raw); (err == nil) != ok {
+			t.Errorf("validateWebhookURL(%q) = %v, want ok=%v", raw, err, ok)
+		}
+	}
+
+	s.cfg.WebhookAllowPrivate = true
+	if err := s.validateWebhookURL("http://127.0.0.1:9000/hook"); err != nil {
+		t.Errorf("private address refused although allowed: %v", err)
+	}
+}
+
+// TestWebhookTransportChecksResolvedAddress connects through a name, so the
+// check must happen on the address it resolves to rather than on the URL
+func TestWebhookTransportChecksResolvedAddress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	port := netip.MustParseAddrPort(server.Listener.Addr().String()).Port()
+	url := "http://localhost:" + strconv.Itoa(int(port))
+
+	client := &http.Client{Transport: webhookTransport(false)}
+	_, err := client.Post(url, "application/json", nil)
+	if !errors.Is(err, errWebhookAddress) {
+		t.Fatalf("delivery to loopback: got %v, want %v", err, errWebhookAddress)
+	}
+
+	client = &http.Client{Transport: webhookTransport(true)}
+	resp, err := client.Post(url, "application/json", nil)
+	if err != nil {
+		t.Fatalf("delivery with private networks allowed: %v", err)
+	}
+	resp.Body.Close()
+}
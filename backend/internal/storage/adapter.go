@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"io"
+	"sync"
 	"time"
 
 	"github.com/appnity/media-vault/internal/models"
@@ -172,6 +173,7 @@ func (f *AdapterFactory) CreateAdapter(ctx context.Context, account *models.Stor
 
 // AdapterPool manages a pool of adapters per storage account
 type AdapterPool struct {
+	mu       sync.Mutex
 	adapters map[uuid.UUID]StorageAdapter
 	factory  *AdapterFactory
 }
@@ -186,6 +188,9 @@ func NewAdapterPool(factory *AdapterFactory) *AdapterPool {
 
 // GetAdapter retrieves or creates an adapter for the given storage account
 func (p *AdapterPool) GetAdapter(ctx context.Context, account *models.StorageAccount) (StorageAdapter, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
 	if adapter, ok := p.adapters[account.ID]; ok {
 		return adapter, nil
 	}
@@ -201,6 +206,9 @@ func (p *AdapterPool) GetAdapter(ctx context.Context, account *models.StorageAcc
 
 // InvalidateAdapter removes an adapter from the pool (e.g., after credentials update)
 func (p *AdapterPool) InvalidateAdapter(accountID uuid.UUID) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
 	if adapter, ok := p.adapters[accountID]; ok {
 		adapter.Close()
 		delete(p.adapters, accountID)
@@ -209,6 +217,9 @@ func (p *AdapterPool) InvalidateAdapter(accountID uuid.UUID) {
 
 // Close closes all adapters in the pool
 func (p *AdapterPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
 	for _, adapter := range p.adapters {
 		adapter.Close()
 	}
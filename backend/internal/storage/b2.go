@@ -259,6 +259,10 @@ func (a *B2Adapter) GetMetadata(ctx context.Context, storageKey string) (*FileMe
 	}, nil
 }
 
+func (a *B2Adapter) SetLegalHold(ctx context.Context, storageKey string, on bool) error {
+	return putLegalHold(ctx, a.client, a.bucketName, storageKey, on)
+}
+
 func (a *B2Adapter) Close() error {
 	return nil
 }
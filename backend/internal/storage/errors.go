@@ -33,6 +33,9 @@ var (
 	// ErrInvalidFileType is returned for disallowed file types
 	ErrInvalidFileType = errors.New("file type not allowed")
 
+	// ErrLegalHoldFailed is returned when an object lock legal hold can't be set
+	ErrLegalHoldFailed = errors.New("failed to set legal hold on object")
+
 	// ErrConnectionFailed is returned when can't connect to provider
 	ErrConnectionFailed = errors.New("failed to connect to storage provider")
 )
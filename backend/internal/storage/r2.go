@@ -247,6 +247,10 @@ func (a *R2Adapter) GetMetadata(ctx context.Context, storageKey string) (*FileMe
 	}, nil
 }
 
+func (a *R2Adapter) SetLegalHold(ctx context.Context, storageKey string, on bool) error {
+	return putLegalHold(ctx, a.client, a.bucketName, storageKey, on)
+}
+
 func (a *R2Adapter) Close() error {
 	return nil
 }
@@ -250,6 +250,10 @@ func (a *S3Adapter) GetMetadata(ctx context.Context, storageKey string) (*FileMe
 	}, nil
 }
 
+func (a *S3Adapter) SetLegalHold(ctx context.Context, storageKey string, on bool) error {
+	return putLegalHold(ctx, a.client, a.bucketName, storageKey, on)
+}
+
 func (a *S3Adapter) Close() error {
 	return nil
 }
@@ -0,0 +1,87 @@
+package testdb
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// ObjectStoreBucket is the only bucket an ObjectStore serves
+const ObjectStoreBucket = "test-bucket"
+
+// ObjectStore is a stand-in S3 endpoint for storage accounts created by
+// StorageAccount. It serves path-style GET, HEAD, PUT and DELETE of objects,
+// which is all the adapters need to download, upload and delete.
+type ObjectStore struct {
+	server *httptest.Server
+
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+// NewObjectStore starts an object store, stopped when the test ends
+func NewObjectStore(t *testing.T) *ObjectStore {
+	t.Helper()
+	s := &ObjectStore{objects: make(map[string][]byte)}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.server.Close)
+	return s
+}
+
+// URL is the endpoint storage accounts point at
+func (s *ObjectStore) URL() string {
+	return s.server.URL
+}
+
+// Put stores an object
+func (s *ObjectStore) Put(key string, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = body
+}
+
+// Get returns an object and whether it exists
+func (s *ObjectStore) Get(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	body, ok := s.objects[key]
+	return body, ok
+}
+
+func (s *ObjectStore) serve(w http.ResponseWriter, r *http.Request) {
+	key, ok := strings.CutPrefix(r.URL.Path, "/"+ObjectStoreBucket+"/")
+	if !ok {
+		http.Error(w, "<Error><Code>NoSuchBucket</Code></Error>", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		body, ok := s.Get(key)
+		if !ok {
+			http.Error(w, "<Error><Code>NoSuchKey</Code></Error>", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		if r.Method == http.MethodGet {
+			w.Write(body)
+		}
+	case http.MethodPut:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.Put(key, body)
+	case http.MethodDelete:
+		s.mu.Lock()
+		delete(s.objects, key)
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "<Error><Code>NotImplemented</Code></Error>", http.StatusNotImplemented)
+	}
+}
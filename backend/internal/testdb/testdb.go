@@ -0,0 +1,208 @@
+// Package testdb gives tests a freshly migrated PostgreSQL database.
+//
+// Tests using it are skipped unless TEST_DATABASE_URL names a database they
+// may wipe: every Open drops the public schema and applies migrations/*.sql
+// in order, as migrate.sh does.
+package testdb
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"testing"
+
+	"github.com/appnity/media-vault/internal/crypto"
+	"github.com/appnity/media-vault/internal/models"
+	"github.com/appnity/media-vault/internal/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// lockID serializes test packages sharing the database, as go test runs
+// them in parallel
+const lockID = 7310412
+
+// Open connects to TEST_DATABASE_URL, resets it to the migrated schema and
+// returns a repository over it. The database stays locked to the calling
+// test until it ends.
+func Open(t *testing.T) (*repository.Repository, *pgxpool.Pool) {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+
+	pool, err := pgxpool.New(ctx, url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	lock, err := pool.Acquire(ctx)
+	if err != nil {
+		pool.Close()
+		t.Fatalf("connect: %v", err)
+	}
+	if _, err := lock.Exec(ctx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+		lock.Release()
+		pool.Close()
+		t.Fatalf("lock database: %v", err)
+	}
+	t.Cleanup(func() {
+		lock.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", lockID)
+		lock.Release()
+		pool.Close()
+	})
+
+	if _, err := pool.Exec(ctx, "DROP SCHEMA public CASCADE; CREATE SCHEMA public;"); err != nil {
+		t.Fatalf("reset schema: %v", err)
+	}
+	files, err := filepath.Glob(filepath.Join(migrationsDir(), "*.sql"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no migrations found in %s", migrationsDir())
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		sql, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("read %s: %v", file, err)
+		}
+		if _, err := pool.Exec(ctx, string(sql)); err != nil {
+			t.Fatalf("apply %s: %v", filepath.Base(file), err)
+		}
+	}
+
+	return repository.NewRepository(pool), pool
+}
+
+// migrationsDir is backend/migrations, found relative to this file so tests
+// run from any package directory
+func migrationsDir() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..", "migrations")
+}
+
+// Employee creates an active employee with a role. Their password is
+// unusable, as tests sign them in by building their identity directly.
+func Employee(t *testing.T, repo *repository.Repository, role models.Role) *models.Employee {
+	t.Helper()
+	id := uuid.New()
+	employee := &models.Employee{
+		Email:        string(role) + "-" + id.String()[:8] + "@example.com",
+		PasswordHash: "!",
+		FullName:     string(role) + " " + id.String()[:8],
+		Role:         role,
+		IsActive:     true,
+	}
+	if err := repo.CreateEmployee(context.Background(), employee); err != nil {
+		t.Fatalf("create employee: %v", err)
+	}
+	return employee
+}
+
+// StorageAccount creates an active, private S3 storage account kept in
+// store, with credentials encrypted by encryptor
+func StorageAccount(t *testing.T, repo *repository.Repository, encryptor *crypto.Encryptor, store *ObjectStore, createdBy *models.Employee) *models.StorageAccount {
+	t.Helper()
+	creds, _ := json.Marshal(map[string]string{
+		"access_key_id":     "test",
+		"secret_access_key": "test",
+		"region":            "us-east-1",
+		"bucket_name":       ObjectStoreBucket,
+	})
+	encrypted, nonce, err := encryptor.Encrypt(creds)
+	if err != nil {
+		t.Fatalf("encrypt credentials: %v", err)
+	}
+
+	bucket, endpoint := ObjectStoreBucket, store.URL()
+	account := &models.StorageAccount{
+		Name:                 "account-" + uuid.NewString()[:8],
+		Provider:             models.ProviderS3,
+		EncryptedCredentials: encrypted,
+		CredentialsNonce:     nonce,
+		BucketName:           &bucket,
+		EndpointURL:          &endpoint,
+		IsActive:             true,
+		MaxFileSizeMB:        100,
+		AllowedTypes:         []models.MediaType{models.MediaTypeImage},
+		CreatedBy:            createdBy.ID,
+	}
+	if err := repo.CreateStorageAccount(context.Background(), account); err != nil {
+		t.Fatalf("create storage account: %v", err)
+	}
+	return account
+}
+
+// MediaGroup creates a media group admitting roles
+func MediaGroup(t *testing.T, repo *repository.Repository, createdBy *models.Employee, roles ...models.Role) *models.MediaGroup {
+	t.Helper()
+	group := &models.MediaGroup{
+		Name:         "group-" + uuid.NewString()[:8],
+		Color:        "#000000",
+		Icon:         "folder",
+		AllowedRoles: roles,
+		CreatedBy:    createdBy.ID,
+	}
+	if err := repo.CreateMediaGroup(context.Background(), group); err != nil {
+		t.Fatalf("create media group: %v", err)
+	}
+	return group
+}
+
+// Folder creates a folder at path in a storage account
+func Folder(t *testing.T, pool *pgxpool.Pool, account *models.StorageAccount, createdBy *models.Employee, path string) uuid.UUID {
+	t.Helper()
+	id := uuid.New()
+	_, err := pool.Exec(context.Background(), `
+		INSERT INTO folders (id, storage_account_id, name, path, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+	`, id, account.ID, filepath.Base(path), path, createdBy.ID)
+	if err != nil {
+		t.Fatalf("create folder: %v", err)
+	}
+	return id
+}
+
+// Media creates a media item from a template, which needs at least its
+// storage account and uploader. The item passed its scan, has a public URL
+// and its object is put into store.
+func Media(t *testing.T, repo *repository.Repository, store *ObjectStore, media *models.Media) *models.Media {
+	t.Helper()
+	if media.OriginalFilename == "" {
+		media.OriginalFilename = "file-" + uuid.NewString()[:8] + ".png"
+	}
+	if media.Filename == "" {
+		media.Filename = media.OriginalFilename
+	}
+	if media.StorageKey == "" {
+		media.StorageKey = "uploads/" + media.Filename
+	}
+	if media.MediaType == "" {
+		media.MediaType = models.MediaTypeImage
+	}
+	if media.MimeType == "" {
+		media.MimeType = "image/png"
+	}
+	if media.ScanStatus == "" {
+		media.ScanStatus = models.ScanStatusClean
+	}
+	if media.PublicURL == nil {
+		url := store.URL() + "/" + ObjectStoreBucket + "/" + media.StorageKey
+		media.PublicURL = &url
+	}
+	if media.Tags == nil {
+		media.Tags = []string{}
+	}
+
+	body := []byte("contents of " + media.OriginalFilename)
+	media.FileSizeBytes = int64(len(body))
+	store.Put(media.StorageKey, body)
+
+	if err := repo.CreateMedia(context.Background(), media); err != nil {
+		t.Fatalf("create media: %v", err)
+	}
+	return media
+}
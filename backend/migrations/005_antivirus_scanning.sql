@@ -0,0 +1,17 @@
+-- Antivirus scanning and quarantine for uploaded media
+CREATE TYPE scan_status AS ENUM ('pending', 'clean', 'infected', 'error', 'skipped');
+
+ALTER TABLE media ADD COLUMN scan_status scan_status NOT NULL DEFAULT 'pending';
+ALTER TABLE media ADD COLUMN scan_signature VARCHAR(255);
+ALTER TABLE media ADD COLUMN scanned_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE media ADD COLUMN quarantined_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE media ADD COLUMN quarantine_released_by UUID REFERENCES employees(id);
+ALTER TABLE media ADD COLUMN quarantine_released_at TIMESTAMP WITH TIME ZONE;
+
+-- Existing files were uploaded before scanning was available
+UPDATE media SET scan_status = 'skipped';
+
+CREATE INDEX idx_media_quarantined ON media(quarantined_at) WHERE quarantined_at IS NOT NULL AND deleted_at IS NULL;
+
+ALTER TYPE audit_action ADD VALUE IF NOT EXISTS 'quarantine';
+ALTER TYPE audit_action ADD VALUE IF NOT EXISTS 'release';
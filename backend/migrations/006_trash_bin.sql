@@ -0,0 +1,11 @@
+-- Trash bin: deleted media stays in storage until it is purged
+ALTER TABLE media ADD COLUMN deleted_by UUID REFERENCES employees(id);
+ALTER TABLE media ADD COLUMN purged_at TIMESTAMP WITH TIME ZONE;
+
+-- Before the trash existed, deleting removed the object from storage immediately
+UPDATE media SET purged_at = deleted_at WHERE deleted_at IS NOT NULL;
+
+CREATE INDEX idx_media_trash ON media(deleted_at) WHERE deleted_at IS NOT NULL AND purged_at IS NULL;
+
+ALTER TYPE audit_action ADD VALUE IF NOT EXISTS 'restore';
+ALTER TYPE audit_action ADD VALUE IF NOT EXISTS 'purge';
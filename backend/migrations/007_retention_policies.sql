@@ -0,0 +1,46 @@
+-- Retention policies: scheduled expiry and minimum retention for media
+CREATE TYPE retention_scope AS ENUM ('group', 'folder', 'media');
+CREATE TYPE retention_action AS ENUM ('trash', 'purge');
+
+CREATE TABLE retention_policies (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL,
+
+    -- What the policy applies to; exactly one target matching the scope
+    scope retention_scope NOT NULL,
+    media_group_id UUID REFERENCES media_groups(id),
+    folder_id UUID REFERENCES folders(id),
+    media_id UUID REFERENCES media(id),
+
+    -- Rules, counted in days from upload
+    expire_after_days INTEGER CHECK (expire_after_days > 0),
+    expire_action retention_action NOT NULL DEFAULT 'trash',
+    min_retention_days INTEGER CHECK (min_retention_days > 0),
+
+    is_active BOOLEAN NOT NULL DEFAULT true,
+
+    -- Metadata
+    created_by UUID NOT NULL REFERENCES employees(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    CHECK (expire_after_days IS NOT NULL OR min_retention_days IS NOT NULL),
+    CHECK (
+        (scope = 'group' AND media_group_id IS NOT NULL AND folder_id IS NULL AND media_id IS NULL) OR
+        (scope = 'folder' AND folder_id IS NOT NULL AND media_group_id IS NULL AND media_id IS NULL) OR
+        (scope = 'media' AND media_id IS NOT NULL AND media_group_id IS NULL AND folder_id IS NULL)
+    )
+);
+
+CREATE INDEX idx_retention_policies_group ON retention_policies(media_group_id) WHERE is_active = true;
+CREATE INDEX idx_retention_policies_folder ON retention_policies(folder_id) WHERE is_active = true;
+CREATE INDEX idx_retention_policies_media ON retention_policies(media_id) WHERE is_active = true;
+
+-- Effective retention, recomputed whenever policies or media placement change
+ALTER TABLE media ADD COLUMN expires_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE media ADD COLUMN retain_until TIMESTAMP WITH TIME ZONE;
+ALTER TABLE media ADD COLUMN expiry_policy_id UUID REFERENCES retention_policies(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_media_expires_at ON media(expires_at) WHERE expires_at IS NOT NULL AND deleted_at IS NULL;
+
+ALTER TYPE audit_action ADD VALUE IF NOT EXISTS 'expire';
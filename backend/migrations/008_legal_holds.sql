@@ -0,0 +1,37 @@
+-- Legal holds: block deletion, moves and overwrites of media under investigation
+CREATE TABLE legal_holds (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+
+    -- What the hold applies to; reuses the retention scopes
+    scope retention_scope NOT NULL,
+    media_group_id UUID REFERENCES media_groups(id),
+    folder_id UUID REFERENCES folders(id),
+    media_id UUID REFERENCES media(id),
+
+    reason TEXT NOT NULL,
+    owner_id UUID NOT NULL REFERENCES employees(id), -- Person accountable for the hold
+    placed_by UUID NOT NULL REFERENCES employees(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    released_at TIMESTAMP WITH TIME ZONE,
+    released_by UUID REFERENCES employees(id),
+    release_reason TEXT,
+
+    CHECK (
+        (scope = 'group' AND media_group_id IS NOT NULL AND folder_id IS NULL AND media_id IS NULL) OR
+        (scope = 'folder' AND folder_id IS NOT NULL AND media_group_id IS NULL AND media_id IS NULL) OR
+        (scope = 'media' AND media_id IS NOT NULL AND media_group_id IS NULL AND folder_id IS NULL)
+    )
+);
+
+CREATE INDEX idx_legal_holds_group ON legal_holds(media_group_id) WHERE released_at IS NULL;
+CREATE INDEX idx_legal_holds_folder ON legal_holds(folder_id) WHERE released_at IS NULL;
+CREATE INDEX idx_legal_holds_media ON legal_holds(media_id) WHERE released_at IS NULL;
+
+-- Effective hold, recomputed whenever holds or media placement change
+ALTER TABLE media ADD COLUMN legal_hold BOOLEAN NOT NULL DEFAULT false;
+
+-- Buckets created with S3 Object Lock also get the hold set on the object
+ALTER TABLE storage_accounts ADD COLUMN object_lock_enabled BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TYPE audit_action ADD VALUE IF NOT EXISTS 'hold';
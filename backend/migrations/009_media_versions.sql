@@ -0,0 +1,27 @@
+-- Media versioning: replace the file behind a media item while keeping its ID
+-- and storage key. Superseded files are archived under versioned keys.
+ALTER TABLE media ADD COLUMN current_version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE media ADD COLUMN version_uploaded_by UUID REFERENCES employees(id);
+ALTER TABLE media ADD COLUMN version_uploaded_at TIMESTAMP WITH TIME ZONE;
+
+-- Previous versions of a media item; the current version lives on media itself
+CREATE TABLE media_versions (
+    media_id UUID NOT NULL REFERENCES media(id) ON DELETE CASCADE,
+    version_number INTEGER NOT NULL,
+
+    storage_key VARCHAR(1000) NOT NULL, -- Archived object, never the live key
+    mime_type VARCHAR(255) NOT NULL,
+    file_size_bytes BIGINT NOT NULL,
+    width INTEGER,
+    height INTEGER,
+    duration_seconds INTEGER,
+
+    uploaded_by UUID NOT NULL REFERENCES employees(id),
+    uploaded_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    replaced_by UUID NOT NULL REFERENCES employees(id),
+    replaced_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (media_id, version_number)
+);
+
+CREATE INDEX idx_media_versions_storage_key ON media_versions(storage_key);
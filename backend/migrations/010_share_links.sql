@@ -0,0 +1,35 @@
+-- Share links: unauthenticated, revocable access to media for people without an account
+CREATE TYPE share_scope AS ENUM ('media', 'folder', 'selection');
+
+CREATE TABLE share_links (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    token_hash VARCHAR(64) NOT NULL UNIQUE, -- SHA-256 of the token; the token itself is never stored
+
+    name VARCHAR(255),
+    scope share_scope NOT NULL,
+    folder_id UUID REFERENCES folders(id), -- Set for folder links; media and selection links use share_link_media
+
+    password_hash VARCHAR(255), -- bcrypt, NULL when the link is not password protected
+    expires_at TIMESTAMP WITH TIME ZONE,
+    max_downloads INTEGER CHECK (max_downloads > 0),
+    download_count INTEGER NOT NULL DEFAULT 0,
+    last_accessed_at TIMESTAMP WITH TIME ZONE,
+
+    created_by UUID NOT NULL REFERENCES employees(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    revoked_by UUID REFERENCES employees(id),
+
+    CHECK ((scope = 'folder') = (folder_id IS NOT NULL))
+);
+
+CREATE INDEX idx_share_links_created_by ON share_links(created_by);
+
+CREATE TABLE share_link_media (
+    share_link_id UUID NOT NULL REFERENCES share_links(id) ON DELETE CASCADE,
+    media_id UUID NOT NULL REFERENCES media(id) ON DELETE CASCADE,
+    PRIMARY KEY (share_link_id, media_id)
+);
+
+ALTER TYPE audit_action ADD VALUE IF NOT EXISTS 'share';
+ALTER TYPE audit_action ADD VALUE IF NOT EXISTS 'revoke';
@@ -0,0 +1,39 @@
+-- Upload requests: let external partners upload into a group and folder
+-- without an account
+CREATE TABLE upload_requests (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    token_hash VARCHAR(64) NOT NULL UNIQUE, -- SHA-256 of the token; the token itself is never stored
+
+    name VARCHAR(255) NOT NULL,
+    message TEXT, -- Shown to the guest on the upload page
+
+    -- Where guest uploads land
+    media_group_id UUID NOT NULL REFERENCES media_groups(id),
+    folder_path VARCHAR(1000) NOT NULL DEFAULT '',
+
+    -- Limits
+    expires_at TIMESTAMP WITH TIME ZONE,
+    max_files INTEGER CHECK (max_files > 0),
+    max_file_size_mb INTEGER CHECK (max_file_size_mb > 0),
+    allowed_types media_type[] NOT NULL DEFAULT '{}', -- Empty allows every type
+    require_review BOOLEAN NOT NULL DEFAULT false,
+    upload_count INTEGER NOT NULL DEFAULT 0,
+
+    created_by UUID NOT NULL REFERENCES employees(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    revoked_by UUID REFERENCES employees(id)
+);
+
+CREATE INDEX idx_upload_requests_created_by ON upload_requests(created_by);
+
+-- Guest uploads are attributed to the request's creator and the guest name
+CREATE TYPE review_status AS ENUM ('pending', 'approved', 'rejected');
+
+ALTER TABLE media ADD COLUMN upload_request_id UUID REFERENCES upload_requests(id);
+ALTER TABLE media ADD COLUMN guest_name VARCHAR(255);
+ALTER TABLE media ADD COLUMN review_status review_status; -- NULL when the upload needs no review
+
+CREATE INDEX idx_media_review ON media(upload_request_id) WHERE review_status = 'pending' AND deleted_at IS NULL;
+
+ALTER TYPE audit_action ADD VALUE IF NOT EXISTS 'review';
@@ -0,0 +1,44 @@
+-- Outbound webhooks: signed JSON events for media and storage lifecycle changes
+CREATE TABLE webhook_endpoints (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL,
+    url VARCHAR(2000) NOT NULL,
+
+    -- HMAC-SHA256 signing secret, encrypted like storage credentials
+    encrypted_secret BYTEA NOT NULL,
+    secret_nonce BYTEA NOT NULL,
+
+    event_types TEXT[] NOT NULL DEFAULT '{}', -- Empty subscribes to every event
+    is_active BOOLEAN NOT NULL DEFAULT true,
+
+    created_by UUID NOT NULL REFERENCES employees(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TYPE webhook_delivery_status AS ENUM ('pending', 'succeeded', 'failed');
+
+-- One row per event per endpoint; doubles as the outbox and the delivery log
+CREATE TABLE webhook_deliveries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    endpoint_id UUID NOT NULL REFERENCES webhook_endpoints(id) ON DELETE CASCADE,
+    event_id UUID NOT NULL, -- Shared by every delivery of the same event, including replays
+    event_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    replay_of UUID REFERENCES webhook_deliveries(id),
+
+    status webhook_delivery_status NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_attempt_at TIMESTAMP WITH TIME ZONE,
+    response_status INTEGER,
+    response_body TEXT, -- Truncated
+    last_error TEXT,
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_webhook_deliveries_endpoint ON webhook_deliveries(endpoint_id, created_at DESC);
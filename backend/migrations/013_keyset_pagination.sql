@@ -0,0 +1,11 @@
+-- Keyset pagination: every sort key is paired with id so cursors resume at an exact row
+CREATE INDEX idx_media_created_at_id ON media(created_at DESC, id DESC) WHERE deleted_at IS NULL;
+CREATE INDEX idx_media_updated_at_id ON media(updated_at, id) WHERE deleted_at IS NULL;
+CREATE INDEX idx_media_filename_id ON media(filename, id) WHERE deleted_at IS NULL;
+CREATE INDEX idx_media_file_size_id ON media(file_size_bytes, id) WHERE deleted_at IS NULL;
+
+-- Superseded by the composite indexes above
+DROP INDEX IF EXISTS idx_media_created_at;
+DROP INDEX IF EXISTS idx_media_filename;
+
+CREATE INDEX idx_audit_logs_created_at_id ON audit_logs(created_at DESC, id DESC);
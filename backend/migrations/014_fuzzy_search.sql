@@ -0,0 +1,29 @@
+-- Fuzzy search: trigram matching over filenames, tags and folder paths
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- The text fuzzy search matches against. array_to_string is only STABLE, so
+-- the wrapper is declared IMMUTABLE to allow indexing it.
+CREATE OR REPLACE FUNCTION media_search_text(filename TEXT, original_filename TEXT, tags TEXT[])
+RETURNS TEXT AS $$
+    SELECT lower(COALESCE(filename, '') || ' ' || COALESCE(original_filename, '') || ' ' || COALESCE(array_to_string(tags, ' '), ''))
+$$ LANGUAGE SQL IMMUTABLE PARALLEL SAFE;
+
+CREATE INDEX idx_media_search_trgm ON media
+    USING GIN (media_search_text(filename, original_filename, tags) gin_trgm_ops)
+    WHERE deleted_at IS NULL;
+
+CREATE INDEX idx_folders_path_trgm ON folders USING GIN (lower(path) gin_trgm_ops) WHERE deleted_at IS NULL;
+
+-- Vocabulary for "did you mean" suggestions, refreshed by the search-terms job
+CREATE MATERIALIZED VIEW media_search_terms AS
+SELECT word, ndoc
+FROM ts_stat($$
+    SELECT to_tsvector('simple', media_search_text(m.filename, m.original_filename, m.tags) || ' ' || COALESCE(f.path, ''))
+    FROM media m
+    LEFT JOIN folders f ON f.id = m.folder_id
+    WHERE m.deleted_at IS NULL
+$$);
+
+-- Unique so the view can be refreshed concurrently
+CREATE UNIQUE INDEX idx_media_search_terms_word ON media_search_terms(word);
+CREATE INDEX idx_media_search_terms_trgm ON media_search_terms USING GIN (word gin_trgm_ops);
@@ -0,0 +1,26 @@
+-- Saved searches: named media filters, optionally shared and pinned as smart collections
+CREATE TYPE saved_search_visibility AS ENUM ('private', 'role', 'group');
+
+CREATE TABLE saved_searches (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    filters JSONB NOT NULL DEFAULT '{}', -- Listing filters, resolved live on every use
+
+    -- Who besides the creator can use it
+    visibility saved_search_visibility NOT NULL DEFAULT 'private',
+    shared_role role_type, -- Set for role visibility
+    shared_group_id UUID REFERENCES media_groups(id), -- Set for group visibility: roles allowed in the group
+    is_collection BOOLEAN NOT NULL DEFAULT false, -- Pinned as a smart collection
+
+    created_by UUID NOT NULL REFERENCES employees(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE,
+
+    CHECK ((visibility = 'role') = (shared_role IS NOT NULL)),
+    CHECK ((visibility = 'group') = (shared_group_id IS NOT NULL))
+);
+
+CREATE UNIQUE INDEX idx_saved_searches_name_unique ON saved_searches(created_by, lower(name)) WHERE deleted_at IS NULL;
+CREATE INDEX idx_saved_searches_shared ON saved_searches(visibility) WHERE deleted_at IS NULL AND visibility <> 'private';
@@ -0,0 +1,44 @@
+-- Collections: curated, ordered sets of media. Unlike groups and folders an
+-- item can be in any number of collections.
+CREATE TYPE collection_permission AS ENUM ('view', 'edit');
+
+CREATE TABLE collections (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    cover_media_id UUID REFERENCES media(id) ON DELETE SET NULL,
+
+    created_by UUID NOT NULL REFERENCES employees(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_collections_created_by ON collections(created_by) WHERE deleted_at IS NULL;
+
+CREATE TABLE collection_items (
+    collection_id UUID NOT NULL REFERENCES collections(id) ON DELETE CASCADE,
+    media_id UUID NOT NULL REFERENCES media(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    added_by UUID NOT NULL REFERENCES employees(id),
+    added_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (collection_id, media_id)
+);
+
+CREATE INDEX idx_collection_items_order ON collection_items(collection_id, position);
+CREATE INDEX idx_collection_items_media ON collection_items(media_id);
+
+-- Who besides the creator may view or edit a collection, by role or by employee
+CREATE TABLE collection_access (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    collection_id UUID NOT NULL REFERENCES collections(id) ON DELETE CASCADE,
+    role role_type,
+    employee_id UUID REFERENCES employees(id) ON DELETE CASCADE,
+    permission collection_permission NOT NULL DEFAULT 'view',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK ((role IS NULL) <> (employee_id IS NULL))
+);
+
+CREATE UNIQUE INDEX idx_collection_access_role ON collection_access(collection_id, role) WHERE role IS NOT NULL;
+CREATE UNIQUE INDEX idx_collection_access_employee ON collection_access(collection_id, employee_id) WHERE employee_id IS NOT NULL;
+CREATE INDEX idx_collection_access_employee_lookup ON collection_access(employee_id) WHERE employee_id IS NOT NULL;
@@ -0,0 +1,8 @@
+-- Refresh token rotation: every refresh replaces the token, and tokens from
+-- one login form a family that is revoked as a whole when a replaced token
+-- is presented again
+ALTER TABLE refresh_tokens ADD COLUMN family_id UUID NOT NULL DEFAULT uuid_generate_v4();
+ALTER TABLE refresh_tokens ADD COLUMN replaced_by UUID; -- The token issued when this one was rotated
+ALTER TABLE refresh_tokens ADD COLUMN revoked_reason VARCHAR(32); -- rotated, logout, logout_all, reuse, deactivated
+
+CREATE INDEX idx_refresh_tokens_family ON refresh_tokens(family_id) WHERE revoked_at IS NULL;
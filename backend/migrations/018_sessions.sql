@@ -0,0 +1,10 @@
+-- Sessions: a session is the family of refresh tokens of one login. Each
+-- token carries the client that last used it and the time the login began,
+-- so the live token of a family describes the whole session.
+ALTER TABLE refresh_tokens ADD COLUMN session_started_at TIMESTAMP WITH TIME ZONE;
+UPDATE refresh_tokens SET session_started_at = created_at;
+ALTER TABLE refresh_tokens ALTER COLUMN session_started_at SET NOT NULL;
+ALTER TABLE refresh_tokens ALTER COLUMN session_started_at SET DEFAULT NOW();
+
+ALTER TABLE refresh_tokens ADD COLUMN ip_address INET;
+ALTER TABLE refresh_tokens ADD COLUMN user_agent VARCHAR(500);
@@ -0,0 +1,24 @@
+-- Service accounts: non-human employees for automation. They cannot log in
+-- with a password and authenticate with API keys instead.
+ALTER TABLE employees ADD COLUMN is_service_account BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- API keys of service accounts, stored as SHA-256 hashes. Empty
+-- storage_account_ids / media_group_ids mean the key is not limited to any.
+CREATE TABLE api_keys (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    service_account_id UUID NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    key_prefix VARCHAR(16) NOT NULL, -- Shown in listings to tell keys apart
+    key_hash VARCHAR(64) NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL,
+    storage_account_ids UUID[] NOT NULL DEFAULT '{}',
+    media_group_ids UUID[] NOT NULL DEFAULT '{}',
+    expires_at TIMESTAMP WITH TIME ZONE,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    last_used_ip INET,
+    created_by UUID REFERENCES employees(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_api_keys_service_account ON api_keys(service_account_id);
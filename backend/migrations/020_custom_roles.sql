@@ -0,0 +1,20 @@
+-- Custom roles: admin-defined bundles of permissions. The built-in roles
+-- keep their fixed presets; an employee with a custom role gets its
+-- permissions instead, while their built-in role still decides role-based
+-- sharing such as media group allowed_roles.
+CREATE TABLE custom_roles (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(64) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    permissions TEXT[] NOT NULL DEFAULT '{}',
+    created_by UUID REFERENCES employees(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER update_custom_roles_updated_at BEFORE UPDATE ON custom_roles
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+-- Employees fall back to their built-in role when the custom role is deleted
+ALTER TABLE employees ADD COLUMN custom_role_id UUID REFERENCES custom_roles(id) ON DELETE SET NULL;
+CREATE INDEX idx_employees_custom_role ON employees(custom_role_id) WHERE custom_role_id IS NOT NULL;
@@ -0,0 +1,31 @@
+-- TOTP two-factor authentication (RFC 6238). The shared secret is encrypted
+-- with ENCRYPTION_KEY; it is pending until the first code confirms it, which
+-- sets totp_enabled_at. totp_last_step is the time step of the last accepted
+-- code, so a code cannot be used twice.
+ALTER TABLE employees
+    ADD COLUMN totp_secret BYTEA,
+    ADD COLUMN totp_nonce BYTEA,
+    ADD COLUMN totp_enabled_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN totp_last_step BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN mfa_failed_attempts INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN mfa_locked_until TIMESTAMP WITH TIME ZONE;
+
+-- Single-use recovery codes, stored as SHA-256 hashes
+CREATE TABLE mfa_recovery_codes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    employee_id UUID NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (employee_id, code_hash)
+);
+
+-- Built-in roles whose employees must use two-factor authentication. Admins
+-- and developers hold credentials to every bucket, so they start out required.
+CREATE TABLE mfa_required_roles (
+    role role_type PRIMARY KEY,
+    updated_by UUID REFERENCES employees(id) ON DELETE SET NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO mfa_required_roles (role) VALUES ('admin'), ('developer');
@@ -0,0 +1,25 @@
+-- Single sign-on: the OpenID Connect identities employees log in with,
+-- identified by the issuer and subject of their ID tokens. An employee has
+-- at most one identity per provider.
+CREATE TABLE employee_identities (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    employee_id UUID NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+    issuer VARCHAR(500) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (issuer, subject),
+    UNIQUE (employee_id, issuer)
+);
+
+-- Authorization requests waiting for the provider to redirect back, keyed by
+-- the SHA-256 hash of their state. Each holds the PKCE code verifier and the
+-- nonce the ID token must carry, and is deleted when used.
+CREATE TABLE oidc_login_states (
+    state_hash VARCHAR(64) PRIMARY KEY,
+    code_verifier VARCHAR(128) NOT NULL,
+    nonce VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_oidc_login_states_expires ON oidc_login_states(expires_at);
@@ -0,0 +1,26 @@
+-- SCIM 2.0 provisioning. Identity providers refer to employees by their own
+-- externalId and push groups, whose members get the role the group's display
+-- name maps to.
+ALTER TABLE employees ADD COLUMN scim_external_id VARCHAR(255);
+
+-- A deleted employee keeps their row, so an email only needs to be unique
+-- among live employees for a deprovisioned employee to be provisioned again
+ALTER TABLE employees DROP CONSTRAINT employees_email_key;
+DROP INDEX idx_employees_email;
+CREATE UNIQUE INDEX idx_employees_email ON employees(email) WHERE deleted_at IS NULL;
+
+CREATE TABLE scim_groups (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    display_name VARCHAR(255) NOT NULL UNIQUE,
+    external_id VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE scim_group_members (
+    group_id UUID NOT NULL REFERENCES scim_groups(id) ON DELETE CASCADE,
+    employee_id UUID NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+    PRIMARY KEY (group_id, employee_id)
+);
+
+CREATE INDEX idx_scim_group_members_employee ON scim_group_members(employee_id);
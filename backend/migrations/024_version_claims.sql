@@ -0,0 +1,6 @@
+-- A new version claims its media item before any object is moved, so two
+-- uploads completing at once cannot both archive the live object. The claim
+-- expires in case the server stops halfway.
+ALTER TABLE media
+    ADD COLUMN version_claim_id UUID,
+    ADD COLUMN version_claim_expires_at TIMESTAMP WITH TIME ZONE;
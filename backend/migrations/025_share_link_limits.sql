@@ -0,0 +1,5 @@
+-- The API key limits a share link was created under. Visitors only reach the
+-- media its creator could reach through that key; NULL is unrestricted.
+ALTER TABLE share_links
+    ADD COLUMN key_storage_account_ids UUID[],
+    ADD COLUMN key_media_group_ids UUID[];
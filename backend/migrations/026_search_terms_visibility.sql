@@ -0,0 +1,18 @@
+-- Keep quarantined media and guest uploads awaiting or failing review out of
+-- the "did you mean" vocabulary, matching what listings show
+DROP MATERIALIZED VIEW IF EXISTS media_search_terms;
+
+CREATE MATERIALIZED VIEW media_search_terms AS
+SELECT word, ndoc
+FROM ts_stat($$
+    SELECT to_tsvector('simple', media_search_text(m.filename, m.original_filename, m.tags) || ' ' || COALESCE(f.path, ''))
+    FROM media m
+    LEFT JOIN folders f ON f.id = m.folder_id
+    WHERE m.deleted_at IS NULL
+        AND m.quarantined_at IS NULL
+        AND (m.review_status IS NULL OR m.review_status = 'approved')
+$$);
+
+-- Unique so the view can be refreshed concurrently
+CREATE UNIQUE INDEX idx_media_search_terms_word ON media_search_terms(word);
+CREATE INDEX idx_media_search_terms_trgm ON media_search_terms USING GIN (word gin_trgm_ops);
@@ -0,0 +1,10 @@
+-- Archived versions keep their own antivirus verdict. Versions archived
+-- before their scan finished are scanned by the retry job, and versions that
+-- did not pass are not served.
+ALTER TABLE media_versions
+    ADD COLUMN scan_status scan_status NOT NULL DEFAULT 'pending',
+    ADD COLUMN scan_signature VARCHAR(255),
+    ADD COLUMN scanned_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_media_versions_awaiting_scan ON media_versions(replaced_at)
+    WHERE scan_status IN ('pending', 'error');
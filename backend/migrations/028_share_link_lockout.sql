@@ -0,0 +1,4 @@
+-- Wrong share link passwords count towards a lockout, like two-factor codes
+ALTER TABLE share_links
+    ADD COLUMN password_failed_attempts INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN password_locked_until TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,4 @@
+-- When single sign-on disabled an employee because the provider granted them
+-- no role. Their next login with a role enables them again; any other change
+-- of their active state clears it, so deactivations by admins and SCIM stick.
+ALTER TABLE employees ADD COLUMN sso_deactivated_at TIMESTAMP WITH TIME ZONE;